@@ -2,17 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/database/mongodb"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/server"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/admin_controller"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/bid_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/health_controller"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/user_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/bid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/testdata"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/user"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/eventlog"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/webhook"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/user_usecase"
@@ -20,69 +33,193 @@ import (
 )
 
 func main() {
-	ctx := context.Background()
-
-	// Tenta carregar .env de múltiplos paths (container e desenvolvimento local)
-	// Não fatal se falhar - variáveis podem vir do ambiente do sistema
-	envPaths := []string{
-		"/cmd/auction/.env", // Path no container scratch
-		"cmd/auction/.env",  // Path relativo (desenvolvimento local)
-		".env",              // Path alternativo
-	}
+	startedAt := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	envLoaded := false
-	for _, path := range envPaths {
-		if err := godotenv.Load(path); err == nil {
-			envLoaded = true
-			log.Printf("Loaded environment from: %s", path)
-			break
-		}
-	}
+	config.LoadEnvFile()
 
-	if !envLoaded {
-		log.Println("No .env file found, using system environment variables")
+	// Malformed settings fail the startup instead of falling back to defaults
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	if err := logger.Configure(appConfig.Log.Format, appConfig.Log.Level); err != nil {
+		log.Fatal(err.Error())
+		return
 	}
 
-	databaseConnection, err := mongodb.NewMongoDBConnection(ctx)
+	databaseConnection, err := mongodb.NewMongoDBConnection(ctx, appConfig.Mongo)
 	if err != nil {
 		log.Fatal(err.Error())
 		return
 	}
 
+	if err := mongodb.EnsureIndexes(ctx, databaseConnection); err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	// Optional write-ahead log of accepted bids, replayed on startup
+	var bidEventLog bid_entity.BidEventLog
+	if path := appConfig.Bid.EventLogPath; path != "" {
+		fileEventLog, err := eventlog.NewFileBidEventLog(path)
+		if err != nil {
+			log.Fatal(err.Error())
+			return
+		}
+		defer fileEventLog.Close()
+		bidEventLog = fileEventLog
+	}
+
 	router := gin.Default()
+	// ClientIP, which keys the bid rate limit, only honors X-Forwarded-For
+	// from these proxies; with none configured it is the peer address
+	if err := router.SetTrustedProxies(appConfig.Server.TrustedProxies); err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	router.Use(middleware.RequestTimeout(appConfig.Server.RequestTimeout))
+
+	// As streams never go idle, they end once the server starts shutting down
+	streamsCtx, closeStreams := context.WithCancel(context.Background())
+	endOnShutdown := middleware.EndOnShutdown(streamsCtx)
 
-	userController, bidController, auctionsController, auctionRepo := initDependencies(databaseConnection)
+	userController, bidController, auctionsController, auctionRepo, bidUseCase :=
+		initDependencies(databaseConnection, bidEventLog, appConfig)
+
+	// Probes for Kubernetes, outside the admin group
+	healthController := health_controller.NewHealthController(databaseConnection.Client(), bidUseCase,
+		appConfig.Server.HealthCheckTimeout)
+	router.GET("/healthz", healthController.Healthz)
+	router.GET("/readyz", healthController.Readyz)
 
 	// Start background goroutine to auto-close expired auctions
-	auctionRepo.StartAuctionCloserRoutine(ctx)
+	auctionRepo.StartAuctionCloserRoutine(ctx, appConfig.Auction.CloseCheckInterval)
+
+	adminAuth := middleware.AdminAuth(appConfig.Server.AdminToken)
+	// Requests refused by the rate limit count as rate_limited rejections
+	bidRateLimit := middleware.BidRateLimit(ctx, appConfig.Server.BidRateLimit, appConfig.Server.BidRateBurst,
+		bidUseCase.RecordRateLimited)
 
 	router.GET("/auction", auctionsController.FindAuctions)
 	router.GET("/auction/:auctionId", auctionsController.FindAuctionById)
 	router.POST("/auction", auctionsController.CreateAuction)
+	router.PATCH("/auction/:auctionId", adminAuth, auctionsController.UpdateAuction)
+	router.PATCH("/auction/:auctionId/close", adminAuth, auctionsController.CloseAuction)
+	router.POST("/auction/:auctionId/relist", adminAuth, auctionsController.RelistAuction)
+	router.DELETE("/auction/:auctionId", adminAuth, auctionsController.DeleteAuction)
+	router.POST("/auction/statuses", auctionsController.FindAuctionStatuses)
 	router.GET("/auction/winner/:auctionId", auctionsController.FindWinningBidByAuctionId)
-	router.POST("/bid", bidController.CreateBid)
+	router.GET("/auction/:auctionId/winning", bidController.FindUserWinningStatus)
+	router.GET("/auction/:auctionId/current-bid", bidController.FindCurrentBid)
+	router.GET("/auction/:auctionId/summary", auctionsController.FindAuctionSummary)
+	router.GET("/auction/:auctionId/stream", middleware.DisableWriteTimeout(), endOnShutdown, bidController.StreamBids)
+	// Streaming responses may outlast HTTP_WRITE_TIMEOUT
+	router.GET("/auction/:auctionId/export", middleware.DisableWriteTimeout(), auctionsController.ExportAuction)
+	router.GET("/auctions/closing/stream", middleware.DisableWriteTimeout(), endOnShutdown,
+		auctionsController.StreamClosingAuctions)
+	router.GET("/category/:category/top", auctionsController.FindCategoryLeaderboard)
+	router.POST("/bid", bidRateLimit, bidController.CreateBid)
 	router.GET("/bid/:auctionId", bidController.FindBidByAuctionId)
+	router.GET("/auction/:auctionId/bids.csv", middleware.DisableWriteTimeout(), bidController.ExportBidsCSV)
+	router.POST("/user", userController.CreateUser)
 	router.GET("/user/:userId", userController.FindUserById)
+	router.PUT("/user/:userId/callback", adminAuth, userController.UpdateCallbackURL)
+	router.GET("/user/:userId/auctions", auctionsController.FindUserAuctions)
+
+	admin := router.Group("/admin", adminAuth)
+	admin.GET("/pending-bids", bidController.FindPendingBids)
+	admin.GET("/bid-rejections", bidController.FindRejectionCounts)
+	admin.GET("/bid-batch-size", bidController.FindBatchSize)
+	admin.GET("/bid-pipeline", bidController.FindPipelineStats)
+	admin.POST("/auctions/close-expired", auctionsController.CloseExpiredAuctions)
+	// Guarded by ALLOW_TEST_PURGE as well, for CI and staging teardown
+	admin.DELETE("/test-data", admin_controller.NewAdminController(
+		testdata.NewPurger(databaseConnection), appConfig.Server.AllowTestPurge).PurgeTestData)
+
+	timeouts := server.Timeouts{
+		Read:  appConfig.Server.ReadTimeout,
+		Write: appConfig.Server.WriteTimeout,
+		Idle:  appConfig.Server.IdleTimeout,
+	}
+	limits := server.Limits{
+		MaxHeaderBytes: appConfig.Server.MaxHeaderBytes,
+		MaxConnections: appConfig.Server.MaxConnections,
+	}
+	httpServer := server.NewServer(":8080", router, timeouts, limits)
+	httpServer.RegisterOnShutdown(closeStreams)
+	listener, err := server.Listen(httpServer.Addr, limits)
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	<-shutdownSignals()
 
-	router.Run(":8080")
+	// Stop serving, stop the closer routine and drain the bid pipeline
+	// before exiting
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+
+	summary := shutdownSummary{
+		Pipeline: gracefulShutdown(shutdownCtx, httpServer, cancel, bidUseCase),
+		Uptime:   time.Since(startedAt),
+	}
+
+	// The drain may have used up the shutdown timeout; the count gets its own
+	countCtx, countCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer countCancel()
+	if pending, err := auctionRepo.CountAuctionsPendingClose(countCtx); err != nil {
+		logger.Error("Error counting auctions pending close", err)
+	} else {
+		summary.AuctionsPendingClose = pending
+	}
+
+	logShutdownSummary(summary)
 }
 
-func initDependencies(database *mongo.Database) (
+func initDependencies(database *mongo.Database, bidEventLog bid_entity.BidEventLog, appConfig *config.Config) (
 	userController *user_controller.UserController,
 	bidController *bid_controller.BidController,
 	auctionController *auction_controller.AuctionController,
-	auctionRepository *auction.AuctionRepository) {
+	auctionRepository *auction.AuctionRepository,
+	bidUseCase bid_usecase.BidUseCaseInterface) {
 
-	auctionRepository = auction.NewAuctionRepository(database)
-	bidRepository := bid.NewBidRepository(database, auctionRepository)
+	auctionRepository = auction.NewAuctionRepository(database, *appConfig)
+	bidRepository := bid.NewBidRepository(database, auctionRepository, appConfig.Bid)
 	userRepository := user.NewUserRepository(database)
 
+	// The closer feeds the auctions about to close to the SSE subscribers
+	closingStream := auction_usecase.NewClosingStream(appConfig.Auction.MaxClosingSubscribers)
+	auctionRepository.SetClosingObserver(closingStream)
+
+	// Bidders with a callback URL are told when their bids are persisted and
+	// when they win
+	callbacks := webhook.NewCallbackDispatcher(context.Background(), userRepository, bidRepository,
+		appConfig.Callback)
+
 	userController = user_controller.NewUserController(
 		user_usecase.NewUserUseCase(userRepository))
+	bidUseCase = bid_usecase.NewBidUseCase(bidRepository, auctionRepository, userRepository,
+		bidEventLog, bid.NewRejectedBidRepository(database), callbacks, appConfig.Bid)
+
+	// Completed auctions stop taking the bids still queued for them and close
+	// their live bid streams
+	auctionRepository.SetCompletionListener(
+		auction_entity.AuctionCompletionListeners{bidRepository, callbacks, bidUseCase})
+	bidController = bid_controller.NewBidController(bidUseCase, appConfig.Server)
+
+	// The leaderboard accounts for the bids still waiting in the pipeline
 	auctionController = auction_controller.NewAuctionController(
-		auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository))
-	bidController = bid_controller.NewBidController(
-		bid_usecase.NewBidUseCase(bidRepository, auctionRepository, userRepository))
+		auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, closingStream, bidUseCase,
+			*appConfig))
 
 	return
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"go.uber.org/zap"
+)
+
+// bidPipeline is the part of the bid use case drained on shutdown.
+type bidPipeline interface {
+	Flush(ctx context.Context) (int, *internal_error.InternalError)
+	Shutdown(ctx context.Context) bid_usecase.PipelineDrainStats
+}
+
+// shutdownSignals returns the channel notified of SIGINT and SIGTERM.
+func shutdownSignals() <-chan os.Signal {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	return quit
+}
+
+// pipelineDrainTimeout bounds the persistence of the remaining bids. It starts
+// once the server is down, so a slow server shutdown does not leave the final
+// batch with an expired context.
+const pipelineDrainTimeout = 5 * time.Second
+
+// gracefulShutdown stops the server once the in-flight requests are answered,
+// stops the closer routine and persists the bids still batched or queued.
+// ctx bounds the server shutdown; the pipeline then gets pipelineDrainTimeout
+// of its own, and the bids it leaves behind are reported lost.
+func gracefulShutdown(
+	ctx context.Context,
+	httpServer *http.Server,
+	stopCloser context.CancelFunc,
+	pipeline bidPipeline) bid_usecase.PipelineDrainStats {
+	// No new bid enters the pipeline once the server is down
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Error("Error shutting down the HTTP server", err)
+	}
+	stopCloser()
+
+	drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), pipelineDrainTimeout)
+	defer cancel()
+
+	flushed, err := pipeline.Flush(drainCtx)
+	if err != nil {
+		logger.Error("Error flushing the bid batch on shutdown", err)
+	}
+
+	stats := pipeline.Shutdown(drainCtx)
+	stats.FlushedBids += flushed
+
+	return stats
+}
+
+// shutdownSummary gathers what operators need to confirm that nothing was
+// silently dropped when the process stopped.
+type shutdownSummary struct {
+	Pipeline             bid_usecase.PipelineDrainStats
+	AuctionsPendingClose int64
+	Uptime               time.Duration
+}
+
+func logShutdownSummary(summary shutdownSummary) {
+	logger.Info("Shutdown summary",
+		zap.Int("bids_flushed", summary.Pipeline.FlushedBids),
+		zap.Int("bids_dropped", summary.Pipeline.DroppedBids),
+		zap.Int("bids_lost", summary.Pipeline.LostBids),
+		zap.Int64("auctions_pending_close", summary.AuctionsPendingClose),
+		zap.Duration("uptime", summary.Uptime))
+}
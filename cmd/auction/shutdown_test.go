@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/bid_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogShutdownSummary(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	restore := logger.SetLogger(zap.New(core))
+	defer restore()
+
+	logShutdownSummary(shutdownSummary{
+		Pipeline:             bid_usecase.PipelineDrainStats{FlushedBids: 3, LostBids: 1},
+		AuctionsPendingClose: 2,
+		Uptime:               90 * time.Second,
+	})
+
+	entries := logs.FilterMessage("Shutdown summary").All()
+	assert.Len(t, entries, 1)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, int64(3), fields["bids_flushed"])
+	assert.Equal(t, int64(1), fields["bids_lost"])
+	assert.Equal(t, int64(2), fields["auctions_pending_close"])
+	assert.Equal(t, 90*time.Second, fields["uptime"])
+}
+
+// The fakes embed the repository interfaces and only implement what the bid
+// pipeline calls.
+
+type fakeAuctionRepository struct {
+	auction_entity.AuctionRepositoryInterface
+	auction *auction_entity.Auction
+}
+
+func (f *fakeAuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	return f.auction, nil
+}
+
+func (f *fakeAuctionRepository) MarkAuctionHasBids(
+	ctx context.Context, auctionId string, version int64) *internal_error.InternalError {
+	return nil
+}
+
+type fakeUserRepository struct {
+	user_entity.UserRepositoryInterface
+}
+
+func (f *fakeUserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	return &user_entity.User{Id: userId}, nil
+}
+
+type fakeBidRepository struct {
+	bid_entity.BidEntityRepository
+	mutex sync.Mutex
+	bids  []bid_entity.Bid
+}
+
+func (f *fakeBidRepository) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) (bid_entity.BidBatchResult, *internal_error.InternalError) {
+	// Like the driver, nothing is written with a context already done
+	if ctx.Err() != nil {
+		return bid_entity.BidBatchResult{Failed: bidEntities}, internal_error.NewContextDoneError(ctx)
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.bids = append(f.bids, bidEntities...)
+	return bid_entity.BidBatchResult{Stored: bidEntities}, nil
+}
+
+func (f *fakeBidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	return nil, internal_error.NewNotFoundError("No bids found for the auction")
+}
+
+func (f *fakeBidRepository) CountBidsAboveAmount(
+	ctx context.Context, auctionId string, amountCents int64) (int64, *internal_error.InternalError) {
+	return 0, nil
+}
+
+func (f *fakeBidRepository) FindHighestBidsByUserId(
+	ctx context.Context, userId string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (f *fakeBidRepository) persisted() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.bids)
+}
+
+func TestShutdownSignalPersistsPendingBids(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Bids stay batched until the shutdown flushes them
+	bidConfig := config.Default().Bid
+	bidConfig.MaxBatchSize = 10
+	bidConfig.BatchInsertInterval = time.Hour
+
+	now := time.Now()
+	auction := &auction_entity.Auction{
+		Id:        uuid.New().String(),
+		Status:    auction_entity.Active,
+		CreatedAt: now,
+		StartsAt:  now,
+		ExpiresAt: now.Add(time.Minute),
+	}
+	bidRepository := &fakeBidRepository{}
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepository,
+		&fakeAuctionRepository{auction: auction}, &fakeUserRepository{}, nil, nil, nil, bidConfig)
+
+	router := gin.New()
+	router.POST("/bid", bid_controller.NewBidController(bidUseCase, config.Default().Server).CreateBid)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	httpServer := &http.Server{Handler: router}
+	served := make(chan error, 1)
+	go func() { served <- httpServer.Serve(listener) }()
+
+	url := "http://" + listener.Addr().String() + "/bid"
+	for _, amount := range []float64{100, 200, 300} {
+		body, _ := json.Marshal(bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: amount,
+		})
+		response, err := http.Post(url, "application/json", bytes.NewReader(body))
+		if assert.Nil(t, err) {
+			response.Body.Close()
+			assert.Equal(t, http.StatusCreated, response.StatusCode)
+		}
+	}
+	assert.Zero(t, bidRepository.persisted())
+
+	quit := shutdownSignals()
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case <-quit:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown signal not received")
+	}
+
+	closerCtx, stopCloser := context.WithCancel(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stats := gracefulShutdown(ctx, httpServer, stopCloser, bidUseCase)
+
+	assert.Equal(t, bid_usecase.PipelineDrainStats{FlushedBids: 3}, stats)
+	assert.Equal(t, 3, bidRepository.persisted())
+	assert.ErrorIs(t, closerCtx.Err(), context.Canceled)
+	assert.True(t, errors.Is(<-served, http.ErrServerClosed))
+}
+
+func TestShutdownEndsStreamsAndFlushesWithFreshDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bidConfig := config.Default().Bid
+	bidConfig.MaxBatchSize = 10
+	bidConfig.BatchInsertInterval = time.Hour
+
+	now := time.Now()
+	auction := &auction_entity.Auction{
+		Id:        uuid.New().String(),
+		Status:    auction_entity.Active,
+		CreatedAt: now,
+		StartsAt:  now,
+		ExpiresAt: now.Add(time.Minute),
+	}
+	bidRepository := &fakeBidRepository{}
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepository,
+		&fakeAuctionRepository{auction: auction}, &fakeUserRepository{}, nil, nil, nil, bidConfig)
+
+	streamsCtx, closeStreams := context.WithCancel(context.Background())
+	router := gin.New()
+	router.POST("/bid", bid_controller.NewBidController(bidUseCase, config.Default().Server).CreateBid)
+	// A stream that, like SSE, only ends with its request context
+	router.GET("/stream", middleware.EndOnShutdown(streamsCtx), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+		<-c.Request.Context().Done()
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	httpServer := &http.Server{Handler: router}
+	httpServer.RegisterOnShutdown(closeStreams)
+	go httpServer.Serve(listener)
+
+	// Without keep-alives no spare connection holds the server shutdown
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	baseURL := "http://" + listener.Addr().String()
+	body, _ := json.Marshal(bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+	response, err := client.Post(baseURL+"/bid", "application/json", bytes.NewReader(body))
+	if assert.Nil(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusCreated, response.StatusCode)
+	}
+
+	stream, err := client.Get(baseURL + "/stream")
+	assert.Nil(t, err)
+	defer stream.Body.Close()
+
+	// The stream ends as soon as the shutdown starts, well within ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	started := time.Now()
+	stats := gracefulShutdown(ctx, httpServer, func() {}, bidUseCase)
+
+	assert.Less(t, time.Since(started), time.Second)
+	assert.Equal(t, bid_usecase.PipelineDrainStats{FlushedBids: 1}, stats)
+	assert.Equal(t, 1, bidRepository.persisted())
+}
+
+func TestShutdownFlushesAfterServerDeadline(t *testing.T) {
+	bidConfig := config.Default().Bid
+	bidConfig.MaxBatchSize = 10
+	bidConfig.BatchInsertInterval = time.Hour
+
+	now := time.Now()
+	auction := &auction_entity.Auction{
+		Id:        uuid.New().String(),
+		Status:    auction_entity.Active,
+		CreatedAt: now,
+		StartsAt:  now,
+		ExpiresAt: now.Add(time.Minute),
+	}
+	bidRepository := &fakeBidRepository{}
+	bidUseCase := bid_usecase.NewBidUseCase(bidRepository,
+		&fakeAuctionRepository{auction: auction}, &fakeUserRepository{}, nil, nil, nil, bidConfig)
+	_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+	assert.Nil(t, err)
+
+	// The server shutdown used up the whole deadline
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stats := gracefulShutdown(ctx, &http.Server{}, func() {}, bidUseCase)
+
+	assert.Equal(t, bid_usecase.PipelineDrainStats{FlushedBids: 1}, stats)
+	assert.Equal(t, 1, bidRepository.persisted())
+}
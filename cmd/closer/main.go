@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/database/mongodb"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/closer"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/bid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/lock"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/user"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/webhook"
+)
+
+// Fecha os leilões expirados num processo dedicado, para que os servidores da
+// API rodem com DISABLE_AUCTION_CLOSER=true. Com -lock, vários processos podem
+// rodar ao mesmo tempo: só o dono do lease executa cada ciclo.
+func main() {
+	config.LoadEnvFile()
+
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if err := logger.Configure(appConfig.Log.Format, appConfig.Log.Level); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	interval := flag.Duration("interval", appConfig.Auction.CloseCheckInterval,
+		"time between cycles (AUCTION_CLOSE_CHECK_INTERVAL)")
+	useLock := flag.Bool("lock", appConfig.Auction.CloserLock,
+		"take a lease in MongoDB before each cycle (CLOSER_LOCK)")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	database, err := mongodb.NewMongoDBConnection(ctx, appConfig.Mongo)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	// The lease outlives a few missed cycles before another process takes over
+	var lease closer.Lease
+	if *useLock {
+		hostname, _ := os.Hostname()
+		owner := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		lease = lock.NewMongoLease(database, "auction_closer", owner, 3*(*interval))
+		log.Printf("Closer lease enabled, owner: %s", owner)
+	}
+
+	// Winners with a callback URL are told when their auctions complete
+	auctionRepository := auction.NewAuctionRepository(database, *appConfig)
+	auctionRepository.SetCompletionListener(webhook.NewCallbackDispatcher(ctx,
+		user.NewUserRepository(database), bid.NewBidRepository(database, auctionRepository, appConfig.Bid),
+		appConfig.Callback))
+
+	log.Printf("Closing expired auctions every %s", *interval)
+	closer.NewCloser(auctionRepository, lease).Run(ctx, *interval)
+	log.Println("Closer stopped")
+}
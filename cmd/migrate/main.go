@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/database/mongodb"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/bid"
+)
+
+const usage = `Usage: migrate <step> [flags]
+
+Steps:
+  expires-at      backfill expires_at from created_at + AUCTION_INTERVAL
+  enums           convert status and condition to the storage in -to
+  bid-timestamps  convert bid timestamps stored in seconds to milliseconds
+  bid-amounts     convert bid amounts stored as floats to integer cents
+
+`
+
+// Executa migrações de dados nos leilões e lances já gravados. Cada passo pode ser
+// repetido sem efeito: só os documentos ainda não migrados são alterados.
+func main() {
+	config.LoadEnvFile()
+
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if err := logger.Configure(appConfig.Log.Format, appConfig.Log.Level); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	to := flags.String("to", appConfig.Auction.EnumStorage,
+		"enums: storage to convert the auctions to, int or string (AUCTION_ENUM_STORAGE)")
+
+	if len(os.Args) < 2 ||
+		(os.Args[1] != "expires-at" && os.Args[1] != "enums" && os.Args[1] != "bid-timestamps" && os.Args[1] != "bid-amounts") {
+		flags.Usage()
+		os.Exit(2)
+	}
+	step := os.Args[1]
+	flags.Parse(os.Args[2:])
+
+	ctx := context.Background()
+
+	database, err := mongodb.NewMongoDBConnection(ctx, appConfig.Mongo)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	repository := auction.NewAuctionRepository(database, *appConfig)
+
+	switch step {
+	case "expires-at":
+		interval := appConfig.Auction.Interval
+		updated, err := repository.BackfillExpiresAt(ctx, interval)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Printf("Backfilled expires_at of %d auction(s) with created_at + %s", updated, interval)
+
+	case "enums":
+		storage := auction.EnumStorage(*to)
+		if storage != auction.IntEnumStorage && storage != auction.StringEnumStorage {
+			log.Fatalf("invalid -to %q, expected int or string", *to)
+		}
+		changed, err := repository.MigrateEnumStorage(ctx, storage)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Printf("Converted %d auction field(s) to %s storage", changed, storage)
+
+	case "bid-timestamps":
+		updated, err := bid.NewBidRepository(database, repository, appConfig.Bid).MigrateTimestampsToMillis(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Printf("Converted the timestamp of %d bid(s) to milliseconds", updated)
+
+	case "bid-amounts":
+		updated, err := bid.NewBidRepository(database, repository, appConfig.Bid).MigrateAmountsToCents(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Printf("Converted the amount of %d bid(s) to cents", updated)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/database/mongodb"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/seed"
+)
+
+// Popula o banco com usuários, leilões e lances de exemplo para desenvolvimento
+// local. Pode ser executado várias vezes: se o banco já foi populado, nada é
+// inserido.
+func main() {
+	config.LoadEnvFile()
+
+	seedConfig := seed.Config{}
+	flag.IntVar(&seedConfig.Users, "users", getEnvInt("SEED_USERS", 10), "number of users (SEED_USERS)")
+	flag.IntVar(&seedConfig.Auctions, "auctions", getEnvInt("SEED_AUCTIONS", 5), "number of auctions (SEED_AUCTIONS)")
+	flag.IntVar(&seedConfig.BidsPerAuction, "bids-per-auction", getEnvInt("SEED_BIDS_PER_AUCTION", 3),
+		"number of bids on each auction (SEED_BIDS_PER_AUCTION)")
+	flag.DurationVar(&seedConfig.AuctionDuration, "auction-duration", time.Hour, "how long the auctions stay open")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if err := logger.Configure(appConfig.Log.Format, appConfig.Log.Level); err != nil {
+		log.Fatal(err.Error())
+	}
+	// The seeded auctions are written like the API would write them
+	auction.SetEnumStorage(auction.EnumStorage(appConfig.Auction.EnumStorage))
+
+	database, err := mongodb.NewMongoDBConnection(ctx, appConfig.Mongo)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	result, err := seed.Run(ctx, database, seedConfig, time.Now())
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if result.Skipped {
+		log.Println("Database already seeded, nothing to do")
+		return
+	}
+
+	log.Printf("Seeded %d user(s), %d auction(s) and %d bid(s)", result.Users, result.Auctions, result.Bids)
+	log.Printf("First user id: %s", seed.UserId(0))
+	if seedConfig.Auctions > 0 {
+		log.Printf("First auction id: %s", seed.AuctionId(0))
+	}
+}
+
+// getEnvInt returns the integer value of the environment variable, or
+// defaultValue when it is unset or invalid.
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+
+	return value
+}
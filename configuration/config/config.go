@@ -0,0 +1,528 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+)
+
+// Config holds the settings of the application, read once at startup and
+// handed to the components that need them.
+type Config struct {
+	Auction  AuctionConfig
+	Bid      BidConfig
+	Server   ServerConfig
+	Callback CallbackConfig
+	Log      LogConfig
+	Mongo    MongoConfig
+}
+
+// ServerConfig holds how the HTTP server bounds its connections and
+// requests, identifies its clients and guards the admin endpoints and the
+// bid stream.
+type ServerConfig struct {
+	ReadTimeout        time.Duration // HTTP_READ_TIMEOUT; 0 disables it
+	WriteTimeout       time.Duration // HTTP_WRITE_TIMEOUT; 0 disables it
+	IdleTimeout        time.Duration // HTTP_IDLE_TIMEOUT; 0 disables it
+	MaxHeaderBytes     int           // HTTP_MAX_HEADER_BYTES
+	MaxConnections     int           // HTTP_MAX_CONNECTIONS; 0 means no limit
+	RequestTimeout     time.Duration // REQUEST_TIMEOUT
+	HealthCheckTimeout time.Duration // HEALTH_CHECK_TIMEOUT
+	BidRateLimit       float64       // BID_RATE_LIMIT, requests per second per IP
+	BidRateBurst       int           // BID_RATE_BURST
+	BidCreateStatus    int           // BID_CREATE_STATUS: 201, or 202 for asynchronous writes
+	AllowTestPurge     bool          // ALLOW_TEST_PURGE
+
+	TrustedProxies []string // TRUSTED_PROXIES
+	AdminToken     string   // ADMIN_TOKEN; empty disables the admin endpoints
+	StreamOrigins  []string // STREAM_ALLOWED_ORIGINS, besides the server's own
+}
+
+// CallbackConfig holds how the callback URLs of the users are called.
+type CallbackConfig struct {
+	MaxAttempts  int           // CALLBACK_MAX_ATTEMPTS
+	RetryBackoff time.Duration // CALLBACK_RETRY_BACKOFF, doubled after each failure
+	Timeout      time.Duration // CALLBACK_TIMEOUT
+}
+
+// LogConfig holds how log entries are written.
+type LogConfig struct {
+	Format string // LOG_FORMAT: json or text
+	Level  string // LOG_LEVEL: debug, info, warn or error
+}
+
+// How a soft-deleted auction is reported: 404 Not Found or 410 Gone.
+const (
+	DeletedAuctionNotFound = "not_found"
+	DeletedAuctionGone     = "gone"
+)
+
+// AuctionConfig holds how long auctions last, how they are stored, how
+// expired ones are closed and how they are listed and reported.
+type AuctionConfig struct {
+	Interval              time.Duration // AUCTION_INTERVAL
+	MinIncrement          float64       // AUCTION_MIN_INCREMENT, the default of new auctions
+	EnumStorage           string        // AUCTION_ENUM_STORAGE: int or string
+	CloseCheckInterval    time.Duration // AUCTION_CLOSE_CHECK_INTERVAL
+	CloseBatchSize        int           // AUCTION_CLOSE_BATCH_SIZE
+	DisableCloser         bool          // DISABLE_AUCTION_CLOSER
+	CloserLock            bool          // CLOSER_LOCK, the default of cmd/closer -lock
+	DeletedResponse       string        // DELETED_AUCTION_RESPONSE
+	MaxLeaderboardSize    int           // MAX_LEADERBOARD_SIZE
+	MaxClosingSubscribers int           // MAX_CLOSING_STREAM_SUBSCRIBERS
+}
+
+// BidConfig holds how bids are validated, queued and batched.
+type BidConfig struct {
+	MaxBatchSize        int                     // MAX_BATCH_SIZE
+	BatchInsertInterval time.Duration           // BATCH_INSERT_INTERVAL
+	AllowSelfOutbid     bool                    // ALLOW_SELF_OUTBID
+	MinSelfRaise        float64                 // MIN_SELF_RAISE
+	TiePolicy           bid_entity.TiePolicy    // BID_TIE_POLICY
+	AmountLimits        bid_entity.AmountLimits // MAX_BID_AMOUNT (0 for no cap), BID_DECIMAL_PLACES
+	Cooldown            time.Duration           // BID_COOLDOWN; 0 disables it
+
+	EnqueueTimeout       time.Duration // BID_ENQUEUE_TIMEOUT
+	QueueBlocking        bool          // BID_QUEUE_BLOCKING
+	MaxConcurrentBids    int           // MAX_CONCURRENT_BIDS; 0 means no limit
+	MaxPendingAuctions   int           // MAX_PENDING_AUCTIONS
+	MaxStreamSubscribers int           // MAX_BID_STREAM_SUBSCRIBERS
+	StallThreshold       time.Duration // BATCH_ROUTINE_STALL_THRESHOLD
+	RecordRejected       bool          // RECORD_REJECTED_BIDS
+	UserLookupDegraded   bool          // USER_LOOKUP_DEGRADED_MODE
+	EventLogPath         string        // BID_EVENT_LOG_PATH; empty disables the log
+
+	Autotune           bool          // BATCH_SIZE_AUTOTUNE
+	MinTunedBatchSize  int           // BATCH_SIZE_MIN
+	MaxTunedBatchSize  int           // BATCH_SIZE_MAX
+	TargetBatchLatency time.Duration // BATCH_TARGET_LATENCY
+}
+
+// MongoConfig holds how to reach MongoDB. URL, when set, is used as is
+// instead of the address built from the other fields.
+type MongoConfig struct {
+	URL            string        // MONGODB_URL
+	Host           string        // MONGODB_HOST
+	Port           int           // MONGODB_PORT, ignored with SRV
+	User           string        // MONGODB_USER
+	Password       string        // MONGODB_PASSWORD
+	Database       string        // MONGODB_DB
+	SRV            bool          // MONGODB_SRV
+	TLS            bool          // MONGODB_TLS
+	ReplicaSet     string        // MONGODB_REPLICA_SET
+	AuthSource     string        // MONGODB_AUTH_SOURCE; admin when credentials are set
+	MaxPoolSize    uint64        // MONGODB_MAX_POOL_SIZE
+	MinPoolSize    uint64        // MONGODB_MIN_POOL_SIZE
+	ConnectTimeout time.Duration // MONGODB_CONNECT_TIMEOUT
+	ConnectRetries int           // MONGODB_CONNECT_RETRIES
+	RetryBaseDelay time.Duration // MONGODB_RETRY_BASE_DELAY
+}
+
+// Default returns the configuration used when no variable is set.
+func Default() Config {
+	return Config{
+		Auction: AuctionConfig{
+			Interval:              5 * time.Minute,
+			MinIncrement:          0,
+			EnumStorage:           "int",
+			CloseCheckInterval:    10 * time.Second,
+			CloseBatchSize:        500,
+			DeletedResponse:       DeletedAuctionNotFound,
+			MaxLeaderboardSize:    100,
+			MaxClosingSubscribers: 100,
+		},
+		Bid: BidConfig{
+			MaxBatchSize:        5,
+			BatchInsertInterval: 3 * time.Minute,
+			AllowSelfOutbid:     false,
+			MinSelfRaise:        0,
+			TiePolicy:           bid_entity.RejectEqual,
+			AmountLimits:        bid_entity.AmountLimits{Decimals: 2},
+
+			EnqueueTimeout:       5 * time.Second,
+			QueueBlocking:        true,
+			MaxPendingAuctions:   10000,
+			MaxStreamSubscribers: 1000,
+			StallThreshold:       30 * time.Second,
+
+			MinTunedBatchSize:  1,
+			MaxTunedBatchSize:  100,
+			TargetBatchLatency: 200 * time.Millisecond,
+		},
+		Server: ServerConfig{
+			ReadTimeout:        15 * time.Second,
+			WriteTimeout:       30 * time.Second,
+			IdleTimeout:        60 * time.Second,
+			MaxHeaderBytes:     http.DefaultMaxHeaderBytes,
+			RequestTimeout:     10 * time.Second,
+			HealthCheckTimeout: 2 * time.Second,
+			BidRateLimit:       10,
+			BidRateBurst:       20,
+			BidCreateStatus:    http.StatusCreated,
+		},
+		Callback: CallbackConfig{
+			MaxAttempts:  3,
+			RetryBackoff: time.Second,
+			Timeout:      5 * time.Second,
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Mongo: MongoConfig{
+			Host:           "localhost",
+			Port:           27017,
+			MaxPoolSize:    100,
+			MinPoolSize:    0,
+			ConnectTimeout: 10 * time.Second,
+			ConnectRetries: 5,
+			RetryBaseDelay: time.Second,
+		},
+	}
+}
+
+// LoadConfig reads the configuration from the environment. Unset variables
+// take their defaults; every variable that is set but malformed is reported,
+// so a typo fails the startup instead of silently falling back.
+func LoadConfig() (*Config, error) {
+	config := Default()
+	loader := &envLoader{}
+
+	loader.duration("AUCTION_INTERVAL", &config.Auction.Interval)
+	loader.nonNegativeFloat("AUCTION_MIN_INCREMENT", &config.Auction.MinIncrement)
+	choice(loader, "AUCTION_ENUM_STORAGE", &config.Auction.EnumStorage, "int", "string")
+	loader.duration("AUCTION_CLOSE_CHECK_INTERVAL", &config.Auction.CloseCheckInterval)
+	loader.positiveInt("AUCTION_CLOSE_BATCH_SIZE", &config.Auction.CloseBatchSize)
+	loader.bool("DISABLE_AUCTION_CLOSER", &config.Auction.DisableCloser)
+	loader.bool("CLOSER_LOCK", &config.Auction.CloserLock)
+	choice(loader, "DELETED_AUCTION_RESPONSE", &config.Auction.DeletedResponse,
+		DeletedAuctionNotFound, DeletedAuctionGone)
+	loader.positiveInt("MAX_LEADERBOARD_SIZE", &config.Auction.MaxLeaderboardSize)
+	loader.positiveInt("MAX_CLOSING_STREAM_SUBSCRIBERS", &config.Auction.MaxClosingSubscribers)
+
+	loader.positiveInt("MAX_BATCH_SIZE", &config.Bid.MaxBatchSize)
+	loader.duration("BATCH_INSERT_INTERVAL", &config.Bid.BatchInsertInterval)
+	loader.bool("ALLOW_SELF_OUTBID", &config.Bid.AllowSelfOutbid)
+	loader.nonNegativeFloat("MIN_SELF_RAISE", &config.Bid.MinSelfRaise)
+	choice(loader, "BID_TIE_POLICY", &config.Bid.TiePolicy, bid_entity.RejectEqual, bid_entity.LastWriteWins)
+	loader.nonNegativeFloat("MAX_BID_AMOUNT", &config.Bid.AmountLimits.Max)
+	// Amounts are held in cents. BID_AMOUNT_MAX_DECIMALS is the former name
+	// of BID_DECIMAL_PLACES, which wins when both are set.
+	loader.intRange("BID_AMOUNT_MAX_DECIMALS", &config.Bid.AmountLimits.Decimals, 0, 2)
+	loader.intRange("BID_DECIMAL_PLACES", &config.Bid.AmountLimits.Decimals, 0, 2)
+	loader.nonNegativeDuration("BID_COOLDOWN", &config.Bid.Cooldown)
+	loader.duration("BID_ENQUEUE_TIMEOUT", &config.Bid.EnqueueTimeout)
+	loader.bool("BID_QUEUE_BLOCKING", &config.Bid.QueueBlocking)
+	loader.nonNegativeInt("MAX_CONCURRENT_BIDS", &config.Bid.MaxConcurrentBids)
+	loader.positiveInt("MAX_PENDING_AUCTIONS", &config.Bid.MaxPendingAuctions)
+	loader.positiveInt("MAX_BID_STREAM_SUBSCRIBERS", &config.Bid.MaxStreamSubscribers)
+	loader.duration("BATCH_ROUTINE_STALL_THRESHOLD", &config.Bid.StallThreshold)
+	loader.bool("RECORD_REJECTED_BIDS", &config.Bid.RecordRejected)
+	loader.bool("USER_LOOKUP_DEGRADED_MODE", &config.Bid.UserLookupDegraded)
+	loader.text("BID_EVENT_LOG_PATH", &config.Bid.EventLogPath)
+	loader.bool("BATCH_SIZE_AUTOTUNE", &config.Bid.Autotune)
+	loader.positiveInt("BATCH_SIZE_MIN", &config.Bid.MinTunedBatchSize)
+	loader.positiveInt("BATCH_SIZE_MAX", &config.Bid.MaxTunedBatchSize)
+	loader.duration("BATCH_TARGET_LATENCY", &config.Bid.TargetBatchLatency)
+	if min, max := config.Bid.MinTunedBatchSize, config.Bid.MaxTunedBatchSize; min > max {
+		loader.errs = append(loader.errs, fmt.Errorf(
+			"invalid BATCH_SIZE_MIN %d: must not exceed BATCH_SIZE_MAX %d", min, max))
+	}
+
+	loader.nonNegativeDuration("HTTP_READ_TIMEOUT", &config.Server.ReadTimeout)
+	loader.nonNegativeDuration("HTTP_WRITE_TIMEOUT", &config.Server.WriteTimeout)
+	loader.nonNegativeDuration("HTTP_IDLE_TIMEOUT", &config.Server.IdleTimeout)
+	loader.positiveInt("HTTP_MAX_HEADER_BYTES", &config.Server.MaxHeaderBytes)
+	loader.nonNegativeInt("HTTP_MAX_CONNECTIONS", &config.Server.MaxConnections)
+	loader.duration("REQUEST_TIMEOUT", &config.Server.RequestTimeout)
+	loader.duration("HEALTH_CHECK_TIMEOUT", &config.Server.HealthCheckTimeout)
+	loader.positiveFloat("BID_RATE_LIMIT", &config.Server.BidRateLimit)
+	loader.positiveInt("BID_RATE_BURST", &config.Server.BidRateBurst)
+	loader.statusCode("BID_CREATE_STATUS", &config.Server.BidCreateStatus, http.StatusCreated, http.StatusAccepted)
+	loader.bool("ALLOW_TEST_PURGE", &config.Server.AllowTestPurge)
+	loader.addresses("TRUSTED_PROXIES", &config.Server.TrustedProxies)
+	loader.text("ADMIN_TOKEN", &config.Server.AdminToken)
+	loader.origins("STREAM_ALLOWED_ORIGINS", &config.Server.StreamOrigins)
+
+	loader.positiveInt("CALLBACK_MAX_ATTEMPTS", &config.Callback.MaxAttempts)
+	loader.duration("CALLBACK_RETRY_BACKOFF", &config.Callback.RetryBackoff)
+	loader.duration("CALLBACK_TIMEOUT", &config.Callback.Timeout)
+
+	choice(loader, "LOG_FORMAT", &config.Log.Format, "json", "text")
+	choice(loader, "LOG_LEVEL", &config.Log.Level, "debug", "info", "warn", "error")
+
+	loader.text("MONGODB_URL", &config.Mongo.URL)
+	loader.text("MONGODB_HOST", &config.Mongo.Host)
+	loader.intRange("MONGODB_PORT", &config.Mongo.Port, 1, 65535)
+	loader.text("MONGODB_USER", &config.Mongo.User)
+	loader.text("MONGODB_PASSWORD", &config.Mongo.Password)
+	loader.text("MONGODB_DB", &config.Mongo.Database)
+	loader.bool("MONGODB_SRV", &config.Mongo.SRV)
+	loader.bool("MONGODB_TLS", &config.Mongo.TLS)
+	loader.text("MONGODB_REPLICA_SET", &config.Mongo.ReplicaSet)
+	loader.text("MONGODB_AUTH_SOURCE", &config.Mongo.AuthSource)
+	loader.uint("MONGODB_MAX_POOL_SIZE", &config.Mongo.MaxPoolSize)
+	loader.uint("MONGODB_MIN_POOL_SIZE", &config.Mongo.MinPoolSize)
+	loader.duration("MONGODB_CONNECT_TIMEOUT", &config.Mongo.ConnectTimeout)
+	loader.positiveInt("MONGODB_CONNECT_RETRIES", &config.Mongo.ConnectRetries)
+	loader.duration("MONGODB_RETRY_BASE_DELAY", &config.Mongo.RetryBaseDelay)
+	// A max pool size of 0 means no limit
+	if max, min := config.Mongo.MaxPoolSize, config.Mongo.MinPoolSize; max > 0 && min > max {
+		loader.errs = append(loader.errs, fmt.Errorf(
+			"invalid MONGODB_MIN_POOL_SIZE %d: must not exceed MONGODB_MAX_POOL_SIZE %d", min, max))
+	}
+
+	if err := errors.Join(loader.errs...); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// envLoader overrides the defaults with the variables that are set and
+// collects the malformed ones.
+type envLoader struct {
+	errs []error
+}
+
+func (l *envLoader) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	return value, ok && value != ""
+}
+
+func (l *envLoader) duration(key string, target *time.Duration) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected a positive duration like 5m or 30s", key, value))
+		return
+	}
+	*target = duration
+}
+
+func (l *envLoader) nonNegativeDuration(key string, target *time.Duration) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected a duration like 5m or 30s, or 0", key, value))
+		return
+	}
+	*target = duration
+}
+
+func (l *envLoader) positiveInt(key string, target *int) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected a positive integer", key, value))
+		return
+	}
+	*target = number
+}
+
+func (l *envLoader) nonNegativeInt(key string, target *int) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected a non-negative integer", key, value))
+		return
+	}
+	*target = number
+}
+
+// statusCode accepts only one of the allowed HTTP status codes.
+func (l *envLoader) statusCode(key string, target *int, allowed ...int) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	codes := make([]string, len(allowed))
+	for i, code := range allowed {
+		if value == strconv.Itoa(code) {
+			*target = code
+			return
+		}
+		codes[i] = strconv.Itoa(code)
+	}
+	l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected one of %s", key, value, strings.Join(codes, ", ")))
+}
+
+func (l *envLoader) intRange(key string, target *int, min, max int) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected an integer from %d to %d", key, value, min, max))
+		return
+	}
+	*target = number
+}
+
+func (l *envLoader) uint(key string, target *uint64) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected a non-negative integer", key, value))
+		return
+	}
+	*target = number
+}
+
+func (l *envLoader) positiveFloat(key string, target *float64) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(number > 0) || math.IsInf(number, 0) {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected a positive number", key, value))
+		return
+	}
+	*target = number
+}
+
+func (l *envLoader) nonNegativeFloat(key string, target *float64) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(number >= 0) || math.IsInf(number, 0) {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected a number not below 0", key, value))
+		return
+	}
+	*target = number
+}
+
+func (l *envLoader) text(key string, target *string) {
+	if value, ok := l.lookup(key); ok {
+		*target = value
+	}
+}
+
+// choice accepts only one of the allowed values.
+func choice[T ~string](l *envLoader, key string, target *T, allowed ...T) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	names := make([]string, len(allowed))
+	for i, option := range allowed {
+		if T(value) == option {
+			*target = option
+			return
+		}
+		names[i] = string(option)
+	}
+	l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected one of %s", key, value, strings.Join(names, ", ")))
+}
+
+// bool accepts what strconv.ParseBool does, plus yes and no.
+func (l *envLoader) bool(key string, target *bool) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	switch value {
+	case "yes":
+		*target = true
+		return
+	case "no":
+		*target = false
+		return
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected true or false", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// addresses reads a comma-separated list of IPs and CIDR ranges.
+func (l *envLoader) addresses(key string, target *[]string) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	var addresses []string
+	for _, address := range strings.Split(value, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if net.ParseIP(address) == nil {
+			if _, _, err := net.ParseCIDR(address); err != nil {
+				l.errs = append(l.errs, fmt.Errorf("invalid %s %q: expected IPs or CIDR ranges separated by commas", key, value))
+				return
+			}
+		}
+		addresses = append(addresses, address)
+	}
+	*target = addresses
+}
+
+// origins reads a comma-separated list of web origins, such as
+// https://app.example.com or http://localhost:3000, without path or query.
+func (l *envLoader) origins(key string, target *[]string) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		parsed, err := url.Parse(origin)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			(parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.User != nil {
+			l.errs = append(l.errs, fmt.Errorf(
+				"invalid %s %q: expected origins like https://app.example.com separated by commas", key, value))
+			return
+		}
+		origins = append(origins, parsed.Scheme+"://"+strings.ToLower(parsed.Host))
+	}
+	*target = origins
+}
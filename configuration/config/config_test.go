@@ -0,0 +1,351 @@
+package config_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+// clearEnv unsets every variable LoadConfig reads for the rest of the test.
+func clearEnv(t *testing.T) {
+	for _, key := range []string{
+		"AUCTION_INTERVAL", "AUCTION_MIN_INCREMENT", "AUCTION_ENUM_STORAGE", "AUCTION_CLOSE_CHECK_INTERVAL",
+		"AUCTION_CLOSE_BATCH_SIZE", "DISABLE_AUCTION_CLOSER", "CLOSER_LOCK", "DELETED_AUCTION_RESPONSE",
+		"MAX_LEADERBOARD_SIZE", "MAX_CLOSING_STREAM_SUBSCRIBERS",
+		"MAX_BATCH_SIZE", "BATCH_INSERT_INTERVAL", "ALLOW_SELF_OUTBID", "MIN_SELF_RAISE",
+		"BID_TIE_POLICY", "MAX_BID_AMOUNT", "BID_DECIMAL_PLACES", "BID_AMOUNT_MAX_DECIMALS", "BID_COOLDOWN",
+		"BID_ENQUEUE_TIMEOUT", "BID_QUEUE_BLOCKING", "MAX_CONCURRENT_BIDS", "MAX_PENDING_AUCTIONS",
+		"MAX_BID_STREAM_SUBSCRIBERS", "BATCH_ROUTINE_STALL_THRESHOLD", "RECORD_REJECTED_BIDS",
+		"USER_LOOKUP_DEGRADED_MODE", "BID_EVENT_LOG_PATH",
+		"BATCH_SIZE_AUTOTUNE", "BATCH_SIZE_MIN", "BATCH_SIZE_MAX", "BATCH_TARGET_LATENCY",
+		"HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "HTTP_MAX_HEADER_BYTES",
+		"HTTP_MAX_CONNECTIONS", "REQUEST_TIMEOUT", "HEALTH_CHECK_TIMEOUT", "BID_RATE_LIMIT", "BID_RATE_BURST",
+		"BID_CREATE_STATUS", "ALLOW_TEST_PURGE", "TRUSTED_PROXIES", "ADMIN_TOKEN", "STREAM_ALLOWED_ORIGINS",
+		"CALLBACK_MAX_ATTEMPTS", "CALLBACK_RETRY_BACKOFF", "CALLBACK_TIMEOUT", "LOG_FORMAT", "LOG_LEVEL",
+		"MONGODB_URL", "MONGODB_HOST", "MONGODB_PORT", "MONGODB_USER", "MONGODB_PASSWORD",
+		"MONGODB_DB", "MONGODB_SRV", "MONGODB_TLS", "MONGODB_REPLICA_SET", "MONGODB_AUTH_SOURCE",
+		"MONGODB_MAX_POOL_SIZE", "MONGODB_MIN_POOL_SIZE", "MONGODB_CONNECT_TIMEOUT",
+		"MONGODB_CONNECT_RETRIES", "MONGODB_RETRY_BASE_DELAY",
+	} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	clearEnv(t)
+
+	appConfig, err := config.LoadConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, config.Default(), *appConfig)
+
+	assert.Equal(t, 5*time.Minute, appConfig.Auction.Interval)
+	assert.Equal(t, 10*time.Second, appConfig.Auction.CloseCheckInterval)
+	assert.Equal(t, 5, appConfig.Bid.MaxBatchSize)
+	assert.Equal(t, 3*time.Minute, appConfig.Bid.BatchInsertInterval)
+	assert.False(t, appConfig.Bid.AllowSelfOutbid)
+	assert.Equal(t, bid_entity.RejectEqual, appConfig.Bid.TiePolicy)
+	assert.Equal(t, bid_entity.AmountLimits{Decimals: 2}, appConfig.Bid.AmountLimits)
+	assert.Equal(t, 500, appConfig.Auction.CloseBatchSize)
+	assert.Equal(t, config.DeletedAuctionNotFound, appConfig.Auction.DeletedResponse)
+	assert.Empty(t, appConfig.Server.TrustedProxies)
+	assert.Empty(t, appConfig.Server.AdminToken)
+	assert.Empty(t, appConfig.Server.StreamOrigins)
+	assert.Equal(t, "int", appConfig.Auction.EnumStorage)
+	assert.Equal(t, 100, appConfig.Auction.MaxLeaderboardSize)
+	assert.Zero(t, appConfig.Bid.Cooldown)
+	assert.True(t, appConfig.Bid.QueueBlocking)
+	assert.Equal(t, 5*time.Second, appConfig.Bid.EnqueueTimeout)
+	assert.Equal(t, 15*time.Second, appConfig.Server.ReadTimeout)
+	assert.Equal(t, http.DefaultMaxHeaderBytes, appConfig.Server.MaxHeaderBytes)
+	assert.Equal(t, http.StatusCreated, appConfig.Server.BidCreateStatus)
+	assert.False(t, appConfig.Server.AllowTestPurge)
+	assert.Equal(t, 3, appConfig.Callback.MaxAttempts)
+	assert.Equal(t, config.LogConfig{Format: "json", Level: "info"}, appConfig.Log)
+	assert.Equal(t, "localhost", appConfig.Mongo.Host)
+	assert.Equal(t, 27017, appConfig.Mongo.Port)
+}
+
+func TestLoadConfigOverrides(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("AUCTION_INTERVAL", "20s")
+	t.Setenv("AUCTION_MIN_INCREMENT", "0.5")
+	t.Setenv("AUCTION_ENUM_STORAGE", "string")
+	t.Setenv("DISABLE_AUCTION_CLOSER", "true")
+	t.Setenv("CLOSER_LOCK", "1")
+	t.Setenv("MAX_LEADERBOARD_SIZE", "20")
+	t.Setenv("MAX_CLOSING_STREAM_SUBSCRIBERS", "10")
+	t.Setenv("AUCTION_CLOSE_CHECK_INTERVAL", "2s")
+	t.Setenv("MAX_BATCH_SIZE", "50")
+	t.Setenv("BATCH_INSERT_INTERVAL", "500ms")
+	t.Setenv("ALLOW_SELF_OUTBID", "true")
+	t.Setenv("AUCTION_CLOSE_BATCH_SIZE", "50")
+	t.Setenv("DELETED_AUCTION_RESPONSE", "gone")
+	t.Setenv("MIN_SELF_RAISE", "2.5")
+	t.Setenv("BID_TIE_POLICY", "last_write_wins")
+	t.Setenv("MAX_BID_AMOUNT", "5000")
+	t.Setenv("BID_DECIMAL_PLACES", "1")
+	t.Setenv("BID_COOLDOWN", "2s")
+	t.Setenv("BID_ENQUEUE_TIMEOUT", "1s")
+	t.Setenv("BID_QUEUE_BLOCKING", "false")
+	t.Setenv("MAX_CONCURRENT_BIDS", "200")
+	t.Setenv("MAX_PENDING_AUCTIONS", "500")
+	t.Setenv("MAX_BID_STREAM_SUBSCRIBERS", "50")
+	t.Setenv("BATCH_ROUTINE_STALL_THRESHOLD", "1m")
+	t.Setenv("RECORD_REJECTED_BIDS", "true")
+	t.Setenv("USER_LOOKUP_DEGRADED_MODE", "yes")
+	t.Setenv("BID_EVENT_LOG_PATH", "/var/lib/auction/bids.log")
+	t.Setenv("BATCH_SIZE_AUTOTUNE", "true")
+	t.Setenv("BATCH_SIZE_MIN", "10")
+	t.Setenv("BATCH_SIZE_MAX", "400")
+	t.Setenv("BATCH_TARGET_LATENCY", "50ms")
+	t.Setenv("HTTP_READ_TIMEOUT", "5s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "0")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "2m")
+	t.Setenv("HTTP_MAX_HEADER_BYTES", "8192")
+	t.Setenv("HTTP_MAX_CONNECTIONS", "500")
+	t.Setenv("REQUEST_TIMEOUT", "3s")
+	t.Setenv("HEALTH_CHECK_TIMEOUT", "500ms")
+	t.Setenv("BID_RATE_LIMIT", "0.5")
+	t.Setenv("BID_RATE_BURST", "4")
+	t.Setenv("BID_CREATE_STATUS", "202")
+	t.Setenv("ALLOW_TEST_PURGE", "true")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 172.16.0.0/12")
+	t.Setenv("ADMIN_TOKEN", "secret")
+	t.Setenv("STREAM_ALLOWED_ORIGINS", "https://App.example.com, http://localhost:3000/")
+	t.Setenv("CALLBACK_MAX_ATTEMPTS", "5")
+	t.Setenv("CALLBACK_RETRY_BACKOFF", "250ms")
+	t.Setenv("CALLBACK_TIMEOUT", "10s")
+	t.Setenv("LOG_FORMAT", "text")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("MONGODB_URL", "mongodb://mongo:27017")
+	t.Setenv("MONGODB_HOST", "mongodb")
+	t.Setenv("MONGODB_PORT", "27018")
+	t.Setenv("MONGODB_USER", "admin")
+	t.Setenv("MONGODB_PASSWORD", "pass")
+	t.Setenv("MONGODB_DB", "auctions")
+	t.Setenv("MONGODB_SRV", "true")
+	t.Setenv("MONGODB_TLS", "yes")
+	t.Setenv("MONGODB_REPLICA_SET", "rs0")
+	t.Setenv("MONGODB_AUTH_SOURCE", "users")
+	t.Setenv("MONGODB_MAX_POOL_SIZE", "50")
+	t.Setenv("MONGODB_MIN_POOL_SIZE", "5")
+	t.Setenv("MONGODB_CONNECT_TIMEOUT", "3s")
+	t.Setenv("MONGODB_CONNECT_RETRIES", "2")
+	t.Setenv("MONGODB_RETRY_BASE_DELAY", "100ms")
+
+	appConfig, err := config.LoadConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, config.Config{
+		Auction: config.AuctionConfig{
+			Interval:              20 * time.Second,
+			MinIncrement:          0.5,
+			EnumStorage:           "string",
+			CloseCheckInterval:    2 * time.Second,
+			CloseBatchSize:        50,
+			DisableCloser:         true,
+			CloserLock:            true,
+			DeletedResponse:       config.DeletedAuctionGone,
+			MaxLeaderboardSize:    20,
+			MaxClosingSubscribers: 10,
+		},
+		Bid: config.BidConfig{
+			MaxBatchSize:        50,
+			BatchInsertInterval: 500 * time.Millisecond,
+			AllowSelfOutbid:     true,
+			MinSelfRaise:        2.5,
+			TiePolicy:           bid_entity.LastWriteWins,
+			AmountLimits:        bid_entity.AmountLimits{Max: 5000, Decimals: 1},
+			Cooldown:            2 * time.Second,
+
+			EnqueueTimeout:       time.Second,
+			QueueBlocking:        false,
+			MaxConcurrentBids:    200,
+			MaxPendingAuctions:   500,
+			MaxStreamSubscribers: 50,
+			StallThreshold:       time.Minute,
+			RecordRejected:       true,
+			UserLookupDegraded:   true,
+			EventLogPath:         "/var/lib/auction/bids.log",
+
+			Autotune:           true,
+			MinTunedBatchSize:  10,
+			MaxTunedBatchSize:  400,
+			TargetBatchLatency: 50 * time.Millisecond,
+		},
+		Server: config.ServerConfig{
+			ReadTimeout:        5 * time.Second,
+			WriteTimeout:       0,
+			IdleTimeout:        2 * time.Minute,
+			MaxHeaderBytes:     8192,
+			MaxConnections:     500,
+			RequestTimeout:     3 * time.Second,
+			HealthCheckTimeout: 500 * time.Millisecond,
+			BidRateLimit:       0.5,
+			BidRateBurst:       4,
+			BidCreateStatus:    http.StatusAccepted,
+			AllowTestPurge:     true,
+
+			TrustedProxies: []string{"10.0.0.1", "172.16.0.0/12"},
+			AdminToken:     "secret",
+			StreamOrigins:  []string{"https://app.example.com", "http://localhost:3000"},
+		},
+		Callback: config.CallbackConfig{
+			MaxAttempts:  5,
+			RetryBackoff: 250 * time.Millisecond,
+			Timeout:      10 * time.Second,
+		},
+		Log: config.LogConfig{
+			Format: "text",
+			Level:  "debug",
+		},
+		Mongo: config.MongoConfig{
+			URL:            "mongodb://mongo:27017",
+			Host:           "mongodb",
+			Port:           27018,
+			User:           "admin",
+			Password:       "pass",
+			Database:       "auctions",
+			SRV:            true,
+			TLS:            true,
+			ReplicaSet:     "rs0",
+			AuthSource:     "users",
+			MaxPoolSize:    50,
+			MinPoolSize:    5,
+			ConnectTimeout: 3 * time.Second,
+			ConnectRetries: 2,
+			RetryBaseDelay: 100 * time.Millisecond,
+		},
+	}, *appConfig)
+}
+
+func TestLoadConfigAllowSelfOutbidValues(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"true": true, "1": true, "yes": true, "TRUE": true,
+		"false": false, "0": false, "no": false,
+	} {
+		clearEnv(t)
+		t.Setenv("ALLOW_SELF_OUTBID", value)
+
+		appConfig, err := config.LoadConfig()
+		if assert.Nil(t, err, value) {
+			assert.Equal(t, expected, appConfig.Bid.AllowSelfOutbid, value)
+		}
+	}
+}
+
+func TestLoadConfigRejectsMalformedValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("AUCTION_INTERVAL", "5minutes")
+	t.Setenv("AUCTION_CLOSE_CHECK_INTERVAL", "10s")
+	t.Setenv("BATCH_INSERT_INTERVAL", "0s")
+	t.Setenv("MAX_BATCH_SIZE", "many")
+	t.Setenv("ALLOW_SELF_OUTBID", "maybe")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1,proxy.local")
+	t.Setenv("STREAM_ALLOWED_ORIGINS", "https://app.example.com/path")
+	t.Setenv("AUCTION_CLOSE_BATCH_SIZE", "0")
+	t.Setenv("DELETED_AUCTION_RESPONSE", "hidden")
+	t.Setenv("MIN_SELF_RAISE", "-1")
+	t.Setenv("BID_TIE_POLICY", "first_wins")
+	t.Setenv("MAX_BID_AMOUNT", "NaN")
+	t.Setenv("BID_DECIMAL_PLACES", "3")
+	t.Setenv("MONGODB_PORT", "mongo")
+	t.Setenv("MONGODB_MAX_POOL_SIZE", "-1")
+	t.Setenv("MONGODB_CONNECT_RETRIES", "0")
+	t.Setenv("AUCTION_ENUM_STORAGE", "names")
+	t.Setenv("AUCTION_MIN_INCREMENT", "-0.5")
+	t.Setenv("BID_COOLDOWN", "-1s")
+	t.Setenv("BID_QUEUE_BLOCKING", "sometimes")
+	t.Setenv("MAX_CONCURRENT_BIDS", "-1")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "-1s")
+	t.Setenv("HTTP_MAX_HEADER_BYTES", "0")
+	t.Setenv("BID_RATE_LIMIT", "0")
+	t.Setenv("BID_CREATE_STATUS", "200")
+	t.Setenv("CALLBACK_MAX_ATTEMPTS", "0")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("LOG_LEVEL", "verbose")
+
+	appConfig, err := config.LoadConfig()
+	assert.Nil(t, appConfig)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `invalid AUCTION_INTERVAL "5minutes"`)
+		assert.Contains(t, err.Error(), `invalid BATCH_INSERT_INTERVAL "0s"`)
+		assert.Contains(t, err.Error(), `invalid MAX_BATCH_SIZE "many"`)
+		assert.Contains(t, err.Error(), `invalid ALLOW_SELF_OUTBID "maybe"`)
+		assert.Contains(t, err.Error(), `invalid TRUSTED_PROXIES "10.0.0.1,proxy.local"`)
+		assert.Contains(t, err.Error(), `invalid STREAM_ALLOWED_ORIGINS "https://app.example.com/path"`)
+		assert.Contains(t, err.Error(), `invalid AUCTION_CLOSE_BATCH_SIZE "0"`)
+		assert.Contains(t, err.Error(), `invalid DELETED_AUCTION_RESPONSE "hidden": expected one of not_found, gone`)
+		assert.Contains(t, err.Error(), `invalid MIN_SELF_RAISE "-1"`)
+		assert.Contains(t, err.Error(), `invalid BID_TIE_POLICY "first_wins": expected one of reject_equal, last_write_wins`)
+		assert.Contains(t, err.Error(), `invalid MAX_BID_AMOUNT "NaN"`)
+		assert.Contains(t, err.Error(), `invalid BID_DECIMAL_PLACES "3": expected an integer from 0 to 2`)
+		assert.Contains(t, err.Error(), `invalid MONGODB_PORT "mongo"`)
+		assert.Contains(t, err.Error(), `invalid MONGODB_MAX_POOL_SIZE "-1"`)
+		assert.Contains(t, err.Error(), `invalid MONGODB_CONNECT_RETRIES "0"`)
+		assert.Contains(t, err.Error(), `invalid AUCTION_ENUM_STORAGE "names": expected one of int, string`)
+		assert.Contains(t, err.Error(), `invalid AUCTION_MIN_INCREMENT "-0.5"`)
+		assert.Contains(t, err.Error(), `invalid BID_COOLDOWN "-1s"`)
+		assert.Contains(t, err.Error(), `invalid BID_QUEUE_BLOCKING "sometimes"`)
+		assert.Contains(t, err.Error(), `invalid MAX_CONCURRENT_BIDS "-1"`)
+		assert.Contains(t, err.Error(), `invalid HTTP_WRITE_TIMEOUT "-1s"`)
+		assert.Contains(t, err.Error(), `invalid HTTP_MAX_HEADER_BYTES "0"`)
+		assert.Contains(t, err.Error(), `invalid BID_RATE_LIMIT "0"`)
+		assert.Contains(t, err.Error(), `invalid BID_CREATE_STATUS "200": expected one of 201, 202`)
+		assert.Contains(t, err.Error(), `invalid CALLBACK_MAX_ATTEMPTS "0"`)
+		assert.Contains(t, err.Error(), `invalid LOG_FORMAT "xml"`)
+		assert.Contains(t, err.Error(), `invalid LOG_LEVEL "verbose"`)
+		assert.NotContains(t, err.Error(), "AUCTION_CLOSE_CHECK_INTERVAL")
+	}
+}
+
+func TestLoadConfigDecimalPlacesFormerName(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("BID_AMOUNT_MAX_DECIMALS", "1")
+
+	appConfig, err := config.LoadConfig()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, appConfig.Bid.AmountLimits.Decimals)
+	}
+
+	// The current name wins when both are set
+	t.Setenv("BID_DECIMAL_PLACES", "0")
+
+	appConfig, err = config.LoadConfig()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0, appConfig.Bid.AmountLimits.Decimals)
+	}
+}
+
+func TestLoadConfigRejectsMinBatchSizeAboveMax(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("BATCH_SIZE_MIN", "200")
+	t.Setenv("BATCH_SIZE_MAX", "100")
+
+	appConfig, err := config.LoadConfig()
+	assert.Nil(t, appConfig)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid BATCH_SIZE_MIN 200: must not exceed BATCH_SIZE_MAX 100")
+	}
+}
+
+func TestLoadConfigRejectsMinPoolAboveMax(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("MONGODB_MAX_POOL_SIZE", "10")
+	t.Setenv("MONGODB_MIN_POOL_SIZE", "20")
+
+	appConfig, err := config.LoadConfig()
+	assert.Nil(t, appConfig)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid MONGODB_MIN_POOL_SIZE 20: must not exceed MONGODB_MAX_POOL_SIZE 10")
+	}
+
+	// Without a maximum the pool is unbounded
+	t.Setenv("MONGODB_MAX_POOL_SIZE", "0")
+
+	_, err = config.LoadConfig()
+	assert.Nil(t, err)
+}
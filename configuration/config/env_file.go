@@ -0,0 +1,30 @@
+package config
+
+import (
+	"log"
+
+	"github.com/joho/godotenv"
+)
+
+// envFilePaths are the .env files looked for, in order: the one copied into
+// the scratch container, then the ones found from the repository root.
+var envFilePaths = []string{
+	"/cmd/auction/.env",
+	"cmd/auction/.env",
+	".env",
+}
+
+// LoadEnvFile loads the first .env file found into the environment, without
+// overriding the variables already set there, so every command reads the same
+// settings as the application. A missing file is not an error: the settings
+// then come from the environment alone.
+func LoadEnvFile() {
+	for _, path := range envFilePaths {
+		if err := godotenv.Load(path); err == nil {
+			log.Printf("Loaded environment from: %s", path)
+			return
+		}
+	}
+
+	log.Println("No .env file found, using system environment variables")
+}
@@ -0,0 +1,49 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// unsetEnv unsets the variables for the rest of the test, restoring them after.
+func unsetEnv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	t.Run("loads the .env file without overriding the environment", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, "cmd", "auction"), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "cmd", "auction", ".env"),
+			[]byte("AUCTION_INTERVAL=7m\nMAX_BATCH_SIZE=9\n"), 0o644))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("BID_COOLDOWN=3s\n"), 0o644))
+		t.Chdir(dir)
+		unsetEnv(t, "AUCTION_INTERVAL", "BID_COOLDOWN")
+		t.Setenv("MAX_BATCH_SIZE", "4")
+
+		config.LoadEnvFile()
+
+		assert.Equal(t, "7m", os.Getenv("AUCTION_INTERVAL"))
+		assert.Equal(t, "4", os.Getenv("MAX_BATCH_SIZE"))
+		// Only the first file found is loaded
+		_, loaded := os.LookupEnv("BID_COOLDOWN")
+		assert.False(t, loaded)
+	})
+
+	t.Run("no file leaves the environment as it is", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		unsetEnv(t, "AUCTION_INTERVAL")
+
+		config.LoadEnvFile()
+
+		_, loaded := os.LookupEnv("AUCTION_INTERVAL")
+		assert.False(t, loaded)
+	})
+}
@@ -0,0 +1,8 @@
+package mongodb
+
+var (
+	BuildMongoURI      = buildMongoURI
+	MaskPassword       = maskPassword
+	BuildClientOptions = buildClientOptions
+	ConnectWithRetry   = connectWithRetry
+)
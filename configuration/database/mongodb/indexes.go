@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes lista os índices criados por EnsureIndexes, por coleção
+var collectionIndexes = map[string][]mongo.IndexModel{
+	// Rotina de fechamento (status + expires_at) e filtros de FindAuctions
+	"auctions": {
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("status_expires_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "category", Value: 1}},
+			Options: options.Index().SetName("category"),
+		},
+	},
+	// Lances de um leilão; o prefixo auction_id atende também as buscas só
+	// por leilão, e amount_cents decrescente entrega o lance vencedor primeiro.
+	// O antigo auction_id_amount_desc, sobre o valor em float, pode ser removido
+	// depois de migrar os lances (cmd/migrate bid-amounts)
+	"bids": {
+		{
+			Keys:    bson.D{{Key: "auction_id", Value: 1}, {Key: "amount_cents", Value: -1}},
+			Options: options.Index().SetName("auction_id_amount_cents_desc"),
+		},
+	},
+}
+
+// EnsureIndexes cria os índices usados pelas consultas da aplicação. É
+// idempotente: recriar um índice já existente, com o mesmo nome e as mesmas
+// chaves, não tem efeito.
+func EnsureIndexes(ctx context.Context, database *mongo.Database) error {
+	for _, collection := range []string{"auctions", "bids"} {
+		names, err := database.Collection(collection).Indexes().CreateMany(ctx, collectionIndexes[collection])
+		if err != nil {
+			err = fmt.Errorf("creating indexes of %s: %w", collection, err)
+			logger.Error("Error trying to create MongoDB indexes", err)
+			return err
+		}
+
+		logger.Info(fmt.Sprintf("MongoDB indexes ready on %s: %v", collection, names))
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package mongodb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/database/mongodb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// createdIndexes returns the keys of each index sent in createIndexes
+// commands, by collection and index name.
+func createdIndexes(mt *mtest.T) map[string]map[string]bson.D {
+	created := map[string]map[string]bson.D{}
+	for _, event := range mt.GetAllStartedEvents() {
+		if event.CommandName != "createIndexes" {
+			continue
+		}
+		collection := event.Command.Lookup("createIndexes").StringValue()
+		if created[collection] == nil {
+			created[collection] = map[string]bson.D{}
+		}
+
+		indexes, _ := event.Command.Lookup("indexes").Array().Values()
+		for _, index := range indexes {
+			var keys bson.D
+			assert.NoError(mt, bson.Unmarshal(index.Document().Lookup("key").Document(), &keys))
+			created[collection][index.Document().Lookup("name").StringValue()] = keys
+		}
+	}
+	return created
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	expected := map[string]map[string]bson.D{
+		"auctions": {
+			"status_expires_at": {{Key: "status", Value: int32(1)}, {Key: "expires_at", Value: int32(1)}},
+			"category":          {{Key: "category", Value: int32(1)}},
+		},
+		"bids": {
+			"auction_id_amount_cents_desc": {{Key: "auction_id", Value: int32(1)}, {Key: "amount_cents", Value: int32(-1)}},
+		},
+	}
+
+	mt.Run("creates the indexes of each collection", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		assert.NoError(mt, mongodb.EnsureIndexes(mt.Context(), mt.DB))
+		assert.Equal(mt, expected, createdIndexes(mt))
+	})
+
+	mt.Run("is idempotent", func(mt *mtest.T) {
+		// MongoDB answers an index that already exists with success
+		for i := 0; i < 4; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "note", Value: "all indexes already exist"}))
+		}
+
+		assert.NoError(mt, mongodb.EnsureIndexes(mt.Context(), mt.DB))
+		assert.NoError(mt, mongodb.EnsureIndexes(mt.Context(), mt.DB))
+		assert.Equal(mt, expected, createdIndexes(mt))
+	})
+
+	mt.Run("reports the collection that failed", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{
+				Code: 85, Name: "IndexOptionsConflict", Message: "index already exists with different options",
+			}))
+
+		err := mongodb.EnsureIndexes(mt.Context(), mt.DB)
+
+		assert.ErrorContains(mt, err, "bids")
+		var commandErr mongo.CommandError
+		assert.True(mt, errors.As(err, &commandErr))
+		assert.Equal(mt, int32(85), commandErr.Code)
+	})
+}
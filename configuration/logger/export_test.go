@@ -0,0 +1,3 @@
+package logger
+
+var NewLogger = newLogger
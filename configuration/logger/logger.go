@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"os"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -10,20 +12,56 @@ var (
 )
 
 func init() {
-	logConfiguration := zap.Config{
-		Level:    zap.NewAtomicLevelAt(zap.InfoLevel),
-		Encoding: "json",
-		EncoderConfig: zapcore.EncoderConfig{
-			MessageKey:   "message",
-			LevelKey:     "level",
-			TimeKey:      "time",
-			EncodeLevel:  zapcore.LowercaseLevelEncoder,
-			EncodeTime:   zapcore.ISO8601TimeEncoder,
-			EncodeCaller: zapcore.ShortCallerEncoder,
-		},
+	log = newLogger(zapcore.Lock(os.Stderr), "json", zapcore.InfoLevel)
+}
+
+// Configure replaces the global logger with one writing to stderr in the
+// given format, "json" or "text", and dropping entries below level. The
+// mains call it once the configuration is loaded; until then entries are
+// written as JSON at info level.
+func Configure(format, level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	log = newLogger(zapcore.Lock(os.Stderr), format, parsed)
+	return nil
+}
+
+// newLogger builds a logger writing to output as one JSON object per line,
+// for log aggregators, or as text when format is "text", for reading in a
+// terminal.
+func newLogger(output zapcore.WriteSyncer, format string, level zapcore.Level) *zap.Logger {
+	encoderConfiguration := zapcore.EncoderConfig{
+		MessageKey:     "msg",
+		LevelKey:       "level",
+		TimeKey:        "ts",
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	log, _ = logConfiguration.Build()
+	encoder := zapcore.NewJSONEncoder(encoderConfiguration)
+	if format == "text" {
+		encoder = zapcore.NewConsoleEncoder(encoderConfiguration)
+	}
+
+	return zap.New(zapcore.NewCore(encoder, output, level))
+}
+
+// SetLogger replaces the global logger and returns a function that restores
+// the previous one. It lets tests observe what the application logs.
+func SetLogger(logger *zap.Logger) (restore func()) {
+	previous := log
+	log = logger
+	return func() { log = previous }
+}
+
+func Debug(message string, tags ...zap.Field) {
+	log.Debug(message, tags...)
+	log.Sync()
 }
 
 func Info(message string, tags ...zap.Field) {
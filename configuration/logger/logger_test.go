@@ -0,0 +1,96 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+// captureLogs routes the global logger to a buffer for the rest of the test.
+func captureLogs(t *testing.T, format string, level zapcore.Level) *bytes.Buffer {
+	var output bytes.Buffer
+	t.Cleanup(logger.SetLogger(logger.NewLogger(zapcore.AddSync(&output), format, level)))
+	return &output
+}
+
+func logLines(output *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSpace(output.String()), "\n")
+}
+
+func TestJSONFormat(t *testing.T) {
+	output := captureLogs(t, "json", zapcore.InfoLevel)
+
+	logger.Info("server started")
+	logger.Error("error trying to insert bids", errors.New("connection refused"))
+
+	lines := logLines(output)
+	assert.Len(t, lines, 2)
+
+	var info map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &info))
+	assert.Equal(t, "info", info["level"])
+	assert.Equal(t, "server started", info["msg"])
+	assert.NotContains(t, info, "error")
+	ts, ok := info["ts"].(string)
+	assert.True(t, ok)
+	_, err := time.Parse("2006-01-02T15:04:05.000Z0700", ts)
+	assert.Nil(t, err)
+
+	var failure map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &failure))
+	assert.Equal(t, "error", failure["level"])
+	assert.Equal(t, "error trying to insert bids", failure["msg"])
+	assert.Equal(t, "connection refused", failure["error"])
+}
+
+func TestTextFormat(t *testing.T) {
+	output := captureLogs(t, "text", zapcore.InfoLevel)
+
+	logger.Info("server started")
+
+	line := logLines(output)[0]
+	assert.False(t, json.Valid([]byte(line)))
+	assert.Contains(t, line, "info")
+	assert.Contains(t, line, "server started")
+}
+
+func TestLogLevel(t *testing.T) {
+	t.Run("debug is suppressed at info", func(t *testing.T) {
+		output := captureLogs(t, "json", zapcore.InfoLevel)
+
+		logger.Debug("bid received")
+		logger.Info("bid accepted")
+
+		lines := logLines(output)
+		assert.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "bid accepted")
+	})
+
+	t.Run("debug is written at debug", func(t *testing.T) {
+		output := captureLogs(t, "json", zapcore.DebugLevel)
+
+		logger.Debug("bid received")
+
+		var entry map[string]any
+		assert.Nil(t, json.Unmarshal([]byte(logLines(output)[0]), &entry))
+		assert.Equal(t, "debug", entry["level"])
+	})
+
+	t.Run("error drops info", func(t *testing.T) {
+		output := captureLogs(t, "json", zapcore.ErrorLevel)
+
+		logger.Info("bid accepted")
+		logger.Error("error trying to insert bids", errors.New("timeout"))
+
+		lines := logLines(output)
+		assert.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "timeout")
+	})
+}
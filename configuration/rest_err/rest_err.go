@@ -1,16 +1,19 @@
 package rest_err
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 )
 
 type RestErr struct {
-	Message string   `json:"message"`
-	Err     string   `json:"err"`
-	Code    int      `json:"code"`
-	Causes  []Causes `json:"causes"`
+	Message   string   `json:"message"`
+	Err       string   `json:"err"`
+	ErrorCode string   `json:"error_code,omitempty"`
+	Code      int      `json:"code"`
+	Causes    []Causes `json:"causes"`
 }
 
 type Causes struct {
@@ -22,15 +25,28 @@ func (r *RestErr) Error() string {
 	return r.Message
 }
 
+// ConvertError builds the response body of an internal error, with the status
+// given by internal_error.HTTPStatus. Any error caused by a deadline, such as a
+// query cut off by REQUEST_TIMEOUT, is reported as a timeout.
 func ConvertError(internalError *internal_error.InternalError) *RestErr {
-	switch internalError.Err {
-	case "bad_request":
-		return NewBadRequestError(internalError.Error())
-	case "not_found":
-		return NewNotFoundError(internalError.Error())
-	default:
-		return NewInternalServerError(internalError.Error())
+	if internalError.Code != internal_error.RequestTimeoutCode &&
+		errors.Is(internalError, context.DeadlineExceeded) {
+		internalError = internal_error.NewRequestTimeoutError()
 	}
+
+	restErr := &RestErr{
+		Message:   internalError.Error(),
+		Err:       internalError.Err,
+		ErrorCode: internalError.Code,
+		Code:      internal_error.HTTPStatus(internalError),
+	}
+
+	// Unknown types are reported as the generic internal_server error
+	if restErr.Code == http.StatusInternalServerError {
+		restErr.Err = "internal_server"
+	}
+
+	return restErr
 }
 
 func NewBadRequestError(message string, causes ...Causes) *RestErr {
@@ -59,3 +75,66 @@ func NewNotFoundError(message string) *RestErr {
 		Causes:  nil,
 	}
 }
+
+func NewConflictError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "conflict",
+		Code:    http.StatusConflict,
+		Causes:  nil,
+	}
+}
+
+func NewGoneError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "gone",
+		Code:    http.StatusGone,
+		Causes:  nil,
+	}
+}
+
+func NewServiceUnavailableError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "service_unavailable",
+		Code:    http.StatusServiceUnavailable,
+		Causes:  nil,
+	}
+}
+
+func NewUnauthorizedError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "unauthorized",
+		Code:    http.StatusUnauthorized,
+		Causes:  nil,
+	}
+}
+
+func NewForbiddenError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "forbidden",
+		Code:    http.StatusForbidden,
+		Causes:  nil,
+	}
+}
+
+func NewUnsupportedMediaTypeError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "unsupported_media_type",
+		Code:    http.StatusUnsupportedMediaType,
+		Causes:  nil,
+	}
+}
+
+func NewTooManyRequestsError(message string) *RestErr {
+	return &RestErr{
+		Message: message,
+		Err:     "too_many_requests",
+		Code:    http.StatusTooManyRequests,
+		Causes:  nil,
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/netutil"
+)
+
+// Timeouts bound how long a connection may take at each stage, so slow or
+// hung clients (slowloris) cannot hold connections forever. Zero disables a
+// timeout.
+type Timeouts struct {
+	Read  time.Duration // reading the whole request, headers included
+	Write time.Duration // from the end of the request headers to the end of the response
+	Idle  time.Duration // waiting for the next request on a keep-alive connection
+}
+
+// Limits cap the resources a flood of clients can take.
+type Limits struct {
+	MaxHeaderBytes int // size of the request headers
+	MaxConnections int // connections served at once (0 = no limit)
+}
+
+// NewServer returns a server for handler listening on addr with the given
+// timeouts and limits. Streaming routes must lift the write timeout
+// themselves (see middleware.DisableWriteTimeout).
+func NewServer(addr string, handler http.Handler, timeouts Timeouts, limits Limits) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       timeouts.Read,
+		ReadHeaderTimeout: timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+		MaxHeaderBytes:    limits.MaxHeaderBytes,
+	}
+}
+
+// Listen listens on addr and, with MaxConnections set, accepts at most that
+// many connections at once. Connections over the cap wait in the backlog
+// until one is closed.
+func Listen(addr string, limits Limits) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return LimitListener(listener, limits.MaxConnections), nil
+}
+
+// LimitListener caps the connections accepted at once by listener; a
+// non-positive max leaves it unlimited.
+func LimitListener(listener net.Listener, max int) net.Listener {
+	if max <= 0 {
+		return listener
+	}
+
+	return netutil.LimitListener(listener, max)
+}
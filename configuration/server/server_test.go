@@ -0,0 +1,100 @@
+package server_test
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewServerAppliesTimeouts(t *testing.T) {
+	handler := http.NewServeMux()
+
+	srv := server.NewServer(":8080", handler, server.Timeouts{
+		Read: time.Second, Write: 2 * time.Second, Idle: 3 * time.Second,
+	}, server.Limits{MaxHeaderBytes: 4096})
+
+	assert.Equal(t, ":8080", srv.Addr)
+	assert.Equal(t, time.Second, srv.ReadTimeout)
+	assert.Equal(t, time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, 2*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 3*time.Second, srv.IdleTimeout)
+	assert.Equal(t, 4096, srv.MaxHeaderBytes)
+}
+
+func TestLimitListenerHoldsConnectionsOverTheCap(t *testing.T) {
+	listener, err := server.Listen("127.0.0.1:0", server.Limits{MaxConnections: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	first, err := net.Dial("tcp", listener.Addr().String())
+	assert.NoError(t, err)
+	defer first.Close()
+	firstAccepted := <-accepted
+
+	// The second connection reaches the backlog but is not accepted
+	second, err := net.Dial("tcp", listener.Addr().String())
+	assert.NoError(t, err)
+	defer second.Close()
+	select {
+	case <-accepted:
+		t.Fatal("connection over the cap was accepted")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Closing the first connection frees the slot
+	firstAccepted.Close()
+	select {
+	case conn := <-accepted:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("connection was not accepted after a slot was freed")
+	}
+}
+
+func TestLimitListenerWithoutCapIsUnchanged(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	assert.Same(t, listener, server.LimitListener(listener, 0))
+}
+
+func TestServerRejectsOversizedHeaders(t *testing.T) {
+	srv := server.NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), server.Timeouts{}, server.Limits{MaxHeaderBytes: 1024})
+	listener, err := server.Listen("127.0.0.1:0", server.Limits{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String(), nil)
+	request.Header.Set("X-Padding", strings.Repeat("a", 8192))
+	response, err := http.DefaultClient.Do(request)
+	if assert.NoError(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, response.StatusCode)
+	}
+}
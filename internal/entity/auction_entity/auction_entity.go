@@ -2,19 +2,25 @@ package auction_entity
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 )
 
+// CreateAuction returns a new active auction that expires interval after now.
 func CreateAuction(
 	productName, category, description string,
-	condition ProductCondition) (*Auction, *internal_error.InternalError) {
+	condition ProductCondition,
+	interval time.Duration) (*Auction, *internal_error.InternalError) {
 
 	now := time.Now()
-	expiresAt := now.Add(getAuctionInterval())
+	expiresAt := now.Add(interval)
 
 	auction := &Auction{
 		Id:          uuid.New().String(),
@@ -24,7 +30,10 @@ func CreateAuction(
 		Condition:   condition,
 		Status:      Active,
 		CreatedAt:   now,
+		StartsAt:    now,
 		ExpiresAt:   expiresAt,
+		UpdatedAt:   now,
+		Version:     1,
 	}
 
 	if err := auction.Validate(); err != nil {
@@ -43,6 +52,10 @@ func (au *Auction) Validate() *internal_error.InternalError {
 		return internal_error.NewBadRequestError("invalid auction object")
 	}
 
+	if au.ReservePrice < 0 || math.IsNaN(au.ReservePrice) || math.IsInf(au.ReservePrice, 0) {
+		return internal_error.NewBadRequestError("reserve price must not be negative")
+	}
+
 	return nil
 }
 
@@ -51,6 +64,57 @@ func (au *Auction) IsExpired() bool {
 	return time.Now().After(au.ExpiresAt)
 }
 
+// SelfOutbidAllowed reports whether the leading bidder may raise their own
+// bid on this auction. A nil AllowSelfOutbid falls back to the global default.
+func (au *Auction) SelfOutbidAllowed(globalDefault bool) bool {
+	if au.AllowSelfOutbid == nil {
+		return globalDefault
+	}
+	return *au.AllowSelfOutbid
+}
+
+// ReserveMet reports whether a winning bid of amountCents reaches the reserve
+// price, below which the item is not sold. Without a reserve, any bid does.
+// Both are compared in cents, as bids are.
+func (au *Auction) ReserveMet(amountCents int64) bool {
+	return amountCents >= bid_entity.ToCents(au.ReservePrice)
+}
+
+// MinIncrementCents is MinIncrement in cents, by which each bid must raise
+// the highest one (0 = any higher bid).
+func (au *Auction) MinIncrementCents() int64 {
+	return bid_entity.ToCents(au.MinIncrement)
+}
+
+// Relist returns a new active auction of the same product, with the same
+// bidding rules, that expires interval after now and links back to au.
+func (au *Auction) Relist(interval time.Duration) (*Auction, *internal_error.InternalError) {
+	relisted, err := CreateAuction(au.ProductName, au.Category, au.Description, au.Condition, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	relisted.AllowSelfOutbid = au.AllowSelfOutbid
+	relisted.MinIncrement = au.MinIncrement
+	relisted.ReservePrice = au.ReservePrice
+	relisted.RelistedFrom = au.Id
+
+	return relisted, nil
+}
+
+// Touch records a mutation of the auction, advancing UpdatedAt and Version.
+// Every change to a stored auction (status change, extension, cancellation)
+// must go through it, or mirror it in the database update.
+func (au *Auction) Touch() {
+	au.UpdatedAt = time.Now()
+	au.Version++
+}
+
+// IsStarted checks if the auction is already open for bids
+func (au *Auction) IsStarted() bool {
+	return !time.Now().Before(au.StartsAt)
+}
+
 type Auction struct {
 	Id          string
 	ProductName string
@@ -58,8 +122,17 @@ type Auction struct {
 	Description string
 	Condition   ProductCondition
 	Status      AuctionStatus
-	CreatedAt   time.Time // Data de criação
-	ExpiresAt   time.Time // Data de expiração (calculada automaticamente)
+	CreatedAt   time.Time  // Data de criação
+	StartsAt    time.Time  // Data de abertura para lances (padrão: CreatedAt)
+	ExpiresAt   time.Time  // Data de expiração (calculada automaticamente)
+	UpdatedAt   time.Time  // Data da última alteração (criação ou mudança de status)
+	ClosedAt    *time.Time // Data do encerramento (nil enquanto ativo)
+	Version     int64      // Incrementada a cada alteração do leilão
+
+	AllowSelfOutbid *bool   // Sobrescreve ALLOW_SELF_OUTBID (nil = padrão global)
+	MinIncrement    float64 // Quanto um lance deve superar o maior lance (0 = qualquer lance maior)
+	ReservePrice    float64 // Valor mínimo de venda (0 = sem reserva)
+	RelistedFrom    string  // Leilão encerrado sem atingir a reserva que este reanuncia
 }
 
 type ProductCondition int
@@ -76,6 +149,122 @@ const (
 	Refurbished
 )
 
+var auctionStatusNames = map[AuctionStatus]string{
+	Active:    "active",
+	Completed: "completed",
+}
+
+// String returns the name of the status (active or completed), or its number
+// when it has none.
+func (s AuctionStatus) String() string {
+	if name, ok := auctionStatusNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseAuctionStatus returns the status named by value, ignoring case and
+// surrounding spaces.
+func ParseAuctionStatus(value string) (AuctionStatus, *internal_error.InternalError) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	for status, statusName := range auctionStatusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+
+	return 0, internal_error.NewBadRequestError(
+		fmt.Sprintf("Invalid auction status %q: expected active or completed", value))
+}
+
+var productConditionNames = map[ProductCondition]string{
+	New:         "new",
+	Used:        "used",
+	Refurbished: "refurbished",
+}
+
+// String returns the name of the condition (new, used or refurbished), or its
+// number when it has none.
+func (c ProductCondition) String() string {
+	if name, ok := productConditionNames[c]; ok {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// ParseProductCondition returns the condition named by value, ignoring case
+// and surrounding spaces.
+func ParseProductCondition(value string) (ProductCondition, *internal_error.InternalError) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	for condition, conditionName := range productConditionNames {
+		if conditionName == name {
+			return condition, nil
+		}
+	}
+
+	return 0, internal_error.NewBadRequestError(
+		fmt.Sprintf("Invalid product condition %q: expected new, used or refurbished", value))
+}
+
+// AuctionFilter holds the optional criteria used to list auctions.
+// A nil pointer or an empty string means the criterion is not applied,
+// so the zero value matches every auction. Category, ProductName and Query
+// match case-insensitive substrings, taken literally.
+type AuctionFilter struct {
+	// Ids keeps only the given auctions; nil leaves the criterion out
+	Ids []string
+
+	Status      *AuctionStatus
+	Condition   *ProductCondition
+	Category    string
+	ProductName string
+
+	// Query matches either the product name or the category
+	Query string
+
+	// HasBids keeps only the auctions with at least one persisted bid
+	HasBids bool
+
+	// Limit caps how many auctions are returned (0 = no limit)
+	Limit int64
+
+	// Skip drops that many auctions, in Sort order, before Limit applies
+	Skip int64
+
+	Sort AuctionSort
+}
+
+// AuctionSort orders a listing. The zero value keeps the storage order.
+type AuctionSort int
+
+const (
+	SortDefault AuctionSort = iota
+	// SortClosedDesc lists the most recently changed auctions first, which
+	// for completed auctions is the close time
+	SortClosedDesc
+	// SortBidCountDesc lists the auctions with the most persisted bids first,
+	// the most recently created first among equal counts
+	SortBidCountDesc
+	SortCreatedAsc
+	SortCreatedDesc
+	SortExpiresAsc
+	SortExpiresDesc
+)
+
+// AuctionWithHighestBid pairs an auction with its current top bid, which is
+// nil when the auction has not received any bid yet.
+type AuctionWithHighestBid struct {
+	Auction
+	HighestBid *bid_entity.Bid
+}
+
+// AuctionSummary is an auction with its top persisted bid and how many bids
+// were persisted for it.
+type AuctionSummary struct {
+	AuctionWithHighestBid
+	BidCount int64
+}
+
 type AuctionRepositoryInterface interface {
 	CreateAuction(
 		ctx context.Context,
@@ -83,19 +272,92 @@ type AuctionRepositoryInterface interface {
 
 	FindAuctions(
 		ctx context.Context,
-		status AuctionStatus,
-		category, productName string) ([]Auction, *internal_error.InternalError)
+		filter AuctionFilter) ([]Auction, *internal_error.InternalError)
+
+	FindAuctionsWithHighestBid(
+		ctx context.Context,
+		filter AuctionFilter) ([]AuctionWithHighestBid, *internal_error.InternalError)
+
+	// CountAuctions counts every auction matching the filter, ignoring its
+	// Skip and Limit, to report the total of a paginated listing
+	CountAuctions(
+		ctx context.Context,
+		filter AuctionFilter) (int64, *internal_error.InternalError)
+
+	// FindTopAuctionsByCategory returns up to limit active auctions of the
+	// category, highest persisted bid first, followed by the pinned ones
+	// (when active in the category) that were not already included.
+	FindTopAuctionsByCategory(
+		ctx context.Context,
+		category string,
+		limit int64,
+		pinnedIds []string) ([]AuctionWithHighestBid, *internal_error.InternalError)
 
 	FindAuctionById(
 		ctx context.Context, id string) (*Auction, *internal_error.InternalError)
+
+	// FindAuctionSummary returns an auction with its highest bid and its bid
+	// count, joined in a single query
+	FindAuctionSummary(
+		ctx context.Context, id string) (*AuctionSummary, *internal_error.InternalError)
+
+	// FindAuctionStatuses returns the status of the given auctions, keyed by
+	// id, in a single query. Ids that do not exist are left out of the map.
+	FindAuctionStatuses(
+		ctx context.Context, ids []string) (map[string]AuctionStatus, *internal_error.InternalError)
+
+	// CloseExpiredAuctions completes the expired active auctions right away,
+	// as one cycle of the closer routine, and returns how many were closed.
+	CloseExpiredAuctions(ctx context.Context) (int64, *internal_error.InternalError)
+
+	// CloseAuction completes an active auction right away, whatever its
+	// expiration, and returns it as completed
+	CloseAuction(ctx context.Context, id string) (*Auction, *internal_error.InternalError)
+
+	// UpdateAuction persists a mutated auction only if the stored version is
+	// still expectedVersion, returning a conflict error otherwise.
+	UpdateAuction(
+		ctx context.Context,
+		auctionEntity *Auction,
+		expectedVersion int64) *internal_error.InternalError
+
+	// MarkAuctionHasBids records, before the first bid is accepted, that the
+	// auction read at version takes bids, after which UpdateAuction fails.
+	// It returns a conflict error when the auction changed since it was read.
+	MarkAuctionHasBids(
+		ctx context.Context,
+		auctionId string,
+		version int64) *internal_error.InternalError
+
+	// DeleteAuction soft-deletes an auction, which no query returns anymore
+	// but FindAuctionById and FindAuctionSummary, reporting it as deleted
+	DeleteAuction(ctx context.Context, id string) *internal_error.InternalError
 }
 
-// getAuctionInterval returns the auction duration from env var
-func getAuctionInterval() time.Duration {
-	auctionInterval := os.Getenv("AUCTION_INTERVAL")
-	duration, err := time.ParseDuration(auctionInterval)
-	if err != nil {
-		return 5 * time.Minute // Default: 5 minutes
+// AuctionClosingObserver is notified by the closer routine, on each cycle,
+// about active auctions approaching their expiration and about the auctions
+// it has just completed.
+type AuctionClosingObserver interface {
+	// ClosingHorizon is how far ahead of now the closer must look for
+	// auctions about to expire; 0 skips the lookup.
+	ClosingHorizon() time.Duration
+
+	AuctionsClosing(now time.Time, auctions []Auction)
+	AuctionsClosed(auctions []Auction)
+}
+
+// AuctionCompletionListener is told, after each closer cycle, which auctions
+// the cycle completed. It must return quickly, as the closer waits for it.
+type AuctionCompletionListener interface {
+	AuctionsCompleted(auctionIds []string)
+}
+
+// AuctionCompletionListeners tells every listener, in order, about the
+// completed auctions.
+type AuctionCompletionListeners []AuctionCompletionListener
+
+func (listeners AuctionCompletionListeners) AuctionsCompleted(auctionIds []string) {
+	for _, listener := range listeners {
+		listener.AuctionsCompleted(auctionIds)
 	}
-	return duration
 }
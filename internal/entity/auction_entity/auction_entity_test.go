@@ -0,0 +1,152 @@
+package auction_entity_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTouchAdvancesUpdatedAtAndVersion(t *testing.T) {
+	auction, err := auction_entity.CreateAuction(
+		"Test Product",
+		"electronics",
+		"This is a test product description for auction",
+		auction_entity.New,
+		5*time.Minute,
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), auction.Version)
+	assert.Equal(t, auction.CreatedAt, auction.UpdatedAt)
+
+	previous := auction.UpdatedAt
+	for version := int64(2); version <= 3; version++ {
+		time.Sleep(time.Millisecond)
+		auction.Touch()
+
+		assert.True(t, auction.UpdatedAt.After(previous))
+		assert.Equal(t, version, auction.Version)
+		previous = auction.UpdatedAt
+	}
+}
+
+func TestValidateRejectsNegativeReservePrice(t *testing.T) {
+	auction, err := auction_entity.CreateAuction(
+		"Test Product",
+		"electronics",
+		"This is a test product description for auction",
+		auction_entity.New,
+		5*time.Minute,
+	)
+	assert.Nil(t, err)
+
+	auction.ReservePrice = -1
+	if err := auction.Validate(); assert.NotNil(t, err) {
+		assert.Equal(t, "bad_request", err.Err)
+	}
+
+	auction.ReservePrice = 500
+	assert.Nil(t, auction.Validate())
+	assert.False(t, auction.ReserveMet(49999))
+	assert.True(t, auction.ReserveMet(50000))
+}
+
+func TestReserveAndMinIncrementCompareInCents(t *testing.T) {
+	auction := auction_entity.Auction{
+		// 0.30000000000000004 as a float, above a bid of 0.30
+		ReservePrice: 0.1 + 0.2,
+		MinIncrement: 0.1 + 0.2,
+	}
+
+	assert.True(t, auction.ReserveMet(30))
+	assert.False(t, auction.ReserveMet(29))
+	assert.Equal(t, int64(30), auction.MinIncrementCents())
+
+	auction.MinIncrement = 0
+	assert.Zero(t, auction.MinIncrementCents())
+}
+
+func TestParseProductCondition(t *testing.T) {
+	for value, expected := range map[string]auction_entity.ProductCondition{
+		"new":           auction_entity.New,
+		"used":          auction_entity.Used,
+		"refurbished":   auction_entity.Refurbished,
+		"NEW":           auction_entity.New,
+		" Refurbished ": auction_entity.Refurbished,
+	} {
+		condition, err := auction_entity.ParseProductCondition(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, condition, value)
+	}
+
+	for _, value := range []string{"", "mint", "1"} {
+		_, err := auction_entity.ParseProductCondition(value)
+		if assert.NotNil(t, err, value) {
+			assert.Equal(t, "bad_request", err.Err)
+		}
+	}
+}
+
+func TestProductConditionString(t *testing.T) {
+	assert.Equal(t, "new", auction_entity.New.String())
+	assert.Equal(t, "used", auction_entity.Used.String())
+	assert.Equal(t, "refurbished", auction_entity.Refurbished.String())
+	assert.Equal(t, "0", auction_entity.ProductCondition(0).String())
+}
+
+func TestParseAuctionStatus(t *testing.T) {
+	for value, expected := range map[string]auction_entity.AuctionStatus{
+		"active":      auction_entity.Active,
+		"completed":   auction_entity.Completed,
+		" Completed ": auction_entity.Completed,
+	} {
+		status, err := auction_entity.ParseAuctionStatus(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, status, value)
+	}
+
+	_, err := auction_entity.ParseAuctionStatus("sold")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "bad_request", err.Err)
+	}
+}
+
+func TestAuctionStatusString(t *testing.T) {
+	assert.Equal(t, "active", auction_entity.Active.String())
+	assert.Equal(t, "completed", auction_entity.Completed.String())
+	assert.Equal(t, "5", auction_entity.AuctionStatus(5).String())
+}
+
+func TestRelistCopiesTheProductAndLinksBack(t *testing.T) {
+	allowSelfOutbid := true
+	original, err := auction_entity.CreateAuction(
+		"Test Product",
+		"electronics",
+		"This is a test product description for auction",
+		auction_entity.Used,
+		time.Minute,
+	)
+	assert.Nil(t, err)
+	original.Status = auction_entity.Completed
+	original.AllowSelfOutbid = &allowSelfOutbid
+	original.MinIncrement = 5
+	original.ReservePrice = 500
+
+	relisted, err := original.Relist(time.Hour)
+
+	assert.Nil(t, err)
+	assert.NotEqual(t, original.Id, relisted.Id)
+	assert.Equal(t, original.Id, relisted.RelistedFrom)
+	assert.Equal(t, auction_entity.Active, relisted.Status)
+	assert.Equal(t, int64(1), relisted.Version)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), relisted.ExpiresAt, time.Second)
+
+	assert.Equal(t, original.ProductName, relisted.ProductName)
+	assert.Equal(t, original.Category, relisted.Category)
+	assert.Equal(t, original.Description, relisted.Description)
+	assert.Equal(t, original.Condition, relisted.Condition)
+	assert.Equal(t, original.AllowSelfOutbid, relisted.AllowSelfOutbid)
+	assert.Equal(t, original.MinIncrement, relisted.MinIncrement)
+	assert.Equal(t, original.ReservePrice, relisted.ReservePrice)
+}
@@ -2,6 +2,10 @@ package bid_entity
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,46 +16,223 @@ type Bid struct {
 	Id        string
 	UserId    string
 	AuctionId string
-	Amount    float64
 	Timestamp time.Time
+
+	// AmountCents is the amount in cents. Bids are compared and ranked by it,
+	// never by the float amount, so equal-looking amounts are always equal.
+	AmountCents int64
 }
 
-func CreateBid(userId, auctionId string, amount float64) (*Bid, *internal_error.InternalError) {
+// CreateBid converts the amount, as sent by the client, to cents. It is
+// validated against limits before the conversion, which would otherwise
+// round away the extra decimal places it must reject.
+func CreateBid(userId, auctionId string, amount float64, limits AmountLimits) (*Bid, *internal_error.InternalError) {
 	bid := &Bid{
 		Id:        uuid.New().String(),
 		UserId:    userId,
 		AuctionId: auctionId,
-		Amount:    amount,
 		Timestamp: time.Now(),
 	}
 
-	if err := bid.Validate(); err != nil {
+	if err := bid.validateIds(); err != nil {
+		return nil, err
+	}
+	if err := limits.validate(amount); err != nil {
 		return nil, err
 	}
+	bid.AmountCents = ToCents(amount)
 
 	return bid, nil
 }
 
-func (b *Bid) Validate() *internal_error.InternalError {
-	if err := uuid.Validate(b.UserId); err != nil {
-		return internal_error.NewBadRequestError("UserId is not a valid id")
+// Amount returns the amount in currency units, for responses and logs.
+func (b *Bid) Amount() float64 {
+	return FromCents(b.AmountCents)
+}
+
+// ToCents converts an amount in currency units to cents, rounding to the
+// nearest cent.
+func ToCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FromCents converts cents back to currency units. Below 2^53 cents the
+// result is the float closest to the decimal amount, the same a client gets
+// by parsing it.
+func FromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+func (b *Bid) Validate(limits AmountLimits) *internal_error.InternalError {
+	if err := b.validateIds(); err != nil {
+		return err
+	}
+
+	return limits.validate(b.Amount())
+}
+
+func (b *Bid) validateIds() *internal_error.InternalError {
+	if b.UserId == "" {
+		return internal_error.NewBadRequestError("UserId is required").
+			WithCode(internal_error.InvalidUserIdCode)
+	} else if err := uuid.Validate(b.UserId); err != nil {
+		return internal_error.NewBadRequestError("UserId is not a valid id").
+			WithCode(internal_error.InvalidUserIdCode)
+	} else if b.AuctionId == "" {
+		return internal_error.NewBadRequestError("AuctionId is required").
+			WithCode(internal_error.InvalidAuctionIdCode)
 	} else if err := uuid.Validate(b.AuctionId); err != nil {
-		return internal_error.NewBadRequestError("AuctionId is not a valid id")
-	} else if b.Amount <= 0 {
-		return internal_error.NewBadRequestError("Amount is not a valid value")
+		return internal_error.NewBadRequestError("AuctionId is not a valid id").
+			WithCode(internal_error.InvalidAuctionIdCode)
+	}
+
+	return nil
+}
+
+// maxExactInteger is the largest integer a float64 holds exactly (2^53).
+// Amounts are bounded so that, scaled to their smallest unit, they stay
+// below it.
+const maxExactInteger = 1 << 53
+
+// AmountLimits bounds the amount of a bid.
+type AmountLimits struct {
+	Max      float64 // largest amount accepted, 0 for no cap; see MaxAmount
+	Decimals int     // decimal places allowed, from 0 to 2 as amounts are held in cents
+}
+
+// MaxAmount returns Max capped so that the amount in its smallest unit (see
+// Decimals) is still an exact float64. Without Max, that cap is the limit.
+func (l AmountLimits) MaxAmount() float64 {
+	exactLimit := maxExactInteger / math.Pow10(l.Decimals)
+	if l.Max <= 0 {
+		return exactLimit
+	}
+	return math.Min(l.Max, exactLimit)
+}
+
+// validate rejects non-positive, NaN and infinite amounts, amounts above
+// MaxAmount and amounts with more than Decimals decimal places.
+func (l AmountLimits) validate(amount float64) *internal_error.InternalError {
+	// NaN fails any comparison, so it is caught by the first condition
+	if !(amount > 0) || math.IsInf(amount, 0) {
+		return internal_error.NewBadRequestError("Amount is not a valid value").
+			WithCode(internal_error.InvalidAmountCode)
+	}
+
+	decimals := l.Decimals
+	if maxAmount := l.MaxAmount(); amount > maxAmount {
+		return internal_error.NewBadRequestError(fmt.Sprintf(
+			"Amount must not exceed %.*f", decimals, maxAmount)).
+			WithCode(internal_error.InvalidAmountCode)
+	}
+
+	// The shortest representation that parses back to the same float is
+	// what the client sent, so its decimal places are the ones that count
+	formatted := strconv.FormatFloat(amount, 'f', -1, 64)
+	if dot := strings.IndexByte(formatted, '.'); dot >= 0 && len(formatted)-dot-1 > decimals {
+		return internal_error.NewBadRequestError(fmt.Sprintf(
+			"Amount must have at most %d decimal places", decimals)).
+			WithCode(internal_error.InvalidAmountCode)
 	}
 
 	return nil
 }
 
+// TiePolicy decides what happens to a bid equal to the current highest one.
+// Validation and winner resolution must follow the same policy.
+type TiePolicy string
+
+const (
+	// RejectEqual refuses an equal bid, so the earliest bid at an amount wins
+	RejectEqual TiePolicy = "reject_equal"
+	// LastWriteWins accepts an equal bid, which takes the lead
+	LastWriteWins TiePolicy = "last_write_wins"
+)
+
+// TimestampSortOrder is the timestamp direction that, after amount
+// descending, puts the winning bid first: 1 (earliest) or -1 (latest).
+func (p TiePolicy) TimestampSortOrder() int {
+	if p == LastWriteWins {
+		return -1
+	}
+	return 1
+}
+
+// BidBatchResult tells what happened to each bid of a batch handed to
+// CreateBid. Failed bids were not written and may be retried.
+type BidBatchResult struct {
+	Stored  []Bid // written, or already present from an earlier attempt
+	Dropped []Bid // not written because the auction no longer takes bids
+	Failed  []Bid // not written because the insert or the lookup failed
+}
+
 type BidEntityRepository interface {
+	// CreateBid writes the bids of a batch and reports the outcome of each.
+	// The error is set when at least one bid failed.
 	CreateBid(
 		ctx context.Context,
-		bidEntities []Bid) *internal_error.InternalError
+		bidEntities []Bid) (BidBatchResult, *internal_error.InternalError)
 
 	FindBidByAuctionId(
 		ctx context.Context, auctionId string) ([]Bid, *internal_error.InternalError)
 
+	// FindBidsPageByAuctionId returns up to limit bids of the auction, oldest
+	// first, after skipping offset of them, and how many bids it has in total.
+	FindBidsPageByAuctionId(
+		ctx context.Context, auctionId string, offset, limit int64) ([]Bid, int64, *internal_error.InternalError)
+
 	FindWinningBidByAuctionId(
 		ctx context.Context, auctionId string) (*Bid, *internal_error.InternalError)
+
+	// StreamBidsByAuctionId sends the bids of an auction, oldest first, without
+	// loading them all in memory. The channel is closed once every bid was
+	// sent or ctx is done.
+	StreamBidsByAuctionId(
+		ctx context.Context, auctionId string) (<-chan Bid, *internal_error.InternalError)
+
+	CountBidsAboveAmount(
+		ctx context.Context, auctionId string, amountCents int64) (int64, *internal_error.InternalError)
+
+	// FindHighestBidsByUserId returns the highest persisted bid of the user on
+	// each auction they bid on, one per auction. A user without bids gets an
+	// empty slice.
+	FindHighestBidsByUserId(
+		ctx context.Context, userId string) ([]Bid, *internal_error.InternalError)
+}
+
+// BidConfirmationNotifier is told about the bids of each batch once it is
+// persisted. It must return quickly, as the batch writer waits for it.
+type BidConfirmationNotifier interface {
+	BidsConfirmed(bids []Bid)
+}
+
+// BidEventLog is an append-only record of accepted bids. A bid is appended
+// before it is queued for the batch insert and settled once its batch has been
+// handed to the repository, so the bids still pending can be rebuilt after a
+// restart.
+type BidEventLog interface {
+	AppendAccepted(bid Bid) error
+	AppendSettled(bids []Bid) error
+
+	// Pending returns the accepted bids not settled yet, oldest first
+	Pending() ([]Bid, error)
+}
+
+// RejectedBid is a bid attempt refused by the validation rules, kept for
+// analytics and fraud detection. Its fields are the raw input, which may
+// itself be what made the bid invalid.
+type RejectedBid struct {
+	Id        string
+	UserId    string
+	AuctionId string
+	Amount    float64
+	Reason    string // rejection category
+	Message   string // error returned to the client
+	Timestamp time.Time
+}
+
+type RejectedBidRepository interface {
+	CreateRejectedBids(
+		ctx context.Context,
+		rejectedBids []RejectedBid) *internal_error.InternalError
 }
@@ -0,0 +1,203 @@
+package bid_entity_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+// defaultLimits are the amount limits used when nothing is configured.
+var defaultLimits = config.Default().Bid.AmountLimits
+
+func TestCreateBidValidatesIds(t *testing.T) {
+	validId := uuid.New().String()
+
+	testCases := []struct {
+		name      string
+		userId    string
+		auctionId string
+		code      string
+		message   string
+	}{
+		{"empty user id", "", validId, internal_error.InvalidUserIdCode, "UserId is required"},
+		{"malformed user id", "not-a-uuid", validId, internal_error.InvalidUserIdCode, "UserId is not a valid id"},
+		{"empty auction id", validId, "", internal_error.InvalidAuctionIdCode, "AuctionId is required"},
+		{"malformed auction id", validId, "not-a-uuid", internal_error.InvalidAuctionIdCode, "AuctionId is not a valid id"},
+		{"both malformed reports user id first", "bad", "bad", internal_error.InvalidUserIdCode, "UserId is not a valid id"},
+		{"valid ids", validId, uuid.New().String(), "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bid, err := bid_entity.CreateBid(tc.userId, tc.auctionId, 100, defaultLimits)
+
+			if tc.code == "" {
+				assert.Nil(t, err)
+				assert.NotNil(t, bid)
+				return
+			}
+
+			assert.Nil(t, bid)
+			assert.NotNil(t, err)
+			assert.Equal(t, "bad_request", err.Err)
+			assert.Equal(t, tc.code, err.Code)
+			assert.Equal(t, tc.message, err.Message)
+		})
+	}
+}
+
+func TestCreateBidValidatesAmount(t *testing.T) {
+	userId, auctionId := uuid.New().String(), uuid.New().String()
+
+	testCases := []struct {
+		name    string
+		amount  float64
+		message string
+	}{
+		{"zero", 0, "Amount is not a valid value"},
+		{"negative", -1, "Amount is not a valid value"},
+		{"NaN", math.NaN(), "Amount is not a valid value"},
+		{"infinite", math.Inf(1), "Amount is not a valid value"},
+		{"negative infinite", math.Inf(-1), "Amount is not a valid value"},
+		{"smallest unit", 0.01, ""},
+		{"two decimal places", 1234.56, ""},
+		{"three decimal places", 1234.567, "Amount must have at most 2 decimal places"},
+		{"whole with zero cents", 10.00, ""},
+		{"one decimal place", 10.5, ""},
+		{"rounds up to the next unit", 10.999, "Amount must have at most 2 decimal places"},
+		{"exact tenths", 0.3, ""},
+		{"billion", 1_000_000_000, ""},
+		{"above a billion with cents", 1_000_000_000.01, ""},
+		{"huge", 1e300, "Amount must not exceed 90071992547409.92"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bid, err := bid_entity.CreateBid(userId, auctionId, tc.amount, defaultLimits)
+
+			if tc.message == "" {
+				assert.Nil(t, err)
+				assert.Equal(t, tc.amount, bid.Amount())
+				return
+			}
+
+			assert.Nil(t, bid)
+			assert.NotNil(t, err)
+			assert.Equal(t, internal_error.InvalidAmountCode, err.Code)
+			assert.Equal(t, tc.message, err.Message)
+		})
+	}
+}
+
+func TestCreateBidRejectsImpreciseFloatSum(t *testing.T) {
+	userId, auctionId := uuid.New().String(), uuid.New().String()
+
+	// Summed at run time, 0.1+0.2 is 0.30000000000000004, which is not what a
+	// client sending 0.3 gets, so its extra decimal places are rejected
+	tenth, fifth := 0.1, 0.2
+	_, err := bid_entity.CreateBid(userId, auctionId, tenth+fifth, defaultLimits)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Amount must have at most 2 decimal places", err.Message)
+	}
+}
+
+func TestCreateBidAmountLimits(t *testing.T) {
+	userId, auctionId := uuid.New().String(), uuid.New().String()
+
+	t.Run("custom maximum without decimal places", func(t *testing.T) {
+		limits := bid_entity.AmountLimits{Max: 500, Decimals: 0}
+
+		_, err := bid_entity.CreateBid(userId, auctionId, 500, limits)
+		assert.Nil(t, err)
+
+		_, err = bid_entity.CreateBid(userId, auctionId, 501, limits)
+		assert.Equal(t, "Amount must not exceed 500", err.Message)
+
+		_, err = bid_entity.CreateBid(userId, auctionId, 10.5, limits)
+		assert.Equal(t, "Amount must have at most 0 decimal places", err.Message)
+	})
+
+	t.Run("one decimal place", func(t *testing.T) {
+		limits := bid_entity.AmountLimits{Max: 1_000_000_000, Decimals: 1}
+
+		for _, amount := range []float64{10, 10.5} {
+			_, err := bid_entity.CreateBid(userId, auctionId, amount, limits)
+			assert.Nil(t, err, amount)
+		}
+
+		_, err := bid_entity.CreateBid(userId, auctionId, 10.25, limits)
+		assert.Equal(t, "Amount must have at most 1 decimal places", err.Message)
+	})
+
+	t.Run("boundary of a custom maximum with decimal places", func(t *testing.T) {
+		limits := bid_entity.AmountLimits{Max: 1000.50, Decimals: 2}
+
+		_, err := bid_entity.CreateBid(userId, auctionId, 1000.50, limits)
+		assert.Nil(t, err)
+
+		_, err = bid_entity.CreateBid(userId, auctionId, 1000.51, limits)
+		assert.Equal(t, "Amount must not exceed 1000.50", err.Message)
+	})
+
+	t.Run("no maximum by default", func(t *testing.T) {
+		assert.Zero(t, defaultLimits.Max)
+
+		_, err := bid_entity.CreateBid(userId, auctionId, 1e13, defaultLimits)
+		assert.Nil(t, err)
+
+		// Only the exact float64 range still bounds the amount
+		_, err = bid_entity.CreateBid(userId, auctionId, 1e14, defaultLimits)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "Amount must not exceed 90071992547409.92", err.Message)
+		}
+	})
+
+	t.Run("infinity is invalid even without a practical maximum", func(t *testing.T) {
+		limits := bid_entity.AmountLimits{Max: 1e300, Decimals: 2}
+
+		_, err := bid_entity.CreateBid(userId, auctionId, math.Inf(1), limits)
+		assert.Equal(t, "Amount is not a valid value", err.Message)
+	})
+
+	t.Run("maximum is capped to the exact float64 range", func(t *testing.T) {
+		// 2^53 cents is the largest amount whose cents are all exact
+		limits := bid_entity.AmountLimits{Max: 1e20, Decimals: 2}
+		assert.Equal(t, float64(1<<53)/100, limits.MaxAmount())
+
+		limits.Decimals = 0
+		assert.Equal(t, float64(1<<53), limits.MaxAmount())
+	})
+}
+
+func TestCreateBidStoresAmountInCents(t *testing.T) {
+	userId, auctionId := uuid.New().String(), uuid.New().String()
+
+	// Each amount scaled by 100 in float lands a hair off the whole cent
+	for amount, cents := range map[float64]int64{
+		0.29:  29,   // 28.999999999999996
+		4.35:  435,  // 434.99999999999994
+		1.1:   110,  // 110.00000000000001
+		19.99: 1999, // 1998.9999999999998
+	} {
+		bid, err := bid_entity.CreateBid(userId, auctionId, amount, defaultLimits)
+		assert.Nil(t, err, amount)
+		assert.Equal(t, cents, bid.AmountCents, amount)
+		assert.Equal(t, amount, bid.Amount(), amount)
+	}
+}
+
+func TestAmountCentsRoundTrip(t *testing.T) {
+	// Amounts that compare inconsistently as floats are plain integers in cents
+	a, b := 0.1, 0.2
+	assert.NotEqual(t, 0.3, a+b)
+	assert.Equal(t, bid_entity.ToCents(0.3), bid_entity.ToCents(a)+bid_entity.ToCents(b))
+
+	assert.Equal(t, int64(100_000_000_000), bid_entity.ToCents(1_000_000_000))
+	assert.Equal(t, 999_999_999.99, bid_entity.FromCents(99_999_999_999))
+	assert.Equal(t, 0.01, bid_entity.FromCents(1))
+}
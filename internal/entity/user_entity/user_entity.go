@@ -2,16 +2,51 @@ package user_entity
 
 import (
 	"context"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 )
 
 type User struct {
 	Id   string
 	Name string
+
+	// CallbackURL, when set, is called when the user's bids are persisted and
+	// when the user wins an auction
+	CallbackURL string
+}
+
+func CreateUser(name string) (*User, *internal_error.InternalError) {
+	user := &User{
+		Id:   uuid.New().String(),
+		Name: strings.TrimSpace(name),
+	}
+
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (u *User) Validate() *internal_error.InternalError {
+	if len(u.Name) <= 1 {
+		return internal_error.NewBadRequestError("name must be longer than 1 character")
+	}
+
+	return nil
 }
 
 type UserRepositoryInterface interface {
+	CreateUser(
+		ctx context.Context, user *User) *internal_error.InternalError
+
 	FindUserById(
 		ctx context.Context, userId string) (*User, *internal_error.InternalError)
+
+	// UpdateUserCallbackURL replaces the user's callback URL; an empty URL
+	// removes it
+	UpdateUserCallbackURL(
+		ctx context.Context, userId, callbackURL string) *internal_error.InternalError
 }
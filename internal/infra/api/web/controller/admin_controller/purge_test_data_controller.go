@@ -0,0 +1,61 @@
+package admin_controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/testdata"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// TestDataPurger removes the documents tagged by integration tests.
+type TestDataPurger interface {
+	Purge(ctx context.Context, prefix string) (*testdata.PurgeOutputDTO, *internal_error.InternalError)
+}
+
+type AdminController struct {
+	purger     TestDataPurger
+	allowPurge bool
+}
+
+// NewAdminController returns the admin endpoints; the test data purge only
+// runs with allowPurge set (ALLOW_TEST_PURGE).
+func NewAdminController(purger TestDataPurger, allowPurge bool) *AdminController {
+	return &AdminController{
+		purger:     purger,
+		allowPurge: allowPurge,
+	}
+}
+
+// PurgeTestData deletes the auctions, bids and users whose id or test_tag
+// starts with the prefix query parameter and returns how many were removed,
+// e.g. {"auctions": 2, "bids": 5, "users": 1}. It only runs when the purge
+// is allowed, so it stays off in production.
+func (a *AdminController) PurgeTestData(c *gin.Context) {
+	if !a.allowPurge {
+		errRest := rest_err.NewForbiddenError("Test data purge is disabled")
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "prefix",
+			Message: "prefix is required",
+		})
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	output, err := a.purger.Purge(c.Request.Context(), prefix)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,66 @@
+package admin_controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/admin_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/testdata"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePurger struct {
+	prefixes []string
+}
+
+func (f *fakePurger) Purge(
+	ctx context.Context, prefix string) (*testdata.PurgeOutputDTO, *internal_error.InternalError) {
+	f.prefixes = append(f.prefixes, prefix)
+	return &testdata.PurgeOutputDTO{Auctions: 2, Bids: 5, Users: 1}, nil
+}
+
+func deleteTestData(purger *fakePurger, allowPurge bool, target string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := router.Group("/admin", middleware.AdminAuth("secret"))
+	admin.DELETE("/test-data", admin_controller.NewAdminController(purger, allowPurge).PurgeTestData)
+
+	request := httptest.NewRequest(http.MethodDelete, target, nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestPurgeTestData(t *testing.T) {
+	purger := &fakePurger{}
+
+	recorder := deleteTestData(purger, true, "/admin/test-data?prefix=ci-run-42")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"auctions":2,"bids":5,"users":1}`, recorder.Body.String())
+	assert.Equal(t, []string{"ci-run-42"}, purger.prefixes)
+}
+
+func TestPurgeTestDataIsBlockedUnlessAllowed(t *testing.T) {
+	purger := &fakePurger{}
+
+	recorder := deleteTestData(purger, false, "/admin/test-data?prefix=ci-run-42")
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Empty(t, purger.prefixes)
+}
+
+func TestPurgeTestDataRequiresPrefix(t *testing.T) {
+	purger := &fakePurger{}
+
+	recorder := deleteTestData(purger, true, "/admin/test-data")
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Empty(t, purger.prefixes)
+}
@@ -0,0 +1,34 @@
+package auction_controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// FindAuctionSummary returns the auction, its current highest bid, its bid
+// count and the seconds it has left, so a client renders it with one request.
+func (u *AuctionController) FindAuctionSummary(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	summary, err := u.auctionUseCase.FindAuctionSummary(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,78 @@
+package auction_controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func getAuctionSummary(useCase auction_usecase.AuctionUseCaseInterface, auctionId string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/auction/:auctionId/summary", auction_controller.NewAuctionController(useCase).FindAuctionSummary)
+
+	request := httptest.NewRequest(http.MethodGet, "/auction/"+auctionId+"/summary", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestFindAuctionSummary(t *testing.T) {
+	auctionId := uuid.New().String()
+	useCase := &fakeAuctionUseCase{summary: &auction_usecase.AuctionSummaryOutputDTO{
+		Auction: auction_usecase.AuctionOutputDTO{Id: auctionId, ProductName: "iPhone 15"},
+		HighestBid: &bid_usecase.CurrentBidOutputDTO{
+			BidOutputDTO: bid_usecase.BidOutputDTO{Id: "bid-1", AuctionId: auctionId, Amount: 700},
+		},
+		BidCount:         4,
+		SecondsRemaining: 120,
+		Status:           auction_usecase.StatusActive,
+	}}
+
+	t.Run("every field in one response", func(t *testing.T) {
+		recorder := getAuctionSummary(useCase, auctionId)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var body struct {
+			Auction struct {
+				Id          string `json:"id"`
+				ProductName string `json:"product_name"`
+			} `json:"auction"`
+			HighestBid struct {
+				Id        string  `json:"id"`
+				Amount    float64 `json:"amount"`
+				Persisted *bool   `json:"persisted"`
+			} `json:"highest_bid"`
+			BidCount         int64  `json:"bid_count"`
+			SecondsRemaining int64  `json:"seconds_remaining"`
+			Status           string `json:"status"`
+		}
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		assert.Equal(t, auctionId, body.Auction.Id)
+		assert.Equal(t, "iPhone 15", body.Auction.ProductName)
+		assert.Equal(t, "bid-1", body.HighestBid.Id)
+		assert.Equal(t, 700.0, body.HighestBid.Amount)
+		if assert.NotNil(t, body.HighestBid.Persisted) {
+			assert.False(t, *body.HighestBid.Persisted)
+		}
+		assert.Equal(t, int64(4), body.BidCount)
+		assert.Equal(t, int64(120), body.SecondsRemaining)
+		assert.Equal(t, "active", body.Status)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, getAuctionSummary(useCase, "not-a-uuid").Code)
+	})
+
+	t.Run("unknown auction", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, getAuctionSummary(useCase, uuid.New().String()).Code)
+	})
+}
@@ -0,0 +1,47 @@
+package auction_controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// CloseExpiredAuctions completes the expired auctions right away and returns
+// how many were closed, e.g. {"closed": 3}.
+func (u *AuctionController) CloseExpiredAuctions(c *gin.Context) {
+	output, err := u.auctionUseCase.CloseExpiredAuctions(c.Request.Context())
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// CloseAuction ends an active auction before its expiration, e.g. when the
+// item was sold offline, and returns it as completed.
+func (u *AuctionController) CloseAuction(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	auctionData, err := u.auctionUseCase.CloseAuction(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, auctionData)
+}
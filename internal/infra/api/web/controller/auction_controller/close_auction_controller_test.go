@@ -0,0 +1,112 @@
+package auction_controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func postCloseExpired(useCase auction_usecase.AuctionUseCaseInterface, token string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := router.Group("/admin", middleware.AdminAuth("secret"))
+	admin.POST("/auctions/close-expired",
+		auction_controller.NewAuctionController(useCase).CloseExpiredAuctions)
+
+	request := httptest.NewRequest(http.MethodPost, "/admin/auctions/close-expired", nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCloseExpiredAuctionsReturnsClosedCount(t *testing.T) {
+	recorder := postCloseExpired(&fakeAuctionUseCase{expired: 3}, "secret")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"closed":3}`, recorder.Body.String())
+}
+
+func TestCloseExpiredAuctionsRequiresAdminToken(t *testing.T) {
+	recorder := postCloseExpired(&fakeAuctionUseCase{expired: 3}, "wrong")
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestCloseExpiredAuctionsReportsFailure(t *testing.T) {
+	recorder := postCloseExpired(&fakeAuctionUseCase{
+		closeErr: internal_error.NewInternalServerError("Error trying to close expired auctions"),
+	}, "secret")
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func patchCloseAuction(
+	useCase auction_usecase.AuctionUseCaseInterface, auctionId, token string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PATCH("/auction/:auctionId/close", middleware.AdminAuth("secret"),
+		auction_controller.NewAuctionController(useCase).CloseAuction)
+
+	request := httptest.NewRequest(http.MethodPatch, "/auction/"+auctionId+"/close", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCloseAuction(t *testing.T) {
+	auctionId := uuid.New().String()
+
+	t.Run("returns the completed auction", func(t *testing.T) {
+		useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{Id: auctionId}}
+
+		recorder := patchCloseAuction(useCase, auctionId, "secret")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), useCase.auction.Status)
+		assert.Contains(t, recorder.Body.String(), `"status":"completed"`)
+	})
+
+	t.Run("already completed", func(t *testing.T) {
+		useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{
+			Id: auctionId, Status: auction_usecase.AuctionStatus(auction_entity.Completed),
+		}}
+
+		recorder := patchCloseAuction(useCase, auctionId, "secret")
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("unknown auction", func(t *testing.T) {
+		recorder := patchCloseAuction(&fakeAuctionUseCase{}, auctionId, "secret")
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		recorder := patchCloseAuction(&fakeAuctionUseCase{}, "not-a-uuid", "secret")
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{Id: auctionId}}
+
+		recorder := patchCloseAuction(useCase, auctionId, "wrong")
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Active), useCase.auction.Status)
+	})
+}
@@ -0,0 +1,87 @@
+package auction_controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func postAuction(useCase auction_usecase.AuctionUseCaseInterface, condition string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auction", auction_controller.NewAuctionController(useCase).CreateAuction)
+
+	body := `{"product_name": "iPhone", "category": "electronics",
+		"description": "Brand new iPhone 15 Pro", "condition": ` + condition + `}`
+	request := httptest.NewRequest(http.MethodPost, "/auction", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCreateAuctionParsesConditionNames(t *testing.T) {
+	testCases := []struct {
+		condition string
+		expected  auction_entity.ProductCondition
+	}{
+		{`"new"`, auction_entity.New},
+		{`"used"`, auction_entity.Used},
+		{`"refurbished"`, auction_entity.Refurbished},
+		{`"Used"`, auction_entity.Used},
+		{`"REFURBISHED"`, auction_entity.Refurbished},
+		// Numbers are still accepted
+		{`2`, auction_entity.Used},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.condition, func(t *testing.T) {
+			useCase := &fakeAuctionUseCase{}
+			recorder := postAuction(useCase, tc.condition)
+
+			assert.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+			if assert.NotNil(t, useCase.created) {
+				assert.Equal(t, auction_usecase.ProductCondition(tc.expected), useCase.created.Condition)
+			}
+		})
+	}
+}
+
+func TestCreateAuctionRejectsUnknownCondition(t *testing.T) {
+	useCase := &fakeAuctionUseCase{}
+	recorder := postAuction(useCase, `"broken"`)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Nil(t, useCase.created)
+
+	var restErr rest_err.RestErr
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &restErr))
+	assert.Equal(t, "bad_request", restErr.Err)
+	assert.Contains(t, restErr.Message, `"broken"`)
+}
+
+func TestProductConditionRoundTripsAsName(t *testing.T) {
+	output, err := json.Marshal(auction_usecase.AuctionOutputDTO{
+		Condition: auction_usecase.ProductCondition(auction_entity.Refurbished),
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), `"condition":"refurbished"`)
+
+	var input auction_usecase.AuctionInputDTO
+	assert.Nil(t, json.Unmarshal([]byte(`{"condition":"refurbished"}`), &input))
+	assert.Equal(t, auction_usecase.ProductCondition(auction_entity.Refurbished), input.Condition)
+
+	// Conditions without a name are written as numbers
+	output, err = json.Marshal(auction_usecase.AuctionOutputDTO{})
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), `"condition":0`)
+}
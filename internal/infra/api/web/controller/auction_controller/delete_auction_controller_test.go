@@ -0,0 +1,62 @@
+package auction_controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func deleteAuction(
+	useCase auction_usecase.AuctionUseCaseInterface, auctionId, token string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.DELETE("/auction/:auctionId", middleware.AdminAuth("secret"),
+		auction_controller.NewAuctionController(useCase).DeleteAuction)
+
+	request := httptest.NewRequest(http.MethodDelete, "/auction/"+auctionId, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestDeleteAuction(t *testing.T) {
+	auctionId := uuid.New().String()
+
+	t.Run("answers no content", func(t *testing.T) {
+		useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{Id: auctionId}}
+
+		recorder := deleteAuction(useCase, auctionId, "secret")
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+		assert.Nil(t, useCase.auction)
+	})
+
+	t.Run("unknown auction", func(t *testing.T) {
+		recorder := deleteAuction(&fakeAuctionUseCase{}, auctionId, "secret")
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		recorder := deleteAuction(&fakeAuctionUseCase{}, "not-a-uuid", "secret")
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{Id: auctionId}}
+
+		recorder := deleteAuction(useCase, auctionId, "wrong")
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.NotNil(t, useCase.auction)
+	})
+}
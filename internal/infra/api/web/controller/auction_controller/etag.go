@@ -0,0 +1,34 @@
+package auction_controller
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+)
+
+// auctionETag derives the entity tag of an auction from its change tracking
+// fields, so it only changes when the auction itself is mutated.
+func auctionETag(auction *auction_usecase.AuctionOutputDTO) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d|%d",
+		auction.Id, auction.Version, auction.UpdatedAt.Unix(), auction.Status)))
+	return fmt.Sprintf(`"%x"`, sum)
+}
+
+// etagMatches reports whether an If-None-Match header matches the given tag.
+// The header may list several tags, use weak tags or be a wildcard.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package auction_controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// ExportAuction returns the auction, its winner and all its bids in a single
+// JSON document. The bids array is written as the bids are read, so the
+// document is never built in memory:
+//
+//	{"exported_at": ..., "auction": {...}, "winner": {...}, "bids": [...], "bid_count": N}
+func (u *AuctionController) ExportAuction(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	export, err := u.auctionUseCase.ExportAuction(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	head, errMarshal := json.Marshal(export)
+	if errMarshal != nil {
+		logger.Error("Error trying to encode auction export", errMarshal)
+		errRest := rest_err.NewInternalServerError("Error trying to export auction")
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="auction-%s-export.json"`, auctionId))
+	c.Status(http.StatusOK)
+
+	// Reopen the encoded object to append the streamed bids array
+	c.Writer.Write(bytes.TrimSuffix(head, []byte("}")))
+	c.Writer.WriteString(`,"bids":[`)
+
+	count := 0
+	for bid := range export.Bids {
+		encoded, errMarshal := json.Marshal(bid)
+		if errMarshal != nil {
+			logger.Error("Error trying to encode exported bid "+bid.Id, errMarshal)
+			continue
+		}
+
+		if count > 0 {
+			c.Writer.WriteString(",")
+		}
+		c.Writer.Write(encoded)
+		count++
+	}
+
+	c.Writer.WriteString(`],"bid_count":` + strconv.Itoa(count) + "}")
+}
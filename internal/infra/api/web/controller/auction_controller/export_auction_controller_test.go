@@ -0,0 +1,92 @@
+package auction_controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func exportAuction(useCase auction_usecase.AuctionUseCaseInterface, auctionId string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/auction/:auctionId/export",
+		auction_controller.NewAuctionController(useCase).ExportAuction)
+
+	request := httptest.NewRequest(http.MethodGet, "/auction/"+auctionId+"/export", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestExportAuctionWritesFullDocument(t *testing.T) {
+	auctionId := uuid.New().String()
+	exportedAt := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	bids := []bid_usecase.BidOutputDTO{
+		{Id: "bid-1", UserId: "user-1", AuctionId: auctionId, Amount: 100},
+		{Id: "bid-2", UserId: "user-2", AuctionId: auctionId, Amount: 250},
+	}
+
+	stream := make(chan bid_usecase.BidOutputDTO, len(bids))
+	for _, bid := range bids {
+		stream <- bid
+	}
+	close(stream)
+
+	useCase := &fakeAuctionUseCase{export: &auction_usecase.AuctionExportDTO{
+		ExportedAt: exportedAt,
+		Auction:    auction_usecase.AuctionOutputDTO{Id: auctionId, Status: 1},
+		Winner:     &bids[1],
+		Bids:       stream,
+	}}
+
+	recorder := exportAuction(useCase, auctionId)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Header().Get("Content-Disposition"), "auction-"+auctionId+"-export.json")
+
+	var document struct {
+		ExportedAt time.Time                        `json:"exported_at"`
+		Auction    auction_usecase.AuctionOutputDTO `json:"auction"`
+		Winner     *bid_usecase.BidOutputDTO        `json:"winner"`
+		Bids       []bid_usecase.BidOutputDTO       `json:"bids"`
+		BidCount   int                              `json:"bid_count"`
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+
+	assert.True(t, exportedAt.Equal(document.ExportedAt))
+	assert.Equal(t, auctionId, document.Auction.Id)
+	assert.Equal(t, auction_usecase.AuctionStatus(1), document.Auction.Status)
+	assert.Equal(t, "bid-2", document.Winner.Id)
+	assert.Len(t, document.Bids, 2)
+	assert.Equal(t, "bid-1", document.Bids[0].Id)
+	assert.Equal(t, 2, document.BidCount)
+}
+
+func TestExportAuctionWithoutBids(t *testing.T) {
+	auctionId := uuid.New().String()
+	stream := make(chan bid_usecase.BidOutputDTO)
+	close(stream)
+
+	useCase := &fakeAuctionUseCase{export: &auction_usecase.AuctionExportDTO{
+		Auction: auction_usecase.AuctionOutputDTO{Id: auctionId},
+		Bids:    stream,
+	}}
+
+	recorder := exportAuction(useCase, auctionId)
+
+	var document map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	assert.Nil(t, document["winner"])
+	assert.Equal(t, []any{}, document["bids"])
+	assert.Equal(t, 0.0, document["bid_count"])
+}
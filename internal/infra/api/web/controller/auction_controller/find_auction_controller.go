@@ -1,13 +1,17 @@
 package auction_controller
 
 import (
-	"context"
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/validation"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
 )
 
@@ -24,30 +28,108 @@ func (u *AuctionController) FindAuctionById(c *gin.Context) {
 		return
 	}
 
-	auctionData, err := u.auctionUseCase.FindAuctionById(context.Background(), auctionId)
+	auctionData, err := u.auctionUseCase.FindAuctionById(c.Request.Context(), auctionId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
 		c.JSON(errRest.Code, errRest)
 		return
 	}
 
+	etag := auctionETag(auctionData)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, auctionData)
 }
 
 func (u *AuctionController) FindAuctions(c *gin.Context) {
-	status := c.Query("status")
-	category := c.Query("category")
-	productName := c.Query("productName")
+	filterInput := auction_usecase.FindAuctionsInputDTO{
+		Category:    c.Query("category"),
+		ProductName: c.Query("productName"),
+		Query:       c.Query("q"),
+	}
+
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) == "highest_bid" {
+			filterInput.IncludeHighestBid = true
+		}
+	}
+
+	if status := c.Query("status"); status != "" {
+		auctionStatus, err := parseAuctionStatus(status)
+		if err != nil {
+			errRest := rest_err.ConvertError(err)
+			c.JSON(errRest.Code, errRest)
+			return
+		}
+		filterInput.Status = &auctionStatus
+	}
+
+	if hasBids := c.Query("has_bids"); hasBids != "" {
+		value, errConv := strconv.ParseBool(hasBids)
+		if errConv != nil {
+			errRest := rest_err.NewBadRequestError("Error trying to validate has_bids param")
+			c.JSON(errRest.Code, errRest)
+			return
+		}
+		filterInput.HasBids = value
+	}
+
+	switch c.Query("sort") {
+	case "":
+	case "closed_desc":
+		filterInput.Sort = auction_usecase.SortClosedDesc
+	case "bid_count_desc":
+		filterInput.Sort = auction_usecase.SortBidCountDesc
+	case "created_at":
+		filterInput.Sort = auction_usecase.SortCreatedAsc
+	case "-created_at":
+		filterInput.Sort = auction_usecase.SortCreatedDesc
+	case "expires_at":
+		filterInput.Sort = auction_usecase.SortExpiresAsc
+	case "-expires_at":
+		filterInput.Sort = auction_usecase.SortExpiresDesc
+	default:
+		errRest := rest_err.NewBadRequestError("Error trying to validate sort param")
+		c.JSON(errRest.Code, errRest)
+		return
+	}
 
-	statusNumber, errConv := strconv.Atoi(status)
-	if errConv != nil {
-		errRest := rest_err.NewBadRequestError("Error trying to validate auction status param")
+	limit, offset, errRest := validation.ParsePagination(c)
+	if errRest != nil {
 		c.JSON(errRest.Code, errRest)
 		return
 	}
+	filterInput.Limit, filterInput.Offset = limit, offset
 
-	auctions, err := u.auctionUseCase.FindAuctions(context.Background(),
-		auction_usecase.AuctionStatus(statusNumber), category, productName)
+	// page (from 1) and pageSize are still accepted in place of limit and offset
+	if page, pageSize := c.Query("page"), c.Query("pageSize"); page != "" || pageSize != "" {
+		if errRest := applyPageParams(&filterInput, page, pageSize); errRest != nil {
+			c.JSON(errRest.Code, errRest)
+			return
+		}
+	}
+
+	// The condition is given by number or, like in the create body, by name
+	if condition := c.Query("condition"); condition != "" {
+		conditionNumber, errConv := strconv.Atoi(condition)
+		if errConv != nil {
+			parsed, err := auction_entity.ParseProductCondition(condition)
+			if err != nil {
+				errRest := rest_err.NewBadRequestError("Error trying to validate auction condition param")
+				c.JSON(errRest.Code, errRest)
+				return
+			}
+			conditionNumber = int(parsed)
+		}
+		productCondition := auction_usecase.ProductCondition(conditionNumber)
+		filterInput.Condition = &productCondition
+	}
+
+	auctions, err := u.auctionUseCase.FindAuctions(c.Request.Context(), filterInput)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
 		c.JSON(errRest.Code, errRest)
@@ -57,6 +139,107 @@ func (u *AuctionController) FindAuctions(c *gin.Context) {
 	c.JSON(http.StatusOK, auctions)
 }
 
+// applyPageParams turns the page and pageSize parameters into the limit and
+// offset of the listing. An absent pageSize keeps the limit, which the use
+// case defaults to 20.
+func applyPageParams(filterInput *auction_usecase.FindAuctionsInputDTO, page, pageSize string) *rest_err.RestErr {
+	if pageSize != "" {
+		value, errConv := strconv.ParseInt(pageSize, 10, 64)
+		if errConv != nil {
+			return rest_err.NewBadRequestError("Error trying to validate pageSize param")
+		}
+		filterInput.Limit = value
+	}
+
+	if page != "" {
+		value, errConv := strconv.ParseInt(page, 10, 64)
+		if errConv != nil || value < 1 {
+			return rest_err.NewBadRequestError("Error trying to validate page param")
+		}
+
+		limit := filterInput.Limit
+		if limit == 0 {
+			limit = defaultPageSize
+		}
+		filterInput.Offset = (value - 1) * limit
+	}
+
+	return nil
+}
+
+// defaultPageSize is the limit the use case applies to a listing without one.
+const defaultPageSize = 20
+
+// parseAuctionStatus accepts a status by number or by name.
+// Names go through AuctionStatus.UnmarshalJSON, like in response bodies.
+func parseAuctionStatus(status string) (auction_usecase.AuctionStatus, *internal_error.InternalError) {
+	if statusNumber, errConv := strconv.Atoi(status); errConv == nil {
+		return auction_usecase.AuctionStatus(statusNumber), nil
+	}
+
+	var auctionStatus auction_usecase.AuctionStatus
+	name, _ := json.Marshal(status)
+	if err := auctionStatus.UnmarshalJSON(name); err != nil {
+		if internalErr, ok := err.(*internal_error.InternalError); ok {
+			return 0, internalErr
+		}
+		return 0, internal_error.NewBadRequestError("Error trying to validate auction status param")
+	}
+
+	return auctionStatus, nil
+}
+
+// defaultLeaderboardSize is how many auctions a category leaderboard lists
+// when no limit is given.
+const defaultLeaderboardSize = 10
+
+// FindCategoryLeaderboard lists the active auctions of a category by current
+// highest bid, descending.
+func (u *AuctionController) FindCategoryLeaderboard(c *gin.Context) {
+	limit := int64(defaultLeaderboardSize)
+	if limitParam := c.Query("limit"); limitParam != "" {
+		value, errConv := strconv.ParseInt(limitParam, 10, 64)
+		if errConv != nil {
+			errRest := rest_err.NewBadRequestError("Error trying to validate limit param")
+			c.JSON(errRest.Code, errRest)
+			return
+		}
+		limit = value
+	}
+
+	leaderboard, err := u.auctionUseCase.FindCategoryLeaderboard(
+		c.Request.Context(), c.Param("category"), limit)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+// FindAuctionStatuses answers the status of many auctions in one round trip,
+// for dashboards polling them.
+func (u *AuctionController) FindAuctionStatuses(c *gin.Context) {
+	var input auction_usecase.FindAuctionStatusesInputDTO
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		restErr := validation.ValidateErr(err)
+
+		c.JSON(restErr.Code, restErr)
+		return
+	}
+
+	statuses, err := u.auctionUseCase.FindAuctionStatuses(c.Request.Context(), input)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
 func (u *AuctionController) FindWinningBidByAuctionId(c *gin.Context) {
 	auctionId := c.Param("auctionId")
 
@@ -70,7 +253,7 @@ func (u *AuctionController) FindWinningBidByAuctionId(c *gin.Context) {
 		return
 	}
 
-	auctionData, err := u.auctionUseCase.FindWinningBidByAuctionId(context.Background(), auctionId)
+	auctionData, err := u.auctionUseCase.FindWinningBidByAuctionId(c.Request.Context(), auctionId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
 		c.JSON(errRest.Code, errRest)
@@ -0,0 +1,247 @@
+package auction_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuctionUseCase struct {
+	auction       *auction_usecase.AuctionOutputDTO
+	closingEvents chan auction_usecase.ClosingAuctionEventDTO
+	export        *auction_usecase.AuctionExportDTO
+	statuses      map[string]string
+	findInput     *auction_usecase.FindAuctionsInputDTO
+	expired       int64
+	closeErr      *internal_error.InternalError
+	patch         []byte
+	summary       *auction_usecase.AuctionSummaryOutputDTO
+	findErr       *internal_error.InternalError // returned by FindAuctionById when set
+	created       *auction_usecase.AuctionInputDTO
+	userAuctions  map[string][]auction_usecase.UserAuctionOutputDTO
+}
+
+func (f *fakeAuctionUseCase) CreateAuction(
+	ctx context.Context, auctionInput auction_usecase.AuctionInputDTO) *internal_error.InternalError {
+	f.created = &auctionInput
+	return nil
+}
+
+func (f *fakeAuctionUseCase) FindAuctionById(
+	ctx context.Context, id string) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	if f.findErr != nil {
+		return nil, f.findErr
+	}
+	if f.auction == nil || f.auction.Id != id {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+	auction := *f.auction
+	return &auction, nil
+}
+
+func (f *fakeAuctionUseCase) FindAuctions(
+	ctx context.Context, filterInput auction_usecase.FindAuctionsInputDTO) (*auction_usecase.FindAuctionsOutputDTO, *internal_error.InternalError) {
+	f.findInput = &filterInput
+	return &auction_usecase.FindAuctionsOutputDTO{
+		Auctions: []auction_usecase.AuctionOutputDTO{},
+		Total:    42,
+		Limit:    filterInput.Limit,
+		Offset:   filterInput.Offset,
+	}, nil
+}
+
+func (f *fakeAuctionUseCase) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*auction_usecase.WinningInfoOutputDTO, *internal_error.InternalError) {
+	return nil, internal_error.NewAuctionNotFoundError()
+}
+
+func (f *fakeAuctionUseCase) FindCategoryLeaderboard(
+	ctx context.Context, category string, limit int64) ([]auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	return []auction_usecase.AuctionOutputDTO{}, nil
+}
+
+func (f *fakeAuctionUseCase) FindAuctionStatuses(
+	ctx context.Context, input auction_usecase.FindAuctionStatusesInputDTO) ([]auction_usecase.AuctionStatusOutputDTO, *internal_error.InternalError) {
+	var output []auction_usecase.AuctionStatusOutputDTO
+	for _, id := range input.AuctionIds {
+		status, ok := f.statuses[id]
+		if !ok {
+			status = auction_usecase.StatusNotFound
+		}
+		output = append(output, auction_usecase.AuctionStatusOutputDTO{Id: id, Status: status})
+	}
+	return output, nil
+}
+
+func (f *fakeAuctionUseCase) ExportAuction(
+	ctx context.Context, auctionId string) (*auction_usecase.AuctionExportDTO, *internal_error.InternalError) {
+	if f.export == nil || f.export.Auction.Id != auctionId {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+	return f.export, nil
+}
+
+func (f *fakeAuctionUseCase) CloseExpiredAuctions(
+	ctx context.Context) (*auction_usecase.CloseExpiredAuctionsOutputDTO, *internal_error.InternalError) {
+	if f.closeErr != nil {
+		return nil, f.closeErr
+	}
+	return &auction_usecase.CloseExpiredAuctionsOutputDTO{Closed: f.expired}, nil
+}
+
+func (f *fakeAuctionUseCase) CloseAuction(
+	ctx context.Context, auctionId string) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	if f.auction == nil || f.auction.Id != auctionId {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+	if f.auction.Status != auction_usecase.AuctionStatus(auction_entity.Active) {
+		return nil, internal_error.NewAuctionCompletedError()
+	}
+	f.auction.Status = auction_usecase.AuctionStatus(auction_entity.Completed)
+	auction := *f.auction
+	return &auction, nil
+}
+
+func (f *fakeAuctionUseCase) RelistAuction(
+	ctx context.Context, auctionId string) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	if f.auction == nil || f.auction.Id != auctionId {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+	if f.auction.Status != auction_usecase.AuctionStatus(auction_entity.Completed) {
+		return nil, internal_error.NewBadRequestError("Only completed auctions can be relisted")
+	}
+	relisted := *f.auction
+	relisted.Id = uuid.New().String()
+	relisted.Status = auction_usecase.AuctionStatus(auction_entity.Active)
+	relisted.RelistedFrom = auctionId
+	return &relisted, nil
+}
+
+func (f *fakeAuctionUseCase) DeleteAuction(ctx context.Context, auctionId string) *internal_error.InternalError {
+	if f.auction == nil || f.auction.Id != auctionId {
+		return internal_error.NewAuctionNotFoundError()
+	}
+	f.auction = nil
+	return nil
+}
+
+func (f *fakeAuctionUseCase) SubscribeClosingAuctions(
+	ctx context.Context, within time.Duration) (<-chan auction_usecase.ClosingAuctionEventDTO, *internal_error.InternalError) {
+	if f.closingEvents == nil {
+		return nil, internal_error.NewServiceUnavailableError("Closing stream is not available")
+	}
+	return f.closingEvents, nil
+}
+
+func (f *fakeAuctionUseCase) FindAuctionSummary(
+	ctx context.Context, auctionId string) (*auction_usecase.AuctionSummaryOutputDTO, *internal_error.InternalError) {
+	if f.summary == nil || f.summary.Auction.Id != auctionId {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+	return f.summary, nil
+}
+
+func (f *fakeAuctionUseCase) FindUserAuctions(
+	ctx context.Context, userId string) ([]auction_usecase.UserAuctionOutputDTO, *internal_error.InternalError) {
+	if auctions, ok := f.userAuctions[userId]; ok {
+		return auctions, nil
+	}
+	return []auction_usecase.UserAuctionOutputDTO{}, nil
+}
+
+func (f *fakeAuctionUseCase) UpdateAuction(
+	ctx context.Context, auctionId string, patch []byte) (*auction_usecase.AuctionOutputDTO, *internal_error.InternalError) {
+	if f.auction == nil || f.auction.Id != auctionId {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+	f.patch = patch
+	auction := *f.auction
+	return &auction, nil
+}
+
+func newRouter(useCase auction_usecase.AuctionUseCaseInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := auction_controller.NewAuctionController(useCase)
+	router.GET("/auction/:auctionId", controller.FindAuctionById)
+	return router
+}
+
+func getAuction(router *gin.Engine, auctionId, ifNoneMatch string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/auction/"+auctionId, nil)
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestFindAuctionByIdETag(t *testing.T) {
+	now := time.Now()
+	useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{
+		Id:        uuid.New().String(),
+		Status:    0,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	router := newRouter(useCase)
+
+	first := getAuction(router, useCase.auction.Id, "")
+	assert.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	notModified := getAuction(router, useCase.auction.Id, etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	// The auction is closed: the stored ETag no longer matches
+	useCase.auction.Status = 1
+	useCase.auction.UpdatedAt = now.Add(time.Minute)
+
+	changed := getAuction(router, useCase.auction.Id, etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+	assert.NotEmpty(t, changed.Body.String())
+}
+
+func TestFindAuctionByIdErrorStatuses(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    *internal_error.InternalError
+		status int
+	}{
+		{"bad request", internal_error.NewBadRequestError("Invalid auction"), http.StatusBadRequest},
+		{"not found", internal_error.NewAuctionNotFoundError(), http.StatusNotFound},
+		{"gone", internal_error.NewGoneError("Auction was deleted"), http.StatusGone},
+		{"internal server", internal_error.NewInternalServerError("Error trying to find auction by id"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRouter(&fakeAuctionUseCase{findErr: tc.err})
+
+			recorder := getAuction(router, uuid.New().String(), "")
+			assert.Equal(t, tc.status, recorder.Code)
+
+			var errRest rest_err.RestErr
+			assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &errRest))
+			assert.Equal(t, tc.status, errRest.Code)
+			assert.Equal(t, tc.err.Code, errRest.ErrorCode)
+		})
+	}
+}
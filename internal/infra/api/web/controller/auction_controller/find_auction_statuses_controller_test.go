@@ -0,0 +1,64 @@
+package auction_controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func postStatuses(useCase auction_usecase.AuctionUseCaseInterface, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auction/statuses",
+		auction_controller.NewAuctionController(useCase).FindAuctionStatuses)
+
+	request := httptest.NewRequest(http.MethodPost, "/auction/statuses", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestFindAuctionStatusesReportsMissingIds(t *testing.T) {
+	useCase := &fakeAuctionUseCase{statuses: map[string]string{
+		"auction-1": auction_usecase.StatusActive,
+		"auction-2": auction_usecase.StatusCompleted,
+	}}
+
+	recorder := postStatuses(useCase, `{"auction_ids":["auction-1","missing","auction-2"]}`)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var statuses []auction_usecase.AuctionStatusOutputDTO
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &statuses))
+	assert.Equal(t, []auction_usecase.AuctionStatusOutputDTO{
+		{Id: "auction-1", Status: "active"},
+		{Id: "missing", Status: "not_found"},
+		{Id: "auction-2", Status: "completed"},
+	}, statuses)
+}
+
+func TestFindAuctionStatusesRejectsEmptyList(t *testing.T) {
+	recorder := postStatuses(&fakeAuctionUseCase{}, `{"auction_ids":[]}`)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestFindAuctionStatusesRejectsTooManyIds(t *testing.T) {
+	ids := make([]string, 101)
+	for i := range ids {
+		ids[i] = "auction"
+	}
+	body, _ := json.Marshal(map[string][]string{"auction_ids": ids})
+
+	recorder := postStatuses(&fakeAuctionUseCase{}, string(body))
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,162 @@
+package auction_controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func getAuctions(useCase auction_usecase.AuctionUseCaseInterface, query string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/auction", auction_controller.NewAuctionController(useCase).FindAuctions)
+
+	request := httptest.NewRequest(http.MethodGet, "/auction?"+query, nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestFindAuctionsRecentlyCompletedDefaultsToFirstPage(t *testing.T) {
+	useCase := &fakeAuctionUseCase{}
+
+	recorder := getAuctions(useCase, "status=completed&sort=closed_desc")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.NotNil(t, useCase.findInput) {
+		assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), *useCase.findInput.Status)
+		assert.Equal(t, auction_usecase.SortClosedDesc, useCase.findInput.Sort)
+		assert.Zero(t, useCase.findInput.Limit)
+		assert.Zero(t, useCase.findInput.Offset)
+	}
+}
+
+func TestFindAuctionsParsesStatusName(t *testing.T) {
+	useCase := &fakeAuctionUseCase{}
+
+	recorder := getAuctions(useCase, "status=Active")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.NotNil(t, useCase.findInput) {
+		assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Active), *useCase.findInput.Status)
+	}
+}
+
+func TestFindAuctionsRejectsUnknownStatusName(t *testing.T) {
+	recorder := getAuctions(&fakeAuctionUseCase{}, "status=sold")
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "expected active or completed")
+}
+
+func TestFindAuctionsParsesBidCountSort(t *testing.T) {
+	useCase := &fakeAuctionUseCase{}
+
+	recorder := getAuctions(useCase, "sort=bid_count_desc")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.NotNil(t, useCase.findInput) {
+		assert.Nil(t, useCase.findInput.Status)
+		assert.Equal(t, auction_usecase.SortBidCountDesc, useCase.findInput.Sort)
+	}
+}
+
+func TestFindAuctionsParsesTextSearch(t *testing.T) {
+	useCase := &fakeAuctionUseCase{}
+
+	recorder := getAuctions(useCase, "q=iPhone&category=eletr&productName=15+Pro")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.NotNil(t, useCase.findInput) {
+		assert.Equal(t, "iPhone", useCase.findInput.Query)
+		assert.Equal(t, "eletr", useCase.findInput.Category)
+		assert.Equal(t, "15 Pro", useCase.findInput.ProductName)
+	}
+}
+
+func TestFindAuctionsParsesDateSorts(t *testing.T) {
+	for query, sort := range map[string]auction_usecase.AuctionSort{
+		"":                 auction_usecase.SortDefault,
+		"sort=created_at":  auction_usecase.SortCreatedAsc,
+		"sort=-created_at": auction_usecase.SortCreatedDesc,
+		"sort=expires_at":  auction_usecase.SortExpiresAsc,
+		"sort=-expires_at": auction_usecase.SortExpiresDesc,
+	} {
+		useCase := &fakeAuctionUseCase{}
+
+		recorder := getAuctions(useCase, query)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, query)
+		if assert.NotNil(t, useCase.findInput, query) {
+			assert.Equal(t, sort, useCase.findInput.Sort, query)
+		}
+	}
+
+	recorder := getAuctions(&fakeAuctionUseCase{}, "sort=updated_at")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestFindAuctionsReturnsPageEnvelope(t *testing.T) {
+	useCase := &fakeAuctionUseCase{}
+
+	recorder := getAuctions(useCase, "limit=5&offset=10")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"data":[],"total":42,"limit":5,"offset":10}`, recorder.Body.String())
+}
+
+func TestFindAuctionsParsesPagination(t *testing.T) {
+	testCases := []struct {
+		query  string
+		limit  int64
+		offset int64
+	}{
+		{"status=1&limit=5&offset=10", 5, 10},
+		// page and pageSize are translated into limit and offset
+		{"status=1&page=3&pageSize=5", 5, 10},
+		{"status=1&page=2", 0, 20},
+	}
+
+	for _, tc := range testCases {
+		useCase := &fakeAuctionUseCase{}
+
+		recorder := getAuctions(useCase, tc.query)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, tc.query)
+		if assert.NotNil(t, useCase.findInput, tc.query) {
+			assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), *useCase.findInput.Status)
+			assert.Equal(t, auction_usecase.SortDefault, useCase.findInput.Sort)
+			assert.Equal(t, tc.limit, useCase.findInput.Limit, tc.query)
+			assert.Equal(t, tc.offset, useCase.findInput.Offset, tc.query)
+		}
+	}
+}
+
+func TestFindAuctionsParsesHasBids(t *testing.T) {
+	useCase := &fakeAuctionUseCase{}
+
+	recorder := getAuctions(useCase, "has_bids=true")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.NotNil(t, useCase.findInput) {
+		assert.True(t, useCase.findInput.HasBids)
+	}
+}
+
+func TestFindAuctionsRejectsInvalidQuery(t *testing.T) {
+	for _, query := range []string{
+		"sort=newest", "status=sold", "page=first", "page=0", "limit=ten", "offset=-", "has_bids=maybe"} {
+		useCase := &fakeAuctionUseCase{}
+
+		recorder := getAuctions(useCase, query)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+		assert.Nil(t, useCase.findInput, query)
+	}
+}
@@ -0,0 +1,76 @@
+package auction_controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func postRelistAuction(
+	useCase auction_usecase.AuctionUseCaseInterface, auctionId, token string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auction/:auctionId/relist", middleware.AdminAuth("secret"),
+		auction_controller.NewAuctionController(useCase).RelistAuction)
+
+	request := httptest.NewRequest(http.MethodPost, "/auction/"+auctionId+"/relist", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRelistAuction(t *testing.T) {
+	auctionId := uuid.New().String()
+	completed := func() *fakeAuctionUseCase {
+		return &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{
+			Id: auctionId, Status: auction_usecase.AuctionStatus(auction_entity.Completed),
+		}}
+	}
+
+	t.Run("returns the new auction", func(t *testing.T) {
+		recorder := postRelistAuction(completed(), auctionId, "secret")
+
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		var relisted auction_usecase.AuctionOutputDTO
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &relisted))
+		assert.NotEqual(t, auctionId, relisted.Id)
+		assert.Equal(t, auctionId, relisted.RelistedFrom)
+		assert.Contains(t, recorder.Body.String(), `"status":"active"`)
+	})
+
+	t.Run("auction not relistable", func(t *testing.T) {
+		useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{Id: auctionId}}
+
+		recorder := postRelistAuction(useCase, auctionId, "secret")
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("unknown auction", func(t *testing.T) {
+		recorder := postRelistAuction(&fakeAuctionUseCase{}, auctionId, "secret")
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		recorder := postRelistAuction(&fakeAuctionUseCase{}, "not-a-uuid", "secret")
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		recorder := postRelistAuction(completed(), auctionId, "wrong")
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
@@ -0,0 +1,58 @@
+package auction_controller
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// defaultClosingWindow is used when the within query param is omitted
+const defaultClosingWindow = 5 * time.Minute
+
+// StreamClosingAuctions pushes, as server-sent events, the auctions entering
+// the closing window (event "closing") and the ones being closed ("closed").
+func (u *AuctionController) StreamClosingAuctions(c *gin.Context) {
+	within := defaultClosingWindow
+	if value := c.Query("within"); value != "" {
+		duration, errParse := time.ParseDuration(value)
+		if errParse != nil {
+			errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+				Field:   "within",
+				Message: "Invalid duration value",
+			})
+			c.JSON(errRest.Code, errRest)
+			return
+		}
+		within = duration
+	}
+
+	// The request context ends the subscription when the client disconnects
+	events, err := u.auctionUseCase.SubscribeClosingAuctions(c.Request.Context(), within)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	// Send the headers right away so the client knows it is subscribed
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if !ok {
+			return false
+		}
+
+		c.SSEvent(event.Event, event.Auction)
+		return true
+	})
+}
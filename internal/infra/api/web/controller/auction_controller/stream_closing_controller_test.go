@@ -0,0 +1,59 @@
+package auction_controller_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamClosing serves the stream through a real server, as the recorder does
+// not support the close notifications gin streaming relies on.
+func streamClosing(t *testing.T, useCase auction_usecase.AuctionUseCaseInterface, query string) (*http.Response, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/auctions/closing/stream",
+		auction_controller.NewAuctionController(useCase).StreamClosingAuctions)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/auctions/closing/stream" + query)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	return response, string(body)
+}
+
+func TestStreamClosingAuctionsWritesEvents(t *testing.T) {
+	useCase := &fakeAuctionUseCase{closingEvents: make(chan auction_usecase.ClosingAuctionEventDTO, 2)}
+	useCase.closingEvents <- auction_usecase.ClosingAuctionEventDTO{
+		Event:   auction_usecase.ClosingEvent,
+		Auction: auction_usecase.AuctionOutputDTO{Id: "auction-1"},
+	}
+	useCase.closingEvents <- auction_usecase.ClosingAuctionEventDTO{
+		Event:   auction_usecase.ClosedEvent,
+		Auction: auction_usecase.AuctionOutputDTO{Id: "auction-1", Status: 1},
+	}
+	close(useCase.closingEvents)
+
+	response, body := streamClosing(t, useCase, "?within=5m")
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	assert.Contains(t, body, "event:closing\ndata:{\"id\":\"auction-1\"")
+	assert.Contains(t, body, "event:closed\ndata:{\"id\":\"auction-1\"")
+}
+
+func TestStreamClosingAuctionsRejectsInvalidWindow(t *testing.T) {
+	response, _ := streamClosing(t, &fakeAuctionUseCase{}, "?within=soon")
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
@@ -0,0 +1,55 @@
+package auction_controller
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// mergePatchContentType is the media type of a JSON Merge Patch (RFC 7386)
+const mergePatchContentType = "application/merge-patch+json"
+
+// maxMergePatchSize bounds the body of an auction edit
+const maxMergePatchSize = 64 << 10
+
+// UpdateAuction edits an auction with a JSON Merge Patch: only the fields
+// sent are changed, and null removes an optional one.
+func (u *AuctionController) UpdateAuction(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	if c.ContentType() != mergePatchContentType {
+		errRest := rest_err.NewUnsupportedMediaTypeError("Content-Type must be " + mergePatchContentType)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxMergePatchSize))
+	if err != nil {
+		errRest := rest_err.NewBadRequestError("Error trying to read the merge patch")
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	auctionData, errUpdate := u.auctionUseCase.UpdateAuction(c.Request.Context(), auctionId, patch)
+	if errUpdate != nil {
+		errRest := rest_err.ConvertError(errUpdate)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.Header("ETag", auctionETag(auctionData))
+	c.JSON(http.StatusOK, auctionData)
+}
@@ -0,0 +1,66 @@
+package auction_controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func patchAuction(
+	useCase auction_usecase.AuctionUseCaseInterface, auctionId, contentType, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PATCH("/auction/:auctionId", auction_controller.NewAuctionController(useCase).UpdateAuction)
+
+	request := httptest.NewRequest(http.MethodPatch, "/auction/"+auctionId, strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestUpdateAuctionWithMergePatch(t *testing.T) {
+	useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{
+		Id: uuid.New().String(), Category: "electronics",
+	}}
+
+	recorder := patchAuction(useCase, useCase.auction.Id,
+		"application/merge-patch+json; charset=utf-8", `{"category":"phones"}`)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"category":"phones"}`, string(useCase.patch))
+	assert.NotEmpty(t, recorder.Header().Get("ETag"))
+	assert.Contains(t, recorder.Body.String(), useCase.auction.Id)
+}
+
+func TestUpdateAuctionRejections(t *testing.T) {
+	auctionId := uuid.New().String()
+
+	t.Run("other content types", func(t *testing.T) {
+		useCase := &fakeAuctionUseCase{auction: &auction_usecase.AuctionOutputDTO{Id: auctionId}}
+
+		recorder := patchAuction(useCase, auctionId, "application/json", `{"category":"phones"}`)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+		assert.Nil(t, useCase.patch)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		recorder := patchAuction(&fakeAuctionUseCase{}, "not-a-uuid", "application/merge-patch+json", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("unknown auction", func(t *testing.T) {
+		recorder := patchAuction(&fakeAuctionUseCase{}, auctionId, "application/merge-patch+json", `{}`)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
@@ -0,0 +1,68 @@
+package auction_controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/auction_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func getUserAuctions(useCase auction_usecase.AuctionUseCaseInterface, userId string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/user/:userId/auctions", auction_controller.NewAuctionController(useCase).FindUserAuctions)
+
+	request := httptest.NewRequest(http.MethodGet, "/user/"+userId+"/auctions", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestFindUserAuctions(t *testing.T) {
+	userId := uuid.New().String()
+	useCase := &fakeAuctionUseCase{userAuctions: map[string][]auction_usecase.UserAuctionOutputDTO{
+		userId: {{
+			Auction:        auction_usecase.AuctionOutputDTO{Id: "auction-1", ProductName: "iPhone 15"},
+			UserHighestBid: bid_usecase.BidOutputDTO{Id: "bid-1", UserId: userId, AuctionId: "auction-1", Amount: 700},
+		}},
+	}}
+
+	t.Run("auctions with the user's highest bid", func(t *testing.T) {
+		recorder := getUserAuctions(useCase, userId)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var body []struct {
+			Auction struct {
+				Id string `json:"id"`
+			} `json:"auction"`
+			UserHighestBid struct {
+				Id     string  `json:"id"`
+				Amount float64 `json:"amount"`
+			} `json:"user_highest_bid"`
+		}
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		if assert.Len(t, body, 1) {
+			assert.Equal(t, "auction-1", body[0].Auction.Id)
+			assert.Equal(t, "bid-1", body[0].UserHighestBid.Id)
+			assert.Equal(t, 700.0, body[0].UserHighestBid.Amount)
+		}
+	})
+
+	t.Run("user without bids gets an empty list", func(t *testing.T) {
+		recorder := getUserAuctions(useCase, uuid.New().String())
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, "[]", recorder.Body.String())
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, getUserAuctions(useCase, "not-a-uuid").Code)
+	})
+}
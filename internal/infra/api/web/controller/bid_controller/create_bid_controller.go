@@ -1,22 +1,26 @@
 package bid_controller
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/validation"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
 )
 
 type BidController struct {
-	bidUseCase bid_usecase.BidUseCaseInterface
+	bidUseCase    bid_usecase.BidUseCaseInterface
+	createStatus  int      // 201, or 202 to reflect that bids are persisted asynchronously
+	streamOrigins []string // origins allowed on the bid stream besides the server's own
 }
 
-func NewBidController(bidUseCase bid_usecase.BidUseCaseInterface) *BidController {
+func NewBidController(bidUseCase bid_usecase.BidUseCaseInterface, serverConfig config.ServerConfig) *BidController {
 	return &BidController{
-		bidUseCase: bidUseCase,
+		bidUseCase:    bidUseCase,
+		createStatus:  serverConfig.BidCreateStatus,
+		streamOrigins: serverConfig.StreamOrigins,
 	}
 }
 
@@ -30,7 +34,8 @@ func (u *BidController) CreateBid(c *gin.Context) {
 		return
 	}
 
-	err := u.bidUseCase.CreateBid(context.Background(), bidInputDTO)
+	// The request context lets CreateBid stop when the client disconnects
+	bidOutput, err := u.bidUseCase.CreateBid(c.Request.Context(), bidInputDTO)
 	if err != nil {
 		restErr := rest_err.ConvertError(err)
 
@@ -38,5 +43,24 @@ func (u *BidController) CreateBid(c *gin.Context) {
 		return
 	}
 
-	c.Status(http.StatusCreated)
+	if u.createStatus != http.StatusAccepted {
+		c.JSON(http.StatusCreated, bidOutput)
+		return
+	}
+
+	// The bid is only queued: point to where its persistence can be followed
+	statusURL := "/auction/" + bidInputDTO.AuctionId + "/current-bid"
+	c.Header("Location", statusURL)
+	c.JSON(http.StatusAccepted, acceptedBidOutputDTO{
+		CreateBidOutputDTO: bidOutput,
+		StatusURL:          statusURL,
+	})
+}
+
+// acceptedBidOutputDTO is the 202 response to a bid still waiting in the
+// batch. StatusURL reports the current bid of the auction, with persisted
+// turning true once the batch is flushed.
+type acceptedBidOutputDTO struct {
+	*bid_usecase.CreateBidOutputDTO
+	StatusURL string `json:"status_url"`
 }
@@ -0,0 +1,63 @@
+package bid_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/bid_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// acceptingBidUseCase accepts every bid as the leading one
+type acceptingBidUseCase struct {
+	fakeBidUseCase
+}
+
+func (f *acceptingBidUseCase) CreateBid(
+	ctx context.Context, bidInputDTO bid_usecase.BidInputDTO) (*bid_usecase.CreateBidOutputDTO, *internal_error.InternalError) {
+	return &bid_usecase.CreateBidOutputDTO{Id: "bid-1", IsHighest: true, Rank: 1}, nil
+}
+
+func postBid(t *testing.T, createStatus int) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/bid", bid_controller.NewBidController(&acceptingBidUseCase{},
+		config.ServerConfig{BidCreateStatus: createStatus}).CreateBid)
+
+	request := httptest.NewRequest(http.MethodPost, "/bid",
+		strings.NewReader(`{"user_id":"user-1","auction_id":"auction-1","amount":100}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCreateBidStatusCode(t *testing.T) {
+	t.Run("201 by default", func(t *testing.T) {
+		recorder := postBid(t, config.Default().Server.BidCreateStatus)
+
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.JSONEq(t, `{"id":"bid-1","is_highest":true,"rank":1}`, recorder.Body.String())
+		assert.Empty(t, recorder.Header().Get("Location"))
+	})
+
+	t.Run("202 with the bid id and a status URL", func(t *testing.T) {
+		recorder := postBid(t, http.StatusAccepted)
+
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		var body map[string]any
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		assert.Equal(t, "bid-1", body["id"])
+		assert.Equal(t, true, body["is_highest"])
+		assert.Equal(t, "/auction/auction-1/current-bid", body["status_url"])
+		assert.Equal(t, "/auction/auction-1/current-bid", recorder.Header().Get("Location"))
+	})
+}
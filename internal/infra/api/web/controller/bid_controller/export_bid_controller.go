@@ -0,0 +1,66 @@
+package bid_controller
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// csvFlushEvery is how many rows are buffered before being sent to the client
+const csvFlushEvery = 100
+
+var bidsCSVHeader = []string{"bid_id", "user_id", "amount", "timestamp"}
+
+// ExportBidsCSV streams the bid history of an auction as CSV, oldest first.
+// Rows are written as they are read from the database, so memory stays
+// bounded regardless of the number of bids.
+func (u *BidController) ExportBidsCSV(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	bids, err := u.bidUseCase.StreamBidsByAuctionId(c.Request.Context(), auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="auction-%s-bids.csv"`, auctionId))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(bidsCSVHeader)
+
+	rows := 0
+	for bid := range bids {
+		writer.Write([]string{
+			bid.Id,
+			bid.UserId,
+			strconv.FormatFloat(bid.Amount, 'f', 2, 64),
+			bid.Timestamp.UTC().Format(time.RFC3339),
+		})
+
+		if rows++; rows%csvFlushEvery == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+	}
+
+	writer.Flush()
+}
@@ -0,0 +1,169 @@
+package bid_controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/bid_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBidUseCase struct {
+	bids []bid_usecase.BidOutputDTO
+
+	// winningUserId is reported as the holder of the highest bid
+	winningUserId string
+
+	// findInput is the page last requested from FindBidByAuctionId
+	findInput *bid_usecase.FindBidsInputDTO
+}
+
+func (f *fakeBidUseCase) CreateBid(
+	ctx context.Context, bidInputDTO bid_usecase.BidInputDTO) (*bid_usecase.CreateBidOutputDTO, *internal_error.InternalError) {
+	return &bid_usecase.CreateBidOutputDTO{}, nil
+}
+
+func (f *fakeBidUseCase) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_usecase.BidOutputDTO, *internal_error.InternalError) {
+	return nil, internal_error.NewNotFoundError("No bids found")
+}
+
+func (f *fakeBidUseCase) FindBidByAuctionId(
+	ctx context.Context, auctionId string, input bid_usecase.FindBidsInputDTO) (*bid_usecase.FindBidsOutputDTO, *internal_error.InternalError) {
+	f.findInput = &input
+	if input.Limit > 100 {
+		return nil, internal_error.NewBadRequestError("limit must be between 1 and 100 and offset must not be negative")
+	}
+	return &bid_usecase.FindBidsOutputDTO{
+		Bids: f.bids, Total: int64(len(f.bids)), Limit: input.Limit, Offset: input.Offset,
+	}, nil
+}
+
+func (f *fakeBidUseCase) StreamBidsByAuctionId(
+	ctx context.Context, auctionId string) (<-chan bid_usecase.BidOutputDTO, *internal_error.InternalError) {
+	stream := make(chan bid_usecase.BidOutputDTO, len(f.bids))
+	for _, bid := range f.bids {
+		stream <- bid
+	}
+	close(stream)
+	return stream, nil
+}
+
+func (f *fakeBidUseCase) FindUserWinningStatus(
+	ctx context.Context, auctionId, userId string) (*bid_usecase.UserWinningStatusOutputDTO, *internal_error.InternalError) {
+	return &bid_usecase.UserWinningStatusOutputDTO{
+		AuctionId:     auctionId,
+		UserId:        userId,
+		Winning:       userId == f.winningUserId,
+		HighestAmount: 100,
+	}, nil
+}
+
+func (f *fakeBidUseCase) FindCurrentBid(
+	ctx context.Context, auctionId string) (*bid_usecase.CurrentBidOutputDTO, *internal_error.InternalError) {
+	if len(f.bids) == 0 {
+		return nil, internal_error.NewNotFoundError("No bids found for the auction")
+	}
+	return &bid_usecase.CurrentBidOutputDTO{BidOutputDTO: f.bids[len(f.bids)-1]}, nil
+}
+
+func (f *fakeBidUseCase) SubscribeBids(
+	ctx context.Context, auctionId string) (<-chan bid_usecase.BidOutputDTO, *internal_error.InternalError) {
+	return nil, internal_error.NewAuctionNotFoundError()
+}
+
+func (f *fakeBidUseCase) AuctionsCompleted(auctionIds []string) {}
+
+func (f *fakeBidUseCase) BatchRoutineStatus() bid_usecase.BatchRoutineStatusDTO {
+	return bid_usecase.BatchRoutineStatusDTO{Running: true}
+}
+
+func (f *fakeBidUseCase) FindPendingBids(ctx context.Context) map[string]bid_usecase.BidOutputDTO {
+	return nil
+}
+
+func (f *fakeBidUseCase) FindRejectionCounts(ctx context.Context) map[string]int64 {
+	return map[string]int64{}
+}
+
+func (f *fakeBidUseCase) RecordRateLimited() {}
+
+func (f *fakeBidUseCase) FindBatchSize(ctx context.Context) bid_usecase.BatchSizeOutputDTO {
+	return bid_usecase.BatchSizeOutputDTO{}
+}
+
+func (f *fakeBidUseCase) FindPipelineStats(ctx context.Context) bid_usecase.PipelineStatsOutputDTO {
+	return bid_usecase.PipelineStatsOutputDTO{}
+}
+
+func (f *fakeBidUseCase) Flush(ctx context.Context) (int, *internal_error.InternalError) {
+	return 0, nil
+}
+
+func (f *fakeBidUseCase) Shutdown(ctx context.Context) bid_usecase.PipelineDrainStats {
+	return bid_usecase.PipelineDrainStats{}
+}
+
+func newRouter(useCase bid_usecase.BidUseCaseInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := bid_controller.NewBidController(useCase, config.ServerConfig{})
+	router.GET("/auction/:auctionId/bids.csv", controller.ExportBidsCSV)
+	router.GET("/auction/:auctionId/winning", controller.FindUserWinningStatus)
+	router.GET("/bid/:auctionId", controller.FindBidByAuctionId)
+	router.GET("/auction/:auctionId/current-bid", controller.FindCurrentBid)
+	return router
+}
+
+func TestExportBidsCSV(t *testing.T) {
+	auctionId := uuid.New().String()
+	useCase := &fakeBidUseCase{bids: []bid_usecase.BidOutputDTO{
+		{
+			Id:        "bid-1",
+			UserId:    "user-1",
+			AuctionId: auctionId,
+			Amount:    1500.5,
+			Timestamp: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			Id:        "bid-2",
+			UserId:    "user-2",
+			AuctionId: auctionId,
+			Amount:    2000,
+			Timestamp: time.Date(2024, 5, 1, 12, 31, 0, 0, time.UTC),
+		},
+	}}
+
+	request := httptest.NewRequest(http.MethodGet, "/auction/"+auctionId+"/bids.csv", nil)
+	recorder := httptest.NewRecorder()
+	newRouter(useCase).ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t,
+		`attachment; filename="auction-`+auctionId+`-bids.csv"`,
+		recorder.Header().Get("Content-Disposition"))
+
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "bid_id,user_id,amount,timestamp", lines[0])
+	assert.Equal(t, "bid-1,user-1,1500.50,2024-05-01T12:30:00Z", lines[1])
+	assert.Equal(t, "bid-2,user-2,2000.00,2024-05-01T12:31:00Z", lines[2])
+}
+
+func TestExportBidsCSVRejectsInvalidAuctionId(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/auction/not-a-uuid/bids.csv", nil)
+	recorder := httptest.NewRecorder()
+	newRouter(&fakeBidUseCase{}).ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
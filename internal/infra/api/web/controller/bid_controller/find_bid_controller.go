@@ -1,12 +1,13 @@
 package bid_controller
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/validation"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
 )
 
 func (u *BidController) FindBidByAuctionId(c *gin.Context) {
@@ -22,12 +23,101 @@ func (u *BidController) FindBidByAuctionId(c *gin.Context) {
 		return
 	}
 
-	bidOutputList, err := u.bidUseCase.FindBidByAuctionId(context.Background(), auctionId)
+	limit, offset, errRest := validation.ParsePagination(c)
+	if errRest != nil {
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	bidPage, err := u.bidUseCase.FindBidByAuctionId(c.Request.Context(), auctionId,
+		bid_usecase.FindBidsInputDTO{Limit: limit, Offset: offset})
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, bidPage)
+}
+
+// FindUserWinningStatus answers whether the user in the user_id query string
+// currently holds the highest bid, so bidders can poll without listing bids.
+func (u *BidController) FindUserWinningStatus(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	userId := c.Query("user_id")
+	if err := uuid.Validate(userId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "user_id",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	status, err := u.bidUseCase.FindUserWinningStatus(c.Request.Context(), auctionId, userId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// FindCurrentBid returns the bid setting the current price of an active
+// auction, whether or not it has been persisted yet.
+func (u *BidController) FindCurrentBid(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	currentBid, err := u.bidUseCase.FindCurrentBid(c.Request.Context(), auctionId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
 		c.JSON(errRest.Code, errRest)
 		return
 	}
 
-	c.JSON(http.StatusOK, bidOutputList)
+	c.JSON(http.StatusOK, currentBid)
+}
+
+// FindPendingBids exposes the pending-bid cache to admins for debugging why a
+// bid was accepted or rejected.
+func (u *BidController) FindPendingBids(c *gin.Context) {
+	c.JSON(http.StatusOK, u.bidUseCase.FindPendingBids(c.Request.Context()))
+}
+
+// FindRejectionCounts exposes to admins why bids are being rejected.
+func (u *BidController) FindRejectionCounts(c *gin.Context) {
+	c.JSON(http.StatusOK, u.bidUseCase.FindRejectionCounts(c.Request.Context()))
+}
+
+// FindBatchSize exposes to admins the batch size the bid writer is using.
+func (u *BidController) FindBatchSize(c *gin.Context) {
+	c.JSON(http.StatusOK, u.bidUseCase.FindBatchSize(c.Request.Context()))
+}
+
+// FindPipelineStats exposes to admins the state of the bid batch routine.
+func (u *BidController) FindPipelineStats(c *gin.Context) {
+	c.JSON(http.StatusOK, u.bidUseCase.FindPipelineStats(c.Request.Context()))
 }
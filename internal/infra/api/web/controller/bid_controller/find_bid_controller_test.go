@@ -0,0 +1,121 @@
+package bid_controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUserWinningStatus(t *testing.T) {
+	auctionId := uuid.New().String()
+	userId := uuid.New().String()
+	router := newRouter(&fakeBidUseCase{winningUserId: userId})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+		"/auction/"+auctionId+"/winning?user_id="+userId, nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var status bid_usecase.UserWinningStatusOutputDTO
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	assert.Equal(t, auctionId, status.AuctionId)
+	assert.Equal(t, userId, status.UserId)
+	assert.True(t, status.Winning)
+	assert.Equal(t, 100.0, status.HighestAmount)
+}
+
+func TestFindUserWinningStatusRejectsInvalidIds(t *testing.T) {
+	router := newRouter(&fakeBidUseCase{})
+
+	for name, path := range map[string]string{
+		"invalid auction id": "/auction/not-a-uuid/winning?user_id=" + uuid.New().String(),
+		"missing user id":    "/auction/" + uuid.New().String() + "/winning",
+		"invalid user id":    "/auction/" + uuid.New().String() + "/winning?user_id=abc",
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
+
+func TestFindBidByAuctionIdReturnsPageEnvelope(t *testing.T) {
+	auctionId := uuid.New().String()
+	useCase := &fakeBidUseCase{bids: []bid_usecase.BidOutputDTO{{Id: "bid-1", AuctionId: auctionId, Amount: 10}}}
+	router := newRouter(useCase)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+		"/bid/"+auctionId+"?limit=5&offset=10", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	if assert.NotNil(t, useCase.findInput) {
+		assert.Equal(t, int64(5), useCase.findInput.Limit)
+		assert.Equal(t, int64(10), useCase.findInput.Offset)
+	}
+
+	var page map[string]json.RawMessage
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &page))
+	assert.Contains(t, page, "data")
+	assert.JSONEq(t, "1", string(page["total"]))
+	assert.JSONEq(t, "5", string(page["limit"]))
+	assert.JSONEq(t, "10", string(page["offset"]))
+}
+
+func TestFindBidByAuctionIdRejectsInvalidPagination(t *testing.T) {
+	auctionId := uuid.New().String()
+
+	for _, query := range []string{"limit=abc", "offset=-", "limit=101"} {
+		recorder := httptest.NewRecorder()
+		newRouter(&fakeBidUseCase{}).ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodGet, "/bid/"+auctionId+"?"+query, nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+	}
+}
+
+func TestFindCurrentBid(t *testing.T) {
+	auctionId := uuid.New().String()
+
+	t.Run("returns the price-setting bid", func(t *testing.T) {
+		router := newRouter(&fakeBidUseCase{bids: []bid_usecase.BidOutputDTO{
+			{Id: "bid-1", UserId: "user-1", AuctionId: auctionId, Amount: 150},
+		}})
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+			"/auction/"+auctionId+"/current-bid", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var currentBid map[string]interface{}
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &currentBid))
+		assert.Equal(t, "bid-1", currentBid["id"])
+		assert.Equal(t, "user-1", currentBid["user_id"])
+		assert.Equal(t, 150.0, currentBid["amount"])
+		assert.Contains(t, currentBid, "persisted")
+	})
+
+	t.Run("no bids yet", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		newRouter(&fakeBidUseCase{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+			"/auction/"+auctionId+"/current-bid", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("invalid auction id", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		newRouter(&fakeBidUseCase{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+			"/auction/not-a-uuid/current-bid", nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
@@ -0,0 +1,94 @@
+package bid_controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"golang.org/x/net/websocket"
+)
+
+// StreamBids upgrades the request to a WebSocket and sends each bid accepted
+// on the auction as a JSON message. The socket is closed when the auction is
+// completed, and the subscription ends when the client disconnects.
+func (u *BidController) StreamBids(c *gin.Context) {
+	auctionId := c.Param("auctionId")
+
+	if err := uuid.Validate(auctionId); err != nil {
+		errRest := rest_err.NewBadRequestError("Invalid fields", rest_err.Causes{
+			Field:   "auctionId",
+			Message: "Invalid UUID value",
+		})
+
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	// Subscribed before the upgrade, so a missing or completed auction is
+	// still answered as JSON
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	bids, err := u.bidUseCase.SubscribeBids(ctx, auctionId)
+	if err != nil {
+		errRest := rest_err.ConvertError(err)
+		c.JSON(errRest.Code, errRest)
+		return
+	}
+
+	server := websocket.Server{Handshake: u.checkStreamOrigin, Handler: func(conn *websocket.Conn) {
+		defer conn.Close()
+
+		// The server read timeout would otherwise cut the connection
+		conn.SetDeadline(time.Time{})
+
+		// The client sends nothing; the read only fails once it is gone
+		go func() {
+			io.Copy(io.Discard, conn)
+			cancel()
+		}()
+
+		for bid := range bids {
+			if err := websocket.JSON.Send(conn, bid); err != nil {
+				logger.Error("Error sending bid to stream of auction "+auctionId, err)
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkStreamOrigin refuses the upgrade, with 403, when a browser opens the
+// stream from a page outside the server's own host and the configured
+// STREAM_ALLOWED_ORIGINS. Clients without an Origin header (non-browser) are
+// accepted.
+func (u *BidController) checkStreamOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return nil
+	}
+	config.Origin = origin
+
+	if strings.EqualFold(origin.Host, req.Host) {
+		return nil
+	}
+
+	normalized := origin.Scheme + "://" + strings.ToLower(origin.Host)
+	for _, allowed := range u.streamOrigins {
+		if normalized == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
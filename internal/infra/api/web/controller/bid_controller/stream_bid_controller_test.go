@@ -0,0 +1,215 @@
+package bid_controller_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/bid_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// The stream is exercised against the real use case; the repositories only
+// implement what accepting a bid needs.
+
+type streamAuctionRepository struct {
+	auction_entity.AuctionRepositoryInterface
+	auctions map[string]*auction_entity.Auction
+}
+
+func (f *streamAuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	auction, ok := f.auctions[id]
+	if !ok {
+		return nil, internal_error.NewNotFoundError("Auction not found")
+	}
+	return auction, nil
+}
+
+func (f *streamAuctionRepository) MarkAuctionHasBids(
+	ctx context.Context, auctionId string, version int64) *internal_error.InternalError {
+	return nil
+}
+
+type streamBidRepository struct {
+	bid_entity.BidEntityRepository
+}
+
+func (f *streamBidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	return nil, internal_error.NewNotFoundError("No bids found for the auction")
+}
+
+func (f *streamBidRepository) CountBidsAboveAmount(
+	ctx context.Context, auctionId string, amountCents int64) (int64, *internal_error.InternalError) {
+	return 0, nil
+}
+
+func (f *streamBidRepository) FindHighestBidsByUserId(
+	ctx context.Context, userId string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+type streamUserRepository struct {
+	user_entity.UserRepositoryInterface
+}
+
+func (f *streamUserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	return &user_entity.User{Id: userId, Name: "Test User"}, nil
+}
+
+func newStreamServer(t *testing.T, auctions ...*auction_entity.Auction) (*httptest.Server, bid_usecase.BidUseCaseInterface) {
+	// Accepted bids stay in the batch for the duration of the test
+	bidConfig := config.Default().Bid
+	bidConfig.MaxBatchSize = 10
+	bidConfig.BatchInsertInterval = time.Hour
+
+	auctionRepository := &streamAuctionRepository{auctions: map[string]*auction_entity.Auction{}}
+	for _, auction := range auctions {
+		auctionRepository.auctions[auction.Id] = auction
+	}
+	useCase := bid_usecase.NewBidUseCase(&streamBidRepository{}, auctionRepository,
+		&streamUserRepository{}, nil, nil, nil, bidConfig)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := bid_controller.NewBidController(useCase,
+		config.ServerConfig{StreamOrigins: []string{"https://app.example.com"}})
+	router.POST("/bid", controller.CreateBid)
+	router.GET("/auction/:auctionId/stream", controller.StreamBids)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, useCase
+}
+
+func newActiveAuction() *auction_entity.Auction {
+	now := time.Now()
+	return &auction_entity.Auction{
+		Id:        uuid.New().String(),
+		Status:    auction_entity.Active,
+		StartsAt:  now,
+		ExpiresAt: now.Add(time.Minute),
+	}
+}
+
+func dialStream(t *testing.T, server *httptest.Server, auctionId string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/auction/" + auctionId + "/stream"
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatalf("dial stream: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return conn
+}
+
+func TestStreamBidsBroadcastsAcceptedBids(t *testing.T) {
+	auction := newActiveAuction()
+	server, _ := newStreamServer(t, auction)
+
+	first := dialStream(t, server, auction.Id)
+	second := dialStream(t, server, auction.Id)
+
+	userId := uuid.New().String()
+	response, err := http.Post(server.URL+"/bid", "application/json", bytes.NewBufferString(
+		`{"user_id":"`+userId+`","auction_id":"`+auction.Id+`","amount":150}`))
+	if assert.Nil(t, err) {
+		response.Body.Close()
+		assert.Equal(t, http.StatusCreated, response.StatusCode)
+	}
+
+	for _, conn := range []*websocket.Conn{first, second} {
+		var bid bid_usecase.BidOutputDTO
+		if assert.Nil(t, websocket.JSON.Receive(conn, &bid)) {
+			assert.Equal(t, userId, bid.UserId)
+			assert.Equal(t, auction.Id, bid.AuctionId)
+			assert.Equal(t, 150.0, bid.Amount)
+		}
+	}
+}
+
+func TestStreamBidsClosesWhenAuctionCompletes(t *testing.T) {
+	auction := newActiveAuction()
+	server, useCase := newStreamServer(t, auction)
+
+	conn := dialStream(t, server, auction.Id)
+
+	// The subscription is registered once the upgrade is answered
+	useCase.AuctionsCompleted([]string{auction.Id})
+
+	var bid bid_usecase.BidOutputDTO
+	assert.ErrorIs(t, websocket.JSON.Receive(conn, &bid), io.EOF)
+}
+
+func TestStreamBidsRejectsUnavailableAuctions(t *testing.T) {
+	completed := newActiveAuction()
+	completed.Status = auction_entity.Completed
+	server, _ := newStreamServer(t, completed)
+
+	for name, tc := range map[string]struct {
+		auctionId string
+		status    int
+	}{
+		"invalid auction id": {"not-a-uuid", http.StatusBadRequest},
+		"unknown auction":    {uuid.New().String(), http.StatusNotFound},
+		"completed auction":  {completed.Id, http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, err := http.Get(server.URL + "/auction/" + tc.auctionId + "/stream")
+			if assert.Nil(t, err) {
+				response.Body.Close()
+				assert.Equal(t, tc.status, response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestStreamBidsChecksOrigin(t *testing.T) {
+	auction := newActiveAuction()
+	server, _ := newStreamServer(t, auction)
+
+	for name, tc := range map[string]struct {
+		origin string
+		status int
+	}{
+		"no origin (non-browser client)": {"", http.StatusSwitchingProtocols},
+		"server's own host":              {server.URL, http.StatusSwitchingProtocols},
+		"configured origin":              {"https://APP.example.com", http.StatusSwitchingProtocols},
+		"other origin":                   {"https://evil.example.com", http.StatusForbidden},
+		"configured host, other scheme":  {"http://app.example.com", http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, server.URL+"/auction/"+auction.Id+"/stream", nil)
+			request.Header.Set("Connection", "Upgrade")
+			request.Header.Set("Upgrade", "websocket")
+			request.Header.Set("Sec-WebSocket-Version", "13")
+			request.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			if tc.origin != "" {
+				request.Header.Set("Origin", tc.origin)
+			}
+
+			response, err := http.DefaultClient.Do(request)
+			if assert.Nil(t, err) {
+				response.Body.Close()
+				assert.Equal(t, tc.status, response.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package health_controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// DatabasePinger checks that MongoDB answers; *mongo.Client implements it.
+type DatabasePinger interface {
+	Ping(ctx context.Context, rp *readpref.ReadPref) error
+}
+
+// BidBatchRoutine reports the state of the routine persisting bid batches;
+// the bid use case implements it.
+type BidBatchRoutine interface {
+	BatchRoutineStatus() bid_usecase.BatchRoutineStatusDTO
+}
+
+// HealthOutputDTO is the body of the probes. Reason tells why the instance
+// is unhealthy.
+type HealthOutputDTO struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// HealthController answers the Kubernetes probes. It only depends on the
+// database client and the bid batch routine.
+type HealthController struct {
+	database    DatabasePinger
+	bidRoutine  BidBatchRoutine
+	pingTimeout time.Duration
+}
+
+// NewHealthController returns probes that give the database ping
+// pingTimeout to answer.
+func NewHealthController(database DatabasePinger, bidRoutine BidBatchRoutine, pingTimeout time.Duration) *HealthController {
+	return &HealthController{
+		database:    database,
+		bidRoutine:  bidRoutine,
+		pingTimeout: pingTimeout,
+	}
+}
+
+// Healthz answers 200 while MongoDB answers a ping within the ping timeout
+// and the bid batch routine is not stalled, and 503 with
+// the reason otherwise. A stalled routine is stuck for good, so a restart is
+// the way out.
+func (hc *HealthController) Healthz(c *gin.Context) {
+	if reason := hc.pingDatabase(c.Request.Context()); reason != "" {
+		c.JSON(http.StatusServiceUnavailable, HealthOutputDTO{Status: "unavailable", Reason: reason})
+		return
+	}
+
+	if status := hc.bidRoutine.BatchRoutineStatus(); status.Stalled {
+		c.JSON(http.StatusServiceUnavailable, HealthOutputDTO{Status: "unavailable", Reason: stalledReason(status)})
+		return
+	}
+
+	c.JSON(http.StatusOK, HealthOutputDTO{Status: "ok"})
+}
+
+// Readyz is Healthz plus the bid batch routine running, which stops on
+// shutdown: an instance whose accepted bids would never be persisted takes no
+// traffic.
+func (hc *HealthController) Readyz(c *gin.Context) {
+	if reason := hc.pingDatabase(c.Request.Context()); reason != "" {
+		c.JSON(http.StatusServiceUnavailable, HealthOutputDTO{Status: "unavailable", Reason: reason})
+		return
+	}
+
+	status := hc.bidRoutine.BatchRoutineStatus()
+	if !status.Running {
+		c.JSON(http.StatusServiceUnavailable, HealthOutputDTO{
+			Status: "unavailable",
+			Reason: "bid batch routine is not running",
+		})
+		return
+	}
+	if status.Stalled {
+		c.JSON(http.StatusServiceUnavailable, HealthOutputDTO{Status: "unavailable", Reason: stalledReason(status)})
+		return
+	}
+
+	c.JSON(http.StatusOK, HealthOutputDTO{Status: "ok"})
+}
+
+func stalledReason(status bid_usecase.BatchRoutineStatusDTO) string {
+	return fmt.Sprintf("bid batch routine stalled: no activity for %s with %d bid(s) waiting",
+		time.Since(status.LastActivityAt).Truncate(time.Millisecond), status.WaitingBids)
+}
+
+// pingDatabase returns why the ping failed, or "" when it succeeded.
+func (hc *HealthController) pingDatabase(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, hc.pingTimeout)
+	defer cancel()
+
+	if err := hc.database.Ping(ctx, readpref.Primary()); err != nil {
+		return "database ping failed: " + err.Error()
+	}
+
+	return ""
+}
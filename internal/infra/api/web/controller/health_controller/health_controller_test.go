@@ -0,0 +1,132 @@
+package health_controller_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/health_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+type fakePinger struct {
+	err   error
+	delay time.Duration // the ping blocks this long unless ctx is done first
+}
+
+func (f *fakePinger) Ping(ctx context.Context, rp *readpref.ReadPref) error {
+	select {
+	case <-time.After(f.delay):
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type fakeBidRoutine struct {
+	bid_usecase.BatchRoutineStatusDTO
+}
+
+func (f *fakeBidRoutine) BatchRoutineStatus() bid_usecase.BatchRoutineStatusDTO {
+	return f.BatchRoutineStatusDTO
+}
+
+func runningRoutine() *fakeBidRoutine {
+	return &fakeBidRoutine{bid_usecase.BatchRoutineStatusDTO{Running: true, LastActivityAt: time.Now()}}
+}
+
+func stalledRoutine() *fakeBidRoutine {
+	return &fakeBidRoutine{bid_usecase.BatchRoutineStatusDTO{
+		Running: true, Stalled: true, LastActivityAt: time.Now().Add(-time.Minute), WaitingBids: 3,
+	}}
+}
+
+// pingTimeout keeps the test of a slow ping short.
+const pingTimeout = 20 * time.Millisecond
+
+func probe(pinger *fakePinger, routine *fakeBidRoutine, path string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := health_controller.NewHealthController(pinger, routine, pingTimeout)
+	router.GET("/healthz", controller.Healthz)
+	router.GET("/readyz", controller.Readyz)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func TestHealthz(t *testing.T) {
+	t.Run("database answers", func(t *testing.T) {
+		recorder := probe(&fakePinger{}, runningRoutine(), "/healthz")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+	})
+
+	t.Run("ping fails", func(t *testing.T) {
+		recorder := probe(&fakePinger{err: errors.New("server selection timeout")}, runningRoutine(), "/healthz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.JSONEq(t, `{"status":"unavailable","reason":"database ping failed: server selection timeout"}`,
+			recorder.Body.String())
+	})
+
+	t.Run("ping exceeds the timeout", func(t *testing.T) {
+		recorder := probe(&fakePinger{delay: time.Minute}, runningRoutine(), "/healthz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "deadline exceeded")
+	})
+
+	t.Run("bid routine stalled", func(t *testing.T) {
+		recorder := probe(&fakePinger{}, stalledRoutine(), "/healthz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "bid batch routine stalled")
+		assert.Contains(t, recorder.Body.String(), "3 bid(s) waiting")
+	})
+
+	// Stopped on shutdown is not a reason to restart
+	t.Run("bid routine stopped", func(t *testing.T) {
+		recorder := probe(&fakePinger{}, &fakeBidRoutine{}, "/healthz")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
+
+func TestReadyz(t *testing.T) {
+	t.Run("database and bid routine up", func(t *testing.T) {
+		recorder := probe(&fakePinger{}, runningRoutine(), "/readyz")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+	})
+
+	t.Run("bid routine stopped", func(t *testing.T) {
+		recorder := probe(&fakePinger{}, &fakeBidRoutine{}, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.JSONEq(t, `{"status":"unavailable","reason":"bid batch routine is not running"}`,
+			recorder.Body.String())
+	})
+
+	t.Run("bid routine stalled", func(t *testing.T) {
+		recorder := probe(&fakePinger{}, stalledRoutine(), "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "bid batch routine stalled")
+	})
+
+	t.Run("ping fails", func(t *testing.T) {
+		recorder := probe(&fakePinger{err: errors.New("connection refused")}, runningRoutine(), "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	})
+}
@@ -0,0 +1,75 @@
+package user_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/user_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/user_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUserUseCase struct {
+	user_usecase.UserUseCaseInterface
+
+	inputs []user_usecase.UserInputDTO
+}
+
+func (f *fakeUserUseCase) CreateUser(
+	ctx context.Context, input user_usecase.UserInputDTO) (*user_usecase.UserOutputDTO, *internal_error.InternalError) {
+	f.inputs = append(f.inputs, input)
+	if len(input.Name) <= 1 {
+		return nil, internal_error.NewBadRequestError("name must be longer than 1 character")
+	}
+	return &user_usecase.UserOutputDTO{Id: "2f1b3c4d-0000-4000-8000-000000000001", Name: input.Name}, nil
+}
+
+func postUser(useCase user_usecase.UserUseCaseInterface, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/user", user_controller.NewUserController(useCase).CreateUser)
+
+	request := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCreateUserReturnsTheNewId(t *testing.T) {
+	useCase := &fakeUserUseCase{}
+
+	recorder := postUser(useCase, `{"name":"Ana"}`)
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.JSONEq(t, `{"id":"2f1b3c4d-0000-4000-8000-000000000001","name":"Ana"}`, recorder.Body.String())
+	assert.Equal(t, []user_usecase.UserInputDTO{{Name: "Ana"}}, useCase.inputs)
+}
+
+func TestCreateUserRejectsInvalidBodies(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{"missing name", `{}`},
+		{"malformed JSON", `{"name":`},
+		{"name too short", `{"name":"A"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := postUser(&fakeUserUseCase{}, tc.body)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			assert.Equal(t, "bad_request", body["err"])
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package user_controller
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -33,7 +32,7 @@ func (u *UserController) FindUserById(c *gin.Context) {
 		return
 	}
 
-	userData, err := u.userUseCase.FindUserById(context.Background(), userId)
+	userData, err := u.userUseCase.FindUserById(c.Request.Context(), userId)
 	if err != nil {
 		errRest := rest_err.ConvertError(err)
 		c.JSON(errRest.Code, errRest)
@@ -0,0 +1,60 @@
+package user_controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/controller/user_controller"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/user_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingUserUseCase struct {
+	user_usecase.UserUseCaseInterface
+
+	err *internal_error.InternalError
+}
+
+func (f *failingUserUseCase) FindUserById(
+	ctx context.Context, userId string) (*user_usecase.UserOutputDTO, *internal_error.InternalError) {
+	return nil, f.err
+}
+
+func TestFindUserByIdErrorStatuses(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    *internal_error.InternalError
+		status int
+		body   string
+	}{
+		{"bad request", internal_error.NewBadRequestError("Invalid user"), http.StatusBadRequest, "bad_request"},
+		{"not found", internal_error.NewNotFoundError("User not found"), http.StatusNotFound, "not_found"},
+		{"internal server", internal_error.NewInternalServerError("Error trying to find user"), http.StatusInternalServerError, "internal_server"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/user/:userId", user_controller.NewUserController(&failingUserUseCase{err: tc.err}).FindUserById)
+
+			request := httptest.NewRequest(http.MethodGet, "/user/"+uuid.New().String(), nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.status, recorder.Code)
+			var errRest rest_err.RestErr
+			assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &errRest))
+			assert.Equal(t, tc.body, errRest.Err)
+			assert.Equal(t, tc.status, errRest.Code)
+			assert.Equal(t, tc.err.Message, errRest.Message)
+		})
+	}
+}
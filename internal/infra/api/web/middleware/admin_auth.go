@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// AdminAuth guards the admin endpoints with adminToken (ADMIN_TOKEN), sent
+// as "Authorization: Bearer <token>". Without a token the admin endpoints
+// are disabled.
+func AdminAuth(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminToken == "" {
+			errRest := rest_err.NewForbiddenError("Admin endpoints are disabled")
+			c.AbortWithStatusJSON(errRest.Code, errRest)
+			return
+		}
+
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			errRest := rest_err.NewUnauthorizedError("Invalid admin token")
+			c.AbortWithStatusJSON(errRest.Code, errRest)
+			return
+		}
+
+		c.Next()
+	}
+}
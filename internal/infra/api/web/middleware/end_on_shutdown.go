@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EndOnShutdown cancels the request context once shutdown is done, so a
+// stream that never goes idle (SSE, WebSocket) ends when the server starts
+// shutting down instead of holding http.Server.Shutdown until its deadline.
+// Only streaming routes should use it, after DisableWriteTimeout.
+func EndOnShutdown(shutdown context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+
+		stop := context.AfterFunc(shutdown, cancel)
+		defer stop()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+)
+
+func TestEndOnShutdownCancelsTheStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	shutdown, startShutdown := context.WithCancel(context.Background())
+	ended := make(chan struct{})
+
+	router := gin.New()
+	router.GET("/stream", middleware.EndOnShutdown(shutdown), func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		close(ended)
+	})
+
+	go router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	select {
+	case <-ended:
+		t.Fatal("stream ended before the shutdown")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	startShutdown()
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatal("stream still open after the shutdown started")
+	}
+}
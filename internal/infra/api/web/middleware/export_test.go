@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const RateLimitIdleTimeout = rateLimitIdleTimeout
+
+type IPRateLimiter = ipRateLimiter
+
+func NewIPRateLimiter(limit float64, burst int) *IPRateLimiter {
+	return newIPRateLimiter(rate.Limit(limit), burst)
+}
+
+func (rl *ipRateLimiter) Allow(ip string, now time.Time) bool {
+	return rl.allow(ip, now)
+}
+
+func (rl *ipRateLimiter) EvictIdle(now time.Time) {
+	rl.evictIdle(now)
+}
+
+func (rl *ipRateLimiter) Len() int {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	return len(rl.visitors)
+}
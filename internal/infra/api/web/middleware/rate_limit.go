@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rateLimitIdleTimeout is how long a client IP goes without requests
+	// before its bucket is dropped; a full bucket is no different from a new one
+	rateLimitIdleTimeout = 3 * time.Minute
+
+	// rateLimitSweepInterval is how often idle buckets are looked for
+	rateLimitSweepInterval = time.Minute
+)
+
+// ipRateLimiter keeps a token bucket per client IP.
+type ipRateLimiter struct {
+	limit    rate.Limit
+	burst    int
+	mutex    sync.Mutex
+	visitors map[string]*rateLimitVisitor
+}
+
+type rateLimitVisitor struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newIPRateLimiter(limit rate.Limit, burst int) *ipRateLimiter {
+	return &ipRateLimiter{
+		limit:    limit,
+		burst:    burst,
+		visitors: make(map[string]*rateLimitVisitor),
+	}
+}
+
+// allow takes a token from the bucket of ip, creating it on first use.
+func (rl *ipRateLimiter) allow(ip string, now time.Time) bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	visitor, ok := rl.visitors[ip]
+	if !ok {
+		visitor = &rateLimitVisitor{limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.visitors[ip] = visitor
+	}
+	visitor.lastSeen = now
+
+	return visitor.limiter.AllowN(now, 1)
+}
+
+// evictIdle drops the buckets of the IPs not seen for rateLimitIdleTimeout.
+func (rl *ipRateLimiter) evictIdle(now time.Time) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	for ip, visitor := range rl.visitors {
+		if now.Sub(visitor.lastSeen) >= rateLimitIdleTimeout {
+			delete(rl.visitors, ip)
+		}
+	}
+}
+
+// sweep evicts idle buckets periodically until ctx is done.
+func (rl *ipRateLimiter) sweep(ctx context.Context) {
+	ticker := time.NewTicker(rateLimitSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			rl.evictIdle(now)
+		}
+	}
+}
+
+// BidRateLimit limits the requests of each client IP with a token bucket of
+// burst tokens refilled at limit per second, answering 429 once it is empty.
+// onLimited, when not nil, is called for each refused request. Idle IPs are
+// forgotten until ctx is done.
+func BidRateLimit(ctx context.Context, limit float64, burst int, onLimited func()) gin.HandlerFunc {
+	limiter := newIPRateLimiter(rate.Limit(limit), burst)
+	go limiter.sweep(ctx)
+
+	return func(c *gin.Context) {
+		if !limiter.allow(c.ClientIP(), time.Now()) {
+			if onLimited != nil {
+				onLimited()
+			}
+			errRest := rest_err.NewTooManyRequestsError("Too many bid requests, try again later")
+			c.AbortWithStatusJSON(errRest.Code, errRest)
+			return
+		}
+
+		c.Next()
+	}
+}
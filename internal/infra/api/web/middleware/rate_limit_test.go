@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBidRateLimit(t *testing.T) {
+	const burst = 5
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gin.SetMode(gin.TestMode)
+	limited := 0
+	router := gin.New()
+	router.POST("/bid", middleware.BidRateLimit(ctx, 0.01, burst, func() { limited++ }), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	post := func(remoteAddr string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/bid", nil)
+		request.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for i := 0; i < burst; i++ {
+		assert.Equal(t, http.StatusCreated, post("192.0.2.1:1234").Code)
+	}
+	assert.Zero(t, limited)
+
+	recorder := post("192.0.2.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, 1, limited)
+
+	var errRest rest_err.RestErr
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &errRest))
+	assert.Equal(t, "too_many_requests", errRest.Err)
+	assert.Equal(t, http.StatusTooManyRequests, errRest.Code)
+
+	// Each client IP has its own bucket
+	assert.Equal(t, http.StatusCreated, post("192.0.2.2:1234").Code)
+}
+
+func TestIPRateLimiterEvictsIdleIPs(t *testing.T) {
+	limiter := middleware.NewIPRateLimiter(1, 1)
+	now := time.Now()
+
+	assert.True(t, limiter.Allow("192.0.2.1", now))
+	assert.True(t, limiter.Allow("192.0.2.2", now.Add(time.Minute)))
+	assert.False(t, limiter.Allow("192.0.2.2", now.Add(time.Minute)))
+
+	limiter.EvictIdle(now.Add(middleware.RateLimitIdleTimeout))
+	assert.Equal(t, 1, limiter.Len())
+
+	limiter.EvictIdle(now.Add(time.Minute + middleware.RateLimitIdleTimeout))
+	assert.Equal(t, 0, limiter.Len())
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestParentContextKey keeps the request context as it was before
+// RequestTimeout bounded it, so streaming routes can lift the deadline.
+const requestParentContextKey = "requestParentContext"
+
+// RequestTimeout bounds the context of each request by timeout, so a slow
+// query is cancelled and reported as a timeout instead of holding the
+// request. Streaming routes lift it through DisableWriteTimeout.
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Set(requestParentContextKey, c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// liftRequestTimeout restores the request context RequestTimeout bounded. It
+// is still cancelled when the client disconnects.
+func liftRequestTimeout(c *gin.Context) {
+	if parent, ok := c.Value(requestParentContextKey).(context.Context); ok {
+		c.Request = c.Request.WithContext(parent)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestTimeout(50 * time.Millisecond))
+
+	deadlines := make(map[string]bool)
+	recordDeadline := func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		deadlines[c.FullPath()] = ok
+		if ok {
+			assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 50*time.Millisecond)
+		}
+		c.Status(http.StatusNoContent)
+	}
+	router.GET("/plain", recordDeadline)
+	router.GET("/stream", middleware.DisableWriteTimeout(), recordDeadline)
+
+	for _, path := range []string{"/plain", "/stream"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	}
+
+	assert.True(t, deadlines["/plain"])
+	// Streaming routes are not bound by REQUEST_TIMEOUT
+	assert.False(t, deadlines["/stream"])
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+)
+
+// DisableWriteTimeout lifts the server write timeout and REQUEST_TIMEOUT for
+// the request, so streaming responses (SSE, exports) are not cut off after
+// HTTP_WRITE_TIMEOUT. Only streaming routes should use it.
+func DisableWriteTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		liftRequestTimeout(c)
+
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+			logger.Error("Error trying to lift the write timeout of "+c.FullPath(), err)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/api/web/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowStream writes two chunks with a pause longer than the write timeout.
+func slowStream(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Writer.WriteString("first\n")
+	c.Writer.Flush()
+
+	time.Sleep(150 * time.Millisecond)
+
+	c.Writer.WriteString("second\n")
+	c.Writer.Flush()
+}
+
+func newTimeoutServer(t *testing.T) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/stream", middleware.DisableWriteTimeout(), slowStream)
+	router.GET("/plain", slowStream)
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDisableWriteTimeoutKeepsStreamOpen(t *testing.T) {
+	server := newTimeoutServer(t)
+
+	response, err := http.Get(server.URL + "/stream")
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(body))
+}
+
+func TestWriteTimeoutCutsOtherRoutes(t *testing.T) {
+	server := newTimeoutServer(t)
+
+	response, err := http.Get(server.URL + "/plain")
+	if err != nil {
+		return // the connection was closed before the headers arrived
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	assert.NotEqual(t, "first\nsecond\n", string(body))
+}
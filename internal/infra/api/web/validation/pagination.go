@@ -0,0 +1,36 @@
+package validation
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+)
+
+// ParsePagination reads the limit and offset query parameters of a listing.
+// Absent parameters are returned as 0, leaving the defaults to the use case,
+// which also checks their range.
+func ParsePagination(c *gin.Context) (limit, offset int64, restErr *rest_err.RestErr) {
+	if limit, restErr = parseInt64Query(c, "limit"); restErr != nil {
+		return 0, 0, restErr
+	}
+	if offset, restErr = parseInt64Query(c, "offset"); restErr != nil {
+		return 0, 0, restErr
+	}
+
+	return limit, offset, nil
+}
+
+func parseInt64Query(c *gin.Context, name string) (int64, *rest_err.RestErr) {
+	param := c.Query(name)
+	if param == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return 0, rest_err.NewBadRequestError("Error trying to validate " + name + " param")
+	}
+
+	return value, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	validator_en "github.com/go-playground/validator/v10/translations/en"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 )
 
 var (
@@ -29,8 +30,12 @@ func init() {
 func ValidateErr(validation_err error) *rest_err.RestErr {
 	var jsonErr *json.UnmarshalTypeError
 	var jsonValidation validator.ValidationErrors
+	var internalErr *internal_error.InternalError
 
-	if errors.As(validation_err, &jsonErr) {
+	// Fields that parse themselves, like the product condition, report why
+	if errors.As(validation_err, &internalErr) {
+		return rest_err.ConvertError(internalErr)
+	} else if errors.As(validation_err, &jsonErr) {
 		return rest_err.NewNotFoundError("Invalid type error")
 	} else if errors.As(validation_err, &jsonValidation) {
 		errorCauses := []rest_err.Causes{}
@@ -0,0 +1,100 @@
+// Package closer runs the auction closer outside the API servers, so they can
+// set DISABLE_AUCTION_CLOSER and leave closing to one dedicated process.
+package closer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// AuctionCloser runs one closer cycle. It is implemented by
+// auction.AuctionRepository.
+type AuctionCloser interface {
+	CloseExpiredAuctions(ctx context.Context) (int64, *internal_error.InternalError)
+}
+
+// Lease guards the cycles when several closer processes run at once. It is
+// implemented by lock.MongoLease.
+type Lease interface {
+	TryAcquire(ctx context.Context, now time.Time) (bool, error)
+	Release(ctx context.Context) error
+}
+
+type Closer struct {
+	auctions AuctionCloser
+
+	// lease is optional: without it every cycle runs
+	lease Lease
+}
+
+func NewCloser(auctions AuctionCloser, lease Lease) *Closer {
+	return &Closer{
+		auctions: auctions,
+		lease:    lease,
+	}
+}
+
+// RunCycle closes the expired auctions once and returns how many it closed.
+// ran is false when another process holds the lease and the cycle was
+// skipped.
+func (c *Closer) RunCycle(ctx context.Context, now time.Time) (closed int64, ran bool, err error) {
+	if c.lease != nil {
+		held, err := c.lease.TryAcquire(ctx, now)
+		if err != nil {
+			return 0, false, err
+		}
+		if !held {
+			return 0, false, nil
+		}
+	}
+
+	closed, closeErr := c.auctions.CloseExpiredAuctions(ctx)
+	if closeErr != nil {
+		return 0, true, closeErr
+	}
+
+	return closed, true, nil
+}
+
+// Run runs a cycle right away and then every interval until ctx is done,
+// releasing the lease on the way out.
+func (c *Closer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		closed, ran, err := c.RunCycle(ctx, time.Now())
+		switch {
+		case err != nil:
+			logger.Error("Error running auction closer cycle", err)
+		case !ran:
+			logger.Info("Auction closer lease held by another process, skipping cycle")
+		case closed > 0:
+			logger.Info(fmt.Sprintf("Closed %d expired auction(s)", closed))
+		}
+
+		select {
+		case <-ctx.Done():
+			c.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Closer) release() {
+	if c.lease == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.lease.Release(ctx); err != nil {
+		logger.Error("Error releasing auction closer lease", err)
+	}
+}
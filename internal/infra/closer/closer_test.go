@@ -0,0 +1,102 @@
+package closer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/closer"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuctionCloser struct {
+	expired int64
+	cycles  int
+	err     *internal_error.InternalError
+}
+
+func (f *fakeAuctionCloser) CloseExpiredAuctions(ctx context.Context) (int64, *internal_error.InternalError) {
+	f.cycles++
+	if f.err != nil {
+		return 0, f.err
+	}
+	closed := f.expired
+	f.expired = 0
+	return closed, nil
+}
+
+type fakeLease struct {
+	held     bool
+	err      error
+	released bool
+}
+
+func (f *fakeLease) TryAcquire(ctx context.Context, now time.Time) (bool, error) {
+	return f.held, f.err
+}
+
+func (f *fakeLease) Release(ctx context.Context) error {
+	f.released = true
+	return nil
+}
+
+func TestRunCycleClosesExpiredAuctions(t *testing.T) {
+	auctions := &fakeAuctionCloser{expired: 3}
+
+	closed, ran, err := closer.NewCloser(auctions, nil).RunCycle(context.Background(), time.Now())
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, int64(3), closed)
+	assert.Equal(t, 1, auctions.cycles)
+}
+
+func TestRunCycleWithLeaseHeld(t *testing.T) {
+	auctions := &fakeAuctionCloser{expired: 2}
+
+	closed, ran, err := closer.NewCloser(auctions, &fakeLease{held: true}).
+		RunCycle(context.Background(), time.Now())
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, int64(2), closed)
+}
+
+func TestRunCycleSkipsWhenLeaseHeldElsewhere(t *testing.T) {
+	auctions := &fakeAuctionCloser{expired: 2}
+
+	closed, ran, err := closer.NewCloser(auctions, &fakeLease{held: false}).
+		RunCycle(context.Background(), time.Now())
+
+	assert.NoError(t, err)
+	assert.False(t, ran)
+	assert.Zero(t, closed)
+	assert.Zero(t, auctions.cycles)
+}
+
+func TestRunCycleReportsErrors(t *testing.T) {
+	_, ran, err := closer.NewCloser(&fakeAuctionCloser{}, &fakeLease{err: errors.New("connection refused")}).
+		RunCycle(context.Background(), time.Now())
+	assert.Error(t, err)
+	assert.False(t, ran)
+
+	_, ran, err = closer.NewCloser(&fakeAuctionCloser{
+		err: internal_error.NewInternalServerError("Error trying to close expired auctions"),
+	}, nil).RunCycle(context.Background(), time.Now())
+	assert.Error(t, err)
+	assert.True(t, ran)
+}
+
+func TestRunReleasesLeaseOnStop(t *testing.T) {
+	auctions := &fakeAuctionCloser{expired: 1}
+	lease := &fakeLease{held: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	closer.NewCloser(auctions, lease).Run(ctx, time.Hour)
+
+	assert.Equal(t, 1, auctions.cycles)
+	assert.True(t, lease.released)
+}
@@ -2,18 +2,29 @@ package auction
 
 import (
 	"context"
-	"os"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
 )
 
-// StartAuctionCloserRoutine starts a background goroutine that periodically
-// checks for expired auctions and closes them automatically.
-func (ar *AuctionRepository) StartAuctionCloserRoutine(ctx context.Context) {
-	interval := getCloseCheckInterval()
+// StartAuctionCloserRoutine starts a background goroutine that checks for
+// expired auctions every interval and closes them automatically. It does nothing
+// when DISABLE_AUCTION_CLOSER is set, for replicas that leave closing to
+// another instance.
+func (ar *AuctionRepository) StartAuctionCloserRoutine(ctx context.Context, interval time.Duration) {
+	if ar.disableCloser {
+		logger.Info("Auction closer routine disabled by DISABLE_AUCTION_CLOSER")
+		return
+	}
+
 	ticker := time.NewTicker(interval)
 
 	logger.Info("Starting auction closer routine, checking every " + interval.String())
@@ -32,37 +43,255 @@ func (ar *AuctionRepository) StartAuctionCloserRoutine(ctx context.Context) {
 	}()
 }
 
-// closeExpiredAuctions finds all active auctions that have expired and marks them as completed.
-func (ar *AuctionRepository) closeExpiredAuctions(ctx context.Context) {
-	now := time.Now().Unix()
+// SetClosingObserver registers the observer notified on every closer cycle.
+// It must be called before StartAuctionCloserRoutine.
+func (ar *AuctionRepository) SetClosingObserver(observer auction_entity.AuctionClosingObserver) {
+	ar.closingObserver = observer
+}
+
+// SetCompletionListener registers the listener told about the auctions each
+// closer cycle completes. It must be called before StartAuctionCloserRoutine.
+func (ar *AuctionRepository) SetCompletionListener(listener auction_entity.AuctionCompletionListener) {
+	ar.completionListener = listener
+}
+
+// CloseExpiredAuctions runs one closer cycle right away, without waiting for
+// the ticker, and returns how many auctions it completed.
+func (ar *AuctionRepository) CloseExpiredAuctions(ctx context.Context) (int64, *internal_error.InternalError) {
+	closed, err := ar.closeExpiredAuctions(ctx)
+	if err != nil {
+		return 0, internal_error.NewInternalServerError("Error trying to close expired auctions").WithCause(err)
+	}
+
+	return closed, nil
+}
+
+// CloseAuction completes an active auction whatever its expiration, e.g. when
+// the item was sold elsewhere. Like a closer cycle, it notifies the closing
+// observer and the completion listener.
+func (ar *AuctionRepository) CloseAuction(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	ar.closeMutex.Lock()
+	defer ar.closeMutex.Unlock()
+
+	filter := bson.M{"_id": id, "status": StoredStatus(auction_entity.Active), "deleted_at": notDeleted}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     StoredStatus(auction_entity.Completed),
+			"updated_at": time.Now().Unix(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
 
+	var auctionEntityMongo AuctionEntityMongo
+	err := ar.Collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&auctionEntityMongo)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Either missing, deleted or no longer active
+		auction, findErr := ar.FindAuctionById(ctx, id)
+		if findErr != nil {
+			return nil, findErr
+		}
+		if auction.Status == auction_entity.Completed {
+			return nil, internal_error.NewAuctionCompletedError()
+		}
+		return nil, internal_error.NewConflictError("Auction changed while being closed")
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to close auction id = %s", id), err)
+		return nil, internal_error.NewInternalServerError("Error trying to close auction").WithCause(err)
+	}
+
+	auction := toAuctionEntity(auctionEntityMongo)
+	if ar.closingObserver != nil {
+		ar.closingObserver.AuctionsClosed([]auction_entity.Auction{auction})
+	}
+	if ar.completionListener != nil {
+		ar.completionListener.AuctionsCompleted([]string{id})
+	}
+
+	logger.Info("Closed auction before its expiration", zap.String("auction_id", id))
+
+	return &auction, nil
+}
+
+// closeExpiredAuctions finds the active auctions that have expired and marks
+// them as completed, at most AUCTION_CLOSE_BATCH_SIZE per cycle (the oldest
+// expirations first) so a large backlog is spread across cycles. It returns
+// how many auctions were completed.
+func (ar *AuctionRepository) closeExpiredAuctions(ctx context.Context) (int64, error) {
+	// A cycle triggered on demand may overlap the ticker; running them one at
+	// a time keeps the observer from seeing the same auction closed twice
+	ar.closeMutex.Lock()
+	defer ar.closeMutex.Unlock()
+
+	now := time.Now()
+
+	// The auctions about to close are only looked up when someone watches them
+	var watched []auction_entity.Auction
+	if ar.closingObserver != nil {
+		if horizon := ar.closingObserver.ClosingHorizon(); horizon > 0 {
+			watched = ar.findAuctionsClosingBefore(ctx, now.Add(horizon))
+		}
+	}
+
+	ids, err := ar.findExpiredAuctionIds(ctx, now, ar.closeBatchSize)
+	if err != nil {
+		logger.Error("Error finding expired auctions", err)
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		if len(watched) > 0 {
+			ar.notifyClosingObserver(now, watched, nil)
+		}
+		return 0, nil
+	}
+
+	// The status is matched again in case an auction changed since the lookup
 	filter := bson.M{
-		"status":     auction_entity.Active,
-		"expires_at": bson.M{"$lte": now},
+		"_id":    bson.M{"$in": ids},
+		"status": StoredStatus(auction_entity.Active),
 	}
 
 	update := bson.M{
-		"$set": bson.M{"status": auction_entity.Completed},
+		"$set": bson.M{
+			"status":     StoredStatus(auction_entity.Completed),
+			"updated_at": now.Unix(),
+		},
+		"$inc": bson.M{"version": 1},
 	}
 
 	result, err := ar.Collection.UpdateMany(ctx, filter, update)
 	if err != nil {
 		logger.Error("Error closing expired auctions", err)
-		return
+		return 0, err
 	}
 
+	if len(watched) > 0 {
+		ar.notifyClosingObserver(now, watched, ids)
+	}
+
+	if ar.completionListener != nil && result.ModifiedCount > 0 {
+		ar.completionListener.AuctionsCompleted(ids)
+	}
+
+	// The ids are the expired auctions matched by this cycle, so operators can
+	// audit which ones were auto-completed
 	if result.ModifiedCount > 0 {
-		logger.Info("Closed " + string(rune(result.ModifiedCount)) + " expired auction(s)")
+		logger.Info(fmt.Sprintf("Closed %d expired auction(s)", result.ModifiedCount),
+			zap.Strings("auction_ids", ids))
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// findAuctionsClosingBefore returns the active auctions expiring up to
+// deadline, including the ones already expired and about to be completed.
+func (ar *AuctionRepository) findAuctionsClosingBefore(
+	ctx context.Context, deadline time.Time) []auction_entity.Auction {
+	filter := bson.M{
+		"status":     StoredStatus(auction_entity.Active),
+		"expires_at": bson.M{"$lte": deadline.Unix()},
+		"deleted_at": notDeleted,
+	}
+
+	cursor, err := ar.Collection.Find(ctx, filter, options.Find().SetSort(bson.M{"expires_at": 1}))
+	if err != nil {
+		logger.Error("Error finding auctions about to close", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	var auctionsMongo []AuctionEntityMongo
+	if err := cursor.All(ctx, &auctionsMongo); err != nil {
+		logger.Error("Error decoding auctions about to close", err)
+		return nil
+	}
+
+	var auctions []auction_entity.Auction
+	for _, auction := range auctionsMongo {
+		auctions = append(auctions, toAuctionEntity(auction))
 	}
+
+	return auctions
 }
 
-// getCloseCheckInterval returns the interval for checking expired auctions.
-// Default: 10 seconds. Configurable via AUCTION_CLOSE_CHECK_INTERVAL env var.
-func getCloseCheckInterval() time.Duration {
-	interval := os.Getenv("AUCTION_CLOSE_CHECK_INTERVAL")
-	duration, err := time.ParseDuration(interval)
+// findExpiredAuctionIds returns the ids of up to limit expired active
+// auctions, the oldest expirations first.
+func (ar *AuctionRepository) findExpiredAuctionIds(
+	ctx context.Context, now time.Time, limit int64) ([]string, error) {
+	opts := options.Find().
+		SetSort(bson.M{"expires_at": 1}).
+		SetLimit(limit).
+		SetProjection(bson.M{"_id": 1})
+
+	cursor, err := ar.Collection.Find(ctx, expiredAuctionsFilter(now), opts)
 	if err != nil {
-		return 10 * time.Second // Default: 10 seconds
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []struct {
+		Id string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(documents))
+	for _, document := range documents {
+		ids = append(ids, document.Id)
+	}
+
+	return ids, nil
+}
+
+// notifyClosingObserver splits the watched auctions between the ones this
+// cycle has just completed (closedIds) and the ones still about to close,
+// which include expired auctions left for the next cycle.
+func (ar *AuctionRepository) notifyClosingObserver(
+	now time.Time, watched []auction_entity.Auction, closedIds []string) {
+	closedSet := make(map[string]struct{}, len(closedIds))
+	for _, id := range closedIds {
+		closedSet[id] = struct{}{}
+	}
+
+	var closing, closed []auction_entity.Auction
+	for _, auction := range watched {
+		if _, ok := closedSet[auction.Id]; !ok {
+			closing = append(closing, auction)
+			continue
+		}
+
+		auction.Status = auction_entity.Completed
+		closedAt := time.Unix(now.Unix(), 0)
+		auction.UpdatedAt = closedAt
+		auction.ClosedAt = &closedAt
+		auction.Version++
+		closed = append(closed, auction)
+	}
+
+	if len(closing) > 0 {
+		ar.closingObserver.AuctionsClosing(now, closing)
+	}
+	if len(closed) > 0 {
+		ar.closingObserver.AuctionsClosed(closed)
+	}
+}
+
+// CountAuctionsPendingClose returns how many active auctions have already
+// expired and are waiting for the closer routine to complete them.
+func (ar *AuctionRepository) CountAuctionsPendingClose(ctx context.Context) (int64, error) {
+	return ar.Collection.CountDocuments(ctx, expiredAuctionsFilter(time.Now()))
+}
+
+// expiredAuctionsFilter matches active auctions whose expiration has passed.
+// Deleted auctions are left alone: they have no winner to settle.
+func expiredAuctionsFilter(now time.Time) bson.M {
+	return bson.M{
+		"status":     StoredStatus(auction_entity.Active),
+		"expires_at": bson.M{"$lte": now.Unix()},
+		"deleted_at": notDeleted,
 	}
-	return duration
 }
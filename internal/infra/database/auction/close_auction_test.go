@@ -2,25 +2,27 @@ package auction_test
 
 import (
 	"context"
-	"os"
 	"testing"
 	"time"
 
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestAuctionExpiresCorrectly(t *testing.T) {
-	// Set auction interval to 1 second for testing
-	os.Setenv("AUCTION_INTERVAL", "1s")
-	defer os.Unsetenv("AUCTION_INTERVAL")
-
 	// Create an auction
 	auction, err := auction_entity.CreateAuction(
 		"Test Product",
 		"electronics",
 		"This is a test product description for auction",
 		auction_entity.New,
+		time.Second,
 	)
 
 	assert.Nil(t, err)
@@ -35,16 +37,13 @@ func TestAuctionExpiresCorrectly(t *testing.T) {
 }
 
 func TestAuctionIsExpiredAfterInterval(t *testing.T) {
-	// Set auction interval to 100 milliseconds for fast testing
-	os.Setenv("AUCTION_INTERVAL", "100ms")
-	defer os.Unsetenv("AUCTION_INTERVAL")
-
 	// Create an auction
 	auction, err := auction_entity.CreateAuction(
 		"Test Product",
 		"electronics",
 		"This is a test product description for auction",
 		auction_entity.New,
+		100*time.Millisecond,
 	)
 
 	assert.Nil(t, err)
@@ -59,9 +58,6 @@ func TestAuctionIsExpiredAfterInterval(t *testing.T) {
 }
 
 func TestAuctionCreatedAtAndExpiresAtAreSet(t *testing.T) {
-	os.Setenv("AUCTION_INTERVAL", "5m")
-	defer os.Unsetenv("AUCTION_INTERVAL")
-
 	beforeCreation := time.Now()
 
 	auction, err := auction_entity.CreateAuction(
@@ -69,6 +65,7 @@ func TestAuctionCreatedAtAndExpiresAtAreSet(t *testing.T) {
 		"test",
 		"A description with more than 10 characters",
 		auction_entity.Used,
+		5*time.Minute,
 	)
 
 	afterCreation := time.Now()
@@ -86,9 +83,6 @@ func TestAuctionCreatedAtAndExpiresAtAreSet(t *testing.T) {
 }
 
 func TestCreateAuctionWithContext(t *testing.T) {
-	os.Setenv("AUCTION_INTERVAL", "30s")
-	defer os.Unsetenv("AUCTION_INTERVAL")
-
 	_, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -97,6 +91,7 @@ func TestCreateAuctionWithContext(t *testing.T) {
 		"electronics",
 		"Brand new iPhone 15 Pro Max 256GB",
 		auction_entity.New,
+		30*time.Second,
 	)
 
 	assert.Nil(t, err)
@@ -109,3 +104,358 @@ func TestCreateAuctionWithContext(t *testing.T) {
 	duration := auction.ExpiresAt.Sub(auction.CreatedAt)
 	assert.Equal(t, 30*time.Second, duration)
 }
+
+func TestCloseExpiredAuctionsTracksChange(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("completing auctions advances updated_at and version", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			expiredIdsResponse("auction-1", "auction-2"),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+		before := time.Now().Unix()
+		repo.CloseExpiredAuctions(context.Background())
+
+		assert.Equal(mt, "find", mt.GetStartedEvent().CommandName)
+		event := mt.GetStartedEvent()
+		assert.Equal(mt, "update", event.CommandName)
+
+		updates, _ := event.Command.Lookup("updates").Array().Values()
+		update := updates[0].Document().Lookup("u").Document()
+
+		set := update.Lookup("$set").Document()
+		assert.Equal(mt, int32(auction_entity.Completed), set.Lookup("status").Int32())
+		assert.GreaterOrEqual(mt, set.Lookup("updated_at").Int64(), before)
+
+		inc := update.Lookup("$inc").Document()
+		assert.Equal(mt, int32(1), inc.Lookup("version").Int32())
+	})
+}
+
+func TestCloseExpiredAuctionsOnDemand(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("returns how many auctions were closed", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			expiredIdsResponse("auction-1", "auction-2", "auction-3"),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}, bson.E{Key: "nModified", Value: 3}))
+
+		closed, err := repo.CloseExpiredAuctions(context.Background())
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(3), closed)
+	})
+
+	mt.Run("returns zero without expired auctions", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(expiredIdsResponse())
+
+		closed, err := repo.CloseExpiredAuctions(context.Background())
+
+		assert.Nil(mt, err)
+		assert.Zero(mt, closed)
+		assert.Equal(mt, "find", mt.GetStartedEvent().CommandName)
+		assert.Nil(mt, mt.GetStartedEvent())
+	})
+
+	mt.Run("reports a failed update", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			expiredIdsResponse("auction-1"),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11600, Message: "interrupted"}))
+
+		closed, err := repo.CloseExpiredAuctions(context.Background())
+
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "internal_server_error", err.Err)
+		}
+		assert.Zero(mt, closed)
+	})
+}
+
+func TestCloseAuction(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("completes the active auction and notifies", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		observer := &fakeClosingObserver{}
+		listener := &fakeCompletionListener{}
+		repo.SetClosingObserver(observer)
+		repo.SetCompletionListener(listener)
+
+		now := time.Now()
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "status", Value: int32(auction_entity.Completed)},
+			{Key: "expires_at", Value: now.Add(time.Hour).Unix()},
+			{Key: "updated_at", Value: now.Unix()},
+			{Key: "version", Value: int64(2)},
+		}}))
+
+		closed, err := repo.CloseAuction(context.Background(), "auction-1")
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, auction_entity.Completed, closed.Status)
+		assert.NotNil(mt, closed.ClosedAt)
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "findAndModify", command.Index(0).Key())
+		assert.Equal(mt, "auction-1", command.Lookup("query", "_id").StringValue())
+		assert.Equal(mt, int32(auction_entity.Active), command.Lookup("query", "status").Int32())
+		assert.Equal(mt, int32(auction_entity.Completed), command.Lookup("update", "$set", "status").Int32())
+		assert.GreaterOrEqual(mt, command.Lookup("update", "$set", "updated_at").Int64(), now.Unix())
+		assert.Equal(mt, int32(1), command.Lookup("update", "$inc", "version").Int32())
+
+		assert.Len(mt, observer.closed, 1)
+		assert.Equal(mt, []string{"auction-1"}, listener.completed)
+	})
+
+	mt.Run("already completed auction", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		listener := &fakeCompletionListener{}
+		repo.SetCompletionListener(listener)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "auction-1"},
+				{Key: "status", Value: int32(auction_entity.Completed)},
+			}))
+
+		closed, err := repo.CloseAuction(context.Background(), "auction-1")
+
+		assert.Nil(mt, closed)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "bad_request", err.Err)
+			assert.Equal(mt, internal_error.AuctionCompletedCode, err.Code)
+		}
+		assert.Empty(mt, listener.completed)
+	})
+
+	mt.Run("missing auction", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		_, err := repo.CloseAuction(context.Background(), "auction-1")
+
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "not_found", err.Err)
+		}
+	})
+}
+
+func TestAuctionCloserRoutineDisabled(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("starts no cycle when DISABLE_AUCTION_CLOSER is set", func(mt *mtest.T) {
+		mt.Setenv("DISABLE_AUCTION_CLOSER", "true")
+
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			expiredIdsResponse("auction-1"),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		repo.StartAuctionCloserRoutine(ctx, 10*time.Millisecond)
+
+		time.Sleep(100 * time.Millisecond)
+
+		assert.Nil(mt, mt.GetStartedEvent())
+	})
+}
+
+// expiredIdsResponse mocks the lookup of expired auction ids of a cycle.
+func expiredIdsResponse(ids ...string) bson.D {
+	var documents []bson.D
+	for _, id := range ids {
+		documents = append(documents, bson.D{{Key: "_id", Value: id}})
+	}
+	return mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, documents...)
+}
+
+type fakeClosingObserver struct {
+	horizon time.Duration
+	closing []auction_entity.Auction
+	closed  []auction_entity.Auction
+}
+
+func (f *fakeClosingObserver) ClosingHorizon() time.Duration {
+	return f.horizon
+}
+
+func (f *fakeClosingObserver) AuctionsClosing(now time.Time, auctions []auction_entity.Auction) {
+	f.closing = append(f.closing, auctions...)
+}
+
+func (f *fakeClosingObserver) AuctionsClosed(auctions []auction_entity.Auction) {
+	f.closed = append(f.closed, auctions...)
+}
+
+func TestCloseExpiredAuctionsNotifiesClosingObserver(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("without a horizon the auctions about to close are not looked up", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		observer := &fakeClosingObserver{}
+		repo.SetClosingObserver(observer)
+		mt.AddMockResponses(expiredIdsResponse())
+
+		repo.CloseExpiredAuctions(context.Background())
+
+		find := mt.GetStartedEvent()
+		assert.Equal(mt, "find", find.CommandName)
+		_, err := find.Command.LookupErr("filter", "_id")
+		assert.Error(mt, err)
+		assert.Nil(mt, mt.GetStartedEvent(), "nothing expired, nothing to update")
+		assert.Empty(mt, observer.closing)
+		assert.Empty(mt, observer.closed)
+	})
+
+	mt.Run("splits watched auctions between closing and closed", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		observer := &fakeClosingObserver{horizon: 5 * time.Minute}
+		repo.SetClosingObserver(observer)
+
+		now := time.Now()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+				bson.D{
+					{Key: "_id", Value: "expired"},
+					{Key: "status", Value: int32(auction_entity.Active)},
+					{Key: "expires_at", Value: now.Add(-time.Second).Unix()},
+					{Key: "version", Value: int64(1)},
+				},
+				bson.D{
+					{Key: "_id", Value: "closing-soon"},
+					{Key: "status", Value: int32(auction_entity.Active)},
+					{Key: "expires_at", Value: now.Add(3 * time.Minute).Unix()},
+					{Key: "version", Value: int64(1)},
+				},
+			),
+			expiredIdsResponse("expired"),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+
+		repo.CloseExpiredAuctions(context.Background())
+
+		find := mt.GetStartedEvent()
+		assert.Equal(mt, "find", find.CommandName)
+		deadline := find.Command.Lookup("filter", "expires_at", "$lte").Int64()
+		assert.InDelta(mt, now.Add(5*time.Minute).Unix(), deadline, 1)
+
+		assert.Len(mt, observer.closing, 1)
+		assert.Equal(mt, "closing-soon", observer.closing[0].Id)
+
+		assert.Len(mt, observer.closed, 1)
+		assert.Equal(mt, "expired", observer.closed[0].Id)
+		assert.Equal(mt, auction_entity.Completed, observer.closed[0].Status)
+		assert.Equal(mt, int64(2), observer.closed[0].Version)
+
+		// The transition is recorded no earlier than the expiration
+		justClosed := observer.closed[0]
+		assert.False(mt, justClosed.UpdatedAt.Before(justClosed.ExpiresAt))
+		if assert.NotNil(mt, justClosed.ClosedAt) {
+			assert.Equal(mt, justClosed.UpdatedAt, *justClosed.ClosedAt)
+		}
+		assert.Nil(mt, observer.closing[0].ClosedAt)
+	})
+}
+
+type fakeCompletionListener struct {
+	completed []string
+}
+
+func (f *fakeCompletionListener) AuctionsCompleted(auctionIds []string) {
+	f.completed = append(f.completed, auctionIds...)
+}
+
+func TestCloseExpiredAuctionsNotifiesCompletionListener(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("reports the completed auctions", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		listener := &fakeCompletionListener{}
+		repo.SetCompletionListener(listener)
+		mt.AddMockResponses(
+			expiredIdsResponse("auction-1", "auction-2"),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+		repo.CloseExpiredAuctions(context.Background())
+
+		assert.Equal(mt, []string{"auction-1", "auction-2"}, listener.completed)
+	})
+
+	mt.Run("is not called when nothing was completed", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		listener := &fakeCompletionListener{}
+		repo.SetCompletionListener(listener)
+		mt.AddMockResponses(
+			expiredIdsResponse("auction-1"),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+		repo.CloseExpiredAuctions(context.Background())
+
+		assert.Empty(mt, listener.completed)
+	})
+}
+
+func TestCloseExpiredAuctionsLogsClosedAuctions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("logs the count and the ids", func(mt *mtest.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		restore := logger.SetLogger(zap.New(core))
+		defer restore()
+
+		repo := newAuctionRepository(mt.DB)
+		ids := []string{"a-1", "a-2", "a-3", "a-4", "a-5"}
+		mt.AddMockResponses(
+			expiredIdsResponse(ids...),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 5}, bson.E{Key: "nModified", Value: 5}))
+
+		repo.CloseExpiredAuctions(context.Background())
+
+		entries := logs.FilterMessage("Closed 5 expired auction(s)").All()
+		if assert.Len(mt, entries, 1) {
+			assert.Equal(mt, []interface{}{"a-1", "a-2", "a-3", "a-4", "a-5"},
+				entries[0].ContextMap()["auction_ids"])
+		}
+	})
+}
+
+func TestCloseExpiredAuctionsRespectsBatchSize(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("closes at most AUCTION_CLOSE_BATCH_SIZE auctions per cycle", func(mt *mtest.T) {
+		mt.Setenv("AUCTION_CLOSE_BATCH_SIZE", "2")
+
+		repo := newAuctionRepository(mt.DB)
+
+		// Five auctions are expired: the first cycle closes the two oldest
+		// and the next cycle picks up from there
+		for _, batch := range [][]string{{"auction-1", "auction-2"}, {"auction-3", "auction-4"}} {
+			mt.AddMockResponses(
+				expiredIdsResponse(batch...),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+			repo.CloseExpiredAuctions(context.Background())
+
+			find := mt.GetStartedEvent()
+			assert.Equal(mt, "find", find.CommandName)
+			assert.Equal(mt, int64(2), find.Command.Lookup("limit").Int64())
+			assert.Equal(mt, int32(1), find.Command.Lookup("sort", "expires_at").Int32())
+
+			update := mt.GetStartedEvent()
+			assert.Equal(mt, "update", update.CommandName)
+			updates, _ := update.Command.Lookup("updates").Array().Values()
+			ids, _ := updates[0].Document().Lookup("q", "_id", "$in").Array().Values()
+			assert.Len(mt, ids, 2)
+			assert.Equal(mt, batch[0], ids[0].StringValue())
+			assert.Equal(mt, batch[1], ids[1].StringValue())
+		}
+	})
+}
@@ -2,32 +2,71 @@ package auction
 
 import (
 	"context"
+	"sync"
 
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AuctionEntityMongo struct {
-	Id          string                          `bson:"_id"`
-	ProductName string                          `bson:"product_name"`
-	Category    string                          `bson:"category"`
-	Description string                          `bson:"description"`
-	Condition   auction_entity.ProductCondition `bson:"condition"`
-	Status      auction_entity.AuctionStatus    `bson:"status"`
-	CreatedAt   int64                           `bson:"created_at"`
-	ExpiresAt   int64                           `bson:"expires_at"`
+	Id          string          `bson:"_id"`
+	ProductName string          `bson:"product_name"`
+	Category    string          `bson:"category"`
+	Description string          `bson:"description"`
+	Condition   StoredCondition `bson:"condition"`
+	Status      StoredStatus    `bson:"status"`
+	CreatedAt   int64           `bson:"created_at"`
+	StartsAt    int64           `bson:"starts_at"`
+	ExpiresAt   int64           `bson:"expires_at"`
+	UpdatedAt   int64           `bson:"updated_at"`
+	Version     int64           `bson:"version"`
+
+	AllowSelfOutbid *bool   `bson:"allow_self_outbid,omitempty"`
+	MinIncrement    float64 `bson:"min_increment,omitempty"`
+	ReservePrice    float64 `bson:"reserve_price,omitempty"`
+	RelistedFrom    string  `bson:"relisted_from,omitempty"`
+
+	// DeletedAt is set by DeleteAuction; the auction is then no longer
+	// returned by any query
+	DeletedAt int64 `bson:"deleted_at,omitempty"`
+
+	// HasBids is set by MarkAuctionHasBids before the first bid is accepted,
+	// after which UpdateAuction no longer applies
+	HasBids bool `bson:"has_bids,omitempty"`
 }
 
 type AuctionRepository struct {
 	Collection *mongo.Collection
+
+	// closingObserver, when set, is notified by the closer routine
+	closingObserver auction_entity.AuctionClosingObserver
+
+	// completionListener, when set, learns which auctions each cycle completed
+	completionListener auction_entity.AuctionCompletionListener
+
+	// closeMutex serializes closer cycles
+	closeMutex sync.Mutex
+
+	closeBatchSize int64                // expired auctions closed per cycle at most
+	disableCloser  bool                 // the closer routine is left to another instance
+	deletedGone    bool                 // soft-deleted auctions are reported as gone (410)
+	tiePolicy      bid_entity.TiePolicy // picks the highest bid among equal amounts
 }
 
-func NewAuctionRepository(database *mongo.Database) *AuctionRepository {
+func NewAuctionRepository(database *mongo.Database, appConfig config.Config) *AuctionRepository {
+	SetEnumStorage(EnumStorage(appConfig.Auction.EnumStorage))
+
 	return &AuctionRepository{
-		Collection: database.Collection("auctions"),
+		Collection:     database.Collection("auctions"),
+		closeBatchSize: int64(appConfig.Auction.CloseBatchSize),
+		disableCloser:  appConfig.Auction.DisableCloser,
+		deletedGone:    appConfig.Auction.DeletedResponse == config.DeletedAuctionGone,
+		tiePolicy:      appConfig.Bid.TiePolicy,
 	}
 }
 
@@ -39,15 +78,23 @@ func (ar *AuctionRepository) CreateAuction(
 		ProductName: auctionEntity.ProductName,
 		Category:    auctionEntity.Category,
 		Description: auctionEntity.Description,
-		Condition:   auctionEntity.Condition,
-		Status:      auctionEntity.Status,
+		Condition:   StoredCondition(auctionEntity.Condition),
+		Status:      StoredStatus(auctionEntity.Status),
 		CreatedAt:   auctionEntity.CreatedAt.Unix(),
+		StartsAt:    auctionEntity.StartsAt.Unix(),
 		ExpiresAt:   auctionEntity.ExpiresAt.Unix(),
+		UpdatedAt:   auctionEntity.UpdatedAt.Unix(),
+		Version:     auctionEntity.Version,
+
+		AllowSelfOutbid: auctionEntity.AllowSelfOutbid,
+		MinIncrement:    auctionEntity.MinIncrement,
+		ReservePrice:    auctionEntity.ReservePrice,
+		RelistedFrom:    auctionEntity.RelistedFrom,
 	}
 	_, err := ar.Collection.InsertOne(ctx, auctionEntityMongo)
 	if err != nil {
 		logger.Error("Error trying to insert auction", err)
-		return internal_error.NewInternalServerError("Error trying to insert auction")
+		return internal_error.NewInternalServerError("Error trying to insert auction").WithCause(err)
 	}
 
 	return nil
@@ -0,0 +1,53 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// notDeleted matches, as the value of deleted_at, the auctions that were not
+// soft-deleted. Every query but FindAuctionById and FindAuctionSummary, which
+// report deleted auctions as such, leaves the deleted ones out.
+var notDeleted = bson.M{"$exists": false}
+
+// DeleteAuction soft-deletes an auction: the document is kept with its bids,
+// but the auction is no longer listed, edited, closed or bid on, and is
+// reported as not found or gone (DELETED_AUCTION_RESPONSE) when queried by
+// id. The completion listener is told, so the bids still queued for it are
+// dropped and its bid streams end.
+func (ar *AuctionRepository) DeleteAuction(ctx context.Context, id string) *internal_error.InternalError {
+	now := time.Now().Unix()
+	filter := bson.M{"_id": id, "deleted_at": notDeleted}
+	update := bson.M{
+		"$set": bson.M{"deleted_at": now, "updated_at": now},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to delete auction id = %s", id), err)
+		return internal_error.NewInternalServerError("Error trying to delete auction").WithCause(err)
+	}
+
+	if result.MatchedCount == 0 {
+		// Either missing or already deleted
+		if _, findErr := ar.FindAuctionById(ctx, id); findErr != nil {
+			return findErr
+		}
+		return internal_error.NewConflictError("Auction changed while being deleted")
+	}
+
+	if ar.completionListener != nil {
+		ar.completionListener.AuctionsCompleted([]string{id})
+	}
+
+	logger.Info("Deleted auction", zap.String("auction_id", id))
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package auction_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestDeleteAuction(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("marks the auction as deleted and notifies", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		listener := &fakeCompletionListener{}
+		repo.SetCompletionListener(listener)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		now := time.Now().Unix()
+		err := repo.DeleteAuction(context.Background(), "auction-1")
+		assert.Nil(mt, err)
+
+		updates, _ := mt.GetStartedEvent().Command.Lookup("updates").Array().Values()
+		statement := updates[0].Document()
+		assert.Equal(mt, "auction-1", statement.Lookup("q", "_id").StringValue())
+		assert.False(mt, statement.Lookup("q", "deleted_at", "$exists").Boolean())
+		assert.GreaterOrEqual(mt, statement.Lookup("u", "$set", "deleted_at").Int64(), now)
+		assert.Equal(mt, int32(1), statement.Lookup("u", "$inc", "version").Int32())
+
+		// The bids still queued for it are dropped
+		assert.Equal(mt, []string{"auction-1"}, listener.completed)
+	})
+
+	mt.Run("already deleted auction", func(mt *mtest.T) {
+		mt.Setenv("DELETED_AUCTION_RESPONSE", "gone")
+		repo := newAuctionRepository(mt.DB)
+		listener := &fakeCompletionListener{}
+		repo.SetCompletionListener(listener)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "auction-1"},
+				{Key: "status", Value: int32(auction_entity.Active)},
+				{Key: "deleted_at", Value: time.Now().Unix()},
+			}))
+
+		err := repo.DeleteAuction(context.Background(), "auction-1")
+
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "gone", err.Err)
+			assert.Equal(mt, internal_error.AuctionDeletedCode, err.Code)
+		}
+		assert.Empty(mt, listener.completed)
+	})
+
+	mt.Run("missing auction", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		err := repo.DeleteAuction(context.Background(), "auction-1")
+
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "not_found", err.Err)
+		}
+	})
+}
+
+func TestDeletedAuctionsAreLeftOut(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	excludesDeleted := func(mt *mtest.T, filter bson.Raw) {
+		assert.False(mt, filter.Lookup("deleted_at", "$exists").Boolean())
+	}
+
+	mt.Run("by the closer", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(expiredIdsResponse())
+
+		_, err := repo.CloseExpiredAuctions(context.Background())
+		assert.Nil(mt, err)
+
+		excludesDeleted(mt, mt.GetStartedEvent().Command.Lookup("filter").Document())
+	})
+
+	mt.Run("by the statuses", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		_, err := repo.FindAuctionStatuses(context.Background(), []string{"auction-1"})
+		assert.Nil(mt, err)
+
+		excludesDeleted(mt, mt.GetStartedEvent().Command.Lookup("filter").Document())
+	})
+
+	mt.Run("by the category leaderboard", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "top", Value: bson.A{}},
+			{Key: "pinned", Value: bson.A{}},
+		}))
+
+		_, err := repo.FindTopAuctionsByCategory(context.Background(), "electronics", 10, nil)
+		assert.Nil(mt, err)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+		excludesDeleted(mt, stages[0].Document().Lookup("$match").Document())
+	})
+
+	mt.Run("by the edits, which report them as deleted", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "auction-1"},
+				{Key: "deleted_at", Value: time.Now().Unix()},
+			}))
+
+		err := repo.MarkAuctionHasBids(context.Background(), "auction-1", 1)
+
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "not_found", err.Err)
+			assert.Equal(mt, internal_error.AuctionDeletedCode, err.Code)
+		}
+	})
+}
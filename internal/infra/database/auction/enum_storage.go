@@ -0,0 +1,143 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// EnumStorage is how an auction's status and condition are written to Mongo.
+type EnumStorage string
+
+const (
+	// IntEnumStorage writes the numeric value of the enum (the default)
+	IntEnumStorage EnumStorage = "int"
+
+	// StringEnumStorage writes the canonical name, readable in ad-hoc queries
+	// and safe from enum reordering
+	StringEnumStorage EnumStorage = "string"
+)
+
+// storeEnumNames is whether StoredStatus and StoredCondition are written as
+// names. It is package state because BSON marshalers take no arguments.
+var storeEnumNames atomic.Bool
+
+// SetEnumStorage sets how auction enums are written from now on;
+// NewAuctionRepository sets it from AUCTION_ENUM_STORAGE.
+func SetEnumStorage(storage EnumStorage) {
+	storeEnumNames.Store(storage == StringEnumStorage)
+}
+
+var (
+	statusNames = map[auction_entity.AuctionStatus]string{
+		auction_entity.Active:    "active",
+		auction_entity.Completed: "completed",
+	}
+
+	conditionNames = map[auction_entity.ProductCondition]string{
+		auction_entity.New:         "new",
+		auction_entity.Used:        "used",
+		auction_entity.Refurbished: "refurbished",
+	}
+)
+
+// StoredStatus is an auction status as written to Mongo, following
+// AUCTION_ENUM_STORAGE. It also encodes the status in filters, so queries
+// match the stored representation. Both representations are decoded, so
+// documents written in either mode stay readable.
+type StoredStatus auction_entity.AuctionStatus
+
+func (s StoredStatus) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalEnum(int64(s), statusNames[auction_entity.AuctionStatus(s)])
+}
+
+func (s *StoredStatus) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value, err := unmarshalEnum(t, data, statusNames)
+	*s = StoredStatus(value)
+	return err
+}
+
+// StoredCondition is a product condition as written to Mongo, following
+// AUCTION_ENUM_STORAGE, like StoredStatus.
+type StoredCondition auction_entity.ProductCondition
+
+func (c StoredCondition) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalEnum(int64(c), conditionNames[auction_entity.ProductCondition(c)])
+}
+
+func (c *StoredCondition) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value, err := unmarshalEnum(t, data, conditionNames)
+	*c = StoredCondition(value)
+	return err
+}
+
+// marshalEnum writes name in string mode, when the value has one, and the
+// value as an int32 otherwise, as the driver encodes a plain int.
+func marshalEnum(value int64, name string) (bsontype.Type, []byte, error) {
+	if name != "" && storeEnumNames.Load() {
+		return bson.MarshalValue(name)
+	}
+
+	return bson.MarshalValue(int32(value))
+}
+
+func unmarshalEnum[E ~int](t bsontype.Type, data []byte, names map[E]string) (E, error) {
+	raw := bson.RawValue{Type: t, Value: data}
+
+	if name, ok := raw.StringValueOK(); ok {
+		for value, candidate := range names {
+			if candidate == name {
+				return value, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown enum name %q", name)
+	}
+
+	if value, ok := raw.AsInt64OK(); ok {
+		return E(value), nil
+	}
+
+	return 0, fmt.Errorf("cannot decode enum from BSON %s", t)
+}
+
+// MigrateEnumStorage rewrites the status and condition of every stored
+// auction into storage, one UpdateMany per enum value, and returns how many
+// fields were changed. It is idempotent; running it before switching
+// AUCTION_ENUM_STORAGE keeps the queries matching every document.
+func (ar *AuctionRepository) MigrateEnumStorage(ctx context.Context, storage EnumStorage) (int64, error) {
+	var changed int64
+
+	migrate := func(field string, value int64, name string) error {
+		from, to := interface{}(name), interface{}(int32(value))
+		if storage == StringEnumStorage {
+			from, to = to, from
+		}
+
+		result, err := ar.Collection.UpdateMany(ctx,
+			bson.M{field: from}, bson.M{"$set": bson.M{field: to}})
+		if err != nil {
+			return err
+		}
+
+		changed += result.ModifiedCount
+		return nil
+	}
+
+	for _, status := range []auction_entity.AuctionStatus{auction_entity.Active, auction_entity.Completed} {
+		if err := migrate("status", int64(status), statusNames[status]); err != nil {
+			return changed, err
+		}
+	}
+	for _, condition := range []auction_entity.ProductCondition{
+		auction_entity.New, auction_entity.Used, auction_entity.Refurbished} {
+		if err := migrate("condition", int64(condition), conditionNames[condition]); err != nil {
+			return changed, err
+		}
+	}
+
+	return changed, nil
+}
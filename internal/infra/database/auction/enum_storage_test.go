@@ -0,0 +1,157 @@
+package auction_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestEnumStorageRoundTrip(t *testing.T) {
+	testCases := []struct {
+		storage   string
+		status    interface{}
+		condition interface{}
+	}{
+		{"int", int32(auction_entity.Completed), int32(auction_entity.Refurbished)},
+		{"string", "completed", "refurbished"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.storage, func(t *testing.T) {
+			auction.SetEnumStorage(auction.EnumStorage(tc.storage))
+			t.Cleanup(func() { auction.SetEnumStorage(auction.IntEnumStorage) })
+
+			stored := auction.AuctionEntityMongo{
+				Id:        uuid.New().String(),
+				Condition: auction.StoredCondition(auction_entity.Refurbished),
+				Status:    auction.StoredStatus(auction_entity.Completed),
+			}
+			document, err := bson.Marshal(stored)
+			assert.Nil(t, err)
+
+			raw := bson.Raw(document)
+			assert.Equal(t, tc.status, rawInterface(raw.Lookup("status")))
+			assert.Equal(t, tc.condition, rawInterface(raw.Lookup("condition")))
+
+			var decoded auction.AuctionEntityMongo
+			assert.Nil(t, bson.Unmarshal(document, &decoded))
+			assert.Equal(t, stored, decoded)
+		})
+	}
+}
+
+func rawInterface(value bson.RawValue) interface{} {
+	if name, ok := value.StringValueOK(); ok {
+		return name
+	}
+	return value.Int32()
+}
+
+func TestEnumStorageReadsBothRepresentations(t *testing.T) {
+	// Documents not migrated yet stay readable after switching modes
+	t.Setenv("AUCTION_ENUM_STORAGE", "string")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("int and string documents", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "int"}, {Key: "status", Value: int32(1)}, {Key: "condition", Value: int32(2)}},
+			bson.D{{Key: "_id", Value: "string"}, {Key: "status", Value: "completed"}, {Key: "condition", Value: "used"}},
+		))
+
+		auctions, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{})
+		assert.Nil(mt, err)
+		if assert.Len(mt, auctions, 2) {
+			for _, found := range auctions {
+				assert.Equal(mt, auction_entity.Completed, found.Status, found.Id)
+				assert.Equal(mt, auction_entity.Used, found.Condition, found.Id)
+			}
+		}
+	})
+
+	mt.Run("unknown names are rejected", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "bad"}, {Key: "status", Value: "archived"}}))
+
+		_, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{})
+		assert.NotNil(mt, err)
+	})
+}
+
+func TestStringEnumStorageQueries(t *testing.T) {
+	t.Setenv("AUCTION_ENUM_STORAGE", "string")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("closer matches and writes status names", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			expiredIdsResponse("auction-1"),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		closed, err := repo.CloseExpiredAuctions(context.Background())
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(1), closed)
+
+		find := mt.GetStartedEvent()
+		assert.Equal(mt, "active", find.Command.Lookup("filter", "status").StringValue())
+		assert.LessOrEqual(mt, find.Command.Lookup("filter", "expires_at", "$lte").Int64(), time.Now().Unix())
+
+		updates, _ := mt.GetStartedEvent().Command.Lookup("updates").Array().Values()
+		update := updates[0].Document()
+		assert.Equal(mt, "active", update.Lookup("q", "status").StringValue())
+		assert.Equal(mt, "completed", update.Lookup("u", "$set", "status").StringValue())
+	})
+
+	mt.Run("listing filters use names", func(mt *mtest.T) {
+		status, condition := auction_entity.Completed, auction_entity.New
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{Status: &status, Condition: &condition})
+
+		assert.Equal(mt, "completed", sent.Lookup("status").StringValue())
+		assert.Equal(mt, "new", sent.Lookup("condition").StringValue())
+	})
+}
+
+func TestMigrateEnumStorage(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("converts every int value to its name", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		// Two statuses and three conditions, one update each
+		for i := 0; i < 5; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(
+				bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+		}
+
+		changed, err := repo.MigrateEnumStorage(context.Background(), auction.StringEnumStorage)
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(10), changed)
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(mt, int32(auction_entity.Active), update.Lookup("q", "status").Int32())
+		assert.Equal(mt, "active", update.Lookup("u", "$set", "status").StringValue())
+	})
+
+	mt.Run("converts names back to ints", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		for i := 0; i < 5; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(
+				bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		}
+
+		changed, err := repo.MigrateEnumStorage(context.Background(), auction.IntEnumStorage)
+		assert.Nil(mt, err)
+		assert.Zero(mt, changed)
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(mt, "active", update.Lookup("q", "status").StringValue())
+		assert.Equal(mt, int32(auction_entity.Active), update.Lookup("u", "$set", "status").Int32())
+	})
+}
@@ -2,83 +2,550 @@ package auction
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// highestBidMongo mirrors the bid document joined by FindAuctionsWithHighestBid.
+// It is declared here because the bid repository package depends on this one.
+type highestBidMongo struct {
+	Id           string  `bson:"_id"`
+	UserId       string  `bson:"user_id"`
+	AuctionId    string  `bson:"auction_id"`
+	AmountCents  int64   `bson:"amount_cents"`
+	LegacyAmount float64 `bson:"amount,omitempty"` // float amount, before migrating to cents
+	Timestamp    int64   `bson:"timestamp"`        // Unix milliseconds, or seconds before migrating
+}
+
+// amountCents decodes the bid amount like the bid repository does.
+func (bid highestBidMongo) amountCents() int64 {
+	if bid.AmountCents == 0 && bid.LegacyAmount != 0 {
+		return bid_entity.ToCents(bid.LegacyAmount)
+	}
+	return bid.AmountCents
+}
+
+// timestamp decodes the bid timestamp like the bid repository does.
+func (bid highestBidMongo) timestamp() time.Time {
+	if bid.Timestamp < 100_000_000_000 {
+		return time.Unix(bid.Timestamp, 0)
+	}
+	return time.UnixMilli(bid.Timestamp)
+}
+
+type auctionWithHighestBidMongo struct {
+	AuctionEntityMongo `bson:",inline"`
+	HighestBid         []highestBidMongo `bson:"highest_bid"`
+}
+
 func (ar *AuctionRepository) FindAuctionById(
 	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
 	filter := bson.M{"_id": id}
 
 	var auctionEntityMongo AuctionEntityMongo
 	if err := ar.Collection.FindOne(ctx, filter).Decode(&auctionEntityMongo); err != nil {
+		// A missing auction is the caller's mistake; anything else is ours
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, internal_error.NewAuctionNotFoundError()
+		}
+
 		logger.Error(fmt.Sprintf("Error trying to find auction by id = %s", id), err)
-		return nil, internal_error.NewInternalServerError("Error trying to find auction by id")
+		return nil, internal_error.NewInternalServerError("Error trying to find auction by id").WithCause(err)
 	}
 
-	return &auction_entity.Auction{
-		Id:          auctionEntityMongo.Id,
-		ProductName: auctionEntityMongo.ProductName,
-		Category:    auctionEntityMongo.Category,
-		Description: auctionEntityMongo.Description,
-		Condition:   auctionEntityMongo.Condition,
-		Status:      auctionEntityMongo.Status,
-		CreatedAt:   time.Unix(auctionEntityMongo.CreatedAt, 0),
-		ExpiresAt:   time.Unix(auctionEntityMongo.ExpiresAt, 0),
+	if auctionEntityMongo.DeletedAt != 0 {
+		return nil, internal_error.NewAuctionDeletedError(ar.deletedGone)
+	}
+
+	auctionEntity := toAuctionEntity(auctionEntityMongo)
+	return &auctionEntity, nil
+}
+
+// FindAuctionSummary reads an auction, its highest bid and its bid count in one
+// aggregation instead of a query for each.
+func (ar *AuctionRepository) FindAuctionSummary(
+	ctx context.Context, id string) (*auction_entity.AuctionSummary, *internal_error.InternalError) {
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"_id": id}}, highestBidLookup(ar.tiePolicy)}, bidCountStages()...)
+
+	cursor, err := ar.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to find summary of auction id = %s", id), err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction summary").WithCause(err)
+	}
+	defer cursor.Close(ctx)
+
+	var summariesMongo []struct {
+		Auction  auctionWithHighestBidMongo `bson:",inline"`
+		BidCount int64                      `bson:"bid_count"`
+	}
+	if err := cursor.All(ctx, &summariesMongo); err != nil {
+		logger.Error(fmt.Sprintf("Error decoding summary of auction id = %s", id), err)
+		return nil, internal_error.NewInternalServerError("Error trying to find auction summary").WithCause(err)
+	}
+	if len(summariesMongo) == 0 {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+
+	summaryMongo := summariesMongo[0]
+	if summaryMongo.Auction.DeletedAt != 0 {
+		return nil, internal_error.NewAuctionDeletedError(ar.deletedGone)
+	}
+
+	return &auction_entity.AuctionSummary{
+		AuctionWithHighestBid: toAuctionWithHighestBid(summaryMongo.Auction),
+		BidCount:              summaryMongo.BidCount,
 	}, nil
 }
 
+func (repo *AuctionRepository) FindAuctionStatuses(
+	ctx context.Context, ids []string) (map[string]auction_entity.AuctionStatus, *internal_error.InternalError) {
+	filter := bson.M{"_id": bson.M{"$in": ids}, "deleted_at": notDeleted}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "status": 1})
+
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Error finding auction statuses", err)
+		return nil, internal_error.NewInternalServerError("Error finding auction statuses").WithCause(err)
+	}
+	defer cursor.Close(ctx)
+
+	var auctionsMongo []struct {
+		Id     string       `bson:"_id"`
+		Status StoredStatus `bson:"status"`
+	}
+	if err := cursor.All(ctx, &auctionsMongo); err != nil {
+		logger.Error("Error decoding auction statuses", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auction statuses").WithCause(err)
+	}
+
+	statuses := make(map[string]auction_entity.AuctionStatus, len(auctionsMongo))
+	for _, auction := range auctionsMongo {
+		statuses[auction.Id] = auction_entity.AuctionStatus(auction.Status)
+	}
+
+	return statuses, nil
+}
+
 func (repo *AuctionRepository) FindAuctions(
 	ctx context.Context,
-	status auction_entity.AuctionStatus,
-	category string,
-	productName string) ([]auction_entity.Auction, *internal_error.InternalError) {
-	filter := bson.M{}
+	auctionFilter auction_entity.AuctionFilter) ([]auction_entity.Auction, *internal_error.InternalError) {
+	// Whether an auction has bids, and how many, is only known by joining them
+	if auctionFilter.HasBids || auctionFilter.Sort == auction_entity.SortBidCountDesc {
+		return repo.aggregateAuctions(ctx, auctionFilter)
+	}
 
-	if status != 0 {
-		filter["status"] = status
+	filter := buildFindAuctionsFilter(auctionFilter)
+
+	opts := options.Find()
+	if sort := buildAuctionsSort(auctionFilter.Sort); sort != nil {
+		opts.SetSort(sort)
+	}
+	if auctionFilter.Skip > 0 {
+		opts.SetSkip(auctionFilter.Skip)
+	}
+	if auctionFilter.Limit > 0 {
+		opts.SetLimit(auctionFilter.Limit)
 	}
 
-	if category != "" {
-		filter["category"] = category
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error("Error finding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error finding auctions").WithCause(err)
+	}
+	defer cursor.Close(ctx)
+
+	var auctionsMongo []AuctionEntityMongo
+	if err := cursor.All(ctx, &auctionsMongo); err != nil {
+		logger.Error("Error decoding auctions", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").WithCause(err)
 	}
 
-	if productName != "" {
-		filter["productName"] = primitive.Regex{Pattern: productName, Options: "i"}
+	// No match is an empty listing, not an error
+	auctionsEntity := make([]auction_entity.Auction, 0, len(auctionsMongo))
+	for _, auction := range auctionsMongo {
+		auctionsEntity = append(auctionsEntity, toAuctionEntity(auction))
 	}
 
-	cursor, err := repo.Collection.Find(ctx, filter)
+	return auctionsEntity, nil
+}
+
+// aggregateAuctions is FindAuctions for the filters that need an aggregation.
+func (repo *AuctionRepository) aggregateAuctions(
+	ctx context.Context,
+	auctionFilter auction_entity.AuctionFilter) ([]auction_entity.Auction, *internal_error.InternalError) {
+	cursor, err := repo.Collection.Aggregate(ctx, buildAuctionsPipeline(auctionFilter))
 	if err != nil {
 		logger.Error("Error finding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error finding auctions")
+		return nil, internal_error.NewInternalServerError("Error finding auctions").WithCause(err)
 	}
 	defer cursor.Close(ctx)
 
 	var auctionsMongo []AuctionEntityMongo
 	if err := cursor.All(ctx, &auctionsMongo); err != nil {
 		logger.Error("Error decoding auctions", err)
-		return nil, internal_error.NewInternalServerError("Error decoding auctions")
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").WithCause(err)
+	}
+
+	auctionsEntity := make([]auction_entity.Auction, 0, len(auctionsMongo))
+	for _, auction := range auctionsMongo {
+		auctionsEntity = append(auctionsEntity, toAuctionEntity(auction))
+	}
+
+	return auctionsEntity, nil
+}
+
+// CountAuctions counts the auctions matching the filter, ignoring its paging,
+// so listings can report their total.
+func (repo *AuctionRepository) CountAuctions(
+	ctx context.Context,
+	auctionFilter auction_entity.AuctionFilter) (int64, *internal_error.InternalError) {
+	if !auctionFilter.HasBids {
+		count, err := repo.Collection.CountDocuments(ctx, buildFindAuctionsFilter(auctionFilter))
+		if err != nil {
+			logger.Error("Error counting auctions", err)
+			return 0, internal_error.NewInternalServerError("Error counting auctions").WithCause(err)
+		}
+
+		return count, nil
+	}
+
+	countFilter := auctionFilter
+	countFilter.Sort, countFilter.Skip, countFilter.Limit = auction_entity.SortDefault, 0, 0
+	pipeline := append(buildAuctionsPipeline(countFilter), bson.M{"$count": "total"})
+
+	cursor, err := repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Error counting auctions", err)
+		return 0, internal_error.NewInternalServerError("Error counting auctions").WithCause(err)
+	}
+	defer cursor.Close(ctx)
+
+	// $count emits no document at all when nothing matches
+	var counts []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		logger.Error("Error decoding auction count", err)
+		return 0, internal_error.NewInternalServerError("Error counting auctions").WithCause(err)
+	}
+	if len(counts) == 0 {
+		return 0, nil
+	}
+
+	return counts[0].Total, nil
+}
+
+// FindAuctionsWithHighestBid lists the auctions matching the filter with their
+// top bid joined in the same aggregation, avoiding one query per auction.
+func (repo *AuctionRepository) FindAuctionsWithHighestBid(
+	ctx context.Context,
+	auctionFilter auction_entity.AuctionFilter) ([]auction_entity.AuctionWithHighestBid, *internal_error.InternalError) {
+	// Paged before the lookup so only the returned auctions are joined
+	pipeline := append(buildAuctionsPipeline(auctionFilter), highestBidLookup(repo.tiePolicy))
+
+	cursor, err := repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Error finding auctions with highest bid", err)
+		return nil, internal_error.NewInternalServerError("Error finding auctions").WithCause(err)
 	}
+	defer cursor.Close(ctx)
 
-	var auctionsEntity []auction_entity.Auction
+	var auctionsMongo []auctionWithHighestBidMongo
+	if err := cursor.All(ctx, &auctionsMongo); err != nil {
+		logger.Error("Error decoding auctions with highest bid", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").WithCause(err)
+	}
+
+	var auctionsEntity []auction_entity.AuctionWithHighestBid
 	for _, auction := range auctionsMongo {
-		auctionsEntity = append(auctionsEntity, auction_entity.Auction{
-			Id:          auction.Id,
-			ProductName: auction.ProductName,
-			Category:    auction.Category,
-			Status:      auction.Status,
-			Description: auction.Description,
-			Condition:   auction.Condition,
-			CreatedAt:   time.Unix(auction.CreatedAt, 0),
-			ExpiresAt:   time.Unix(auction.ExpiresAt, 0),
-		})
+		auctionsEntity = append(auctionsEntity, toAuctionWithHighestBid(auction))
+	}
+
+	return auctionsEntity, nil
+}
+
+// FindTopAuctionsByCategory ranks the active auctions of a category by their
+// highest persisted bid in one aggregation. The pinned auctions are returned
+// too, after the ranked ones, so the caller can rerank them with bids that
+// are not persisted yet.
+func (repo *AuctionRepository) FindTopAuctionsByCategory(
+	ctx context.Context,
+	category string,
+	limit int64,
+	pinnedIds []string) ([]auction_entity.AuctionWithHighestBid, *internal_error.InternalError) {
+	if pinnedIds == nil {
+		pinnedIds = []string{}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{
+			"status":     StoredStatus(auction_entity.Active),
+			"category":   category,
+			"deleted_at": notDeleted,
+		}},
+		highestBidLookup(repo.tiePolicy),
+		bson.M{"$addFields": bson.M{"highest_amount": bson.M{
+			"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$highest_bid.amount_cents", 0}}, 0},
+		}}},
+		bson.M{"$facet": bson.M{
+			"top": bson.A{
+				bson.M{"$sort": bson.D{{Key: "highest_amount", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": limit},
+			},
+			"pinned": bson.A{bson.M{"$match": bson.M{"_id": bson.M{"$in": pinnedIds}}}},
+		}},
+	}
+
+	cursor, err := repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error("Error finding top auctions by category", err)
+		return nil, internal_error.NewInternalServerError("Error finding auctions").WithCause(err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Top    []auctionWithHighestBidMongo `bson:"top"`
+		Pinned []auctionWithHighestBidMongo `bson:"pinned"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil || len(facets) != 1 {
+		logger.Error("Error decoding top auctions by category", err)
+		return nil, internal_error.NewInternalServerError("Error decoding auctions").WithCause(err)
+	}
+
+	var auctionsEntity []auction_entity.AuctionWithHighestBid
+	seen := make(map[string]struct{})
+	for _, auction := range append(facets[0].Top, facets[0].Pinned...) {
+		if _, ok := seen[auction.Id]; ok {
+			continue
+		}
+		seen[auction.Id] = struct{}{}
+		auctionsEntity = append(auctionsEntity, toAuctionWithHighestBid(auction))
 	}
 
 	return auctionsEntity, nil
 }
+
+// buildAuctionsPipeline matches, sorts and pages the auctions of the filter.
+// With HasBids, the auctions without bids are dropped before paging; the
+// lookup stops at the first bid of each auction instead of joining them all.
+// Sorting by bid count counts the bids of each auction in the lookup instead.
+func buildAuctionsPipeline(auctionFilter auction_entity.AuctionFilter) bson.A {
+	pipeline := bson.A{bson.M{"$match": buildFindAuctionsFilter(auctionFilter)}}
+	byBidCount := auctionFilter.Sort == auction_entity.SortBidCountDesc
+	if byBidCount {
+		pipeline = append(pipeline, bidCountStages()...)
+	}
+	if sort := buildAuctionsSort(auctionFilter.Sort); sort != nil {
+		pipeline = append(pipeline, bson.M{"$sort": sort})
+	}
+	if byBidCount {
+		if auctionFilter.HasBids {
+			pipeline = append(pipeline, bson.M{"$match": bson.M{"bid_count": bson.M{"$gt": 0}}})
+		}
+		pipeline = append(pipeline, bson.M{"$project": bson.M{"bid_count": 0}})
+	} else if auctionFilter.HasBids {
+		pipeline = append(pipeline,
+			bson.M{"$lookup": bson.M{
+				"from": "bids",
+				"let":  bson.M{"auctionId": "$_id"},
+				"pipeline": bson.A{
+					bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$auction_id", "$$auctionId"}}}},
+					bson.M{"$limit": 1},
+					bson.M{"$project": bson.M{"_id": 1}},
+				},
+				"as": "any_bid",
+			}},
+			bson.M{"$match": bson.M{"any_bid": bson.M{"$ne": bson.A{}}}},
+			bson.M{"$project": bson.M{"any_bid": 0}})
+	}
+	if auctionFilter.Skip > 0 {
+		pipeline = append(pipeline, bson.M{"$skip": auctionFilter.Skip})
+	}
+	if auctionFilter.Limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": auctionFilter.Limit})
+	}
+
+	return pipeline
+}
+
+// bidCountStages count the bids of each auction into a bid_count field, zero
+// for auctions without bids.
+func bidCountStages() bson.A {
+	return bson.A{
+		bson.M{"$lookup": bson.M{
+			"from": "bids",
+			"let":  bson.M{"auctionId": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$auction_id", "$$auctionId"}}}},
+				bson.M{"$count": "total"},
+			},
+			"as": "bid_count",
+		}},
+		bson.M{"$addFields": bson.M{"bid_count": bson.M{
+			"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$bid_count.total", 0}}, 0},
+		}}},
+	}
+}
+
+// bidAmountCents is the amount of a bid in cents as an aggregation
+// expression, converting the float amount of bids not migrated yet like the
+// bid repository does.
+var bidAmountCents = bson.M{"$ifNull": bson.A{"$amount_cents", bson.M{"$toLong": bson.M{
+	"$round": bson.A{bson.M{"$multiply": bson.A{"$amount", 100}}, 0},
+}}}}
+
+// highestBidLookup joins the top bid of each auction as a one-element (or
+// empty) highest_bid array, honouring the tie policy on equal amounts. Bids
+// not migrated to cents yet compete with their converted amount.
+func highestBidLookup(tiePolicy bid_entity.TiePolicy) bson.M {
+	return bson.M{"$lookup": bson.M{
+		"from": "bids",
+		"let":  bson.M{"auctionId": "$_id"},
+		"pipeline": bson.A{
+			bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$auction_id", "$$auctionId"}}}},
+			bson.M{"$addFields": bson.M{"amount_cents": bidAmountCents}},
+			bson.M{"$sort": bson.D{
+				{Key: "amount_cents", Value: -1},
+				{Key: "timestamp", Value: tiePolicy.TimestampSortOrder()},
+			}},
+			bson.M{"$limit": 1},
+		},
+		"as": "highest_bid",
+	}}
+}
+
+func toAuctionWithHighestBid(auction auctionWithHighestBidMongo) auction_entity.AuctionWithHighestBid {
+	auctionWithHighestBid := auction_entity.AuctionWithHighestBid{
+		Auction: toAuctionEntity(auction.AuctionEntityMongo),
+	}
+
+	if len(auction.HighestBid) > 0 {
+		highestBid := auction.HighestBid[0]
+		auctionWithHighestBid.HighestBid = &bid_entity.Bid{
+			Id:        highestBid.Id,
+			UserId:    highestBid.UserId,
+			AuctionId: highestBid.AuctionId,
+			Timestamp: highestBid.timestamp(),
+
+			AmountCents: highestBid.amountCents(),
+		}
+	}
+
+	return auctionWithHighestBid
+}
+
+// buildFindAuctionsFilter composes a single query with only the criteria that
+// were provided. Status and condition are pointers because their zero values
+// (Active and the unset condition) are meaningful and must not be inferred.
+// Deleted auctions are never listed.
+func buildFindAuctionsFilter(auctionFilter auction_entity.AuctionFilter) bson.M {
+	filter := bson.M{"deleted_at": notDeleted}
+
+	if auctionFilter.Ids != nil {
+		filter["_id"] = bson.M{"$in": auctionFilter.Ids}
+	}
+
+	if auctionFilter.Status != nil {
+		filter["status"] = StoredStatus(*auctionFilter.Status)
+	}
+
+	if auctionFilter.Condition != nil {
+		filter["condition"] = StoredCondition(*auctionFilter.Condition)
+	}
+
+	if auctionFilter.Category != "" {
+		filter["category"] = containsPattern(auctionFilter.Category)
+	}
+
+	if auctionFilter.ProductName != "" {
+		filter["product_name"] = containsPattern(auctionFilter.ProductName)
+	}
+
+	if auctionFilter.Query != "" {
+		filter["$or"] = bson.A{
+			bson.M{"product_name": containsPattern(auctionFilter.Query)},
+			bson.M{"category": containsPattern(auctionFilter.Query)},
+		}
+	}
+
+	return filter
+}
+
+// containsPattern matches values containing text, ignoring case. Regex
+// metacharacters in text are escaped, so user input is never run as a pattern.
+func containsPattern(text string) primitive.Regex {
+	return primitive.Regex{Pattern: regexp.QuoteMeta(text), Options: "i"}
+}
+
+// buildAuctionsSort returns the sort document for a listing, or nil to keep
+// the storage order. The id breaks ties so pages never overlap.
+func buildAuctionsSort(sort auction_entity.AuctionSort) bson.D {
+	switch sort {
+	case auction_entity.SortClosedDesc:
+		return bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: 1}}
+	case auction_entity.SortBidCountDesc:
+		// bid_count is computed by buildAuctionsPipeline
+		return bson.D{{Key: "bid_count", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}
+	case auction_entity.SortCreatedAsc:
+		return bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+	case auction_entity.SortCreatedDesc:
+		return bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}
+	case auction_entity.SortExpiresAsc:
+		return bson.D{{Key: "expires_at", Value: 1}, {Key: "_id", Value: 1}}
+	case auction_entity.SortExpiresDesc:
+		return bson.D{{Key: "expires_at", Value: -1}, {Key: "_id", Value: 1}}
+	default:
+		return nil
+	}
+}
+
+func toAuctionEntity(auctionEntityMongo AuctionEntityMongo) auction_entity.Auction {
+	// Auctions stored before updated_at existed were never changed since creation
+	updatedAt := auctionEntityMongo.UpdatedAt
+	if updatedAt == 0 {
+		updatedAt = auctionEntityMongo.CreatedAt
+	}
+
+	auction := auction_entity.Auction{
+		Id:          auctionEntityMongo.Id,
+		ProductName: auctionEntityMongo.ProductName,
+		Category:    auctionEntityMongo.Category,
+		Description: auctionEntityMongo.Description,
+		Condition:   auction_entity.ProductCondition(auctionEntityMongo.Condition),
+		Status:      auction_entity.AuctionStatus(auctionEntityMongo.Status),
+		CreatedAt:   time.Unix(auctionEntityMongo.CreatedAt, 0),
+		StartsAt:    time.Unix(auctionEntityMongo.StartsAt, 0),
+		ExpiresAt:   time.Unix(auctionEntityMongo.ExpiresAt, 0),
+		UpdatedAt:   time.Unix(updatedAt, 0),
+		Version:     auctionEntityMongo.Version,
+
+		AllowSelfOutbid: auctionEntityMongo.AllowSelfOutbid,
+		MinIncrement:    auctionEntityMongo.MinIncrement,
+		ReservePrice:    auctionEntityMongo.ReservePrice,
+		RelistedFrom:    auctionEntityMongo.RelistedFrom,
+	}
+
+	// A completed auction is not changed after the closer sets its status, so
+	// updated_at records when it closed. Without it, the expiration is the
+	// earliest it could have closed.
+	if auction.Status == auction_entity.Completed {
+		closedAt := auction.ExpiresAt
+		if auctionEntityMongo.UpdatedAt != 0 {
+			closedAt = auction.UpdatedAt
+		}
+		auction.ClosedAt = &closedAt
+	}
+
+	return auction
+}
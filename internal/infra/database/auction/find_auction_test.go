@@ -0,0 +1,843 @@
+package auction_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/rest_err"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// newAuctionRepository builds the repository from the current environment, so
+// tests can adjust settings with Setenv before constructing it.
+func newAuctionRepository(database *mongo.Database) *auction.AuctionRepository {
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		panic(err)
+	}
+	return auction.NewAuctionRepository(database, *appConfig)
+}
+
+// findFilterSent runs FindAuctions against a mocked deployment and returns the
+// filter document that was actually sent to MongoDB.
+func findFilterSent(mt *mtest.T, filter auction_entity.AuctionFilter) bson.Raw {
+	repo := newAuctionRepository(mt.DB)
+	mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+	_, err := repo.FindAuctions(mt.Context(), filter)
+	assert.Nil(mt, err)
+
+	return mt.GetStartedEvent().Command.Lookup("filter").Document()
+}
+
+func TestFindAuctionsFilter(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("empty filter only leaves deleted auctions out", func(mt *mtest.T) {
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{})
+
+		elements, _ := sent.Elements()
+		if assert.Len(mt, elements, 1) {
+			assert.False(mt, sent.Lookup("deleted_at", "$exists").Boolean())
+		}
+	})
+
+	mt.Run("active status is applied and not treated as unset", func(mt *mtest.T) {
+		status := auction_entity.Active
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{Status: &status})
+
+		assert.Equal(mt, int32(auction_entity.Active), sent.Lookup("status").Int32())
+		_, err := sent.LookupErr("condition")
+		assert.Error(mt, err)
+	})
+
+	mt.Run("unset status is not defaulted to active", func(mt *mtest.T) {
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{Category: "electronics"})
+
+		_, err := sent.LookupErr("status")
+		assert.Error(mt, err)
+		pattern, _ := sent.Lookup("category").Regex()
+		assert.Equal(mt, "electronics", pattern)
+	})
+
+	mt.Run("all criteria are composed into one query", func(mt *mtest.T) {
+		status := auction_entity.Completed
+		condition := auction_entity.Used
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{
+			Status:      &status,
+			Condition:   &condition,
+			Category:    "electronics",
+			ProductName: "iphone",
+		})
+
+		assert.Equal(mt, int32(auction_entity.Completed), sent.Lookup("status").Int32())
+		assert.Equal(mt, int32(auction_entity.Used), sent.Lookup("condition").Int32())
+
+		pattern, options := sent.Lookup("category").Regex()
+		assert.Equal(mt, "electronics", pattern)
+		assert.Equal(mt, "i", options)
+
+		pattern, options = sent.Lookup("product_name").Regex()
+		assert.Equal(mt, "iphone", pattern)
+		assert.Equal(mt, "i", options)
+	})
+
+	mt.Run("ids restrict the query to those auctions", func(mt *mtest.T) {
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{Ids: []string{"auction-1", "auction-2"}})
+
+		ids, _ := sent.Lookup("_id", "$in").Array().Values()
+		if assert.Len(mt, ids, 2) {
+			assert.Equal(mt, "auction-1", ids[0].StringValue())
+			assert.Equal(mt, "auction-2", ids[1].StringValue())
+		}
+	})
+
+	mt.Run("text criteria match case-insensitive substrings", func(mt *mtest.T) {
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{ProductName: "iphone"})
+
+		pattern, options := sent.Lookup("product_name").Regex()
+		assert.Equal(mt, "i", options)
+		matcher := regexp.MustCompile("(?" + options + ")" + pattern)
+		assert.True(mt, matcher.MatchString("Apple IPHONE 15"))
+		assert.False(mt, matcher.MatchString("Galaxy S24"))
+	})
+
+	mt.Run("regex metacharacters are matched literally", func(mt *mtest.T) {
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{ProductName: "a.b*", Category: "c++"})
+
+		pattern, options := sent.Lookup("product_name").Regex()
+		assert.Equal(mt, `a\.b\*`, pattern)
+		matcher := regexp.MustCompile("(?" + options + ")" + pattern)
+		assert.True(mt, matcher.MatchString("Item A.B* edition"))
+		assert.False(mt, matcher.MatchString("axbbb"))
+
+		pattern, _ = sent.Lookup("category").Regex()
+		assert.Equal(mt, `c\+\+`, pattern)
+	})
+
+	mt.Run("q matches the product name or the category", func(mt *mtest.T) {
+		sent := findFilterSent(mt, auction_entity.AuctionFilter{Query: "iPhone?"})
+
+		alternatives, _ := sent.Lookup("$or").Array().Values()
+		if assert.Len(mt, alternatives, 2) {
+			pattern, options := alternatives[0].Document().Lookup("product_name").Regex()
+			assert.Equal(mt, `iPhone\?`, pattern)
+			assert.Equal(mt, "i", options)
+
+			pattern, options = alternatives[1].Document().Lookup("category").Regex()
+			assert.Equal(mt, `iPhone\?`, pattern)
+			assert.Equal(mt, "i", options)
+		}
+	})
+}
+
+func TestFindAuctionsDecodesDocuments(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("no match is an empty slice", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		auctions, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{})
+
+		assert.Nil(mt, err)
+		assert.NotNil(mt, auctions)
+		assert.Empty(mt, auctions)
+	})
+
+	mt.Run("timestamps are converted back to time", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "product_name", Value: "iPhone"},
+			{Key: "category", Value: "electronics"},
+			{Key: "status", Value: int32(auction_entity.Active)},
+			{Key: "condition", Value: int32(auction_entity.Used)},
+			{Key: "created_at", Value: createdAt.Unix()},
+			{Key: "expires_at", Value: createdAt.Add(5 * time.Minute).Unix()},
+		}))
+
+		auctions, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{})
+
+		assert.Nil(mt, err)
+		if assert.Len(mt, auctions, 1) {
+			assert.Equal(mt, "auction-1", auctions[0].Id)
+			assert.Equal(mt, auction_entity.Used, auctions[0].Condition)
+			assert.True(mt, createdAt.Equal(auctions[0].CreatedAt))
+			assert.True(mt, createdAt.Add(5*time.Minute).Equal(auctions[0].ExpiresAt))
+		}
+	})
+
+	mt.Run("the relisted auction is linked back", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "auction-2"},
+			{Key: "status", Value: int32(auction_entity.Active)},
+			{Key: "relisted_from", Value: "auction-1"},
+		}))
+
+		auctions, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{})
+
+		assert.Nil(mt, err)
+		if assert.Len(mt, auctions, 1) {
+			assert.Equal(mt, "auction-1", auctions[0].RelistedFrom)
+		}
+	})
+
+	mt.Run("undecodable documents are an internal error", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "status", Value: "unknown"},
+		}))
+
+		_, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{})
+
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "internal_server_error", err.Err)
+		}
+	})
+}
+
+func TestFindAuctionsWithHighestBid(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("embeds the top bid and leaves auctions without bids empty", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: "auction-with-bids"},
+				{Key: "product_name", Value: "iPhone"},
+				{Key: "highest_bid", Value: bson.A{bson.D{
+					{Key: "_id", Value: "bid-1"},
+					{Key: "user_id", Value: "user-1"},
+					{Key: "auction_id", Value: "auction-with-bids"},
+					{Key: "amount_cents", Value: int64(25050)},
+					{Key: "timestamp", Value: int64(1700000000)},
+				}}},
+			},
+			bson.D{
+				{Key: "_id", Value: "auction-without-bids"},
+				{Key: "product_name", Value: "MacBook"},
+				{Key: "highest_bid", Value: bson.A{}},
+			},
+		))
+
+		auctions, err := repo.FindAuctionsWithHighestBid(mt.Context(), auction_entity.AuctionFilter{})
+		assert.Nil(mt, err)
+		assert.Len(mt, auctions, 2)
+
+		assert.Equal(mt, "auction-with-bids", auctions[0].Id)
+		assert.NotNil(mt, auctions[0].HighestBid)
+		assert.Equal(mt, "bid-1", auctions[0].HighestBid.Id)
+		assert.Equal(mt, "user-1", auctions[0].HighestBid.UserId)
+		assert.Equal(mt, 250.5, auctions[0].HighestBid.Amount())
+
+		assert.Equal(mt, "auction-without-bids", auctions[1].Id)
+		assert.Nil(mt, auctions[1].HighestBid)
+
+		command := mt.GetStartedEvent().Command
+		stages, _ := command.Lookup("pipeline").Array().Values()
+		assert.Len(mt, stages, 2)
+		lookup := stages[1].Document().Lookup("$lookup").Document()
+		assert.Equal(mt, "bids", lookup.Lookup("from").StringValue())
+		assert.Equal(mt, "highest_bid", lookup.Lookup("as").StringValue())
+
+		inner, _ := lookup.Lookup("pipeline").Array().Values()
+		amount := inner[1].Document().Lookup("$addFields", "amount_cents", "$ifNull").Array()
+		assert.Equal(mt, "$amount_cents", amount.Index(0).Value().StringValue())
+	})
+}
+
+func TestFindAuctionsSortedByDate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	oldest := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	documents := map[string]bson.D{}
+	for i, id := range []string{"first", "second", "third"} {
+		documents[id] = bson.D{
+			{Key: "_id", Value: id},
+			{Key: "created_at", Value: oldest.Add(time.Duration(i) * time.Hour).Unix()},
+			// Created later, but expiring sooner
+			{Key: "expires_at", Value: oldest.Add(time.Duration(10-i) * time.Hour).Unix()},
+		}
+	}
+
+	testCases := []struct {
+		name  string
+		sort  auction_entity.AuctionSort
+		field string
+		order int32
+		ids   []string
+	}{
+		{"created_at", auction_entity.SortCreatedAsc, "created_at", 1, []string{"first", "second", "third"}},
+		{"-created_at", auction_entity.SortCreatedDesc, "created_at", -1, []string{"third", "second", "first"}},
+		{"expires_at", auction_entity.SortExpiresAsc, "expires_at", 1, []string{"third", "second", "first"}},
+		{"-expires_at", auction_entity.SortExpiresDesc, "expires_at", -1, []string{"first", "second", "third"}},
+	}
+
+	for _, tc := range testCases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			repo := newAuctionRepository(mt.DB)
+
+			// The mock returns the documents in the order MongoDB would for
+			// the sort sent
+			batch := make([]bson.D, 0, len(tc.ids))
+			for _, id := range tc.ids {
+				batch = append(batch, documents[id])
+			}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, batch...))
+
+			auctions, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{Sort: tc.sort})
+			assert.Nil(mt, err)
+
+			sort := mt.GetStartedEvent().Command.Lookup("sort").Document()
+			elements, _ := sort.Elements()
+			if assert.Len(mt, elements, 2) {
+				assert.Equal(mt, tc.field, elements[0].Key())
+				assert.Equal(mt, tc.order, elements[0].Value().Int32())
+				assert.Equal(mt, "_id", elements[1].Key())
+			}
+
+			ids := make([]string, 0, len(auctions))
+			for i, found := range auctions {
+				ids = append(ids, found.Id)
+				if i == 0 {
+					continue
+				}
+				previous := auctions[i-1]
+				switch tc.sort {
+				case auction_entity.SortCreatedAsc:
+					assert.True(mt, previous.CreatedAt.Before(found.CreatedAt))
+				case auction_entity.SortCreatedDesc:
+					assert.True(mt, previous.CreatedAt.After(found.CreatedAt))
+				case auction_entity.SortExpiresAsc:
+					assert.True(mt, previous.ExpiresAt.Before(found.ExpiresAt))
+				case auction_entity.SortExpiresDesc:
+					assert.True(mt, previous.ExpiresAt.After(found.ExpiresAt))
+				}
+			}
+			assert.Equal(mt, tc.ids, ids)
+		})
+	}
+}
+
+func TestFindAuctionsWithHighestBidSortedByCloseTime(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("sorts and pages before joining the top bid", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		completed := auction_entity.Completed
+		_, err := repo.FindAuctionsWithHighestBid(mt.Context(), auction_entity.AuctionFilter{
+			Status: &completed,
+			Sort:   auction_entity.SortClosedDesc,
+			Skip:   20,
+			Limit:  10,
+		})
+		assert.Nil(mt, err)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+		assert.Len(mt, stages, 5)
+
+		sort := stages[1].Document().Lookup("$sort").Document()
+		elements, _ := sort.Elements()
+		if assert.Len(mt, elements, 2) {
+			assert.Equal(mt, "updated_at", elements[0].Key())
+			assert.Equal(mt, int32(-1), elements[0].Value().Int32())
+			assert.Equal(mt, "_id", elements[1].Key())
+		}
+		assert.Equal(mt, int64(20), stages[2].Document().Lookup("$skip").Int64())
+		assert.Equal(mt, int64(10), stages[3].Document().Lookup("$limit").Int64())
+		_, lookupErr := stages[4].Document().LookupErr("$lookup")
+		assert.NoError(mt, lookupErr)
+	})
+}
+
+func TestFindAuctionsSortedByBidCount(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("counts the bids and sorts before paging", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "most-bids"}},
+			bson.D{{Key: "_id", Value: "newer-tied"}},
+			bson.D{{Key: "_id", Value: "older-tied"}}))
+
+		auctions, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{
+			Sort:  auction_entity.SortBidCountDesc,
+			Skip:  20,
+			Limit: 10,
+		})
+		assert.Nil(mt, err)
+		if assert.Len(mt, auctions, 3) {
+			assert.Equal(mt, "most-bids", auctions[0].Id)
+			assert.Equal(mt, "newer-tied", auctions[1].Id)
+			assert.Equal(mt, "older-tied", auctions[2].Id)
+		}
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "aggregate", command.Index(0).Key())
+		stages, _ := command.Lookup("pipeline").Array().Values()
+		if !assert.Len(mt, stages, 7) {
+			return
+		}
+
+		// Each auction is joined with the count of its bids, not the bids
+		lookup := stages[1].Document().Lookup("$lookup").Document()
+		assert.Equal(mt, "bids", lookup.Lookup("from").StringValue())
+		probe, _ := lookup.Lookup("pipeline").Array().Values()
+		if assert.Len(mt, probe, 2) {
+			assert.Equal(mt, "total", probe[1].Document().Lookup("$count").StringValue())
+		}
+		_, addFieldsErr := stages[2].Document().LookupErr("$addFields", "bid_count")
+		assert.NoError(mt, addFieldsErr)
+
+		// Equal counts are ordered by creation, then id
+		sort := stages[3].Document().Lookup("$sort").Document()
+		elements, _ := sort.Elements()
+		if assert.Len(mt, elements, 3) {
+			assert.Equal(mt, "bid_count", elements[0].Key())
+			assert.Equal(mt, int32(-1), elements[0].Value().Int32())
+			assert.Equal(mt, "created_at", elements[1].Key())
+			assert.Equal(mt, int32(-1), elements[1].Value().Int32())
+			assert.Equal(mt, "_id", elements[2].Key())
+			assert.Equal(mt, int32(1), elements[2].Value().Int32())
+		}
+
+		assert.Equal(mt, int32(0), stages[4].Document().Lookup("$project", "bid_count").Int32())
+		assert.Equal(mt, int64(20), stages[5].Document().Lookup("$skip").Int64())
+		assert.Equal(mt, int64(10), stages[6].Document().Lookup("$limit").Int64())
+	})
+
+	mt.Run("reuses the count to keep only auctions with bids", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		_, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{
+			Sort:    auction_entity.SortBidCountDesc,
+			HasBids: true,
+		})
+		assert.Nil(mt, err)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+		if !assert.Len(mt, stages, 6) {
+			return
+		}
+		assert.Equal(mt, int32(0), stages[4].Document().Lookup("$match", "bid_count", "$gt").Int32())
+		assert.Equal(mt, int32(0), stages[5].Document().Lookup("$project", "bid_count").Int32())
+	})
+}
+
+func TestFindAuctionsWithBids(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("drops the auctions without bids before paging", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "auction-with-bids"}, {Key: "product_name", Value: "iPhone"}}))
+
+		auctions, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{
+			Category: "electronics",
+			HasBids:  true,
+			Limit:    10,
+		})
+		assert.Nil(mt, err)
+		if assert.Len(mt, auctions, 1) {
+			assert.Equal(mt, "auction-with-bids", auctions[0].Id)
+		}
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "aggregate", command.Index(0).Key())
+		stages, _ := command.Lookup("pipeline").Array().Values()
+		if !assert.Len(mt, stages, 5) {
+			return
+		}
+
+		category, _ := stages[0].Document().Lookup("$match", "category").Regex()
+		assert.Equal(mt, "electronics", category)
+
+		// Only the first bid of each auction is joined
+		lookup := stages[1].Document().Lookup("$lookup").Document()
+		assert.Equal(mt, "bids", lookup.Lookup("from").StringValue())
+		probe, _ := lookup.Lookup("pipeline").Array().Values()
+		if assert.Len(mt, probe, 3) {
+			assert.Equal(mt, int32(1), probe[1].Document().Lookup("$limit").Int32())
+		}
+
+		// Auctions whose lookup found no bid are excluded
+		empty, _ := stages[2].Document().Lookup("$match", "any_bid", "$ne").Array().Values()
+		assert.Empty(mt, empty)
+		assert.Equal(mt, int32(0), stages[3].Document().Lookup("$project", "any_bid").Int32())
+		assert.Equal(mt, int64(10), stages[4].Document().Lookup("$limit").Int64())
+	})
+
+	mt.Run("is a plain find without the flag", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		_, err := repo.FindAuctions(mt.Context(), auction_entity.AuctionFilter{Category: "electronics"})
+		assert.Nil(mt, err)
+
+		assert.Equal(mt, "find", mt.GetStartedEvent().Command.Index(0).Key())
+	})
+}
+
+func TestFindAuctionByIdSoftDeleted(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	auctionDocument := func(deletedAt int64) bson.D {
+		document := bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "product_name", Value: "Notebook"},
+			{Key: "status", Value: int32(auction_entity.Active)},
+		}
+		if deletedAt != 0 {
+			document = append(document, bson.E{Key: "deleted_at", Value: deletedAt})
+		}
+		return document
+	}
+
+	testCases := []struct {
+		name       string
+		response   string
+		err        string
+		httpStatus int
+	}{
+		{"404 by default", "", "not_found", http.StatusNotFound},
+		{"410 when configured", "gone", "gone", http.StatusGone},
+	}
+
+	for _, tc := range testCases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			mt.Setenv("DELETED_AUCTION_RESPONSE", tc.response)
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+				auctionDocument(time.Now().Unix())))
+
+			found, err := newAuctionRepository(mt.DB).FindAuctionById(mt.Context(), "auction-1")
+
+			assert.Nil(mt, found)
+			if assert.NotNil(mt, err) {
+				assert.Equal(mt, tc.err, err.Err)
+				assert.Equal(mt, internal_error.AuctionDeletedCode, err.Code)
+				assert.Equal(mt, tc.httpStatus, rest_err.ConvertError(err).Code)
+			}
+		})
+	}
+
+	mt.Run("auctions not deleted are returned", func(mt *mtest.T) {
+		mt.Setenv("DELETED_AUCTION_RESPONSE", "gone")
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			auctionDocument(0)))
+
+		found, err := newAuctionRepository(mt.DB).FindAuctionById(mt.Context(), "auction-1")
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, "Notebook", found.ProductName)
+	})
+}
+
+func TestFindAuctionByIdClosedAt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	expiresAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	auctionDocument := func(status auction_entity.AuctionStatus, updatedAt int64) bson.D {
+		return bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "status", Value: int32(status)},
+			{Key: "created_at", Value: expiresAt.Add(-time.Hour).Unix()},
+			{Key: "expires_at", Value: expiresAt.Unix()},
+			{Key: "updated_at", Value: updatedAt},
+		}
+	}
+
+	mt.Run("completed auctions closed at their last update", func(mt *mtest.T) {
+		closedAt := expiresAt.Add(7 * time.Second)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			auctionDocument(auction_entity.Completed, closedAt.Unix())))
+
+		found, err := newAuctionRepository(mt.DB).FindAuctionById(mt.Context(), "auction-1")
+
+		assert.Nil(mt, err)
+		if assert.NotNil(mt, found.ClosedAt) {
+			assert.True(mt, closedAt.Equal(*found.ClosedAt))
+			assert.False(mt, found.ClosedAt.Before(found.ExpiresAt))
+		}
+	})
+
+	mt.Run("completed auctions stored without updated_at closed at expiration", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			auctionDocument(auction_entity.Completed, 0)))
+
+		found, err := newAuctionRepository(mt.DB).FindAuctionById(mt.Context(), "auction-1")
+
+		assert.Nil(mt, err)
+		if assert.NotNil(mt, found.ClosedAt) {
+			assert.True(mt, expiresAt.Equal(*found.ClosedAt))
+		}
+	})
+
+	mt.Run("active auctions are not closed", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			auctionDocument(auction_entity.Active, expiresAt.Add(-time.Minute).Unix())))
+
+		found, err := newAuctionRepository(mt.DB).FindAuctionById(mt.Context(), "auction-1")
+
+		assert.Nil(mt, err)
+		assert.Nil(mt, found.ClosedAt)
+	})
+}
+
+func TestFindAuctionByIdErrors(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("missing auction is not found", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		found, err := newAuctionRepository(mt.DB).FindAuctionById(mt.Context(), "auction-1")
+
+		assert.Nil(mt, found)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "not_found", err.Err)
+			assert.Equal(mt, internal_error.AuctionNotFoundCode, err.Code)
+			assert.Equal(mt, http.StatusNotFound, rest_err.ConvertError(err).Code)
+		}
+	})
+
+	mt.Run("database failure is an internal error", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    91,
+			Name:    "ShutdownInProgress",
+			Message: "The server is in quiesce mode and will shut down",
+		}))
+
+		found, err := newAuctionRepository(mt.DB).FindAuctionById(mt.Context(), "auction-1")
+
+		assert.Nil(mt, found)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "internal_server_error", err.Err)
+			assert.Equal(mt, http.StatusInternalServerError, rest_err.ConvertError(err).Code)
+		}
+	})
+}
+
+func TestFindAuctionSummary(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("joins the highest bid and the bid count in one aggregation", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "product_name", Value: "iPhone"},
+			{Key: "status", Value: int32(auction_entity.Completed)},
+			{Key: "highest_bid", Value: bson.A{bson.D{
+				{Key: "_id", Value: "bid-1"},
+				{Key: "user_id", Value: "user-1"},
+				{Key: "auction_id", Value: "auction-1"},
+				{Key: "amount_cents", Value: int64(25050)},
+				{Key: "timestamp", Value: int64(1700000000)},
+			}}},
+			{Key: "bid_count", Value: int32(7)},
+		}))
+
+		summary, err := newAuctionRepository(mt.DB).FindAuctionSummary(mt.Context(), "auction-1")
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, "auction-1", summary.Id)
+		assert.Equal(mt, "iPhone", summary.ProductName)
+		assert.Equal(mt, auction_entity.Completed, summary.Status)
+		assert.Equal(mt, int64(7), summary.BidCount)
+		if assert.NotNil(mt, summary.HighestBid) {
+			assert.Equal(mt, "bid-1", summary.HighestBid.Id)
+			assert.Equal(mt, 250.5, summary.HighestBid.Amount())
+		}
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "aggregate", command.Index(0).Key())
+		stages, _ := command.Lookup("pipeline").Array().Values()
+		assert.Len(mt, stages, 4)
+		assert.Equal(mt, "auction-1", stages[0].Document().Lookup("$match", "_id").StringValue())
+		assert.Equal(mt, "highest_bid", stages[1].Document().Lookup("$lookup", "as").StringValue())
+		assert.Equal(mt, "bid_count", stages[2].Document().Lookup("$lookup", "as").StringValue())
+		// Nothing else was sent to the server
+		assert.Nil(mt, mt.GetStartedEvent())
+	})
+
+	mt.Run("auctions without bids have no highest bid", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "highest_bid", Value: bson.A{}},
+			{Key: "bid_count", Value: int32(0)},
+		}))
+
+		summary, err := newAuctionRepository(mt.DB).FindAuctionSummary(mt.Context(), "auction-1")
+
+		assert.Nil(mt, err)
+		assert.Nil(mt, summary.HighestBid)
+		assert.Zero(mt, summary.BidCount)
+	})
+
+	mt.Run("missing auction is not found", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		summary, err := newAuctionRepository(mt.DB).FindAuctionSummary(mt.Context(), "auction-1")
+
+		assert.Nil(mt, summary)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, internal_error.AuctionNotFoundCode, err.Code)
+		}
+	})
+
+	mt.Run("deleted auction", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "auction-1"},
+			{Key: "deleted_at", Value: time.Now().Unix()},
+		}))
+
+		summary, err := newAuctionRepository(mt.DB).FindAuctionSummary(mt.Context(), "auction-1")
+
+		assert.Nil(mt, summary)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, internal_error.AuctionDeletedCode, err.Code)
+		}
+	})
+}
+
+func TestFindAuctionStatuses(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("queries every id at once and leaves missing ones out", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "auction-1"}, {Key: "status", Value: auction_entity.Active}},
+			bson.D{{Key: "_id", Value: "auction-2"}, {Key: "status", Value: auction_entity.Completed}},
+		))
+
+		statuses, err := repo.FindAuctionStatuses(mt.Context(), []string{"auction-1", "missing", "auction-2"})
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, map[string]auction_entity.AuctionStatus{
+			"auction-1": auction_entity.Active,
+			"auction-2": auction_entity.Completed,
+		}, statuses)
+
+		ids, _ := mt.GetStartedEvent().Command.Lookup("filter", "_id", "$in").Array().Values()
+		assert.Len(mt, ids, 3)
+		assert.Equal(mt, "missing", ids[1].StringValue())
+	})
+}
+
+func TestFindTopAuctionsByCategory(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	auctionDocument := func(id string, amount float64) bson.D {
+		return bson.D{
+			{Key: "_id", Value: id},
+			{Key: "category", Value: "electronics"},
+			{Key: "highest_bid", Value: bson.A{bson.D{
+				{Key: "_id", Value: "bid-" + id},
+				{Key: "auction_id", Value: id},
+				{Key: "amount_cents", Value: int64(amount * 100)},
+			}}},
+		}
+	}
+
+	mt.Run("returns the ranked auctions followed by the pinned ones", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "top", Value: bson.A{auctionDocument("auction-1", 300), auctionDocument("auction-2", 200)}},
+			{Key: "pinned", Value: bson.A{auctionDocument("auction-2", 200), auctionDocument("auction-9", 10)}},
+		}))
+
+		auctions, err := repo.FindTopAuctionsByCategory(mt.Context(), "electronics", 2, []string{"auction-2", "auction-9"})
+
+		assert.Nil(mt, err)
+		assert.Len(mt, auctions, 3)
+		assert.Equal(mt, "auction-1", auctions[0].Id)
+		assert.Equal(mt, 300.0, auctions[0].HighestBid.Amount())
+		assert.Equal(mt, "auction-2", auctions[1].Id)
+		assert.Equal(mt, "auction-9", auctions[2].Id)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+		match := stages[0].Document().Lookup("$match").Document()
+		assert.Equal(mt, int32(auction_entity.Active), match.Lookup("status").Int32())
+		assert.Equal(mt, "electronics", match.Lookup("category").StringValue())
+
+		facet := stages[3].Document().Lookup("$facet").Document()
+		top, _ := facet.Lookup("top").Array().Values()
+		assert.Equal(mt, int64(2), top[1].Document().Lookup("$limit").Int64())
+	})
+
+	mt.Run("category without active auctions", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+			{Key: "top", Value: bson.A{}},
+			{Key: "pinned", Value: bson.A{}},
+		}))
+
+		auctions, err := repo.FindTopAuctionsByCategory(mt.Context(), "furniture", 10, nil)
+
+		assert.Nil(mt, err)
+		assert.Empty(mt, auctions)
+	})
+}
+
+func TestCountAuctions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("counts the matching documents", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{{Key: "n", Value: int32(7)}}))
+
+		total, err := repo.CountAuctions(mt.Context(), auction_entity.AuctionFilter{
+			Category: "electronics", Skip: 20, Limit: 10,
+		})
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(7), total)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+		if assert.NotEmpty(mt, stages) {
+			category, _ := stages[0].Document().Lookup("$match", "category").Regex()
+			assert.Equal(mt, "electronics", category)
+		}
+		for _, stage := range stages {
+			_, err := stage.Document().LookupErr("$skip")
+			assert.Error(mt, err)
+		}
+	})
+
+	mt.Run("counts the auctions with bids after the lookup", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+			bson.D{{Key: "total", Value: int64(3)}}))
+
+		total, err := repo.CountAuctions(mt.Context(), auction_entity.AuctionFilter{HasBids: true, Limit: 10})
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(3), total)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+		if assert.Len(mt, stages, 5) {
+			assert.Equal(mt, "total", stages[4].Document().Lookup("$count").StringValue())
+		}
+	})
+
+	mt.Run("is zero when nothing matches", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		total, err := repo.CountAuctions(mt.Context(), auction_entity.AuctionFilter{HasBids: true})
+		assert.Nil(mt, err)
+		assert.Zero(mt, total)
+	})
+}
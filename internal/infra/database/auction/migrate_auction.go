@@ -0,0 +1,90 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backfillBatchSize caps how many auctions a single bulk write updates
+const backfillBatchSize = 500
+
+// missingExpiresAt matches auctions stored before expires_at existed, whose
+// field is absent (or null or zero) and which the closer therefore never sees.
+var missingExpiresAt = bson.M{"$in": bson.A{nil, 0}}
+
+// BackfillExpiresAt sets expires_at to created_at + interval on the auctions
+// missing it and returns how many were updated. Running it again updates
+// nothing. Auctions without created_at are left alone.
+func (ar *AuctionRepository) BackfillExpiresAt(ctx context.Context, interval time.Duration) (int64, error) {
+	filter := bson.M{"expires_at": missingExpiresAt}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "created_at": 1, "expires_at": 1})
+
+	cursor, err := ar.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var updated int64
+	models := make([]mongo.WriteModel, 0, backfillBatchSize)
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+
+		result, err := ar.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return err
+		}
+
+		updated += result.ModifiedCount
+		models = models[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var document struct {
+			Id        string `bson:"_id"`
+			CreatedAt int64  `bson:"created_at"`
+			ExpiresAt int64  `bson:"expires_at"`
+		}
+		if err := cursor.Decode(&document); err != nil {
+			return updated, err
+		}
+
+		// Already backfilled (e.g. by a concurrent run) since the lookup
+		if document.ExpiresAt != 0 {
+			continue
+		}
+		if document.CreatedAt == 0 {
+			logger.Info(fmt.Sprintf("Auction %s has no created_at, expires_at not backfilled", document.Id))
+			continue
+		}
+
+		expiresAt := time.Unix(document.CreatedAt, 0).Add(interval).Unix()
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": document.Id, "expires_at": missingExpiresAt}).
+			SetUpdate(bson.M{"$set": bson.M{"expires_at": expiresAt}}))
+
+		if len(models) == backfillBatchSize {
+			if err := flush(); err != nil {
+				return updated, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return updated, err
+	}
+
+	if err := flush(); err != nil {
+		return updated, err
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,66 @@
+package auction_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestBackfillExpiresAt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("backfills only the auctions missing expires_at", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "missing-1"}, {Key: "created_at", Value: int64(1700000000)}},
+				bson.D{
+					{Key: "_id", Value: "present"},
+					{Key: "created_at", Value: int64(1700000000)},
+					{Key: "expires_at", Value: int64(1700000600)},
+				},
+				bson.D{{Key: "_id", Value: "missing-2"}, {Key: "created_at", Value: int64(1700001000)}},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+		)
+
+		updated, err := repo.BackfillExpiresAt(context.Background(), 5*time.Minute)
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(2), updated)
+
+		find := mt.GetStartedEvent()
+		assert.Equal(mt, "find", find.CommandName)
+		missing, _ := find.Command.Lookup("filter", "expires_at", "$in").Array().Values()
+		assert.Len(mt, missing, 2)
+		assert.Equal(mt, bson.TypeNull, missing[0].Type)
+
+		update := mt.GetStartedEvent()
+		assert.Equal(mt, "update", update.CommandName)
+		updates, _ := update.Command.Lookup("updates").Array().Values()
+		if assert.Len(mt, updates, 2) {
+			first := updates[0].Document()
+			assert.Equal(mt, "missing-1", first.Lookup("q", "_id").StringValue())
+			assert.Equal(mt, int64(1700000300), first.Lookup("u", "$set", "expires_at").Int64())
+
+			second := updates[1].Document()
+			assert.Equal(mt, "missing-2", second.Lookup("q", "_id").StringValue())
+			assert.Equal(mt, int64(1700001300), second.Lookup("u", "$set", "expires_at").Int64())
+		}
+	})
+
+	mt.Run("nothing to backfill", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		updated, err := repo.BackfillExpiresAt(context.Background(), 5*time.Minute)
+		assert.Nil(mt, err)
+		assert.Zero(mt, updated)
+
+		assert.Equal(mt, "find", mt.GetStartedEvent().CommandName)
+		assert.Nil(mt, mt.GetStartedEvent(), "no update without auctions to backfill")
+	})
+}
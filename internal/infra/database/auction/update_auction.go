@@ -0,0 +1,124 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateAuction persists the mutable fields of an auction using optimistic
+// concurrency: the update only applies if the stored version still equals
+// expectedVersion. When another writer (an admin action or the closer
+// routine) changed the auction first, a conflict error is returned so the
+// caller can reload the auction and retry. Auctions stored before the
+// version field existed are read as version 0 and match it while the field is
+// still absent. An auction marked by MarkAuctionHasBids is not updated either,
+// so an edit cannot race the first bid, and a missing or deleted auction is
+// reported as such.
+func (ar *AuctionRepository) UpdateAuction(
+	ctx context.Context,
+	auctionEntity *auction_entity.Auction,
+	expectedVersion int64) *internal_error.InternalError {
+	filter := bson.M{
+		"_id":        auctionEntity.Id,
+		"version":    versionFilter(expectedVersion),
+		"has_bids":   bson.M{"$ne": true},
+		"deleted_at": notDeleted,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     StoredStatus(auctionEntity.Status),
+			"expires_at": auctionEntity.ExpiresAt.Unix(),
+			"updated_at": auctionEntity.UpdatedAt.Unix(),
+			"version":    auctionEntity.Version,
+
+			"product_name":      auctionEntity.ProductName,
+			"category":          auctionEntity.Category,
+			"description":       auctionEntity.Description,
+			"condition":         StoredCondition(auctionEntity.Condition),
+			"allow_self_outbid": auctionEntity.AllowSelfOutbid,
+			"min_increment":     auctionEntity.MinIncrement,
+			"reserve_price":     auctionEntity.ReservePrice,
+		},
+	}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to update auction %s", auctionEntity.Id), err)
+		return internal_error.NewInternalServerError("Error trying to update auction").WithCause(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ar.explainUnmatched(ctx, auctionEntity.Id)
+	}
+
+	return nil
+}
+
+// MarkAuctionHasBids records that the auction, as read at version, is taking
+// bids, before its first bid is accepted. It fails with a conflict when the
+// auction was changed since it was read, so the bid is not validated against
+// stale rules, and with not found when the auction is missing or deleted.
+func (ar *AuctionRepository) MarkAuctionHasBids(
+	ctx context.Context, auctionId string, version int64) *internal_error.InternalError {
+	filter := bson.M{
+		"_id":        auctionId,
+		"version":    versionFilter(version),
+		"deleted_at": notDeleted,
+	}
+
+	result, err := ar.Collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"has_bids": true}})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to mark auction %s as having bids", auctionId), err)
+		return internal_error.NewInternalServerError("Error trying to place bid").WithCause(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ar.explainUnmatched(ctx, auctionId)
+	}
+
+	return nil
+}
+
+// explainUnmatched reports why a conditional update matched no auction: it
+// does not exist, it was deleted, it already has bids, or its version moved on.
+func (ar *AuctionRepository) explainUnmatched(ctx context.Context, auctionId string) *internal_error.InternalError {
+	var document AuctionEntityMongo
+	opts := options.FindOne().SetProjection(bson.M{"has_bids": 1, "deleted_at": 1})
+	if err := ar.Collection.FindOne(ctx, bson.M{"_id": auctionId}, opts).Decode(&document); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return internal_error.NewAuctionNotFoundError()
+		}
+		logger.Error(fmt.Sprintf("Error trying to find auction %s", auctionId), err)
+		return internal_error.NewInternalServerError("Error trying to find auction").WithCause(err)
+	}
+
+	if document.DeletedAt != 0 {
+		return internal_error.NewAuctionDeletedError(ar.deletedGone)
+	}
+
+	if document.HasBids {
+		return internal_error.NewConflictError("Auction cannot be edited once it has received bids")
+	}
+
+	return internal_error.NewConflictError(
+		"Auction was modified concurrently, reload it and try again")
+}
+
+// versionFilter matches the stored version of an auction read at version.
+// Version 0 also matches a missing field, as decoded from legacy documents.
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return version
+}
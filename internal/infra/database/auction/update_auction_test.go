@@ -0,0 +1,164 @@
+package auction_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestUpdateAuctionOptimisticConcurrency(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	newMutatedAuction := func() *auction_entity.Auction {
+		auctionEntity, _ := auction_entity.CreateAuction(
+			"Test Product", "electronics", "This is a test product description", auction_entity.New,
+			5*time.Minute)
+		auctionEntity.Status = auction_entity.Completed
+		auctionEntity.Touch()
+		return auctionEntity
+	}
+
+	mt.Run("applies the update when the version is current", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		auctionEntity := newMutatedAuction()
+		err := repo.UpdateAuction(mt.Context(), auctionEntity, 1)
+		assert.Nil(mt, err)
+
+		updates, _ := mt.GetStartedEvent().Command.Lookup("updates").Array().Values()
+		statement := updates[0].Document()
+		assert.Equal(mt, int64(1), statement.Lookup("q", "version").Int64())
+		assert.Equal(mt, int64(2), statement.Lookup("u", "$set", "version").Int64())
+	})
+
+	mt.Run("saves the editable fields", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		auctionEntity := newMutatedAuction()
+		auctionEntity.Description = "An edited test product description"
+		auctionEntity.ReservePrice = 250
+		err := repo.UpdateAuction(mt.Context(), auctionEntity, 1)
+		assert.Nil(mt, err)
+
+		updates, _ := mt.GetStartedEvent().Command.Lookup("updates").Array().Values()
+		set := updates[0].Document().Lookup("u", "$set").Document()
+		assert.Equal(mt, "Test Product", set.Lookup("product_name").StringValue())
+		assert.Equal(mt, "An edited test product description", set.Lookup("description").StringValue())
+		assert.Equal(mt, 250.0, set.Lookup("reserve_price").Double())
+		// A removed override is stored as null, which reads back as unset
+		assert.Equal(mt, bson.TypeNull, set.Lookup("allow_self_outbid").Type)
+	})
+
+	mt.Run("rejects a stale version with a conflict", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{{Key: "_id", Value: "auction-1"}}))
+
+		err := repo.UpdateAuction(mt.Context(), newMutatedAuction(), 1)
+		assert.NotNil(mt, err)
+		assert.Equal(mt, "conflict", err.Err)
+		assert.Equal(mt, "Auction was modified concurrently, reload it and try again", err.Message)
+	})
+
+	mt.Run("does not update an auction that has bids", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "auction-1"},
+				{Key: "has_bids", Value: true},
+			}))
+
+		err := repo.UpdateAuction(mt.Context(), newMutatedAuction(), 1)
+
+		updates, _ := mt.GetAllStartedEvents()[0].Command.Lookup("updates").Array().Values()
+		assert.Equal(mt, true, updates[0].Document().Lookup("q", "has_bids", "$ne").Boolean())
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "conflict", err.Err)
+			assert.Equal(mt, "Auction cannot be edited once it has received bids", err.Message)
+		}
+	})
+
+	mt.Run("reports a missing auction as not found", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		err := repo.UpdateAuction(mt.Context(), newMutatedAuction(), 1)
+		assert.NotNil(mt, err)
+		assert.Equal(mt, "not_found", err.Err)
+		assert.Equal(mt, internal_error.AuctionNotFoundCode, err.Code)
+	})
+
+	mt.Run("matches legacy auctions stored without a version", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		auctionEntity := newMutatedAuction()
+		auctionEntity.Version = 1
+		err := repo.UpdateAuction(mt.Context(), auctionEntity, 0)
+		assert.Nil(mt, err)
+
+		updates, _ := mt.GetStartedEvent().Command.Lookup("updates").Array().Values()
+		values, _ := updates[0].Document().Lookup("q", "version", "$in").Array().Values()
+		if assert.Len(mt, values, 2) {
+			assert.Equal(mt, int32(0), values[0].Int32())
+			assert.Equal(mt, bson.TypeNull, values[1].Type)
+		}
+	})
+}
+
+func TestMarkAuctionHasBids(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("marks the auction read at the version", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		err := repo.MarkAuctionHasBids(mt.Context(), "auction-1", 2)
+		assert.Nil(mt, err)
+
+		updates, _ := mt.GetStartedEvent().Command.Lookup("updates").Array().Values()
+		statement := updates[0].Document()
+		assert.Equal(mt, "auction-1", statement.Lookup("q", "_id").StringValue())
+		assert.Equal(mt, int64(2), statement.Lookup("q", "version").Int64())
+		assert.Equal(mt, true, statement.Lookup("u", "$set", "has_bids").Boolean())
+	})
+
+	mt.Run("rejects an auction changed since it was read", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{{Key: "_id", Value: "auction-1"}}))
+
+		err := repo.MarkAuctionHasBids(mt.Context(), "auction-1", 2)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "conflict", err.Err)
+		}
+	})
+
+	mt.Run("reports a missing auction as not found", func(mt *mtest.T) {
+		repo := newAuctionRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch))
+
+		err := repo.MarkAuctionHasBids(mt.Context(), "auction-1", 2)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, internal_error.AuctionNotFoundCode, err.Code)
+		}
+	})
+}
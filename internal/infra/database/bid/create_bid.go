@@ -2,10 +2,11 @@ package bid
 
 import (
 	"context"
-	"os"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
@@ -16,107 +17,152 @@ import (
 )
 
 type BidEntityMongo struct {
-	Id        string  `bson:"_id"`
-	UserId    string  `bson:"user_id"`
-	AuctionId string  `bson:"auction_id"`
-	Amount    float64 `bson:"amount"`
-	Timestamp int64   `bson:"timestamp"`
+	Id          string `bson:"_id"`
+	UserId      string `bson:"user_id"`
+	AuctionId   string `bson:"auction_id"`
+	AmountCents int64  `bson:"amount_cents"`
+	Timestamp   int64  `bson:"timestamp"` // Unix milliseconds
+
+	// LegacyAmount is the float amount of bids stored before amount_cents,
+	// until MigrateAmountsToCents converts it
+	LegacyAmount float64 `bson:"amount,omitempty"`
 }
 
 type BidRepository struct {
 	Collection            *mongo.Collection
 	AuctionRepository     *auction.AuctionRepository
-	auctionInterval       time.Duration
 	auctionStatusMap      map[string]auction_entity.AuctionStatus
 	auctionEndTimeMap     map[string]time.Time
 	auctionStatusMapMutex *sync.Mutex
 	auctionEndTimeMutex   *sync.Mutex
+	tiePolicy             bid_entity.TiePolicy // picks the winner among equal amounts
 }
 
-func NewBidRepository(database *mongo.Database, auctionRepository *auction.AuctionRepository) *BidRepository {
+func NewBidRepository(
+	database *mongo.Database, auctionRepository *auction.AuctionRepository, bidConfig config.BidConfig) *BidRepository {
 	return &BidRepository{
-		auctionInterval:       getAuctionInterval(),
 		auctionStatusMap:      make(map[string]auction_entity.AuctionStatus),
 		auctionEndTimeMap:     make(map[string]time.Time),
 		auctionStatusMapMutex: &sync.Mutex{},
 		auctionEndTimeMutex:   &sync.Mutex{},
 		Collection:            database.Collection("bids"),
 		AuctionRepository:     auctionRepository,
+		tiePolicy:             bidConfig.TiePolicy,
 	}
 }
 
+// AuctionsCompleted marks the auctions as completed in the status cache, so
+// the bids still queued for an auction closed before its expiration are
+// dropped instead of inserted after the close. It implements
+// auction_entity.AuctionCompletionListener.
+func (bd *BidRepository) AuctionsCompleted(auctionIds []string) {
+	bd.auctionStatusMapMutex.Lock()
+	defer bd.auctionStatusMapMutex.Unlock()
+
+	for _, auctionId := range auctionIds {
+		bd.auctionStatusMap[auctionId] = auction_entity.Completed
+	}
+}
+
+// bidOutcome is where insertBid puts a bid of the batch.
+type bidOutcome int
+
+const (
+	bidStored bidOutcome = iota
+	bidDropped
+	bidFailed
+)
+
 func (bd *BidRepository) CreateBid(
 	ctx context.Context,
-	bidEntities []bid_entity.Bid) *internal_error.InternalError {
+	bidEntities []bid_entity.Bid) (bid_entity.BidBatchResult, *internal_error.InternalError) {
+	outcomes := make([]bidOutcome, len(bidEntities))
+
 	var wg sync.WaitGroup
-	for _, bid := range bidEntities {
+	for i, bid := range bidEntities {
 		wg.Add(1)
-		go func(bidValue bid_entity.Bid) {
+		go func(i int, bidValue bid_entity.Bid) {
 			defer wg.Done()
+			outcomes[i] = bd.insertBid(ctx, bidValue)
+		}(i, bid)
+	}
+	wg.Wait()
 
-			bd.auctionStatusMapMutex.Lock()
-			auctionStatus, okStatus := bd.auctionStatusMap[bidValue.AuctionId]
-			bd.auctionStatusMapMutex.Unlock()
-
-			bd.auctionEndTimeMutex.Lock()
-			auctionEndTime, okEndTime := bd.auctionEndTimeMap[bidValue.AuctionId]
-			bd.auctionEndTimeMutex.Unlock()
-
-			bidEntityMongo := &BidEntityMongo{
-				Id:        bidValue.Id,
-				UserId:    bidValue.UserId,
-				AuctionId: bidValue.AuctionId,
-				Amount:    bidValue.Amount,
-				Timestamp: bidValue.Timestamp.Unix(),
-			}
-
-			if okEndTime && okStatus {
-				now := time.Now()
-				if auctionStatus == auction_entity.Completed || now.After(auctionEndTime) {
-					return
-				}
-
-				if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-					logger.Error("Error trying to insert bid", err)
-					return
-				}
+	var result bid_entity.BidBatchResult
+	for i, outcome := range outcomes {
+		switch outcome {
+		case bidStored:
+			result.Stored = append(result.Stored, bidEntities[i])
+		case bidDropped:
+			result.Dropped = append(result.Dropped, bidEntities[i])
+		default:
+			result.Failed = append(result.Failed, bidEntities[i])
+		}
+	}
 
-				return
-			}
+	if len(result.Failed) > 0 {
+		return result, internal_error.NewInternalServerError(fmt.Sprintf(
+			"Error trying to insert %d of %d bid(s)", len(result.Failed), len(bidEntities)))
+	}
+	return result, nil
+}
 
-			auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, bidValue.AuctionId)
-			if err != nil {
-				logger.Error("Error trying to find auction by id", err)
-				return
+// insertBid writes a bid unless its auction no longer takes bids. A bid
+// already stored by an earlier attempt is rejected by its unique _id and
+// counts as stored.
+func (bd *BidRepository) insertBid(ctx context.Context, bidValue bid_entity.Bid) bidOutcome {
+	bd.auctionStatusMapMutex.Lock()
+	auctionStatus, okStatus := bd.auctionStatusMap[bidValue.AuctionId]
+	bd.auctionStatusMapMutex.Unlock()
+
+	bd.auctionEndTimeMutex.Lock()
+	auctionEndTime, okEndTime := bd.auctionEndTimeMap[bidValue.AuctionId]
+	bd.auctionEndTimeMutex.Unlock()
+
+	if okEndTime && okStatus {
+		if auctionStatus == auction_entity.Completed || time.Now().After(auctionEndTime) {
+			return bidDropped
+		}
+	} else {
+		auctionEntity, err := bd.AuctionRepository.FindAuctionById(ctx, bidValue.AuctionId)
+		if err != nil {
+			// A missing or deleted auction takes no bids; a lookup failure
+			// is retried with the next flush
+			if err.IsNotFound() || err.Code == internal_error.AuctionDeletedCode {
+				return bidDropped
 			}
-			if auctionEntity.Status == auction_entity.Completed {
-				return
-			}
-
-			bd.auctionStatusMapMutex.Lock()
-			bd.auctionStatusMap[bidValue.AuctionId] = auctionEntity.Status
-			bd.auctionStatusMapMutex.Unlock()
+			logger.Error("Error trying to find auction by id", err)
+			return bidFailed
+		}
+		if auctionEntity.Status == auction_entity.Completed {
+			return bidDropped
+		}
+
+		bd.auctionStatusMapMutex.Lock()
+		bd.auctionStatusMap[bidValue.AuctionId] = auctionEntity.Status
+		bd.auctionStatusMapMutex.Unlock()
+
+		bd.auctionEndTimeMutex.Lock()
+		bd.auctionEndTimeMap[bidValue.AuctionId] = auctionEntity.ExpiresAt
+		bd.auctionEndTimeMutex.Unlock()
+	}
 
-			bd.auctionEndTimeMutex.Lock()
-			bd.auctionEndTimeMap[bidValue.AuctionId] = auctionEntity.ExpiresAt
-			bd.auctionEndTimeMutex.Unlock()
+	bidEntityMongo := &BidEntityMongo{
+		Id:        bidValue.Id,
+		UserId:    bidValue.UserId,
+		AuctionId: bidValue.AuctionId,
+		Timestamp: bidValue.Timestamp.UnixMilli(),
 
-			if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
-				logger.Error("Error trying to insert bid", err)
-				return
-			}
-		}(bid)
+		AmountCents: bidValue.AmountCents,
 	}
-	wg.Wait()
-	return nil
-}
 
-func getAuctionInterval() time.Duration {
-	auctionInterval := os.Getenv("AUCTION_INTERVAL")
-	duration, err := time.ParseDuration(auctionInterval)
-	if err != nil {
-		return time.Minute * 5
+	if _, err := bd.Collection.InsertOne(ctx, bidEntityMongo); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return bidStored
+		}
+		logger.Error("Error trying to insert bid", err)
+		return bidFailed
 	}
 
-	return duration
+	return bidStored
 }
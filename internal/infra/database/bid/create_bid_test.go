@@ -0,0 +1,113 @@
+package bid_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func auctionResponse(status int32) bson.D {
+	return mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+		{Key: "_id", Value: "auction-1"},
+		{Key: "status", Value: status},
+		{Key: "expires_at", Value: time.Now().Add(time.Hour).Unix()},
+	})
+}
+
+func TestCreateBidReportsEachOutcome(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	placed := []bid_entity.Bid{{Id: "bid-1", UserId: "user-1", AuctionId: "auction-1", AmountCents: 10000, Timestamp: time.Now()}}
+
+	mt.Run("stored", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(auctionResponse(0), mtest.CreateSuccessResponse())
+
+		result, err := repo.CreateBid(mt.Context(), placed)
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, placed, result.Stored)
+		assert.Empty(mt, result.Failed)
+	})
+
+	mt.Run("insert failure is returned, not swallowed", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(auctionResponse(0), mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 91, Name: "ShutdownInProgress", Message: "shutting down",
+		}))
+
+		result, err := repo.CreateBid(mt.Context(), placed)
+
+		assert.NotNil(mt, err)
+		assert.Empty(mt, result.Stored)
+		assert.Equal(mt, placed, result.Failed)
+	})
+
+	mt.Run("bid stored by an earlier attempt", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(auctionResponse(0), mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index: 0, Code: 11000, Message: "duplicate key",
+		}))
+
+		result, err := repo.CreateBid(mt.Context(), placed)
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, placed, result.Stored)
+	})
+
+	mt.Run("completed auction drops the bid", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(auctionResponse(1))
+
+		result, err := repo.CreateBid(mt.Context(), placed)
+
+		assert.Nil(mt, err)
+		assert.Empty(mt, result.Stored)
+		assert.Equal(mt, placed, result.Dropped)
+	})
+
+	mt.Run("auction lookup failure", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 91, Name: "ShutdownInProgress", Message: "shutting down",
+		}))
+
+		result, err := repo.CreateBid(mt.Context(), placed)
+
+		assert.NotNil(mt, err)
+		assert.Equal(mt, placed, result.Failed)
+	})
+}
+
+func TestCreateBidDropsBidsOfAuctionClosedEarly(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("queued bid after the close", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(auctionResponse(0), mtest.CreateSuccessResponse())
+
+		// The first bid caches the auction as active
+		first := bid_entity.Bid{Id: "bid-1", UserId: "user-1", AuctionId: "auction-1", AmountCents: 10000, Timestamp: time.Now()}
+		_, err := repo.CreateBid(mt.Context(), []bid_entity.Bid{first})
+		assert.Nil(mt, err)
+
+		repo.AuctionsCompleted([]string{"auction-1"})
+
+		queued := bid_entity.Bid{Id: "bid-2", UserId: "user-2", AuctionId: "auction-1", AmountCents: 20000, Timestamp: time.Now()}
+		result, err := repo.CreateBid(mt.Context(), []bid_entity.Bid{queued})
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, []bid_entity.Bid{queued}, result.Dropped)
+		assert.Empty(mt, result.Stored)
+		inserts := 0
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName == "insert" {
+				inserts++
+			}
+		}
+		assert.Equal(mt, 1, inserts)
+	})
+}
@@ -0,0 +1,61 @@
+package bid
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RejectedBidEntityMongo struct {
+	Id        string  `bson:"_id"`
+	UserId    string  `bson:"user_id"`
+	AuctionId string  `bson:"auction_id"`
+	Amount    float64 `bson:"amount"`
+	Reason    string  `bson:"reason"`
+	Message   string  `bson:"message"`
+	Timestamp int64   `bson:"timestamp"`
+}
+
+// RejectedBidRepository stores refused bid attempts apart from the bids, so
+// they never affect winners or rankings.
+type RejectedBidRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewRejectedBidRepository(database *mongo.Database) *RejectedBidRepository {
+	return &RejectedBidRepository{
+		Collection: database.Collection("rejected_bids"),
+	}
+}
+
+func (rr *RejectedBidRepository) CreateRejectedBids(
+	ctx context.Context,
+	rejectedBids []bid_entity.RejectedBid) *internal_error.InternalError {
+	if len(rejectedBids) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(rejectedBids))
+	for _, rejectedBid := range rejectedBids {
+		documents = append(documents, RejectedBidEntityMongo{
+			Id:        rejectedBid.Id,
+			UserId:    rejectedBid.UserId,
+			AuctionId: rejectedBid.AuctionId,
+			Amount:    rejectedBid.Amount,
+			Reason:    rejectedBid.Reason,
+			Message:   rejectedBid.Message,
+			Timestamp: rejectedBid.Timestamp.Unix(),
+		})
+	}
+
+	if _, err := rr.Collection.InsertMany(ctx, documents); err != nil {
+		logger.Error("Error trying to insert rejected bids", err)
+		return internal_error.NewInternalServerError("Error trying to insert rejected bids").WithCause(err)
+	}
+
+	return nil
+}
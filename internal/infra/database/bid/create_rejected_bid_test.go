@@ -0,0 +1,47 @@
+package bid_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/bid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCreateRejectedBids(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("stores attempts in their own collection", func(mt *mtest.T) {
+		repo := bid.NewRejectedBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := repo.CreateRejectedBids(mt.Context(), []bid_entity.RejectedBid{{
+			Id:        "rejected-1",
+			UserId:    "user-1",
+			AuctionId: "auction-1",
+			Amount:    50,
+			Reason:    "too_low",
+			Message:   "Bid must be higher than current highest bid",
+			Timestamp: time.Unix(1700000000, 0),
+		}})
+
+		assert.Nil(mt, err)
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "rejected_bids", command.Lookup("insert").StringValue())
+
+		documents, _ := command.Lookup("documents").Array().Values()
+		assert.Len(mt, documents, 1)
+		assert.Equal(mt, "too_low", documents[0].Document().Lookup("reason").StringValue())
+		assert.Equal(mt, int64(1700000000), documents[0].Document().Lookup("timestamp").Int64())
+	})
+
+	mt.Run("nothing to store", func(mt *mtest.T) {
+		repo := bid.NewRejectedBidRepository(mt.DB)
+
+		assert.Nil(mt, repo.CreateRejectedBids(mt.Context(), nil))
+		assert.Nil(mt, mt.GetStartedEvent())
+	})
+}
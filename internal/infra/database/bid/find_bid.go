@@ -9,6 +9,7 @@ import (
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -16,12 +17,14 @@ func (bd *BidRepository) FindBidByAuctionId(
 	ctx context.Context, auctionId string) ([]bid_entity.Bid, *internal_error.InternalError) {
 	filter := bson.M{"auction_id": auctionId}
 
-	cursor, err := bd.Collection.Find(ctx, filter)
+	// Chronological, with the id settling bids stored in the same millisecond
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := bd.Collection.Find(ctx, filter, opts)
 	if err != nil {
 		logger.Error(
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
 		return nil, internal_error.NewInternalServerError(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).WithCause(err)
 	}
 
 	var bidEntitiesMongo []BidEntityMongo
@@ -29,39 +32,240 @@ func (bd *BidRepository) FindBidByAuctionId(
 		logger.Error(
 			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
 		return nil, internal_error.NewInternalServerError(
-			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId))
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).WithCause(err)
 	}
 
 	var bidEntities []bid_entity.Bid
 	for _, bidEntityMongo := range bidEntitiesMongo {
-		bidEntities = append(bidEntities, bid_entity.Bid{
-			Id:        bidEntityMongo.Id,
-			UserId:    bidEntityMongo.UserId,
-			AuctionId: bidEntityMongo.AuctionId,
-			Amount:    bidEntityMongo.Amount,
-			Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
-		})
+		bidEntities = append(bidEntities, toBidEntity(bidEntityMongo))
 	}
 
 	return bidEntities, nil
 }
 
+func (bd *BidRepository) FindBidsPageByAuctionId(
+	ctx context.Context, auctionId string, offset, limit int64) ([]bid_entity.Bid, int64, *internal_error.InternalError) {
+	filter := bson.M{"auction_id": auctionId}
+
+	total, err := bd.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to count bids by auctionId %s", auctionId), err)
+		return nil, 0, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).WithCause(err)
+	}
+
+	// The id breaks timestamp ties so pages never overlap
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	cursor, err := bd.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
+		return nil, 0, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).WithCause(err)
+	}
+
+	var bidEntitiesMongo []BidEntityMongo
+	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId), err)
+		return nil, 0, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by auctionId %s", auctionId)).WithCause(err)
+	}
+
+	bidEntities := make([]bid_entity.Bid, 0, len(bidEntitiesMongo))
+	for _, bidEntityMongo := range bidEntitiesMongo {
+		bidEntities = append(bidEntities, toBidEntity(bidEntityMongo))
+	}
+
+	return bidEntities, total, nil
+}
+
+// storedAmountCents is the amount of a bid in cents as an aggregation
+// expression, converting the float amount of bids not migrated yet like
+// MigrateAmountsToCents does, so they rank and count like the migrated ones.
+var storedAmountCents = bson.M{"$ifNull": bson.A{"$amount_cents", bson.M{"$toLong": bson.M{
+	"$round": bson.A{bson.M{"$multiply": bson.A{"$amount", 100}}, 0},
+}}}}
+
 func (bd *BidRepository) FindWinningBidByAuctionId(
 	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
-	filter := bson.M{"auction_id": auctionId}
+	// Ties on amount are resolved by the same policy that validates bids.
+	// The id settles bids stored in the same millisecond the same way on
+	// every call.
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"auction_id": auctionId}},
+		bson.M{"$addFields": bson.M{"amount_cents": storedAmountCents}},
+		bson.M{"$sort": bson.D{
+			{Key: "amount_cents", Value: -1},
+			{Key: "timestamp", Value: bd.tiePolicy.TimestampSortOrder()},
+			{Key: "_id", Value: 1},
+		}},
+		bson.M{"$limit": 1},
+	}
 
-	var bidEntityMongo BidEntityMongo
-	opts := options.FindOne().SetSort(bson.D{{"amount", -1}})
-	if err := bd.Collection.FindOne(ctx, filter, opts).Decode(&bidEntityMongo); err != nil {
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
 		logger.Error("Error trying to find the auction winner", err)
-		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner")
+		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner").WithCause(err)
+	}
+	defer cursor.Close(ctx)
+
+	var bidEntitiesMongo []BidEntityMongo
+	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
+		logger.Error("Error trying to find the auction winner", err)
+		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner").WithCause(err)
+	}
+	if len(bidEntitiesMongo) == 0 {
+		return nil, internal_error.NewNotFoundError("No bids found for the auction")
+	}
+
+	bidEntity := toBidEntity(bidEntitiesMongo[0])
+	return &bidEntity, nil
+}
+
+func (bd *BidRepository) StreamBidsByAuctionId(
+	ctx context.Context, auctionId string) (<-chan bid_entity.Bid, *internal_error.InternalError) {
+	filter := bson.M{"auction_id": auctionId}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := bd.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to stream bids by auctionId %s", auctionId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to stream bids by auctionId %s", auctionId)).WithCause(err)
+	}
+
+	bids := make(chan bid_entity.Bid)
+	go func() {
+		defer close(bids)
+		defer closeCursor(cursor, auctionId)
+
+		// Checking ctx first stops without another getMore once the
+		// consumer is gone
+		for ctx.Err() == nil && cursor.Next(ctx) {
+			var bidEntityMongo BidEntityMongo
+			if err := cursor.Decode(&bidEntityMongo); err != nil {
+				logger.Error(
+					fmt.Sprintf("Error trying to decode bid streamed for auctionId %s", auctionId), err)
+				return
+			}
+
+			select {
+			case bids <- toBidEntity(bidEntityMongo):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := cursor.Err(); err != nil && ctx.Err() == nil {
+			logger.Error(
+				fmt.Sprintf("Error trying to stream bids by auctionId %s", auctionId), err)
+		}
+	}()
+
+	return bids, nil
+}
+
+// cursorCloseTimeout bounds how long releasing a streaming cursor may take.
+const cursorCloseTimeout = 5 * time.Second
+
+// closeCursor releases the server-side cursor of a stream. It uses its own
+// context, since the stream's one is usually cancelled by then.
+func closeCursor(cursor *mongo.Cursor, auctionId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cursorCloseTimeout)
+	defer cancel()
+
+	if err := cursor.Close(ctx); err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to close bid stream for auctionId %s", auctionId), err)
+	}
+}
+
+func (bd *BidRepository) CountBidsAboveAmount(
+	ctx context.Context, auctionId string, amountCents int64) (int64, *internal_error.InternalError) {
+	filter := bson.M{
+		"auction_id": auctionId,
+		"$expr":      bson.M{"$gt": bson.A{storedAmountCents, amountCents}},
+	}
+
+	count, err := bd.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to count bids above %.2f for auctionId %s",
+			bid_entity.FromCents(amountCents), auctionId), err)
+		return 0, internal_error.NewInternalServerError("Error trying to count bids").WithCause(err)
+	}
+
+	return count, nil
+}
+
+func (bd *BidRepository) FindHighestBidsByUserId(
+	ctx context.Context, userId string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	// The first bid of each auction, by amount then time, is the user's highest
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"user_id": userId}},
+		bson.M{"$addFields": bson.M{"amount_cents": storedAmountCents}},
+		bson.M{"$sort": bson.D{{Key: "amount_cents", Value: -1}, {Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$group": bson.M{"_id": "$auction_id", "bid": bson.M{"$first": "$$ROOT"}}},
+		bson.M{"$replaceRoot": bson.M{"newRoot": "$bid"}},
+	}
+
+	cursor, err := bd.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to find bids by userId %s", userId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by userId %s", userId)).WithCause(err)
+	}
+	defer cursor.Close(ctx)
+
+	var bidEntitiesMongo []BidEntityMongo
+	if err := cursor.All(ctx, &bidEntitiesMongo); err != nil {
+		logger.Error(
+			fmt.Sprintf("Error trying to decode bids by userId %s", userId), err)
+		return nil, internal_error.NewInternalServerError(
+			fmt.Sprintf("Error trying to find bids by userId %s", userId)).WithCause(err)
+	}
+
+	bidEntities := make([]bid_entity.Bid, 0, len(bidEntitiesMongo))
+	for _, bidEntityMongo := range bidEntitiesMongo {
+		bidEntities = append(bidEntities, toBidEntity(bidEntityMongo))
 	}
 
-	return &bid_entity.Bid{
+	return bidEntities, nil
+}
+
+func toBidEntity(bidEntityMongo BidEntityMongo) bid_entity.Bid {
+	return bid_entity.Bid{
 		Id:        bidEntityMongo.Id,
 		UserId:    bidEntityMongo.UserId,
 		AuctionId: bidEntityMongo.AuctionId,
-		Amount:    bidEntityMongo.Amount,
-		Timestamp: time.Unix(bidEntityMongo.Timestamp, 0),
-	}, nil
+		Timestamp: fromStoredTimestamp(bidEntityMongo.Timestamp),
+
+		AmountCents: fromStoredAmount(bidEntityMongo.AmountCents, bidEntityMongo.LegacyAmount),
+	}
+}
+
+// fromStoredAmount returns the amount of a bid in cents, converting the
+// float amount of bids not migrated yet.
+func fromStoredAmount(amountCents int64, legacyAmount float64) int64 {
+	if amountCents == 0 && legacyAmount != 0 {
+		return bid_entity.ToCents(legacyAmount)
+	}
+	return amountCents
+}
+
+// fromStoredTimestamp rebuilds a bid timestamp stored in Unix milliseconds.
+// Bids stored before that are in seconds until migrated, and are told apart
+// by being below legacySecondsLimit.
+func fromStoredTimestamp(timestamp int64) time.Time {
+	if timestamp < legacySecondsLimit {
+		return time.Unix(timestamp, 0)
+	}
+	return time.UnixMilli(timestamp)
 }
@@ -0,0 +1,419 @@
+package bid_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/bid"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// newBidRepository builds the repository from the current environment, so
+// tests can adjust settings with Setenv before constructing it.
+func newBidRepository(database *mongo.Database) *bid.BidRepository {
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		panic(err)
+	}
+	return bid.NewBidRepository(database, auction.NewAuctionRepository(database, *appConfig), appConfig.Bid)
+}
+
+func TestFindWinningBidFollowsTiePolicy(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	testCases := []struct {
+		policy         string
+		timestampOrder int32
+	}{
+		{"reject_equal", 1},     // earliest bid at the top amount wins
+		{"last_write_wins", -1}, // latest bid at the top amount wins
+	}
+
+	for _, tc := range testCases {
+		mt.Run(tc.policy, func(mt *mtest.T) {
+			mt.Setenv("BID_TIE_POLICY", tc.policy)
+
+			repo := newBidRepository(mt.DB)
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "bid-1"},
+				{Key: "auction_id", Value: "auction-1"},
+				{Key: "amount_cents", Value: int64(10000)},
+			}))
+
+			winner, err := repo.FindWinningBidByAuctionId(mt.Context(), "auction-1")
+			assert.Nil(mt, err)
+			assert.Equal(mt, "bid-1", winner.Id)
+
+			stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+			if !assert.Len(mt, stages, 4) {
+				return
+			}
+			assert.Equal(mt, "auction-1", stages[0].Document().Lookup("$match", "auction_id").StringValue())
+			assertConvertsLegacyAmount(mt, stages[1].Document().Lookup("$addFields", "amount_cents").Document())
+
+			elements, _ := stages[2].Document().Lookup("$sort").Document().Elements()
+			if assert.Len(mt, elements, 3) {
+				assert.Equal(mt, "amount_cents", elements[0].Key())
+				assert.Equal(mt, int32(-1), elements[0].Value().Int32())
+				assert.Equal(mt, "timestamp", elements[1].Key())
+				assert.Equal(mt, tc.timestampOrder, elements[1].Value().Int32())
+				// Bids tied on amount within the same second
+				assert.Equal(mt, "_id", elements[2].Key())
+				assert.Equal(mt, int32(1), elements[2].Value().Int32())
+			}
+		})
+	}
+}
+
+// assertConvertsLegacyAmount checks that amount falls back to the float amount
+// of the bids not migrated to cents yet.
+func assertConvertsLegacyAmount(mt *mtest.T, amount bson.Raw) {
+	mt.Helper()
+	ifNull, _ := amount.Lookup("$ifNull").Array().Values()
+	if assert.Len(mt, ifNull, 2) {
+		assert.Equal(mt, "$amount_cents", ifNull[0].StringValue())
+		round, _ := ifNull[1].Document().Lookup("$toLong", "$round").Array().Values()
+		multiply, _ := round[0].Document().Lookup("$multiply").Array().Values()
+		assert.Equal(mt, "$amount", multiply[0].StringValue())
+		assert.Equal(mt, int32(100), multiply[1].Int32())
+	}
+}
+
+func TestFindWinningBidRanksLegacyAmounts(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("bid stored before amount_cents", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		// MongoDB returns the converted amount_cents next to the float amount
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "bid-legacy"},
+			{Key: "auction_id", Value: "auction-1"},
+			{Key: "amount", Value: 250.5},
+			{Key: "amount_cents", Value: int64(25050)},
+		}))
+
+		winner, err := repo.FindWinningBidByAuctionId(mt.Context(), "auction-1")
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, "bid-legacy", winner.Id)
+		assert.Equal(mt, int64(25050), winner.AmountCents)
+	})
+}
+
+func TestCountBidsAboveAmountCountsLegacyAmounts(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("compares the converted amount", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch,
+			bson.D{{Key: "n", Value: int32(2)}}))
+
+		count, err := repo.CountBidsAboveAmount(mt.Context(), "auction-1", 10000)
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(2), count)
+
+		stages, _ := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Values()
+		match := stages[0].Document().Lookup("$match").Document()
+		assert.Equal(mt, "auction-1", match.Lookup("auction_id").StringValue())
+		gt, _ := match.Lookup("$expr", "$gt").Array().Values()
+		if assert.Len(mt, gt, 2) {
+			assertConvertsLegacyAmount(mt, gt[0].Document())
+			assert.Equal(mt, int64(10000), gt[1].Int64())
+		}
+	})
+}
+
+func TestFindWinningBidWithoutBids(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("reports not found", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch))
+
+		winner, err := repo.FindWinningBidByAuctionId(mt.Context(), "auction-1")
+		assert.Nil(mt, winner)
+		assert.NotNil(mt, err)
+		assert.True(mt, err.IsNotFound())
+	})
+}
+
+func bidDocument(id string, amountCents int64, timestamp int64) bson.D {
+	return bson.D{
+		{Key: "_id", Value: id},
+		{Key: "user_id", Value: "user-1"},
+		{Key: "auction_id", Value: "auction-1"},
+		{Key: "amount_cents", Value: amountCents},
+		{Key: "timestamp", Value: timestamp},
+	}
+}
+
+func TestStreamBidsByAuctionId(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("sends every bid across batches and closes the channel", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(42, "db.bids", mtest.FirstBatch,
+				bidDocument("bid-1", 10000, 1700000001),
+				bidDocument("bid-2", 20000, 1700000002)),
+			mtest.CreateCursorResponse(0, "db.bids", mtest.NextBatch,
+				bidDocument("bid-3", 30000, 1700000003)),
+		)
+
+		stream, err := repo.StreamBidsByAuctionId(mt.Context(), "auction-1")
+		assert.Nil(mt, err)
+
+		var ids []string
+		for bid := range stream {
+			ids = append(ids, bid.Id)
+		}
+		assert.Equal(mt, []string{"bid-1", "bid-2", "bid-3"}, ids)
+
+		find := mt.GetStartedEvent()
+		assert.Equal(mt, "find", find.CommandName)
+		assert.Equal(mt, "auction-1", find.Command.Lookup("filter", "auction_id").StringValue())
+		assert.Equal(mt, int32(1), find.Command.Lookup("sort", "timestamp").Int32())
+		assert.Equal(mt, "getMore", mt.GetStartedEvent().CommandName)
+	})
+
+	mt.Run("closes the channel when the consumer cancels", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch,
+			bidDocument("bid-1", 10000, 1700000001),
+			bidDocument("bid-2", 20000, 1700000002)))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := repo.StreamBidsByAuctionId(ctx, "auction-1")
+		assert.Nil(mt, err)
+
+		<-stream
+		cancel()
+
+		// At most the bid already being sent is delivered before closing
+		received := 0
+		for range stream {
+			received++
+		}
+		assert.LessOrEqual(mt, received, 1)
+	})
+
+	mt.Run("kills the open cursor when cancelled mid-stream", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(42, "db.bids", mtest.FirstBatch,
+				bidDocument("bid-1", 10000, 1700000001),
+				bidDocument("bid-2", 20000, 1700000002)),
+			mtest.CreateSuccessResponse(),
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := repo.StreamBidsByAuctionId(ctx, "auction-1")
+		assert.Nil(mt, err)
+
+		<-stream
+		cancel()
+
+		// The channel only closes after the stream goroutine released the cursor
+		for range stream {
+		}
+
+		assert.Equal(mt, "find", mt.GetStartedEvent().CommandName)
+		killCursors := mt.GetStartedEvent()
+		if assert.NotNil(mt, killCursors) {
+			assert.Equal(mt, "killCursors", killCursors.CommandName)
+			ids, _ := killCursors.Command.Lookup("cursors").Array().Values()
+			if assert.Len(mt, ids, 1) {
+				assert.Equal(mt, int64(42), ids[0].Int64())
+			}
+		}
+		assert.Nil(mt, mt.GetStartedEvent())
+	})
+}
+
+func TestFindBidsPageByAuctionId(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("returns the page and the total", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(12)}}),
+			mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch,
+				bidDocument("bid-11", 11000, 1700000011), bidDocument("bid-12", 12000, 1700000012)))
+
+		bids, total, err := repo.FindBidsPageByAuctionId(mt.Context(), "auction-1", 10, 5)
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(12), total)
+		if assert.Len(mt, bids, 2) {
+			assert.Equal(mt, "bid-11", bids[0].Id)
+			assert.Equal(mt, 120.0, bids[1].Amount())
+		}
+
+		// The count is sent first, without paging
+		assert.Equal(mt, "aggregate", mt.GetStartedEvent().Command.Index(0).Key())
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "find", command.Index(0).Key())
+		assert.Equal(mt, int64(10), command.Lookup("skip").Int64())
+		assert.Equal(mt, int64(5), command.Lookup("limit").Int64())
+		sort, _ := command.Lookup("sort").Document().Elements()
+		if assert.Len(mt, sort, 2) {
+			assert.Equal(mt, "timestamp", sort[0].Key())
+			assert.Equal(mt, "_id", sort[1].Key())
+		}
+	})
+
+	mt.Run("auction without bids is an empty page", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch))
+
+		bids, total, err := repo.FindBidsPageByAuctionId(mt.Context(), "auction-1", 0, 20)
+		assert.Nil(mt, err)
+		assert.Zero(mt, total)
+		assert.NotNil(mt, bids)
+		assert.Empty(mt, bids)
+	})
+}
+
+func TestFindBidByAuctionIdKeepsSameSecondOrder(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("bids placed within one second come back in insertion order", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.auctions", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "auction-1"},
+				{Key: "status", Value: int32(0)},
+				{Key: "expires_at", Value: time.Now().Add(time.Hour).Unix()},
+			}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		second := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		placed := []bid_entity.Bid{
+			{Id: "bid-c", UserId: "user-1", AuctionId: "auction-1", AmountCents: 10000, Timestamp: second.Add(100 * time.Millisecond)},
+			{Id: "bid-a", UserId: "user-2", AuctionId: "auction-1", AmountCents: 11000, Timestamp: second.Add(450 * time.Millisecond)},
+			{Id: "bid-b", UserId: "user-1", AuctionId: "auction-1", AmountCents: 12000, Timestamp: second.Add(900 * time.Millisecond)},
+		}
+		for _, placedBid := range placed {
+			_, err := repo.CreateBid(mt.Context(), []bid_entity.Bid{placedBid})
+			assert.Nil(mt, err)
+		}
+
+		// The stored documents are what the bids collection returns
+		var stored []bson.D
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName != "insert" {
+				continue
+			}
+			document := event.Command.Lookup("documents").Array().Index(0).Value().Document()
+			stored = append(stored, bidDocument(
+				document.Lookup("_id").StringValue(),
+				document.Lookup("amount_cents").Int64(),
+				document.Lookup("timestamp").Int64()))
+		}
+		if !assert.Len(mt, stored, 3) {
+			return
+		}
+		mt.ClearEvents()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch, stored...))
+
+		bids, err := repo.FindBidByAuctionId(mt.Context(), "auction-1")
+		assert.Nil(mt, err)
+
+		find := mt.GetStartedEvent()
+		sort, _ := find.Command.Lookup("sort").Document().Elements()
+		if assert.Len(mt, sort, 2) {
+			assert.Equal(mt, "timestamp", sort[0].Key())
+			assert.Equal(mt, int32(1), sort[0].Value().Int32())
+			assert.Equal(mt, "_id", sort[1].Key())
+		}
+
+		if assert.Len(mt, bids, 3) {
+			for i, found := range bids {
+				assert.Equal(mt, placed[i].Id, found.Id)
+				assert.True(mt, placed[i].Timestamp.Equal(found.Timestamp), found.Timestamp)
+			}
+		}
+	})
+
+	mt.Run("bids stored in seconds are still decoded", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch,
+			bidDocument("bid-1", 10000, 1700000001),
+			bidDocument("bid-2", 20000, 1700000002500)))
+
+		bids, err := repo.FindBidByAuctionId(mt.Context(), "auction-1")
+		assert.Nil(mt, err)
+		if assert.Len(mt, bids, 2) {
+			assert.True(mt, time.Unix(1700000001, 0).Equal(bids[0].Timestamp))
+			assert.True(mt, time.UnixMilli(1700000002500).Equal(bids[1].Timestamp))
+		}
+	})
+
+	mt.Run("bids stored with a float amount are still decoded", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "bid-1"}, {Key: "amount", Value: 4.35}, {Key: "timestamp", Value: int64(1700000001000)}},
+			bidDocument("bid-2", 436, 1700000002000)))
+
+		bids, err := repo.FindBidByAuctionId(mt.Context(), "auction-1")
+		assert.Nil(mt, err)
+		if assert.Len(mt, bids, 2) {
+			assert.Equal(mt, int64(435), bids[0].AmountCents)
+			assert.Equal(mt, int64(436), bids[1].AmountCents)
+		}
+	})
+}
+
+func TestFindHighestBidsByUserId(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("one highest bid per auction", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		second := bidDocument("bid-2", 5000, 2000)
+		second[2].Value = "auction-2"
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch,
+			bidDocument("bid-1", 15000, 1000), second))
+
+		bids, err := repo.FindHighestBidsByUserId(mt.Context(), "user-1")
+
+		assert.Nil(mt, err)
+		if assert.Len(mt, bids, 2) {
+			assert.Equal(mt, "auction-1", bids[0].AuctionId)
+			assert.Equal(mt, int64(15000), bids[0].AmountCents)
+			assert.Equal(mt, "auction-2", bids[1].AuctionId)
+		}
+
+		pipeline := mt.GetStartedEvent().Command.Lookup("pipeline").Array()
+		stages, _ := pipeline.Values()
+		if assert.Len(mt, stages, 5) {
+			assert.Equal(mt, "user-1", stages[0].Document().Lookup("$match", "user_id").StringValue())
+			assertConvertsLegacyAmount(mt, stages[1].Document().Lookup("$addFields", "amount_cents").Document())
+			sort, _ := stages[2].Document().Lookup("$sort").Document().Elements()
+			assert.Equal(mt, "amount_cents", sort[0].Key())
+			assert.Equal(mt, "$auction_id", stages[3].Document().Lookup("$group", "_id").StringValue())
+		}
+	})
+
+	mt.Run("user without bids", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.bids", mtest.FirstBatch))
+
+		bids, err := repo.FindHighestBidsByUserId(mt.Context(), "user-1")
+
+		assert.Nil(mt, err)
+		assert.Empty(mt, bids)
+	})
+}
@@ -0,0 +1,49 @@
+package bid
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// legacySecondsLimit separates bid timestamps stored in seconds from the ones
+// in milliseconds: seconds stay below it until the year 5138, and
+// milliseconds are above it for any date after March 1973.
+const legacySecondsLimit = 100_000_000_000
+
+// MigrateTimestampsToMillis converts the bid timestamps still stored in
+// seconds to milliseconds and returns how many bids were updated. Running it
+// again updates nothing.
+func (bd *BidRepository) MigrateTimestampsToMillis(ctx context.Context) (int64, error) {
+	filter := bson.M{"timestamp": bson.M{"$lt": legacySecondsLimit}}
+	update := bson.A{bson.M{"$set": bson.M{
+		"timestamp": bson.M{"$multiply": bson.A{"$timestamp", int64(1000)}},
+	}}}
+
+	result, err := bd.Collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// MigrateAmountsToCents converts the float amount of the bids stored before
+// amount_cents, rounding it to the nearest cent, and returns how many bids
+// were updated. Running it again updates nothing.
+func (bd *BidRepository) MigrateAmountsToCents(ctx context.Context) (int64, error) {
+	filter := bson.M{"amount": bson.M{"$exists": true}}
+	update := bson.A{
+		bson.M{"$set": bson.M{"amount_cents": bson.M{"$toLong": bson.M{
+			"$round": bson.A{bson.M{"$multiply": bson.A{"$amount", 100}}, 0},
+		}}}},
+		bson.M{"$unset": "amount"},
+	}
+
+	result, err := bd.Collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
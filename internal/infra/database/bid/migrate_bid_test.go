@@ -0,0 +1,88 @@
+package bid_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMigrateTimestampsToMillis(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("converts only the timestamps in seconds", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 3}, bson.E{Key: "nModified", Value: 3}))
+
+		updated, err := repo.MigrateTimestampsToMillis(context.Background())
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(3), updated)
+
+		update := mt.GetStartedEvent()
+		assert.Equal(mt, "update", update.CommandName)
+		updates, _ := update.Command.Lookup("updates").Array().Values()
+		if assert.Len(mt, updates, 1) {
+			statement := updates[0].Document()
+			assert.True(mt, statement.Lookup("multi").Boolean())
+			assert.Equal(mt, int64(100_000_000_000), statement.Lookup("q", "timestamp", "$lt").AsInt64())
+
+			pipeline, _ := statement.Lookup("u").Array().Values()
+			if assert.Len(mt, pipeline, 1) {
+				multiply, _ := pipeline[0].Document().Lookup("$set", "timestamp", "$multiply").Array().Values()
+				assert.Equal(mt, "$timestamp", multiply[0].StringValue())
+				assert.Equal(mt, int64(1000), multiply[1].Int64())
+			}
+		}
+	})
+
+	mt.Run("reports write errors", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 2, Message: "bad"}))
+
+		updated, err := repo.MigrateTimestampsToMillis(context.Background())
+		assert.NotNil(mt, err)
+		assert.Zero(mt, updated)
+	})
+}
+
+func TestMigrateAmountsToCents(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("converts only the float amounts", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+		updated, err := repo.MigrateAmountsToCents(context.Background())
+		assert.Nil(mt, err)
+		assert.Equal(mt, int64(2), updated)
+
+		update := mt.GetStartedEvent()
+		updates, _ := update.Command.Lookup("updates").Array().Values()
+		if assert.Len(mt, updates, 1) {
+			statement := updates[0].Document()
+			assert.True(mt, statement.Lookup("multi").Boolean())
+			assert.True(mt, statement.Lookup("q", "amount", "$exists").Boolean())
+
+			pipeline, _ := statement.Lookup("u").Array().Values()
+			if assert.Len(mt, pipeline, 2) {
+				round, _ := pipeline[0].Document().Lookup("$set", "amount_cents", "$toLong", "$round").Array().Values()
+				multiply, _ := round[0].Document().Lookup("$multiply").Array().Values()
+				assert.Equal(mt, "$amount", multiply[0].StringValue())
+				assert.Equal(mt, "amount", pipeline[1].Document().Lookup("$unset").StringValue())
+			}
+		}
+	})
+
+	mt.Run("reports write errors", func(mt *mtest.T) {
+		repo := newBidRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 2, Message: "bad"}))
+
+		updated, err := repo.MigrateAmountsToCents(context.Background())
+		assert.NotNil(mt, err)
+		assert.Zero(mt, updated)
+	})
+}
@@ -0,0 +1,68 @@
+// Package lock provides a lease on a named lock stored in MongoDB, so that
+// only one of several processes runs a given job at a time.
+package lock
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLease is a lease on the lock name held by owner. It expires after ttl
+// unless renewed, so a crashed owner never blocks the others for long.
+type MongoLease struct {
+	Collection *mongo.Collection
+
+	name  string
+	owner string
+	ttl   time.Duration
+}
+
+func NewMongoLease(database *mongo.Database, name, owner string, ttl time.Duration) *MongoLease {
+	return &MongoLease{
+		Collection: database.Collection("locks"),
+		name:       name,
+		owner:      owner,
+		ttl:        ttl,
+	}
+}
+
+// TryAcquire takes the lease, or renews it when already held, until now+ttl.
+// It reports false while another owner holds a lease that has not expired.
+func (l *MongoLease) TryAcquire(ctx context.Context, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id": l.name,
+		"$or": bson.A{
+			bson.M{"owner": l.owner},
+			bson.M{"expires_at": bson.M{"$lte": now.Unix()}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"owner":      l.owner,
+			"expires_at": now.Add(l.ttl).Unix(),
+		},
+	}
+
+	// When another owner holds the lease the filter misses and the upsert
+	// collides with the existing _id
+	_, err := l.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Release gives the lease up, if still held, so another owner can take it
+// without waiting for it to expire.
+func (l *MongoLease) Release(ctx context.Context) error {
+	_, err := l.Collection.DeleteOne(ctx, bson.M{"_id": l.name, "owner": l.owner})
+	return err
+}
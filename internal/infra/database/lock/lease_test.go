@@ -0,0 +1,78 @@
+package lock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/lock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoLeaseTryAcquire(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	now := time.Unix(1700000000, 0)
+
+	mt.Run("takes a free or expired lease", func(mt *mtest.T) {
+		lease := lock.NewMongoLease(mt.DB, "auction_closer", "closer-1", 30*time.Second)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		held, err := lease.TryAcquire(mt.Context(), now)
+
+		assert.NoError(mt, err)
+		assert.True(mt, held)
+
+		event := mt.GetStartedEvent()
+		assert.Equal(mt, "locks", event.Command.Lookup("update").StringValue())
+		updates, _ := event.Command.Lookup("updates").Array().Values()
+		update := updates[0].Document()
+		assert.True(mt, update.Lookup("upsert").Boolean())
+		assert.Equal(mt, "auction_closer", update.Lookup("q", "_id").StringValue())
+		set := update.Lookup("u", "$set").Document()
+		assert.Equal(mt, "closer-1", set.Lookup("owner").StringValue())
+		assert.Equal(mt, now.Add(30*time.Second).Unix(), set.Lookup("expires_at").Int64())
+	})
+
+	mt.Run("is not taken while another owner holds it", func(mt *mtest.T) {
+		lease := lock.NewMongoLease(mt.DB, "auction_closer", "closer-2", 30*time.Second)
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index: 0, Code: 11000, Message: "E11000 duplicate key error",
+		}))
+
+		held, err := lease.TryAcquire(mt.Context(), now)
+
+		assert.NoError(mt, err)
+		assert.False(mt, held)
+	})
+
+	mt.Run("reports other failures", func(mt *mtest.T) {
+		lease := lock.NewMongoLease(mt.DB, "auction_closer", "closer-1", 30*time.Second)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 11600, Message: "interrupted",
+		}))
+
+		held, err := lease.TryAcquire(mt.Context(), now)
+
+		var commandErr mongo.CommandError
+		assert.ErrorAs(mt, err, &commandErr)
+		assert.False(mt, held)
+	})
+}
+
+func TestMongoLeaseRelease(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("only deletes the lease of its owner", func(mt *mtest.T) {
+		lease := lock.NewMongoLease(mt.DB, "auction_closer", "closer-1", 30*time.Second)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		assert.NoError(mt, lease.Release(mt.Context()))
+
+		deletes, _ := mt.GetStartedEvent().Command.Lookup("deletes").Array().Values()
+		query := deletes[0].Document().Lookup("q").Document()
+		assert.Equal(mt, "auction_closer", query.Lookup("_id").StringValue())
+		assert.Equal(mt, "closer-1", query.Lookup("owner").StringValue())
+	})
+}
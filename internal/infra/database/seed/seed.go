@@ -0,0 +1,165 @@
+// Package seed populates a database with sample users, auctions and bids for
+// local development. Every document has a deterministic id, so running the
+// seeder again never duplicates data.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/auction"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/bid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// namespace derives the seeded ids, so the same index always maps to the same
+// UUID across runs and machines.
+var namespace = uuid.MustParse("6f1c2a8e-0d4b-4c55-9a0e-5b8f3d1e7c42")
+
+var (
+	categories = []string{"eletronicos", "games", "livros", "moveis"}
+	conditions = []auction_entity.ProductCondition{
+		auction_entity.New, auction_entity.Used, auction_entity.Refurbished,
+	}
+)
+
+// Config sets how much sample data is created.
+type Config struct {
+	Users          int
+	Auctions       int
+	BidsPerAuction int
+
+	// AuctionDuration is how long the seeded auctions stay open
+	AuctionDuration time.Duration
+}
+
+// Result reports what a run inserted. Skipped is true when the database was
+// already seeded and nothing was written.
+type Result struct {
+	Users    int
+	Auctions int
+	Bids     int
+	Skipped  bool
+}
+
+// UserId returns the id of the i-th seeded user.
+func UserId(i int) string {
+	return seedId("user", i)
+}
+
+// AuctionId returns the id of the i-th seeded auction.
+func AuctionId(i int) string {
+	return seedId("auction", i)
+}
+
+// BidId returns the id of the j-th seeded bid of the i-th seeded auction.
+func BidId(i, j int) string {
+	return seedId(fmt.Sprintf("auction-%d-bid", i), j)
+}
+
+func seedId(kind string, i int) string {
+	return uuid.NewSHA1(namespace, []byte(fmt.Sprintf("%s-%d", kind, i))).String()
+}
+
+// Run inserts the sample data described by config, with auctions opening at
+// now. It does nothing when the first seeded user already exists; documents
+// left by an interrupted run are kept and only the missing ones are added.
+func Run(ctx context.Context, database *mongo.Database, config Config, now time.Time) (Result, error) {
+	if config.Users <= 0 {
+		return Result{}, errors.New("seed needs at least one user")
+	}
+
+	users := database.Collection("users")
+	err := users.FindOne(ctx, bson.M{"_id": UserId(0)}).Err()
+	if err == nil {
+		return Result{Skipped: true}, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return Result{}, err
+	}
+
+	result := Result{}
+
+	userDocuments := make([]interface{}, 0, config.Users)
+	for i := 0; i < config.Users; i++ {
+		userDocuments = append(userDocuments, user.UserEntityMongo{
+			Id:   UserId(i),
+			Name: fmt.Sprintf("Usuário %d", i+1),
+		})
+	}
+
+	auctionDocuments := make([]interface{}, 0, config.Auctions)
+	bidDocuments := make([]interface{}, 0, config.Auctions*config.BidsPerAuction)
+	for i := 0; i < config.Auctions; i++ {
+		auctionDocuments = append(auctionDocuments, auction.AuctionEntityMongo{
+			Id:          AuctionId(i),
+			ProductName: fmt.Sprintf("Produto %d", i+1),
+			Category:    categories[i%len(categories)],
+			Description: fmt.Sprintf("Produto de exemplo número %d para testes locais", i+1),
+			Condition:   auction.StoredCondition(conditions[i%len(conditions)]),
+			Status:      auction.StoredStatus(auction_entity.Active),
+			CreatedAt:   now.Unix(),
+			StartsAt:    now.Unix(),
+			ExpiresAt:   now.Add(config.AuctionDuration).Unix(),
+			UpdatedAt:   now.Unix(),
+			Version:     1,
+		})
+
+		// Increasing amounts from alternating users, as the bid rules require
+		for j := 0; j < config.BidsPerAuction; j++ {
+			bidDocuments = append(bidDocuments, bid.BidEntityMongo{
+				Id:        BidId(i, j),
+				UserId:    UserId((i + j) % config.Users),
+				AuctionId: AuctionId(i),
+				Timestamp: now.Add(time.Duration(j) * time.Second).UnixMilli(),
+
+				AmountCents: int64(100+10*j) * 100,
+			})
+		}
+	}
+
+	if result.Users, err = insertMissing(ctx, users, userDocuments); err != nil {
+		return result, err
+	}
+	if result.Auctions, err = insertMissing(ctx, database.Collection("auctions"), auctionDocuments); err != nil {
+		return result, err
+	}
+	if result.Bids, err = insertMissing(ctx, database.Collection("bids"), bidDocuments); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// insertMissing inserts the documents, ignoring the ones whose id already
+// exists, and returns how many were inserted.
+func insertMissing(ctx context.Context, collection *mongo.Collection, documents []interface{}) (int, error) {
+	if len(documents) == 0 {
+		return 0, nil
+	}
+
+	_, err := collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return len(documents), nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return 0, err
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return 0, err
+		}
+	}
+
+	return len(documents) - len(bulkErr.WriteErrors), nil
+}
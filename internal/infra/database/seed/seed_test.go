@@ -0,0 +1,109 @@
+package seed_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/seed"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var config = seed.Config{Users: 3, Auctions: 2, BidsPerAuction: 4, AuctionDuration: time.Hour}
+
+// insertedIds returns the collection and the ids of the documents sent by the
+// next insert command.
+func insertedIds(mt *mtest.T) (string, []string) {
+	event := mt.GetStartedEvent()
+	assert.Equal(mt, "insert", event.CommandName)
+
+	values, _ := event.Command.Lookup("documents").Array().Values()
+	var ids []string
+	for _, value := range values {
+		ids = append(ids, value.Document().Lookup("_id").StringValue())
+	}
+
+	return event.Command.Lookup("insert").StringValue(), ids
+}
+
+func TestRunSeedsEveryCollection(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("creates the expected documents", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch), // not seeded yet
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		result, err := seed.Run(mt.Context(), mt.DB, config, time.Now())
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, seed.Result{Users: 3, Auctions: 2, Bids: 8}, result)
+
+		assert.Equal(mt, "find", mt.GetStartedEvent().CommandName)
+
+		collection, ids := insertedIds(mt)
+		assert.Equal(mt, "users", collection)
+		assert.Equal(mt, []string{seed.UserId(0), seed.UserId(1), seed.UserId(2)}, ids)
+
+		collection, ids = insertedIds(mt)
+		assert.Equal(mt, "auctions", collection)
+		assert.Equal(mt, []string{seed.AuctionId(0), seed.AuctionId(1)}, ids)
+
+		collection, ids = insertedIds(mt)
+		assert.Equal(mt, "bids", collection)
+		assert.Len(mt, ids, 8)
+		assert.Equal(mt, seed.BidId(1, 3), ids[7])
+	})
+
+	mt.Run("ids are deterministic", func(mt *mtest.T) {
+		assert.Equal(mt, seed.UserId(0), seed.UserId(0))
+		assert.NotEqual(mt, seed.UserId(0), seed.UserId(1))
+		assert.NotEqual(mt, seed.AuctionId(0), seed.BidId(0, 0))
+	})
+}
+
+func TestRunIsIdempotent(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("skips an already seeded database", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: seed.UserId(0)}, {Key: "name", Value: "Usuário 1"}}))
+
+		result, err := seed.Run(mt.Context(), mt.DB, config, time.Now())
+
+		assert.NoError(mt, err)
+		assert.True(mt, result.Skipped)
+		assert.Equal(mt, "find", mt.GetStartedEvent().CommandName)
+		assert.Nil(mt, mt.GetStartedEvent())
+	})
+
+	mt.Run("completes an interrupted run without duplicates", func(mt *mtest.T) {
+		duplicate := mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch),
+			mtest.CreateWriteErrorsResponse(duplicate, mtest.WriteError{Index: 1, Code: 11000, Message: "E11000 duplicate key error"}),
+			mtest.CreateWriteErrorsResponse(duplicate),
+			mtest.CreateSuccessResponse(),
+		)
+
+		result, err := seed.Run(mt.Context(), mt.DB, config, time.Now())
+
+		assert.NoError(mt, err)
+		assert.Equal(mt, seed.Result{Users: 1, Auctions: 1, Bids: 8}, result)
+	})
+
+	mt.Run("fails on other write errors", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 121, Message: "validation failed"}),
+		)
+
+		_, err := seed.Run(mt.Context(), mt.DB, config, time.Now())
+
+		assert.Error(mt, err)
+	})
+}
@@ -0,0 +1,68 @@
+// Package testdata removes the data left by integration tests in CI and
+// staging databases.
+package testdata
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// purgedCollections are cleaned in this order, auctions before their bids.
+var purgedCollections = []string{"auctions", "bids", "users"}
+
+// PurgeOutputDTO reports how many documents were removed per collection.
+type PurgeOutputDTO struct {
+	Auctions int64 `json:"auctions"`
+	Bids     int64 `json:"bids"`
+	Users    int64 `json:"users"`
+}
+
+// Purger deletes test documents by prefix.
+type Purger struct {
+	database *mongo.Database
+}
+
+func NewPurger(database *mongo.Database) *Purger {
+	return &Purger{database: database}
+}
+
+// Purge deletes the auctions, bids and users whose id or test_tag field starts
+// with prefix. Documents without a matching id or tag are never touched.
+func (p *Purger) Purge(ctx context.Context, prefix string) (*PurgeOutputDTO, *internal_error.InternalError) {
+	if prefix == "" {
+		return nil, internal_error.NewBadRequestError("prefix is required")
+	}
+
+	filter := prefixFilter(prefix)
+	deleted := make(map[string]int64, len(purgedCollections))
+	for _, name := range purgedCollections {
+		result, err := p.database.Collection(name).DeleteMany(ctx, filter)
+		if err != nil {
+			logger.Error("Error trying to purge test data from "+name, err)
+			return nil, internal_error.NewInternalServerError("Error trying to purge test data")
+		}
+		deleted[name] = result.DeletedCount
+	}
+
+	return &PurgeOutputDTO{
+		Auctions: deleted["auctions"],
+		Bids:     deleted["bids"],
+		Users:    deleted["users"],
+	}, nil
+}
+
+// prefixFilter matches the documents whose _id or test_tag starts with
+// prefix, taken literally.
+func prefixFilter(prefix string) bson.M {
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}
+	return bson.M{"$or": bson.A{
+		bson.M{"_id": pattern},
+		bson.M{"test_tag": pattern},
+	}}
+}
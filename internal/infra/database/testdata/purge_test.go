@@ -0,0 +1,67 @@
+package testdata_test
+
+import (
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/testdata"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func deletedResponse(n int32) bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: n})
+}
+
+func TestPurgeDeletesOnlyTaggedDocuments(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("filters every collection by id or test_tag prefix", func(mt *mtest.T) {
+		mt.AddMockResponses(deletedResponse(2), deletedResponse(5), deletedResponse(1))
+
+		output, err := testdata.NewPurger(mt.DB).Purge(mt.Context(), "ci-run.42")
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, &testdata.PurgeOutputDTO{Auctions: 2, Bids: 5, Users: 1}, output)
+
+		for _, collection := range []string{"auctions", "bids", "users"} {
+			event := mt.GetStartedEvent()
+			assert.Equal(mt, "delete", event.CommandName)
+			assert.Equal(mt, collection, event.Command.Lookup("delete").StringValue())
+
+			deletes, _ := event.Command.Lookup("deletes").Array().Values()
+			assert.Len(mt, deletes, 1)
+			clauses, _ := deletes[0].Document().Lookup("q", "$or").Array().Values()
+			assert.Len(mt, clauses, 2)
+
+			// The prefix is anchored and taken literally, dots included
+			for i, field := range []string{"_id", "test_tag"} {
+				pattern, _ := clauses[i].Document().Lookup(field).Regex()
+				assert.Equal(mt, `^ci-run\.42`, pattern)
+			}
+		}
+	})
+
+	mt.Run("rejects an empty prefix without deleting", func(mt *mtest.T) {
+		output, err := testdata.NewPurger(mt.DB).Purge(mt.Context(), "")
+
+		assert.Nil(mt, output)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "bad_request", err.Err)
+		}
+		assert.Nil(mt, mt.GetStartedEvent())
+	})
+
+	mt.Run("reports a failed delete", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 11600, Message: "interrupted",
+		}))
+
+		output, err := testdata.NewPurger(mt.DB).Purge(mt.Context(), "ci-")
+
+		assert.Nil(mt, output)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "internal_server_error", err.Err)
+		}
+	})
+}
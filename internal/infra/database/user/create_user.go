@@ -0,0 +1,25 @@
+package user
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+func (ur *UserRepository) CreateUser(
+	ctx context.Context, userEntity *user_entity.User) *internal_error.InternalError {
+	userEntityMongo := &UserEntityMongo{
+		Id:          userEntity.Id,
+		Name:        userEntity.Name,
+		CallbackURL: userEntity.CallbackURL,
+	}
+
+	if _, err := ur.Collection.InsertOne(ctx, userEntityMongo); err != nil {
+		logger.Error("Error trying to insert user", err)
+		return internal_error.NewInternalServerError("Error trying to insert user").WithCause(err)
+	}
+
+	return nil
+}
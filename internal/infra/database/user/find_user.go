@@ -13,8 +13,9 @@ import (
 )
 
 type UserEntityMongo struct {
-	Id   string `bson:"_id"`
-	Name string `bson:"name"`
+	Id          string `bson:"_id"`
+	Name        string `bson:"name"`
+	CallbackURL string `bson:"callback_url,omitempty"`
 }
 
 type UserRepository struct {
@@ -35,18 +36,19 @@ func (ur *UserRepository) FindUserById(
 	err := ur.Collection.FindOne(ctx, filter).Decode(&userEntityMongo)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			logger.Error(fmt.Sprintf("User not found with this id = %d", userId), err)
+			logger.Error(fmt.Sprintf("User not found with this id = %s", userId), err)
 			return nil, internal_error.NewNotFoundError(
-				fmt.Sprintf("User not found with this id = %d", userId))
+				fmt.Sprintf("User not found with this id = %s", userId))
 		}
 
 		logger.Error("Error trying to find user by userId", err)
-		return nil, internal_error.NewInternalServerError("Error trying to find user by userId")
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId").WithCause(err)
 	}
 
 	userEntity := &user_entity.User{
-		Id:   userEntityMongo.Id,
-		Name: userEntityMongo.Name,
+		Id:          userEntityMongo.Id,
+		Name:        userEntityMongo.Name,
+		CallbackURL: userEntityMongo.CallbackURL,
 	}
 
 	return userEntity, nil
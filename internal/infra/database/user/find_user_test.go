@@ -0,0 +1,124 @@
+package user_test
+
+import (
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/database/user"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestFindUserById(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("missing user is not found", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch))
+
+		found, err := repo.FindUserById(mt.Context(), "missing")
+
+		assert.Nil(mt, found)
+		assert.True(mt, err.IsNotFound())
+	})
+
+	mt.Run("database failure is not reported as not found", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code: 91, Message: "shutdown in progress",
+		}))
+
+		found, err := repo.FindUserById(mt.Context(), "user-1")
+
+		assert.Nil(mt, found)
+		assert.False(mt, err.IsNotFound())
+		assert.Equal(mt, "internal_server_error", err.Err)
+	})
+
+	mt.Run("existing user", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "user-1"}, {Key: "name", Value: "Ana"}}))
+
+		found, err := repo.FindUserById(mt.Context(), "user-1")
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, "Ana", found.Name)
+	})
+}
+
+func TestCreateUser(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("inserts the user document", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := repo.CreateUser(mt.Context(), &user_entity.User{Id: "user-1", Name: "Ana"})
+
+		assert.Nil(mt, err)
+		event := mt.GetStartedEvent()
+		assert.Equal(mt, "insert", event.CommandName)
+		documents, _ := event.Command.Lookup("documents").Array().Values()
+		if assert.Len(mt, documents, 1) {
+			document := documents[0].Document()
+			assert.Equal(mt, "user-1", document.Lookup("_id").StringValue())
+			assert.Equal(mt, "Ana", document.Lookup("name").StringValue())
+			_, missing := document.LookupErr("callback_url")
+			assert.Error(mt, missing)
+		}
+	})
+
+	mt.Run("insert failure", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index: 0, Code: 11000, Message: "duplicate key",
+		}))
+
+		err := repo.CreateUser(mt.Context(), &user_entity.User{Id: "user-1", Name: "Ana"})
+
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, "internal_server_error", err.Err)
+		}
+	})
+}
+
+func TestUpdateUserCallbackURL(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("sets the callback URL", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		err := repo.UpdateUserCallbackURL(mt.Context(), "user-1", "https://example.com/hook")
+
+		assert.Nil(mt, err)
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u")
+		assert.Equal(mt, "https://example.com/hook", update.Document().Lookup("$set", "callback_url").StringValue())
+	})
+
+	mt.Run("an empty URL removes it", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		err := repo.UpdateUserCallbackURL(mt.Context(), "user-1", "")
+
+		assert.Nil(mt, err)
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u")
+		_, lookupErr := update.Document().LookupErr("$unset", "callback_url")
+		assert.NoError(mt, lookupErr)
+	})
+
+	mt.Run("missing user is not found", func(mt *mtest.T) {
+		repo := user.NewUserRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+		err := repo.UpdateUserCallbackURL(mt.Context(), "missing", "https://example.com/hook")
+
+		assert.True(mt, err.IsNotFound())
+	})
+}
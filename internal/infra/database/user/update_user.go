@@ -0,0 +1,33 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UpdateUserCallbackURL stores the URL notified about the user's bids, or
+// removes it when callbackURL is empty.
+func (ur *UserRepository) UpdateUserCallbackURL(
+	ctx context.Context, userId, callbackURL string) *internal_error.InternalError {
+	update := bson.M{"$set": bson.M{"callback_url": callbackURL}}
+	if callbackURL == "" {
+		update = bson.M{"$unset": bson.M{"callback_url": ""}}
+	}
+
+	result, err := ur.Collection.UpdateOne(ctx, bson.M{"_id": userId}, update)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error trying to update the callback URL of user %s", userId), err)
+		return internal_error.NewInternalServerError("Error trying to update the user callback URL").WithCause(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return internal_error.NewNotFoundError(
+			fmt.Sprintf("User not found with this id = %s", userId))
+	}
+
+	return nil
+}
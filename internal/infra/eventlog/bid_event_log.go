@@ -0,0 +1,212 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+)
+
+const (
+	acceptedEvent = "accepted"
+	settledEvent  = "settled"
+)
+
+// bidEvent is one line of the log file, encoded as JSON.
+type bidEvent struct {
+	Type      string  `json:"type"`
+	Id        string  `json:"id"`
+	UserId    string  `json:"user_id,omitempty"`
+	AuctionId string  `json:"auction_id,omitempty"`
+	Amount    float64 `json:"amount,omitempty"`    // in currency units, converted back to cents on read
+	Timestamp int64   `json:"timestamp,omitempty"` // Unix nanoseconds
+}
+
+func newAcceptedEvent(bid bid_entity.Bid) bidEvent {
+	return bidEvent{
+		Type:      acceptedEvent,
+		Id:        bid.Id,
+		UserId:    bid.UserId,
+		AuctionId: bid.AuctionId,
+		Amount:    bid.Amount(),
+		Timestamp: bid.Timestamp.UnixNano(),
+	}
+}
+
+// FileBidEventLog is a bid_entity.BidEventLog kept in a JSON lines file.
+// Accepted events are synced to disk before returning; the file is compacted
+// on open and truncated whenever no bid is pending.
+type FileBidEventLog struct {
+	mutex   sync.Mutex
+	file    *os.File
+	pending map[string]bid_entity.Bid
+}
+
+// NewFileBidEventLog opens (or creates) the log at path and loads the bids
+// still pending from a previous run.
+func NewFileBidEventLog(path string) (*FileBidEventLog, error) {
+	pending, err := readPending(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := compact(path, pending); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileBidEventLog{file: file, pending: pending}, nil
+}
+
+func (l *FileBidEventLog) AppendAccepted(bid bid_entity.Bid) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.write(newAcceptedEvent(bid)); err != nil {
+		return err
+	}
+
+	l.pending[bid.Id] = bid
+	return nil
+}
+
+func (l *FileBidEventLog) AppendSettled(bids []bid_entity.Bid) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, bid := range bids {
+		delete(l.pending, bid.Id)
+	}
+
+	// Nothing left to rebuild: start over with an empty file
+	if len(l.pending) == 0 {
+		return l.file.Truncate(0)
+	}
+
+	for _, bid := range bids {
+		if err := l.write(bidEvent{Type: settledEvent, Id: bid.Id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *FileBidEventLog) Pending() ([]bid_entity.Bid, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return sortedBids(l.pending), nil
+}
+
+// Close releases the log file.
+func (l *FileBidEventLog) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.file.Close()
+}
+
+// write appends an event and syncs it to disk. It must be called with mutex
+// held.
+func (l *FileBidEventLog) write(event bidEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return l.file.Sync()
+}
+
+// readPending replays the log at path, returning the accepted bids that were
+// never settled. A missing file means nothing is pending.
+func readPending(path string) (map[string]bid_entity.Bid, error) {
+	pending := make(map[string]bid_entity.Bid)
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return pending, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event bidEvent
+		// A torn last line from a crash mid-write is skipped: its bid was
+		// never acknowledged to the client
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case acceptedEvent:
+			pending[event.Id] = bid_entity.Bid{
+				Id:        event.Id,
+				UserId:    event.UserId,
+				AuctionId: event.AuctionId,
+				Timestamp: time.Unix(0, event.Timestamp),
+
+				AmountCents: bid_entity.ToCents(event.Amount),
+			}
+		case settledEvent:
+			delete(pending, event.Id)
+		}
+	}
+
+	return pending, scanner.Err()
+}
+
+// compact rewrites the log at path with only the pending accepted events.
+func compact(path string, pending map[string]bid_entity.Bid) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	log := &FileBidEventLog{file: file}
+	for _, bid := range sortedBids(pending) {
+		if err := log.write(newAcceptedEvent(bid)); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// sortedBids orders bids by timestamp, then id, so a replay is deterministic.
+func sortedBids(bids map[string]bid_entity.Bid) []bid_entity.Bid {
+	sorted := make([]bid_entity.Bid, 0, len(bids))
+	for _, bid := range bids {
+		sorted = append(sorted, bid)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].Timestamp.Equal(sorted[j].Timestamp) {
+			return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+		}
+		return sorted[i].Id < sorted[j].Id
+	})
+
+	return sorted
+}
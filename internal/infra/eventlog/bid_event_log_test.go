@@ -0,0 +1,93 @@
+package eventlog_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/eventlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBid(amount float64, timestamp time.Time) bid_entity.Bid {
+	return bid_entity.Bid{
+		Id:        uuid.New().String(),
+		UserId:    uuid.New().String(),
+		AuctionId: "auction-1",
+		Timestamp: timestamp,
+
+		AmountCents: bid_entity.ToCents(amount),
+	}
+}
+
+func TestFileBidEventLogSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bids.log")
+	now := time.Now()
+
+	eventLog, err := eventlog.NewFileBidEventLog(path)
+	assert.NoError(t, err)
+
+	first, second, third := newBid(100, now), newBid(200, now.Add(time.Second)), newBid(300, now.Add(2*time.Second))
+	for _, bid := range []bid_entity.Bid{first, second, third} {
+		assert.NoError(t, eventLog.AppendAccepted(bid))
+	}
+	assert.NoError(t, eventLog.AppendSettled([]bid_entity.Bid{first}))
+	assert.NoError(t, eventLog.Close())
+
+	reopened, err := eventlog.NewFileBidEventLog(path)
+	assert.NoError(t, err)
+	defer reopened.Close()
+
+	pending, err := reopened.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, second.Id, pending[0].Id)
+	assert.Equal(t, second.Amount(), pending[0].Amount())
+	assert.Equal(t, second.UserId, pending[0].UserId)
+	assert.True(t, second.Timestamp.Equal(pending[0].Timestamp))
+	assert.Equal(t, third.Id, pending[1].Id)
+}
+
+func TestFileBidEventLogTruncatesWhenNothingIsPending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bids.log")
+
+	eventLog, err := eventlog.NewFileBidEventLog(path)
+	assert.NoError(t, err)
+	defer eventLog.Close()
+
+	bid := newBid(100, time.Now())
+	assert.NoError(t, eventLog.AppendAccepted(bid))
+	assert.NoError(t, eventLog.AppendSettled([]bid_entity.Bid{bid}))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+func TestFileBidEventLogSkipsTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bids.log")
+
+	eventLog, err := eventlog.NewFileBidEventLog(path)
+	assert.NoError(t, err)
+	bid := newBid(100, time.Now())
+	assert.NoError(t, eventLog.AppendAccepted(bid))
+	assert.NoError(t, eventLog.Close())
+
+	// Simulate a crash in the middle of writing the next event
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"type":"accepted","id":"torn`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	reopened, err := eventlog.NewFileBidEventLog(path)
+	assert.NoError(t, err)
+	defer reopened.Close()
+
+	pending, _ := reopened.Pending()
+	assert.Len(t, pending, 1)
+	assert.Equal(t, bid.Id, pending[0].Id)
+}
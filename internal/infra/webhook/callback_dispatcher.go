@@ -0,0 +1,283 @@
+// Package webhook calls the callback URLs registered by users when their bids
+// are persisted and when they win an auction.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// Events posted to the callback URLs.
+const (
+	BidConfirmedEvent = "bid_confirmed"
+	AuctionWonEvent   = "auction_won"
+)
+
+const (
+	// callbackQueueSize is how many notifications may wait to be dispatched
+	// before new ones are dropped
+	callbackQueueSize = 1000
+
+	// maxConcurrentCallbacks caps the requests in flight, retries included
+	maxConcurrentCallbacks = 10
+)
+
+// CallbackEvent is the JSON body posted to a callback URL.
+type CallbackEvent struct {
+	Event     string    `json:"event"`
+	BidId     string    `json:"bid_id"`
+	UserId    string    `json:"user_id"`
+	AuctionId string    `json:"auction_id"`
+	Amount    float64   `json:"amount"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WinningBidFinder looks up the bid that wins a completed auction.
+type WinningBidFinder interface {
+	FindWinningBidByAuctionId(
+		ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError)
+}
+
+// notification is a batch of persisted bids or of completed auctions.
+type notification struct {
+	bids       []bid_entity.Bid
+	auctionIds []string
+}
+
+// CallbackDispatcher notifies users through their callback URLs. It
+// implements bid_entity.BidConfirmationNotifier and
+// auction_entity.AuctionCompletionListener: both only queue the notification,
+// and a background routine resolves the URLs and posts the events, retrying
+// failed requests with exponential backoff. Delivery is at least once, so
+// receivers should deduplicate by event and bid_id.
+type CallbackDispatcher struct {
+	users   user_entity.UserRepositoryInterface
+	winners WinningBidFinder
+	client  *http.Client
+
+	maxAttempts  int
+	retryBackoff time.Duration
+
+	notifications chan notification
+	inFlight      chan struct{}
+}
+
+// NewCallbackDispatcher starts the routine that dispatches the queued
+// notifications until ctx is done. Each request may take callbackConfig.Timeout
+// and is tried up to callbackConfig.MaxAttempts times, waiting
+// callbackConfig.RetryBackoff before the first retry and doubling it for each
+// following one.
+func NewCallbackDispatcher(
+	ctx context.Context,
+	users user_entity.UserRepositoryInterface,
+	winners WinningBidFinder,
+	callbackConfig config.CallbackConfig) *CallbackDispatcher {
+	dispatcher := &CallbackDispatcher{
+		users:         users,
+		winners:       winners,
+		client:        newCallbackClient(callbackConfig.Timeout, publicDestination),
+		maxAttempts:   callbackConfig.MaxAttempts,
+		retryBackoff:  callbackConfig.RetryBackoff,
+		notifications: make(chan notification, callbackQueueSize),
+		inFlight:      make(chan struct{}, maxConcurrentCallbacks),
+	}
+	go dispatcher.run(ctx)
+
+	return dispatcher
+}
+
+// newCallbackClient returns the client posting the callbacks. Every address
+// it dials must pass allowed, which is checked after DNS resolution so a host
+// name cannot point it at an internal service, and redirects are returned as
+// the response instead of being followed.
+func newCallbackClient(timeout time.Duration, allowed func(net.IP) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed(ip) {
+				return fmt.Errorf("callback destination %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicDestination rejects the loopback, private, link-local (including the
+// cloud metadata address), multicast and unspecified addresses.
+func publicDestination(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// BidsConfirmed queues a bid_confirmed event for each persisted bid whose
+// bidder has a callback URL.
+func (cd *CallbackDispatcher) BidsConfirmed(bids []bid_entity.Bid) {
+	cd.enqueue(notification{bids: bids})
+}
+
+// AuctionsCompleted queues an auction_won event for the winner of each
+// completed auction, if the winner has a callback URL.
+func (cd *CallbackDispatcher) AuctionsCompleted(auctionIds []string) {
+	cd.enqueue(notification{auctionIds: auctionIds})
+}
+
+// enqueue drops the notification when the queue is full rather than blocking
+// the batch writer or the closer.
+func (cd *CallbackDispatcher) enqueue(n notification) {
+	select {
+	case cd.notifications <- n:
+	default:
+		logger.Error("Callback notification dropped, queue is full",
+			errors.New("callback queue full"))
+	}
+}
+
+func (cd *CallbackDispatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-cd.notifications:
+			// A batch often holds several bids of the same user
+			urls := make(map[string]string)
+			for _, bid := range n.bids {
+				cd.dispatch(ctx, urls, BidConfirmedEvent, bid)
+			}
+			for _, auctionId := range n.auctionIds {
+				cd.dispatchWinner(ctx, urls, auctionId)
+			}
+		}
+	}
+}
+
+func (cd *CallbackDispatcher) dispatchWinner(ctx context.Context, urls map[string]string, auctionId string) {
+	winner, err := cd.winners.FindWinningBidByAuctionId(ctx, auctionId)
+	if err != nil {
+		// An auction closed without bids has no one to notify
+		if !err.IsNotFound() {
+			logger.Error(fmt.Sprintf("Error finding the winner of auction %s for its callback", auctionId), err)
+		}
+		return
+	}
+
+	cd.dispatch(ctx, urls, AuctionWonEvent, *winner)
+}
+
+// dispatch posts the event to the bidder's callback URL in the background,
+// waiting while maxConcurrentCallbacks requests are in flight. urls caches the
+// callback URL of the users already looked up.
+func (cd *CallbackDispatcher) dispatch(
+	ctx context.Context, urls map[string]string, event string, bid bid_entity.Bid) {
+	callbackURL, ok := urls[bid.UserId]
+	if !ok {
+		user, err := cd.users.FindUserById(ctx, bid.UserId)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error finding user %s for its callback", bid.UserId), err)
+			return
+		}
+		callbackURL = user.CallbackURL
+		urls[bid.UserId] = callbackURL
+	}
+	if callbackURL == "" {
+		return
+	}
+
+	select {
+	case cd.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+
+	go func() {
+		defer func() { <-cd.inFlight }()
+		cd.post(ctx, callbackURL, CallbackEvent{
+			Event:     event,
+			BidId:     bid.Id,
+			UserId:    bid.UserId,
+			AuctionId: bid.AuctionId,
+			Amount:    bid.Amount(),
+			Timestamp: bid.Timestamp,
+		})
+	}()
+}
+
+// post sends the event, retrying up to maxAttempts times with a backoff that
+// doubles after each failure. Any 2xx response counts as delivered.
+func (cd *CallbackDispatcher) post(ctx context.Context, url string, event CallbackEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("Error encoding callback event", err)
+		return
+	}
+
+	backoff := cd.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err = cd.send(ctx, url, body)
+		if err == nil {
+			return
+		}
+
+		if attempt >= cd.maxAttempts {
+			logger.Error(fmt.Sprintf("Giving up on %s callback for bid %s after %d attempt(s)",
+				event.Event, event.BidId, attempt), err)
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (cd *CallbackDispatcher) send(ctx context.Context, url string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := cd.client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("callback answered with status %d", response.StatusCode)
+	}
+
+	return nil
+}
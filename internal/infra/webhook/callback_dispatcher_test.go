@@ -0,0 +1,199 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/infra/webhook"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUserRepository struct {
+	callbackURLs map[string]string
+}
+
+func (f *fakeUserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	return &user_entity.User{Id: userId, CallbackURL: f.callbackURLs[userId]}, nil
+}
+
+func (f *fakeUserRepository) CreateUser(
+	ctx context.Context, user *user_entity.User) *internal_error.InternalError {
+	return nil
+}
+
+func (f *fakeUserRepository) UpdateUserCallbackURL(
+	ctx context.Context, userId, callbackURL string) *internal_error.InternalError {
+	return nil
+}
+
+type fakeWinningBidFinder struct {
+	winners map[string]bid_entity.Bid
+}
+
+func (f *fakeWinningBidFinder) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	winner, ok := f.winners[auctionId]
+	if !ok {
+		return nil, internal_error.NewNotFoundError("No bids found for the auction")
+	}
+	return &winner, nil
+}
+
+// newEndpoint starts a callback receiver that fails the first failures
+// requests with 503 and reports the events it accepts.
+func newEndpoint(t *testing.T, failures int64) (*httptest.Server, <-chan webhook.CallbackEvent, *atomic.Int64) {
+	events := make(chan webhook.CallbackEvent, 10)
+	var requests atomic.Int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var event webhook.CallbackEvent
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&event))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+
+	return server, events, &requests
+}
+
+// callbackConfig tries each callback maxAttempts times without waiting
+// between the attempts.
+func callbackConfig(maxAttempts int) config.CallbackConfig {
+	return config.CallbackConfig{MaxAttempts: maxAttempts, RetryBackoff: time.Millisecond, Timeout: 5 * time.Second}
+}
+
+// newDispatcher returns a dispatcher allowed to call the local test endpoints.
+func newDispatcher(
+	users user_entity.UserRepositoryInterface, winners webhook.WinningBidFinder,
+	callbackConfig config.CallbackConfig) *webhook.CallbackDispatcher {
+	dispatcher := webhook.NewCallbackDispatcher(context.Background(), users, winners, callbackConfig)
+	webhook.AllowPrivateDestinations(dispatcher)
+	return dispatcher
+}
+
+func receive(t *testing.T, events <-chan webhook.CallbackEvent) webhook.CallbackEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback not received")
+		return webhook.CallbackEvent{}
+	}
+}
+
+func assertNoEvent(t *testing.T, events <-chan webhook.CallbackEvent) {
+	t.Helper()
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected callback %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBidsConfirmedCallsBidderCallback(t *testing.T) {
+	server, events, _ := newEndpoint(t, 0)
+	users := &fakeUserRepository{callbackURLs: map[string]string{"user-1": server.URL}}
+	dispatcher := newDispatcher(users, &fakeWinningBidFinder{}, config.Default().Callback)
+
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	dispatcher.BidsConfirmed([]bid_entity.Bid{
+		{Id: "bid-1", UserId: "user-1", AuctionId: "auction-1", AmountCents: 15000, Timestamp: timestamp},
+		// Users without a callback URL are skipped
+		{Id: "bid-2", UserId: "user-2", AuctionId: "auction-1", AmountCents: 20000, Timestamp: timestamp},
+	})
+
+	event := receive(t, events)
+	assert.Equal(t, webhook.BidConfirmedEvent, event.Event)
+	assert.Equal(t, "bid-1", event.BidId)
+	assert.Equal(t, "user-1", event.UserId)
+	assert.Equal(t, "auction-1", event.AuctionId)
+	assert.Equal(t, 150.0, event.Amount)
+	assert.True(t, timestamp.Equal(event.Timestamp))
+	assertNoEvent(t, events)
+}
+
+func TestCallbackRetriesFailedRequests(t *testing.T) {
+	t.Run("delivered after transient failures", func(t *testing.T) {
+		server, events, requests := newEndpoint(t, 2)
+		users := &fakeUserRepository{callbackURLs: map[string]string{"user-1": server.URL}}
+		dispatcher := newDispatcher(users, &fakeWinningBidFinder{}, callbackConfig(3))
+
+		dispatcher.BidsConfirmed([]bid_entity.Bid{{Id: "bid-1", UserId: "user-1", AuctionId: "auction-1"}})
+
+		assert.Equal(t, "bid-1", receive(t, events).BidId)
+		assert.Equal(t, int64(3), requests.Load())
+	})
+
+	t.Run("gives up after the max attempts", func(t *testing.T) {
+		server, events, requests := newEndpoint(t, 5)
+		users := &fakeUserRepository{callbackURLs: map[string]string{"user-1": server.URL}}
+		dispatcher := newDispatcher(users, &fakeWinningBidFinder{}, callbackConfig(2))
+
+		dispatcher.BidsConfirmed([]bid_entity.Bid{{Id: "bid-1", UserId: "user-1", AuctionId: "auction-1"}})
+
+		assertNoEvent(t, events)
+		assert.Equal(t, int64(2), requests.Load())
+	})
+}
+
+func TestAuctionsCompletedCallsWinnerCallback(t *testing.T) {
+	server, events, _ := newEndpoint(t, 0)
+	users := &fakeUserRepository{callbackURLs: map[string]string{"user-1": server.URL}}
+	winners := &fakeWinningBidFinder{winners: map[string]bid_entity.Bid{
+		"auction-1": {Id: "bid-9", UserId: "user-1", AuctionId: "auction-1", AmountCents: 90000},
+	}}
+	dispatcher := newDispatcher(users, winners, config.Default().Callback)
+
+	// auction-2 closed without bids, so nobody is notified for it
+	dispatcher.AuctionsCompleted([]string{"auction-1", "auction-2"})
+
+	event := receive(t, events)
+	assert.Equal(t, webhook.AuctionWonEvent, event.Event)
+	assert.Equal(t, "bid-9", event.BidId)
+	assert.Equal(t, "auction-1", event.AuctionId)
+	assert.Equal(t, 900.0, event.Amount)
+	assertNoEvent(t, events)
+}
+
+func TestCallbackRefusesInternalDestinations(t *testing.T) {
+	t.Run("loopback address", func(t *testing.T) {
+		server, events, requests := newEndpoint(t, 0)
+		users := &fakeUserRepository{callbackURLs: map[string]string{"user-1": server.URL}}
+		dispatcher := webhook.NewCallbackDispatcher(
+			context.Background(), users, &fakeWinningBidFinder{}, callbackConfig(1))
+
+		dispatcher.BidsConfirmed([]bid_entity.Bid{{Id: "bid-1", UserId: "user-1", AuctionId: "auction-1"}})
+
+		assertNoEvent(t, events)
+		assert.Zero(t, requests.Load())
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		target, events, requests := newEndpoint(t, 0)
+		redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+		t.Cleanup(redirect.Close)
+		users := &fakeUserRepository{callbackURLs: map[string]string{"user-1": redirect.URL}}
+		dispatcher := newDispatcher(users, &fakeWinningBidFinder{}, callbackConfig(1))
+
+		dispatcher.BidsConfirmed([]bid_entity.Bid{{Id: "bid-1", UserId: "user-1", AuctionId: "auction-1"}})
+
+		assertNoEvent(t, events)
+		assert.Zero(t, requests.Load())
+	})
+}
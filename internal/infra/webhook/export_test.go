@@ -0,0 +1,9 @@
+package webhook
+
+import "net"
+
+// AllowPrivateDestinations lets the dispatcher call the httptest servers,
+// which listen on the loopback address.
+func AllowPrivateDestinations(cd *CallbackDispatcher) {
+	cd.client = newCallbackClient(cd.client.Timeout, func(net.IP) bool { return true })
+}
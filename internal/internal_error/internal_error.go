@@ -1,14 +1,94 @@
 package internal_error
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Machine-readable codes that let clients react to a specific condition
+// without parsing the message. They refine, but never replace, Err.
+const (
+	AuctionNotFoundCode      = "auction_not_found"
+	AuctionDeletedCode       = "auction_deleted"
+	AuctionCompletedCode     = "auction_completed"
+	AuctionNotStartedCode    = "auction_not_started"
+	AuctionExpiredCode       = "auction_expired"
+	BidCooldownCode          = "bid_cooldown"
+	RequestCancelledCode     = "request_cancelled"
+	RequestTimeoutCode       = "request_timeout"
+	InvalidUserIdCode        = "invalid_user_id"
+	InvalidAuctionIdCode     = "invalid_auction_id"
+	InvalidAmountCode        = "invalid_amount"
+	UserNotFoundCode         = "user_not_found"
+	UserUnavailableCode      = "user_service_unavailable"
+	BidTooLowCode            = "bid_too_low"
+	AlreadyHighestBidderCode = "already_highest_bidder"
+	PipelineClosedCode       = "bid_pipeline_closed"
+	PipelineFullCode         = "bid_pipeline_full"
+	TooManyBidsCode          = "too_many_bids"
+)
+
 type InternalError struct {
 	Message string
 	Err     string
+	Code    string
+
+	// Cause is the underlying error, kept for errors.Is/As and server-side
+	// logs. It never reaches the client, which only sees Message.
+	Cause error
 }
 
 func (ie *InternalError) Error() string {
 	return ie.Message
 }
 
+// Unwrap returns the underlying cause, if any.
+func (ie *InternalError) Unwrap() error {
+	return ie.Cause
+}
+
+// WithCause attaches the underlying error and returns the error.
+func (ie *InternalError) WithCause(cause error) *InternalError {
+	ie.Cause = cause
+	return ie
+}
+
+// WithCode attaches a machine-readable code to the error and returns it.
+func (ie *InternalError) WithCode(code string) *InternalError {
+	ie.Code = code
+	return ie
+}
+
+// IsNotFound reports whether the error means the resource does not exist, as
+// opposed to a failure while looking it up.
+func (ie *InternalError) IsNotFound() bool {
+	return ie.Err == "not_found"
+}
+
+// HTTPStatus returns the HTTP status matching the type of err. Internal server
+// errors and unknown types map to 500.
+func HTTPStatus(err *InternalError) int {
+	switch err.Err {
+	case "bad_request":
+		return http.StatusBadRequest
+	case "not_found":
+		return http.StatusNotFound
+	case "conflict":
+		return http.StatusConflict
+	case "gone":
+		return http.StatusGone
+	case "service_unavailable":
+		return http.StatusServiceUnavailable
+	case "gateway_timeout":
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func NewNotFoundError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
@@ -16,6 +96,14 @@ func NewNotFoundError(message string) *InternalError {
 	}
 }
 
+// NewGoneError reports a resource that existed but was removed.
+func NewGoneError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "gone",
+	}
+}
+
 func NewInternalServerError(message string) *InternalError {
 	return &InternalError{
 		Message: message,
@@ -29,3 +117,90 @@ func NewBadRequestError(message string) *InternalError {
 		Err:     "bad_request",
 	}
 }
+
+func NewConflictError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "conflict",
+	}
+}
+
+func NewServiceUnavailableError(message string) *InternalError {
+	return &InternalError{
+		Message: message,
+		Err:     "service_unavailable",
+	}
+}
+
+func NewAuctionNotFoundError() *InternalError {
+	return NewNotFoundError("Auction not found").WithCode(AuctionNotFoundCode)
+}
+
+// NewAuctionDeletedError reports a soft-deleted auction, as 410 Gone when gone
+// is set and as an ordinary 404 otherwise.
+func NewAuctionDeletedError(gone bool) *InternalError {
+	if gone {
+		return NewGoneError("Auction was deleted").WithCode(AuctionDeletedCode)
+	}
+
+	return NewNotFoundError("Auction not found").WithCode(AuctionDeletedCode)
+}
+
+func NewAuctionCompletedError() *InternalError {
+	return NewBadRequestError("Auction is no longer active").WithCode(AuctionCompletedCode)
+}
+
+func NewAuctionNotStartedError() *InternalError {
+	return NewBadRequestError("Auction has not started yet").WithCode(AuctionNotStartedCode)
+}
+
+func NewAuctionExpiredError() *InternalError {
+	return NewBadRequestError("Auction has expired").WithCode(AuctionExpiredCode)
+}
+
+func NewBidCooldownError(remaining time.Duration) *InternalError {
+	return NewBadRequestError(fmt.Sprintf(
+		"You must wait %s before bidding again on this auction",
+		remaining.Truncate(time.Millisecond))).WithCode(BidCooldownCode)
+}
+
+func NewRequestCancelledError() *InternalError {
+	return NewBadRequestError("Request was cancelled").WithCode(RequestCancelledCode)
+}
+
+func NewRequestTimeoutError() *InternalError {
+	return &InternalError{
+		Message: "Request timed out",
+		Err:     "gateway_timeout",
+		Code:    RequestTimeoutCode,
+	}
+}
+
+// NewContextDoneError reports why ctx is done: a timeout once its deadline
+// has passed, a cancellation otherwise.
+func NewContextDoneError(ctx context.Context) *InternalError {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return NewRequestTimeoutError().WithCause(ctx.Err())
+	}
+	return NewRequestCancelledError().WithCause(ctx.Err())
+}
+
+func NewUserNotFoundError() *InternalError {
+	return NewNotFoundError("User not found").WithCode(UserNotFoundCode)
+}
+
+func NewUserUnavailableError() *InternalError {
+	return NewServiceUnavailableError("Unable to verify the user, try again later").WithCode(UserUnavailableCode)
+}
+
+func NewPipelineClosedError() *InternalError {
+	return NewServiceUnavailableError("Bid pipeline is shutting down").WithCode(PipelineClosedCode)
+}
+
+func NewPipelineFullError() *InternalError {
+	return NewServiceUnavailableError("Bid pipeline is full, try again later").WithCode(PipelineFullCode)
+}
+
+func NewTooManyBidsError() *InternalError {
+	return NewServiceUnavailableError("Too many bids in progress, try again later").WithCode(TooManyBidsCode)
+}
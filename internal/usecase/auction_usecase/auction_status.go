@@ -0,0 +1,45 @@
+package auction_usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+)
+
+// MarshalJSON writes the status by name (active or completed), or as a number
+// when it has none.
+func (s AuctionStatus) MarshalJSON() ([]byte, error) {
+	status := auction_entity.AuctionStatus(s)
+	if _, err := auction_entity.ParseAuctionStatus(status.String()); err != nil {
+		return []byte(strconv.FormatInt(int64(s), 10)), nil
+	}
+
+	return json.Marshal(status.String())
+}
+
+// UnmarshalJSON reads a status by name, in any case, or by number as clients
+// did before the names were written. An unknown name is a bad request.
+func (s *AuctionStatus) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var number int64
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+		*s = AuctionStatus(number)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	status, err := auction_entity.ParseAuctionStatus(name)
+	if err != nil {
+		return err
+	}
+	*s = AuctionStatus(status)
+	return nil
+}
@@ -0,0 +1,46 @@
+package auction_usecase_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuctionStatusRoundTripsAsName(t *testing.T) {
+	for _, status := range []auction_entity.AuctionStatus{auction_entity.Active, auction_entity.Completed} {
+		data, err := json.Marshal(auction_usecase.AuctionStatus(status))
+		assert.Nil(t, err)
+		assert.Equal(t, `"`+status.String()+`"`, string(data))
+
+		var decoded auction_usecase.AuctionStatus
+		assert.Nil(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, auction_usecase.AuctionStatus(status), decoded)
+	}
+}
+
+func TestAuctionStatusUnmarshalJSON(t *testing.T) {
+	var status auction_usecase.AuctionStatus
+
+	assert.Nil(t, json.Unmarshal([]byte(`"Completed"`), &status))
+	assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), status)
+
+	// Numbers are still accepted
+	assert.Nil(t, json.Unmarshal([]byte(`0`), &status))
+	assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Active), status)
+
+	err := json.Unmarshal([]byte(`"sold"`), &status)
+	var internalErr *internal_error.InternalError
+	if assert.ErrorAs(t, err, &internalErr) {
+		assert.Equal(t, "bad_request", internalErr.Err)
+	}
+}
+
+func TestAuctionStatusWithoutNameMarshalsAsNumber(t *testing.T) {
+	data, err := json.Marshal(auction_usecase.AuctionStatus(7))
+	assert.Nil(t, err)
+	assert.Equal(t, `7`, string(data))
+}
@@ -0,0 +1,41 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+)
+
+// FindAuctionSummary reads the auction, its persisted highest bid and its bid
+// count in a single query. A bid accepted but not persisted yet is reported
+// as the highest when it tops the stored one.
+func (au *AuctionUseCase) FindAuctionSummary(
+	ctx context.Context,
+	auctionId string) (*AuctionSummaryOutputDTO, *internal_error.InternalError) {
+	summary, err := au.auctionRepositoryInterface.FindAuctionSummary(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &AuctionSummaryOutputDTO{
+		Auction:  newAuctionOutputDTO(summary.Auction),
+		BidCount: summary.BidCount,
+		Status:   summary.Status.String(),
+	}
+
+	var persisted *bid_usecase.BidOutputDTO
+	if summary.HighestBid != nil {
+		persisted = newBidOutputDTO(summary.HighestBid)
+		output.HighestBid = &bid_usecase.CurrentBidOutputDTO{BidOutputDTO: *persisted, Persisted: true}
+	}
+	if au.pendingBids != nil {
+		if pending, ok := au.pendingBids.FindPendingBids(ctx)[auctionId]; ok && au.outranks(pending, persisted) {
+			output.HighestBid = &bid_usecase.CurrentBidOutputDTO{BidOutputDTO: pending}
+		}
+	}
+
+	output.SecondsRemaining, _ = timeRemaining(summary.Status, summary.ExpiresAt)
+
+	return output, nil
+}
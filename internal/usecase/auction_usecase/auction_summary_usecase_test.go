@@ -0,0 +1,111 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAuctionSummaryOfActiveAuction(t *testing.T) {
+	auctionRepository := newEditableAuction()
+	auctionRepository.highestBids = map[string]*bid_entity.Bid{
+		"auction-1": {Id: "bid-1", UserId: "user-1", AuctionId: "auction-1", AmountCents: 70000},
+	}
+	auctionRepository.bidCounts = map[string]int64{"auction-1": 4}
+
+	t.Run("persisted highest bid", func(t *testing.T) {
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, fakePendingBids{}, config.Default())
+
+		summary, err := useCase.FindAuctionSummary(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "auction-1", summary.Auction.Id)
+		assert.Equal(t, "iPhone 15", summary.Auction.ProductName)
+		assert.Equal(t, auction_usecase.StatusActive, summary.Status)
+		assert.Equal(t, int64(4), summary.BidCount)
+		if assert.NotNil(t, summary.HighestBid) {
+			assert.Equal(t, "bid-1", summary.HighestBid.Id)
+			assert.Equal(t, 700.0, summary.HighestBid.Amount)
+			assert.True(t, summary.HighestBid.Persisted)
+		}
+
+		// The auction expires in about an hour
+		assert.InDelta(t, time.Hour.Seconds()-time.Minute.Seconds(), summary.SecondsRemaining, 5)
+	})
+
+	t.Run("pending bid above the persisted one", func(t *testing.T) {
+		pending := fakePendingBids{"auction-1": {Id: "bid-2", UserId: "user-2", AuctionId: "auction-1", Amount: 800}}
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, pending, config.Default())
+
+		summary, err := useCase.FindAuctionSummary(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		if assert.NotNil(t, summary.HighestBid) {
+			assert.Equal(t, "bid-2", summary.HighestBid.Id)
+			assert.Equal(t, 800.0, summary.HighestBid.Amount)
+			assert.False(t, summary.HighestBid.Persisted)
+		}
+		assert.Equal(t, int64(4), summary.BidCount)
+	})
+
+	t.Run("without bids", func(t *testing.T) {
+		useCase := auction_usecase.NewAuctionUseCase(newEditableAuction(), &fakeBidRepository{}, nil, fakePendingBids{}, config.Default())
+
+		summary, err := useCase.FindAuctionSummary(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		assert.Nil(t, summary.HighestBid)
+		assert.Zero(t, summary.BidCount)
+		assert.Positive(t, summary.SecondsRemaining)
+	})
+}
+
+func TestFindAuctionSummaryOfCompletedAuction(t *testing.T) {
+	createdAt := time.Now().Add(-time.Hour)
+	auctionRepository := &fakeAuctionRepository{
+		auctions: []auction_entity.Auction{{
+			Id:          "auction-1",
+			ProductName: "MacBook",
+			Status:      auction_entity.Completed,
+			CreatedAt:   createdAt,
+			ExpiresAt:   createdAt.Add(time.Minute),
+		}},
+		highestBids: map[string]*bid_entity.Bid{
+			"auction-1": {Id: "bid-9", UserId: "user-1", AuctionId: "auction-1", AmountCents: 90000},
+		},
+		bidCounts: map[string]int64{"auction-1": 12},
+	}
+	useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, fakePendingBids{}, config.Default())
+
+	summary, err := useCase.FindAuctionSummary(context.Background(), "auction-1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "auction-1", summary.Auction.Id)
+	assert.Equal(t, "MacBook", summary.Auction.ProductName)
+	assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), summary.Auction.Status)
+	assert.Equal(t, auction_usecase.StatusCompleted, summary.Status)
+	assert.Equal(t, int64(12), summary.BidCount)
+	assert.Zero(t, summary.SecondsRemaining)
+	assert.Equal(t, &bid_usecase.CurrentBidOutputDTO{
+		BidOutputDTO: bid_usecase.BidOutputDTO{Id: "bid-9", UserId: "user-1", AuctionId: "auction-1", Amount: 900},
+		Persisted:    true,
+	}, summary.HighestBid)
+}
+
+func TestFindAuctionSummaryOfMissingAuction(t *testing.T) {
+	useCase := auction_usecase.NewAuctionUseCase(&fakeAuctionRepository{}, &fakeBidRepository{}, nil, fakePendingBids{}, config.Default())
+
+	summary, err := useCase.FindAuctionSummary(context.Background(), "auction-1")
+
+	assert.Nil(t, summary)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "not_found", err.Err)
+	}
+}
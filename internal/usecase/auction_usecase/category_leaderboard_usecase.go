@@ -0,0 +1,90 @@
+package auction_usecase
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+)
+
+// FindCategoryLeaderboard returns up to limit active auctions of a category,
+// sorted by their current highest bid, descending. A bid accepted but not
+// persisted yet counts as the current highest when it tops the stored one.
+func (au *AuctionUseCase) FindCategoryLeaderboard(
+	ctx context.Context,
+	category string,
+	limit int64) ([]AuctionOutputDTO, *internal_error.InternalError) {
+	if limit <= 0 || limit > au.maxLeaderboardSize {
+		return nil, internal_error.NewBadRequestError(
+			"limit must be between 1 and " + strconv.FormatInt(au.maxLeaderboardSize, 10))
+	}
+
+	var pendingBids map[string]bid_usecase.BidOutputDTO
+	if au.pendingBids != nil {
+		pendingBids = au.pendingBids.FindPendingBids(ctx)
+	}
+
+	// Auctions with a pending bid may outrank the persisted top, so they are
+	// fetched along with it
+	pinnedIds := make([]string, 0, len(pendingBids))
+	for auctionId := range pendingBids {
+		pinnedIds = append(pinnedIds, auctionId)
+	}
+
+	auctions, err := au.auctionRepositoryInterface.FindTopAuctionsByCategory(ctx, category, limit, pinnedIds)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := make([]AuctionOutputDTO, 0, len(auctions))
+	for _, auction := range auctions {
+		auctionOutput := newAuctionOutputDTO(auction.Auction)
+		if auction.HighestBid != nil {
+			auctionOutput.HighestBid = newBidOutputDTO(auction.HighestBid)
+		}
+
+		if pending, ok := pendingBids[auction.Id]; ok && au.outranks(pending, auctionOutput.HighestBid) {
+			pendingBid := pending
+			auctionOutput.HighestBid = &pendingBid
+		}
+
+		leaderboard = append(leaderboard, auctionOutput)
+	}
+
+	sort.SliceStable(leaderboard, func(i, j int) bool {
+		return highestAmount(leaderboard[i]) > highestAmount(leaderboard[j])
+	})
+
+	if int64(len(leaderboard)) > limit {
+		leaderboard = leaderboard[:limit]
+	}
+
+	return leaderboard, nil
+}
+
+// outranks reports whether a pending bid replaces the persisted highest one,
+// following the tie policy on equal amounts. The amounts are compared in
+// cents, like when the bids were accepted.
+func (au *AuctionUseCase) outranks(pending bid_usecase.BidOutputDTO, persisted *bid_usecase.BidOutputDTO) bool {
+	if persisted == nil {
+		return true
+	}
+
+	pendingCents, persistedCents := bid_entity.ToCents(pending.Amount), bid_entity.ToCents(persisted.Amount)
+	if pendingCents > persistedCents {
+		return true
+	}
+
+	return pendingCents == persistedCents && au.tiePolicy == bid_entity.LastWriteWins
+}
+
+func highestAmount(auction AuctionOutputDTO) float64 {
+	if auction.HighestBid == nil {
+		return 0
+	}
+
+	return auction.HighestBid.Amount
+}
@@ -0,0 +1,109 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePendingBids map[string]bid_usecase.BidOutputDTO
+
+func (f fakePendingBids) FindPendingBids(ctx context.Context) map[string]bid_usecase.BidOutputDTO {
+	return f
+}
+
+func (f fakePendingBids) Flush(ctx context.Context) (int, *internal_error.InternalError) {
+	return len(f), nil
+}
+
+func leaderboardIds(leaderboard []auction_usecase.AuctionOutputDTO) []string {
+	var ids []string
+	for _, auction := range leaderboard {
+		ids = append(ids, auction.Id)
+	}
+	return ids
+}
+
+func TestFindCategoryLeaderboardOrdersByCurrentHighestBid(t *testing.T) {
+	// auction-0..auction-3 are active electronics auctions
+	repository := newFakeAuctionRepository(4)
+	repository.highestBids = map[string]*bid_entity.Bid{
+		"auction-0": {Id: "bid-0", AuctionId: "auction-0", AmountCents: 10000},
+		"auction-1": {Id: "bid-1", AuctionId: "auction-1", AmountCents: 30000},
+		"auction-2": {Id: "bid-2", AuctionId: "auction-2", AmountCents: 20000},
+	}
+
+	// auction-3 only has a bid still waiting in the pipeline, and it is the top
+	pending := fakePendingBids{
+		"auction-3": {Id: "pending-3", AuctionId: "auction-3", Amount: 500},
+		"auction-0": {Id: "pending-0", AuctionId: "auction-0", Amount: 50}, // below the persisted one
+	}
+
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, pending, config.Default())
+
+	leaderboard, err := useCase.FindCategoryLeaderboard(context.Background(), "electronics", 3)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"auction-3", "auction-1", "auction-2"}, leaderboardIds(leaderboard))
+	assert.Equal(t, "pending-3", leaderboard[0].HighestBid.Id)
+	assert.Equal(t, 500.0, leaderboard[0].HighestBid.Amount)
+	assert.Equal(t, "bid-1", leaderboard[1].HighestBid.Id)
+}
+
+func TestFindCategoryLeaderboardKeepsPersistedBidOverLowerPending(t *testing.T) {
+	repository := newFakeAuctionRepository(2)
+	repository.highestBids = map[string]*bid_entity.Bid{
+		"auction-0": {Id: "bid-0", AuctionId: "auction-0", AmountCents: 10000},
+		"auction-1": {Id: "bid-1", AuctionId: "auction-1", AmountCents: 30000},
+	}
+	pending := fakePendingBids{"auction-0": {Id: "pending-0", AuctionId: "auction-0", Amount: 400}}
+
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, pending, config.Default())
+
+	leaderboard, err := useCase.FindCategoryLeaderboard(context.Background(), "electronics", 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"auction-0", "auction-1"}, leaderboardIds(leaderboard))
+	assert.Equal(t, "pending-0", leaderboard[0].HighestBid.Id)
+}
+
+func TestFindCategoryLeaderboardSkipsInactiveAndOtherCategories(t *testing.T) {
+	repository := newFakeAuctionRepository(3)
+	repository.auctions[1].Status = auction_entity.Completed
+	repository.auctions[2].Category = "books"
+
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	leaderboard, err := useCase.FindCategoryLeaderboard(context.Background(), "electronics", 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"auction-0"}, leaderboardIds(leaderboard))
+	assert.Nil(t, leaderboard[0].HighestBid)
+}
+
+func TestFindCategoryLeaderboardWithoutActiveAuctions(t *testing.T) {
+	useCase := auction_usecase.NewAuctionUseCase(newFakeAuctionRepository(2), nil, nil, nil, config.Default())
+
+	leaderboard, err := useCase.FindCategoryLeaderboard(context.Background(), "furniture", 10)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, leaderboard)
+	assert.Empty(t, leaderboard)
+}
+
+func TestFindCategoryLeaderboardValidatesLimit(t *testing.T) {
+	useCase := auction_usecase.NewAuctionUseCase(newFakeAuctionRepository(1), nil, nil, nil, config.Default())
+
+	for _, limit := range []int64{0, -1, 101} {
+		_, err := useCase.FindCategoryLeaderboard(context.Background(), "electronics", limit)
+		assert.NotNil(t, err)
+		assert.Equal(t, "bad_request", err.Err)
+	}
+}
@@ -0,0 +1,41 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// CloseAuction completes an active auction right away, whatever its
+// expiration. Its pending bids, batched or still queued, are persisted first,
+// while the auction still accepts them: bids reaching the batch writer after
+// the auction completed are dropped, and the winner is read from the
+// persisted bids.
+func (au *AuctionUseCase) CloseAuction(
+	ctx context.Context,
+	auctionId string) (*AuctionOutputDTO, *internal_error.InternalError) {
+	auction, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status != auction_entity.Active {
+		return nil, internal_error.NewAuctionCompletedError()
+	}
+
+	if au.pendingBids != nil {
+		if _, hasPending := au.pendingBids.FindPendingBids(ctx)[auctionId]; hasPending {
+			if _, err := au.pendingBids.Flush(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	closed, err := au.auctionRepositoryInterface.CloseAuction(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	output := newAuctionOutputDTO(*closed)
+	return &output, nil
+}
@@ -0,0 +1,176 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// flushRecorder records whether the pending bids were flushed, and whether
+// the auction was still active at that moment.
+type flushRecorder struct {
+	fakePendingBids
+	auctionRepository *fakeAuctionRepository
+	flushed           bool
+	activeWhenFlushed bool
+}
+
+func (f *flushRecorder) Flush(ctx context.Context) (int, *internal_error.InternalError) {
+	f.flushed = true
+	f.activeWhenFlushed = f.auctionRepository.auctions[0].Status == auction_entity.Active
+	return len(f.fakePendingBids), nil
+}
+
+// closingBidRepository drops the bids of a completed auction, as the bid
+// repository does once told of the close, and holds every insert until
+// release is closed.
+type closingBidRepository struct {
+	fakeBidRepository
+	auctionRepository *fakeAuctionRepository
+	release           chan struct{}
+}
+
+func (r *closingBidRepository) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) (bid_entity.BidBatchResult, *internal_error.InternalError) {
+	<-r.release
+	if r.auctionRepository.auctions[0].Status == auction_entity.Completed {
+		return bid_entity.BidBatchResult{Dropped: bidEntities}, nil
+	}
+	return r.fakeBidRepository.CreateBid(ctx, bidEntities)
+}
+
+// flushSignal tells when the close starts flushing the pending bids.
+type flushSignal struct {
+	auction_usecase.PendingBidsSource
+	flushing chan struct{}
+}
+
+func (f *flushSignal) Flush(ctx context.Context) (int, *internal_error.InternalError) {
+	close(f.flushing)
+	return f.PendingBidsSource.Flush(ctx)
+}
+
+type fakeUserRepository struct {
+	user_entity.UserRepositoryInterface
+}
+
+func (fakeUserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	return &user_entity.User{Id: userId}, nil
+}
+
+func TestCloseAuction(t *testing.T) {
+	t.Run("completes an active auction before its expiration", func(t *testing.T) {
+		auctionRepository := newEditableAuction()
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, fakePendingBids{}, config.Default())
+
+		closed, err := useCase.CloseAuction(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), closed.Status)
+		assert.Equal(t, auction_entity.Completed, auctionRepository.auctions[0].Status)
+	})
+
+	t.Run("persists the pending bids of the auction first", func(t *testing.T) {
+		auctionRepository := newEditableAuction()
+		pending := &flushRecorder{
+			fakePendingBids:   fakePendingBids{"auction-1": {Id: "bid-1", AuctionId: "auction-1", Amount: 900}},
+			auctionRepository: auctionRepository,
+		}
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, pending, config.Default())
+
+		_, err := useCase.CloseAuction(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		assert.True(t, pending.flushed)
+		assert.True(t, pending.activeWhenFlushed)
+	})
+
+	t.Run("pending bids of other auctions are left to the batch", func(t *testing.T) {
+		auctionRepository := newEditableAuction()
+		pending := &flushRecorder{
+			fakePendingBids:   fakePendingBids{"auction-2": {Id: "bid-2", AuctionId: "auction-2"}},
+			auctionRepository: auctionRepository,
+		}
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, pending, config.Default())
+
+		_, err := useCase.CloseAuction(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		assert.False(t, pending.flushed)
+	})
+
+	t.Run("already completed auction", func(t *testing.T) {
+		auctionRepository := newEditableAuction()
+		auctionRepository.auctions[0].Status = auction_entity.Completed
+		pending := &flushRecorder{
+			fakePendingBids:   fakePendingBids{"auction-1": {Id: "bid-1", AuctionId: "auction-1"}},
+			auctionRepository: auctionRepository,
+		}
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, pending, config.Default())
+
+		closed, err := useCase.CloseAuction(context.Background(), "auction-1")
+
+		assert.Nil(t, closed)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "bad_request", err.Err)
+			assert.Equal(t, internal_error.AuctionCompletedCode, err.Code)
+		}
+		assert.False(t, pending.flushed)
+	})
+
+	t.Run("missing auction", func(t *testing.T) {
+		useCase := auction_usecase.NewAuctionUseCase(&fakeAuctionRepository{}, &fakeBidRepository{}, nil, fakePendingBids{}, config.Default())
+
+		_, err := useCase.CloseAuction(context.Background(), "auction-1")
+
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "not_found", err.Err)
+		}
+	})
+}
+
+func TestCloseAuctionPersistsBidsQueuedInTheChannel(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "2")
+	appConfig, configErr := config.LoadConfig()
+	assert.Nil(t, configErr)
+
+	auctionRepository := newEditableAuction()
+	auctionId := uuid.New().String()
+	auctionRepository.auctions[0].Id = auctionId
+	bidRepository := &closingBidRepository{auctionRepository: auctionRepository, release: make(chan struct{})}
+	bidUseCase := bid_usecase.NewBidUseCase(
+		bidRepository, auctionRepository, fakeUserRepository{}, nil, nil, nil, appConfig.Bid)
+	pending := &flushSignal{PendingBidsSource: bidUseCase, flushing: make(chan struct{})}
+	useCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, nil, pending, config.Default())
+
+	// The first two bids fill a batch held by the repository, so the other
+	// two wait in the channel, whose capacity is the batch size
+	for i := 1; i <= 4; i++ {
+		_, err := bidUseCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: float64(1000 * i),
+		})
+		assert.Nil(t, err)
+	}
+
+	closed := make(chan *internal_error.InternalError)
+	go func() {
+		_, err := useCase.CloseAuction(context.Background(), auctionId)
+		closed <- err
+	}()
+	<-pending.flushing
+	close(bidRepository.release)
+
+	assert.Nil(t, <-closed)
+	assert.Len(t, bidRepository.bids, 4)
+	assert.Empty(t, bidUseCase.FindPendingBids(context.Background()))
+}
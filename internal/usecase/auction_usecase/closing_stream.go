@@ -0,0 +1,155 @@
+package auction_usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+const (
+	ClosingEvent = "closing" // the auction entered the subscriber's window
+	ClosedEvent  = "closed"  // the closer completed the auction
+
+	// maxClosingWindow bounds how far ahead a subscriber may watch
+	maxClosingWindow = 24 * time.Hour
+
+	// closingEventsBuffer is how many events a subscriber may lag behind
+	// before it is dropped as a slow consumer
+	closingEventsBuffer = 32
+)
+
+// ClosingAuctionEventDTO is pushed to the subscribers of the closing stream.
+type ClosingAuctionEventDTO struct {
+	Event   string           `json:"event"`
+	Auction AuctionOutputDTO `json:"auction"`
+}
+
+// ClosingStream fans out the closer's view of expiring auctions to a bounded
+// number of subscribers. It implements auction_entity.AuctionClosingObserver.
+type ClosingStream struct {
+	mutex          sync.Mutex
+	subscribers    map[*closingSubscriber]struct{}
+	maxSubscribers int
+}
+
+type closingSubscriber struct {
+	within    time.Duration
+	events    chan ClosingAuctionEventDTO
+	announced map[string]struct{} // auctions already sent as closing
+}
+
+// NewClosingStream returns a stream watched by at most maxSubscribers clients
+// at once.
+func NewClosingStream(maxSubscribers int) *ClosingStream {
+	return &ClosingStream{
+		subscribers:    make(map[*closingSubscriber]struct{}),
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+// Subscribe registers a subscriber for auctions expiring within the given
+// window. The returned channel is closed when ctx is done or when the
+// subscriber falls too far behind.
+func (cs *ClosingStream) Subscribe(
+	ctx context.Context, within time.Duration) (<-chan ClosingAuctionEventDTO, *internal_error.InternalError) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	if len(cs.subscribers) >= cs.maxSubscribers {
+		return nil, internal_error.NewServiceUnavailableError("Too many closing stream subscribers")
+	}
+
+	subscriber := &closingSubscriber{
+		within:    within,
+		events:    make(chan ClosingAuctionEventDTO, closingEventsBuffer),
+		announced: make(map[string]struct{}),
+	}
+	cs.subscribers[subscriber] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		cs.mutex.Lock()
+		cs.remove(subscriber)
+		cs.mutex.Unlock()
+	}()
+
+	return subscriber.events, nil
+}
+
+// ClosingHorizon returns the widest window among the current subscribers.
+func (cs *ClosingStream) ClosingHorizon() time.Duration {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	var horizon time.Duration
+	for subscriber := range cs.subscribers {
+		if subscriber.within > horizon {
+			horizon = subscriber.within
+		}
+	}
+
+	return horizon
+}
+
+// AuctionsClosing sends each auction once to every subscriber whose window
+// it has entered.
+func (cs *ClosingStream) AuctionsClosing(now time.Time, auctions []auction_entity.Auction) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	for subscriber := range cs.subscribers {
+		for _, auction := range auctions {
+			if _, ok := subscriber.announced[auction.Id]; ok ||
+				auction.ExpiresAt.Sub(now) > subscriber.within {
+				continue
+			}
+
+			subscriber.announced[auction.Id] = struct{}{}
+			if !cs.send(subscriber, ClosingEvent, auction) {
+				break
+			}
+		}
+	}
+}
+
+// AuctionsClosed sends the completed auctions to every subscriber.
+func (cs *ClosingStream) AuctionsClosed(auctions []auction_entity.Auction) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	for subscriber := range cs.subscribers {
+		for _, auction := range auctions {
+			delete(subscriber.announced, auction.Id)
+			if !cs.send(subscriber, ClosedEvent, auction) {
+				break
+			}
+		}
+	}
+}
+
+// send delivers an event without blocking the closer, dropping a subscriber
+// whose buffer is full. It must be called with mutex held.
+func (cs *ClosingStream) send(
+	subscriber *closingSubscriber, event string, auction auction_entity.Auction) bool {
+	select {
+	case subscriber.events <- ClosingAuctionEventDTO{Event: event, Auction: newAuctionOutputDTO(auction)}:
+		return true
+	default:
+		cs.remove(subscriber)
+		return false
+	}
+}
+
+// remove unregisters a subscriber and closes its channel. It must be called
+// with mutex held.
+func (cs *ClosingStream) remove(subscriber *closingSubscriber) {
+	if _, ok := cs.subscribers[subscriber]; !ok {
+		return
+	}
+
+	delete(cs.subscribers, subscriber)
+	close(subscriber.events)
+}
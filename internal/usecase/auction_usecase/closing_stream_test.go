@@ -0,0 +1,108 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func receiveClosingEvent(
+	t *testing.T, events <-chan auction_usecase.ClosingAuctionEventDTO) (auction_usecase.ClosingAuctionEventDTO, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("no closing event received")
+		return auction_usecase.ClosingAuctionEventDTO{}, false
+	}
+}
+
+func assertNoClosingEvent(t *testing.T, events <-chan auction_usecase.ClosingAuctionEventDTO) {
+	t.Helper()
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected closing event %+v", event)
+	default:
+	}
+}
+
+func TestClosingStreamNotifiesAuctionEnteringWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := auction_usecase.NewClosingStream(1)
+	useCase := auction_usecase.NewAuctionUseCase(newFakeAuctionRepository(0), nil, stream, nil, config.Default())
+
+	events, err := useCase.SubscribeClosingAuctions(ctx, 5*time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, stream.ClosingHorizon())
+
+	now := time.Now()
+	auction := auction_entity.Auction{Id: "auction-1", Status: auction_entity.Active, ExpiresAt: now.Add(10 * time.Minute)}
+
+	// Still outside the window
+	stream.AuctionsClosing(now, []auction_entity.Auction{auction})
+	assertNoClosingEvent(t, events)
+
+	// Six minutes later it is four minutes from closing
+	later := now.Add(6 * time.Minute)
+	stream.AuctionsClosing(later, []auction_entity.Auction{auction})
+	event, _ := receiveClosingEvent(t, events)
+	assert.Equal(t, auction_usecase.ClosingEvent, event.Event)
+	assert.Equal(t, "auction-1", event.Auction.Id)
+
+	// It is announced only once
+	stream.AuctionsClosing(later.Add(time.Minute), []auction_entity.Auction{auction})
+	assertNoClosingEvent(t, events)
+
+	auction.Status = auction_entity.Completed
+	stream.AuctionsClosed([]auction_entity.Auction{auction})
+	event, _ = receiveClosingEvent(t, events)
+	assert.Equal(t, auction_usecase.ClosedEvent, event.Event)
+	assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), event.Auction.Status)
+}
+
+func TestClosingStreamUnsubscribesOnDisconnect(t *testing.T) {
+	stream := auction_usecase.NewClosingStream(1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := stream.Subscribe(ctx, time.Minute)
+	assert.Nil(t, err)
+
+	cancel()
+
+	_, ok := receiveClosingEvent(t, events)
+	assert.False(t, ok)
+	assert.Zero(t, stream.ClosingHorizon())
+}
+
+func TestClosingStreamBoundsSubscribers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := auction_usecase.NewClosingStream(1)
+
+	_, err := stream.Subscribe(ctx, time.Minute)
+	assert.Nil(t, err)
+
+	_, err = stream.Subscribe(ctx, time.Minute)
+	assert.NotNil(t, err)
+	assert.Equal(t, "service_unavailable", err.Err)
+}
+
+func TestSubscribeClosingAuctionsValidatesWindow(t *testing.T) {
+	useCase := auction_usecase.NewAuctionUseCase(
+		newFakeAuctionRepository(0), nil, auction_usecase.NewClosingStream(1), nil, config.Default())
+
+	for _, within := range []time.Duration{0, -time.Minute, 25 * time.Hour} {
+		_, err := useCase.SubscribeClosingAuctions(context.Background(), within)
+		assert.NotNil(t, err)
+		assert.Equal(t, "bad_request", err.Err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
@@ -14,7 +15,16 @@ type AuctionInputDTO struct {
 	ProductName string           `json:"product_name" binding:"required,min=1"`
 	Category    string           `json:"category" binding:"required,min=2"`
 	Description string           `json:"description" binding:"required,min=10,max=200"`
-	Condition   ProductCondition `json:"condition" binding:"oneof=0 1 2"`
+	Condition   ProductCondition `json:"condition" binding:"oneof=0 1 2 3"`
+
+	// AllowSelfOutbid overrides ALLOW_SELF_OUTBID for this auction when set
+	AllowSelfOutbid *bool `json:"allow_self_outbid,omitempty"`
+
+	// MinIncrement overrides AUCTION_MIN_INCREMENT for this auction when set
+	MinIncrement *float64 `json:"min_increment,omitempty" binding:"omitempty,gte=0"`
+
+	// ReservePrice is the lowest winning bid for which the item is sold
+	ReservePrice float64 `json:"reserve_price,omitempty"`
 }
 
 type AuctionOutputDTO struct {
@@ -26,19 +36,144 @@ type AuctionOutputDTO struct {
 	Status      AuctionStatus    `json:"status"`
 	CreatedAt   time.Time        `json:"created_at" time_format:"2006-01-02 15:04:05"`
 	ExpiresAt   time.Time        `json:"expires_at" time_format:"2006-01-02 15:04:05"`
+	UpdatedAt   time.Time        `json:"updated_at" time_format:"2006-01-02 15:04:05"`
+	ClosedAt    *time.Time       `json:"closed_at,omitempty" time_format:"2006-01-02 15:04:05"`
+	Version     int64            `json:"version"`
+
+	// SecondsRemaining and IsExpired are computed when the response is built,
+	// from the server clock, and never stored. Both treat a completed auction
+	// as expired.
+	SecondsRemaining int64 `json:"seconds_remaining"`
+	IsExpired        bool  `json:"is_expired"`
+
+	AllowSelfOutbid *bool   `json:"allow_self_outbid,omitempty"`
+	MinIncrement    float64 `json:"min_increment"`
+
+	// RelistedFrom is the auction this one relists, when it does
+	RelistedFrom string `json:"relisted_from,omitempty"`
+
+	// HighestBid is only filled when requested with include=highest_bid
+	HighestBid *bid_usecase.BidOutputDTO `json:"highest_bid,omitempty"`
+
+	// Sold is only filled in the recently completed view: the winning bid is
+	// then HighestBid, and false means the auction closed without bids
+	Sold *bool `json:"sold,omitempty"`
+}
+
+// FindAuctionsInputDTO carries the optional listing filters. Nil pointers and
+// empty strings leave the corresponding criterion out of the query.
+type FindAuctionsInputDTO struct {
+	Status      *AuctionStatus
+	Condition   *ProductCondition
+	Category    string
+	ProductName string
+
+	// Query matches either the product name or the category
+	Query string
+
+	// HasBids keeps only the auctions with at least one persisted bid
+	HasBids bool
+
+	// IncludeHighestBid embeds the current top bid of each auction
+	IncludeHighestBid bool
+
+	// Sort orders the listing; SortClosedDesc is the recently completed view
+	Sort AuctionSort
+
+	// Limit (default 20, at most 100) and Offset select the page returned
+	Limit  int64
+	Offset int64
+}
+
+// FindAuctionsOutputDTO is a page of the listing and how many auctions match
+// the filters in total.
+type FindAuctionsOutputDTO struct {
+	Auctions []AuctionOutputDTO `json:"data"`
+	Total    int64              `json:"total"`
+	Limit    int64              `json:"limit"`
+	Offset   int64              `json:"offset"`
 }
 
+// FindAuctionStatusesInputDTO lists the auctions whose status is requested.
+type FindAuctionStatusesInputDTO struct {
+	AuctionIds []string `json:"auction_ids" binding:"required,min=1,max=100"`
+}
+
+// AuctionStatusOutputDTO is the status of one requested auction: "active",
+// "completed" or, when no auction has that id, "not_found".
+type AuctionStatusOutputDTO struct {
+	Id     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// AuctionExportDTO is a full archival export of an auction. Bids are streamed,
+// oldest first, so large auctions are never held in memory; the channel is
+// closed once every bid was sent.
+type AuctionExportDTO struct {
+	ExportedAt time.Time                 `json:"exported_at"`
+	Auction    AuctionOutputDTO          `json:"auction"`
+	Winner     *bid_usecase.BidOutputDTO `json:"winner"`
+
+	Bids <-chan bid_usecase.BidOutputDTO `json:"-"`
+}
+
+// CloseExpiredAuctionsOutputDTO reports how many auctions an on-demand closer
+// cycle completed.
+type CloseExpiredAuctionsOutputDTO struct {
+	Closed int64 `json:"closed"`
+}
+
+// WinningInfoOutputDTO is the winner of an auction. ReserveMet is false when
+// there is no bid or the highest one is below the reserve price, in which
+// case the item is not sold even though Bid is reported.
 type WinningInfoOutputDTO struct {
-	Auction AuctionOutputDTO          `json:"auction"`
-	Bid     *bid_usecase.BidOutputDTO `json:"bid,omitempty"`
+	Auction    AuctionOutputDTO          `json:"auction"`
+	Bid        *bid_usecase.BidOutputDTO `json:"bid,omitempty"`
+	ReserveMet bool                      `json:"reserve_met"`
+}
+
+// AuctionSummaryOutputDTO is what a client needs to render an auction at
+// once. HighestBid may still be pending in the batch, while BidCount only
+// counts persisted bids. SecondsRemaining is zero once the auction expired or
+// was completed.
+type AuctionSummaryOutputDTO struct {
+	Auction          AuctionOutputDTO                 `json:"auction"`
+	HighestBid       *bid_usecase.CurrentBidOutputDTO `json:"highest_bid"`
+	BidCount         int64                            `json:"bid_count"`
+	SecondsRemaining int64                            `json:"seconds_remaining"`
+	Status           string                           `json:"status"`
+}
+
+// UserAuctionOutputDTO is an auction a user has bid on, with the highest
+// persisted bid of that user on it.
+type UserAuctionOutputDTO struct {
+	Auction        AuctionOutputDTO         `json:"auction"`
+	UserHighestBid bid_usecase.BidOutputDTO `json:"user_highest_bid"`
+}
+
+// PendingBidsSource provides, for each auction, the highest bid accepted but
+// not persisted yet, and persists the pending bids on demand. It is
+// implemented by bid_usecase.BidUseCaseInterface.
+type PendingBidsSource interface {
+	FindPendingBids(ctx context.Context) map[string]bid_usecase.BidOutputDTO
+	Flush(ctx context.Context) (int, *internal_error.InternalError)
 }
 
 func NewAuctionUseCase(
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface,
-	bidRepositoryInterface bid_entity.BidEntityRepository) AuctionUseCaseInterface {
+	bidRepositoryInterface bid_entity.BidEntityRepository,
+	closingStream *ClosingStream,
+	pendingBids PendingBidsSource,
+	appConfig config.Config) AuctionUseCaseInterface {
 	return &AuctionUseCase{
 		auctionRepositoryInterface: auctionRepositoryInterface,
 		bidRepositoryInterface:     bidRepositoryInterface,
+		closingStream:              closingStream,
+		pendingBids:                pendingBids,
+		auctionInterval:            appConfig.Auction.Interval,
+		minIncrement:               appConfig.Auction.MinIncrement,
+		maxLeaderboardSize:         int64(appConfig.Auction.MaxLeaderboardSize),
+		tiePolicy:                  appConfig.Bid.TiePolicy,
 	}
 }
 
@@ -52,20 +187,92 @@ type AuctionUseCaseInterface interface {
 
 	FindAuctions(
 		ctx context.Context,
-		status AuctionStatus,
-		category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError)
+		filterInput FindAuctionsInputDTO) (*FindAuctionsOutputDTO, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context,
 		auctionId string) (*WinningInfoOutputDTO, *internal_error.InternalError)
+
+	FindCategoryLeaderboard(
+		ctx context.Context,
+		category string,
+		limit int64) ([]AuctionOutputDTO, *internal_error.InternalError)
+
+	FindAuctionStatuses(
+		ctx context.Context,
+		input FindAuctionStatusesInputDTO) ([]AuctionStatusOutputDTO, *internal_error.InternalError)
+
+	ExportAuction(
+		ctx context.Context,
+		auctionId string) (*AuctionExportDTO, *internal_error.InternalError)
+
+	// FindAuctionSummary returns an auction with its current highest bid, its
+	// bid count and the time it has left
+	FindAuctionSummary(
+		ctx context.Context,
+		auctionId string) (*AuctionSummaryOutputDTO, *internal_error.InternalError)
+
+	// FindUserAuctions lists the auctions a user has bid on, each with the
+	// highest bid of that user
+	FindUserAuctions(
+		ctx context.Context,
+		userId string) ([]UserAuctionOutputDTO, *internal_error.InternalError)
+
+	SubscribeClosingAuctions(
+		ctx context.Context,
+		within time.Duration) (<-chan ClosingAuctionEventDTO, *internal_error.InternalError)
+
+	CloseExpiredAuctions(ctx context.Context) (*CloseExpiredAuctionsOutputDTO, *internal_error.InternalError)
+
+	// CloseAuction ends an active auction early, persisting its pending bids
+	// first
+	CloseAuction(ctx context.Context, auctionId string) (*AuctionOutputDTO, *internal_error.InternalError)
+
+	// RelistAuction creates a new auction of the product of a completed
+	// auction whose reserve price was not met
+	RelistAuction(ctx context.Context, auctionId string) (*AuctionOutputDTO, *internal_error.InternalError)
+
+	// DeleteAuction soft-deletes an auction, keeping its bids
+	DeleteAuction(ctx context.Context, auctionId string) *internal_error.InternalError
+
+	// UpdateAuction applies a JSON Merge Patch to the editable fields of an
+	// active auction that has not received any bid
+	UpdateAuction(
+		ctx context.Context,
+		auctionId string,
+		patch []byte) (*AuctionOutputDTO, *internal_error.InternalError)
 }
 
 type ProductCondition int64
 type AuctionStatus int64
+type AuctionSort int64
+
+const (
+	SortDefault AuctionSort = iota
+	// SortClosedDesc lists completed auctions, most recently closed first,
+	// with their winning bid
+	SortClosedDesc
+	// SortBidCountDesc lists the most active auctions first, by number of
+	// persisted bids
+	SortBidCountDesc
+	// SortCreatedAsc and SortCreatedDesc order by creation time;
+	// SortCreatedDesc (newest first) is what a listing without sort gets
+	SortCreatedAsc
+	SortCreatedDesc
+	// SortExpiresAsc and SortExpiresDesc order by expiration time
+	SortExpiresAsc
+	SortExpiresDesc
+)
 
 type AuctionUseCase struct {
 	auctionRepositoryInterface auction_entity.AuctionRepositoryInterface
 	bidRepositoryInterface     bid_entity.BidEntityRepository
+	closingStream              *ClosingStream
+	pendingBids                PendingBidsSource
+	auctionInterval            time.Duration
+	minIncrement               float64 // of the auctions that do not set their own
+	maxLeaderboardSize         int64
+	tiePolicy                  bid_entity.TiePolicy // decides equal pending and persisted bids
 }
 
 func (au *AuctionUseCase) CreateAuction(
@@ -75,11 +282,23 @@ func (au *AuctionUseCase) CreateAuction(
 		auctionInput.ProductName,
 		auctionInput.Category,
 		auctionInput.Description,
-		auction_entity.ProductCondition(auctionInput.Condition))
+		auction_entity.ProductCondition(auctionInput.Condition),
+		au.auctionInterval)
 	if err != nil {
 		return err
 	}
 
+	auction.AllowSelfOutbid = auctionInput.AllowSelfOutbid
+	auction.MinIncrement = au.minIncrement
+	if auctionInput.MinIncrement != nil {
+		auction.MinIncrement = *auctionInput.MinIncrement
+	}
+
+	auction.ReservePrice = auctionInput.ReservePrice
+	if err := auction.Validate(); err != nil {
+		return err
+	}
+
 	if err := au.auctionRepositoryInterface.CreateAuction(
 		ctx, auction); err != nil {
 		return err
@@ -87,3 +306,32 @@ func (au *AuctionUseCase) CreateAuction(
 
 	return nil
 }
+
+// CloseExpiredAuctions runs one closer cycle immediately, for tests and manual
+// operations that cannot wait for AUCTION_CLOSE_CHECK_INTERVAL.
+func (au *AuctionUseCase) CloseExpiredAuctions(
+	ctx context.Context) (*CloseExpiredAuctionsOutputDTO, *internal_error.InternalError) {
+	closed, err := au.auctionRepositoryInterface.CloseExpiredAuctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CloseExpiredAuctionsOutputDTO{Closed: closed}, nil
+}
+
+// SubscribeClosingAuctions streams the auctions entering the closing window
+// and the ones being closed until ctx is done.
+func (au *AuctionUseCase) SubscribeClosingAuctions(
+	ctx context.Context,
+	within time.Duration) (<-chan ClosingAuctionEventDTO, *internal_error.InternalError) {
+	if au.closingStream == nil {
+		return nil, internal_error.NewServiceUnavailableError("Closing stream is not available")
+	}
+
+	if within <= 0 || within > maxClosingWindow {
+		return nil, internal_error.NewBadRequestError(
+			"within must be a positive duration up to " + maxClosingWindow.String())
+	}
+
+	return au.closingStream.Subscribe(ctx, within)
+}
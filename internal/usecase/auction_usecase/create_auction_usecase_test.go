@@ -0,0 +1,41 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAuctionDefaultsMinIncrement(t *testing.T) {
+	override := 5.0
+	for _, testCase := range []struct {
+		name         string
+		minIncrement *float64
+		expected     float64
+	}{
+		{name: "configured default", expected: 2.5},
+		{name: "auction override", minIncrement: &override, expected: 5},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			appConfig := config.Default()
+			appConfig.Auction.MinIncrement = 2.5
+			auctionRepository := newFakeAuctionRepository(0)
+			useCase := auction_usecase.NewAuctionUseCase(auctionRepository, nil, nil, nil, appConfig)
+
+			err := useCase.CreateAuction(context.Background(), auction_usecase.AuctionInputDTO{
+				ProductName:  "iPhone 15",
+				Category:     "electronics",
+				Description:  "Brand new iPhone 15, sealed in its box",
+				MinIncrement: testCase.minIncrement,
+			})
+
+			assert.Nil(t, err)
+			if assert.Len(t, auctionRepository.auctions, 1) {
+				assert.Equal(t, testCase.expected, auctionRepository.auctions[0].MinIncrement)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// DeleteAuction soft-deletes an auction. Its bids are kept, but it is no
+// longer listed, edited, closed or bid on, and its pending bids are dropped.
+func (au *AuctionUseCase) DeleteAuction(ctx context.Context, auctionId string) *internal_error.InternalError {
+	return au.auctionRepositoryInterface.DeleteAuction(ctx, auctionId)
+}
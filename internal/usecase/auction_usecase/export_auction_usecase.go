@@ -0,0 +1,48 @@
+package auction_usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+)
+
+func (au *AuctionUseCase) ExportAuction(
+	ctx context.Context,
+	auctionId string) (*AuctionExportDTO, *internal_error.InternalError) {
+	auction, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &AuctionExportDTO{
+		ExportedAt: time.Now(),
+		Auction:    newAuctionOutputDTO(*auction),
+	}
+
+	// An auction without bids has no winner
+	if winner, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auctionId); err == nil {
+		export.Winner = newBidOutputDTO(winner)
+	}
+
+	bids, err := au.bidRepositoryInterface.StreamBidsByAuctionId(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	bidOutputs := make(chan bid_usecase.BidOutputDTO)
+	go func() {
+		defer close(bidOutputs)
+		for bid := range bids {
+			select {
+			case bidOutputs <- *newBidOutputDTO(&bid):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	export.Bids = bidOutputs
+
+	return export, nil
+}
@@ -0,0 +1,132 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBidRepository struct {
+	bids []bid_entity.Bid
+}
+
+func (f *fakeBidRepository) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) (bid_entity.BidBatchResult, *internal_error.InternalError) {
+	f.bids = append(f.bids, bidEntities...)
+	return bid_entity.BidBatchResult{Stored: bidEntities}, nil
+}
+
+func (f *fakeBidRepository) FindBidByAuctionId(
+	ctx context.Context, auctionId string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	return f.bids, nil
+}
+
+func (f *fakeBidRepository) FindBidsPageByAuctionId(
+	ctx context.Context, auctionId string, offset, limit int64) ([]bid_entity.Bid, int64, *internal_error.InternalError) {
+	return f.bids, int64(len(f.bids)), nil
+}
+
+func (f *fakeBidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	var winner *bid_entity.Bid
+	for i := range f.bids {
+		if winner == nil || f.bids[i].Amount() > winner.Amount() {
+			winner = &f.bids[i]
+		}
+	}
+	if winner == nil {
+		return nil, internal_error.NewNotFoundError("No bids found")
+	}
+	return winner, nil
+}
+
+func (f *fakeBidRepository) StreamBidsByAuctionId(
+	ctx context.Context, auctionId string) (<-chan bid_entity.Bid, *internal_error.InternalError) {
+	bids := make(chan bid_entity.Bid, len(f.bids))
+	for _, bid := range f.bids {
+		bids <- bid
+	}
+	close(bids)
+	return bids, nil
+}
+
+func (f *fakeBidRepository) CountBidsAboveAmount(
+	ctx context.Context, auctionId string, amountCents int64) (int64, *internal_error.InternalError) {
+	return 0, nil
+}
+
+func (f *fakeBidRepository) FindHighestBidsByUserId(
+	ctx context.Context, userId string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	highest := map[string]int{}
+	var bids []bid_entity.Bid
+	for _, bid := range f.bids {
+		if bid.UserId != userId {
+			continue
+		}
+		if i, ok := highest[bid.AuctionId]; !ok {
+			highest[bid.AuctionId] = len(bids)
+			bids = append(bids, bid)
+		} else if bid.AmountCents > bids[i].AmountCents {
+			bids[i] = bid
+		}
+	}
+	return bids, nil
+}
+
+func TestExportAuctionIncludesWinnerAndBids(t *testing.T) {
+	auctionRepository := newFakeAuctionRepository(1)
+	auctionRepository.auctions[0].Status = auction_entity.Completed
+	auctionId := auctionRepository.auctions[0].Id
+
+	bidRepository := &fakeBidRepository{bids: []bid_entity.Bid{
+		{Id: "bid-1", UserId: "user-1", AuctionId: auctionId, AmountCents: 10000, Timestamp: time.Now()},
+		{Id: "bid-2", UserId: "user-2", AuctionId: auctionId, AmountCents: 30000, Timestamp: time.Now()},
+		{Id: "bid-3", UserId: "user-1", AuctionId: auctionId, AmountCents: 20000, Timestamp: time.Now()},
+	}}
+
+	useCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, nil, nil, config.Default())
+
+	export, err := useCase.ExportAuction(context.Background(), auctionId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, auctionId, export.Auction.Id)
+	assert.Equal(t, auction_usecase.AuctionStatus(auction_entity.Completed), export.Auction.Status)
+	assert.Equal(t, "bid-2", export.Winner.Id)
+	assert.False(t, export.ExportedAt.IsZero())
+
+	var ids []string
+	for bid := range export.Bids {
+		ids = append(ids, bid.Id)
+	}
+	assert.Equal(t, []string{"bid-1", "bid-2", "bid-3"}, ids)
+}
+
+func TestExportAuctionWithoutBidsHasNoWinner(t *testing.T) {
+	auctionRepository := newFakeAuctionRepository(1)
+	auctionId := auctionRepository.auctions[0].Id
+
+	useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, nil, config.Default())
+
+	export, err := useCase.ExportAuction(context.Background(), auctionId)
+
+	assert.Nil(t, err)
+	assert.Nil(t, export.Winner)
+	_, open := <-export.Bids
+	assert.False(t, open)
+}
+
+func TestExportAuctionNotFound(t *testing.T) {
+	useCase := auction_usecase.NewAuctionUseCase(newFakeAuctionRepository(0), &fakeBidRepository{}, nil, nil, config.Default())
+
+	export, err := useCase.ExportAuction(context.Background(), "missing")
+
+	assert.Nil(t, export)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,29 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAuctionStatusesMixesExistingAndMissingIds(t *testing.T) {
+	repository := newFakeAuctionRepository(2)
+	repository.auctions[1].Status = auction_entity.Completed
+
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	output, err := useCase.FindAuctionStatuses(context.Background(), auction_usecase.FindAuctionStatusesInputDTO{
+		AuctionIds: []string{"auction-1", "missing", "auction-0", "auction-1"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []auction_usecase.AuctionStatusOutputDTO{
+		{Id: "auction-1", Status: auction_usecase.StatusCompleted},
+		{Id: "missing", Status: auction_usecase.StatusNotFound},
+		{Id: "auction-0", Status: auction_usecase.StatusActive},
+	}, output)
+}
@@ -2,9 +2,12 @@ package auction_usecase
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
 )
@@ -16,43 +19,132 @@ func (au *AuctionUseCase) FindAuctionById(
 		return nil, err
 	}
 
-	return &AuctionOutputDTO{
-		Id:          auctionEntity.Id,
-		ProductName: auctionEntity.ProductName,
-		Category:    auctionEntity.Category,
-		Description: auctionEntity.Description,
-		Condition:   ProductCondition(auctionEntity.Condition),
-		Status:      AuctionStatus(auctionEntity.Status),
-		CreatedAt:   auctionEntity.CreatedAt,
-		ExpiresAt:   auctionEntity.ExpiresAt,
-	}, nil
+	auctionOutputDTO := newAuctionOutputDTO(*auctionEntity)
+	return &auctionOutputDTO, nil
 }
 
 func (au *AuctionUseCase) FindAuctions(
 	ctx context.Context,
-	status AuctionStatus,
-	category, productName string) ([]AuctionOutputDTO, *internal_error.InternalError) {
-	auctionEntities, err := au.auctionRepositoryInterface.FindAuctions(
-		ctx, auction_entity.AuctionStatus(status), category, productName)
+	filterInput FindAuctionsInputDTO) (*FindAuctionsOutputDTO, *internal_error.InternalError) {
+	filter := auction_entity.AuctionFilter{
+		Category:    filterInput.Category,
+		ProductName: filterInput.ProductName,
+		Query:       filterInput.Query,
+		HasBids:     filterInput.HasBids,
+		Sort:        auction_entity.AuctionSort(filterInput.Sort),
+	}
+
+	// The recently completed view only makes sense for completed auctions
+	// and always embeds the winning bid
+	recentlyCompleted := filterInput.Sort == SortClosedDesc
+	if recentlyCompleted {
+		if filterInput.Status == nil {
+			completed := AuctionStatus(auction_entity.Completed)
+			filterInput.Status = &completed
+		} else if *filterInput.Status != AuctionStatus(auction_entity.Completed) {
+			return nil, internal_error.NewBadRequestError("sort=closed_desc requires status=completed")
+		}
+		filterInput.IncludeHighestBid = true
+	}
+
+	if filterInput.Status != nil {
+		status := auction_entity.AuctionStatus(*filterInput.Status)
+		filter.Status = &status
+	}
+
+	if filterInput.Condition != nil {
+		condition := auction_entity.ProductCondition(*filterInput.Condition)
+		filter.Condition = &condition
+	}
+
+	// Newest first unless asked otherwise, so pages are stable
+	if filter.Sort == auction_entity.SortDefault {
+		filter.Sort = auction_entity.SortCreatedDesc
+	}
+
+	// Every listing is paginated, so none returns the whole collection
+	if filterInput.Limit == 0 {
+		filterInput.Limit = defaultPageSize
+	}
+	if filterInput.Limit < 1 || filterInput.Limit > maxPageSize || filterInput.Offset < 0 {
+		return nil, internal_error.NewBadRequestError(fmt.Sprintf(
+			"limit must be between 1 and %d and offset must not be negative", maxPageSize))
+	}
+	filter.Skip, filter.Limit = filterInput.Offset, filterInput.Limit
+
+	auctionOutputs := []AuctionOutputDTO{}
+	if filterInput.IncludeHighestBid {
+		auctionEntities, err := au.auctionRepositoryInterface.FindAuctionsWithHighestBid(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range auctionEntities {
+			auctionOutput := newAuctionOutputDTO(value.Auction)
+			if value.HighestBid != nil {
+				auctionOutput.HighestBid = newBidOutputDTO(value.HighestBid)
+			}
+			if recentlyCompleted {
+				sold := value.HighestBid != nil && value.ReserveMet(value.HighestBid.AmountCents)
+				auctionOutput.Sold = &sold
+			}
+			auctionOutputs = append(auctionOutputs, auctionOutput)
+		}
+	} else {
+		auctionEntities, err := au.auctionRepositoryInterface.FindAuctions(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range auctionEntities {
+			auctionOutputs = append(auctionOutputs, newAuctionOutputDTO(value))
+		}
+	}
+
+	total, err := au.auctionRepositoryInterface.CountAuctions(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	var auctionOutputs []AuctionOutputDTO
-	for _, value := range auctionEntities {
-		auctionOutputs = append(auctionOutputs, AuctionOutputDTO{
-			Id:          value.Id,
-			ProductName: value.ProductName,
-			Category:    value.Category,
-			Description: value.Description,
-			Condition:   ProductCondition(value.Condition),
-			Status:      AuctionStatus(value.Status),
-			CreatedAt:   value.CreatedAt,
-			ExpiresAt:   value.ExpiresAt,
-		})
+	return &FindAuctionsOutputDTO{
+		Auctions: auctionOutputs,
+		Total:    total,
+		Limit:    filterInput.Limit,
+		Offset:   filterInput.Offset,
+	}, nil
+}
+
+// FindAuctionStatuses resolves the status of every requested auction in one
+// query. The output follows the order of the input, without duplicates, and
+// reports the ids that do not exist as not found.
+func (au *AuctionUseCase) FindAuctionStatuses(
+	ctx context.Context,
+	input FindAuctionStatusesInputDTO) ([]AuctionStatusOutputDTO, *internal_error.InternalError) {
+	var ids []string
+	seen := make(map[string]struct{}, len(input.AuctionIds))
+	for _, id := range input.AuctionIds {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
 	}
 
-	return auctionOutputs, nil
+	statuses, err := au.auctionRepositoryInterface.FindAuctionStatuses(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]AuctionStatusOutputDTO, 0, len(ids))
+	for _, id := range ids {
+		statusName := StatusNotFound
+		if status, ok := statuses[id]; ok {
+			statusName = status.String()
+		}
+		output = append(output, AuctionStatusOutputDTO{Id: id, Status: statusName})
+	}
+
+	return output, nil
 }
 
 func (au *AuctionUseCase) FindWinningBidByAuctionId(
@@ -63,7 +155,27 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		return nil, err
 	}
 
-	auctionOutputDTO := AuctionOutputDTO{
+	auctionOutputDTO := newAuctionOutputDTO(*auction)
+
+	bidWinning, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auction.Id)
+	if err != nil {
+		logger.Error("", err)
+		return &WinningInfoOutputDTO{
+			Auction: auctionOutputDTO,
+			Bid:     nil,
+		}, nil
+	}
+
+	return &WinningInfoOutputDTO{
+		Auction:    auctionOutputDTO,
+		Bid:        newBidOutputDTO(bidWinning),
+		ReserveMet: auction.ReserveMet(bidWinning.AmountCents),
+	}, nil
+}
+
+func newAuctionOutputDTO(auction auction_entity.Auction) AuctionOutputDTO {
+	secondsRemaining, expired := timeRemaining(auction.Status, auction.ExpiresAt)
+	return AuctionOutputDTO{
 		Id:          auction.Id,
 		ProductName: auction.ProductName,
 		Category:    auction.Category,
@@ -72,27 +184,55 @@ func (au *AuctionUseCase) FindWinningBidByAuctionId(
 		Status:      AuctionStatus(auction.Status),
 		CreatedAt:   auction.CreatedAt,
 		ExpiresAt:   auction.ExpiresAt,
+		UpdatedAt:   auction.UpdatedAt,
+		ClosedAt:    auction.ClosedAt,
+		Version:     auction.Version,
+
+		SecondsRemaining: secondsRemaining,
+		IsExpired:        expired,
+
+		AllowSelfOutbid: auction.AllowSelfOutbid,
+		MinIncrement:    auction.MinIncrement,
+		RelistedFrom:    auction.RelistedFrom,
 	}
+}
 
-	bidWinning, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auction.Id)
-	if err != nil {
-		logger.Error("", err)
-		return &WinningInfoOutputDTO{
-			Auction: auctionOutputDTO,
-			Bid:     nil,
-		}, nil
+// timeRemaining returns the whole seconds left until expiresAt, never
+// negative, and whether the auction can no longer take bids. A completed
+// auction has no time left even when it closed early.
+func timeRemaining(status auction_entity.AuctionStatus, expiresAt time.Time) (int64, bool) {
+	if status != auction_entity.Active {
+		return 0, true
 	}
 
-	bidOutputDTO := &bid_usecase.BidOutputDTO{
-		Id:        bidWinning.Id,
-		UserId:    bidWinning.UserId,
-		AuctionId: bidWinning.AuctionId,
-		Amount:    bidWinning.Amount,
-		Timestamp: bidWinning.Timestamp,
+	remaining := time.Until(expiresAt)
+	if remaining <= 0 {
+		return 0, true
 	}
+	return int64(remaining.Seconds()), false
+}
 
-	return &WinningInfoOutputDTO{
-		Auction: auctionOutputDTO,
-		Bid:     bidOutputDTO,
-	}, nil
+// Status names returned by FindAuctionStatuses.
+const (
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusNotFound  = "not_found"
+)
+
+func newBidOutputDTO(bid *bid_entity.Bid) *bid_usecase.BidOutputDTO {
+	return &bid_usecase.BidOutputDTO{
+		Id:        bid.Id,
+		UserId:    bid.UserId,
+		AuctionId: bid.AuctionId,
+		Amount:    bid.Amount(),
+		Timestamp: bid.Timestamp,
+	}
 }
+
+const (
+	// defaultPageSize is the page size of a listing without a limit
+	defaultPageSize = 20
+
+	// maxPageSize bounds how many auctions a single page may hold
+	maxPageSize = 100
+)
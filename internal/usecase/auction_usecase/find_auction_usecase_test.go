@@ -0,0 +1,482 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuctionRepository struct {
+	auctions    []auction_entity.Auction
+	highestBids map[string]*bid_entity.Bid // persisted top bid per auction
+	bidCounts   map[string]int64           // persisted bids per auction
+	lastFilter  auction_entity.AuctionFilter
+
+	// updated and expectedVersion are the arguments of the last UpdateAuction
+	updated         *auction_entity.Auction
+	expectedVersion int64
+}
+
+func (f *fakeAuctionRepository) CreateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	f.auctions = append(f.auctions, *auctionEntity)
+	return nil
+}
+
+func (f *fakeAuctionRepository) FindAuctions(
+	ctx context.Context, filter auction_entity.AuctionFilter) ([]auction_entity.Auction, *internal_error.InternalError) {
+	f.lastFilter = filter
+	var auctions []auction_entity.Auction
+	for _, auction := range f.auctions {
+		if filter.Ids != nil && !slices.Contains(filter.Ids, auction.Id) {
+			continue
+		}
+		if filter.HasBids && f.highestBids[auction.Id] == nil {
+			continue
+		}
+		if filter.Status == nil || auction.Status == *filter.Status {
+			auctions = append(auctions, auction)
+		}
+	}
+	if filter.Sort == auction_entity.SortClosedDesc {
+		sort.SliceStable(auctions, func(i, j int) bool { return auctions[i].UpdatedAt.After(auctions[j].UpdatedAt) })
+	}
+	if filter.Skip > 0 {
+		auctions = auctions[min(filter.Skip, int64(len(auctions))):]
+	}
+	if filter.Limit > 0 && int64(len(auctions)) > filter.Limit {
+		auctions = auctions[:filter.Limit]
+	}
+	return auctions, nil
+}
+
+func (f *fakeAuctionRepository) CountAuctions(
+	ctx context.Context, filter auction_entity.AuctionFilter) (int64, *internal_error.InternalError) {
+	// The counted filter is not the one under test
+	lastFilter := f.lastFilter
+	defer func() { f.lastFilter = lastFilter }()
+
+	filter.Skip, filter.Limit = 0, 0
+	auctions, err := f.FindAuctions(ctx, filter)
+	return int64(len(auctions)), err
+}
+
+func (f *fakeAuctionRepository) FindAuctionsWithHighestBid(
+	ctx context.Context, filter auction_entity.AuctionFilter) ([]auction_entity.AuctionWithHighestBid, *internal_error.InternalError) {
+	auctions, err := f.FindAuctions(ctx, filter)
+	var result []auction_entity.AuctionWithHighestBid
+	for _, auction := range auctions {
+		result = append(result, auction_entity.AuctionWithHighestBid{
+			Auction: auction, HighestBid: f.highestBids[auction.Id],
+		})
+	}
+	return result, err
+}
+
+func (f *fakeAuctionRepository) UpdateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction, expectedVersion int64) *internal_error.InternalError {
+	updated := *auctionEntity
+	f.updated, f.expectedVersion = &updated, expectedVersion
+	return nil
+}
+
+func (f *fakeAuctionRepository) MarkAuctionHasBids(
+	ctx context.Context, auctionId string, version int64) *internal_error.InternalError {
+	return nil
+}
+
+func (f *fakeAuctionRepository) CloseExpiredAuctions(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	var closed int64
+	for i := range f.auctions {
+		if f.auctions[i].Status == auction_entity.Active && f.auctions[i].IsExpired() {
+			f.auctions[i].Status = auction_entity.Completed
+			closed++
+		}
+	}
+	return closed, nil
+}
+
+func (f *fakeAuctionRepository) CloseAuction(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	auction, err := f.FindAuctionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status != auction_entity.Active {
+		return nil, internal_error.NewAuctionCompletedError()
+	}
+	auction.Status = auction_entity.Completed
+	auction.Touch()
+	closed := *auction
+	return &closed, nil
+}
+
+func (f *fakeAuctionRepository) DeleteAuction(ctx context.Context, id string) *internal_error.InternalError {
+	for i := range f.auctions {
+		if f.auctions[i].Id == id {
+			f.auctions = append(f.auctions[:i], f.auctions[i+1:]...)
+			return nil
+		}
+	}
+	return internal_error.NewAuctionNotFoundError()
+}
+
+func (f *fakeAuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	for i := range f.auctions {
+		if f.auctions[i].Id == id {
+			return &f.auctions[i], nil
+		}
+	}
+	return nil, internal_error.NewAuctionNotFoundError()
+}
+
+func (f *fakeAuctionRepository) FindAuctionSummary(
+	ctx context.Context, id string) (*auction_entity.AuctionSummary, *internal_error.InternalError) {
+	auction, err := f.FindAuctionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &auction_entity.AuctionSummary{
+		AuctionWithHighestBid: auction_entity.AuctionWithHighestBid{Auction: *auction, HighestBid: f.highestBids[id]},
+		BidCount:              f.bidCounts[id],
+	}, nil
+}
+
+func (f *fakeAuctionRepository) FindTopAuctionsByCategory(
+	ctx context.Context, category string, limit int64, pinnedIds []string) ([]auction_entity.AuctionWithHighestBid, *internal_error.InternalError) {
+	var active []auction_entity.AuctionWithHighestBid
+	for _, auction := range f.auctions {
+		if auction.Status == auction_entity.Active && auction.Category == category {
+			active = append(active, auction_entity.AuctionWithHighestBid{
+				Auction: auction, HighestBid: f.highestBids[auction.Id],
+			})
+		}
+	}
+
+	amount := func(auction auction_entity.AuctionWithHighestBid) float64 {
+		if auction.HighestBid == nil {
+			return 0
+		}
+		return auction.HighestBid.Amount()
+	}
+	sort.SliceStable(active, func(i, j int) bool { return amount(active[i]) > amount(active[j]) })
+
+	result := active
+	if int64(len(result)) > limit {
+		result = append([]auction_entity.AuctionWithHighestBid(nil), active[:limit]...)
+		for _, auction := range active[limit:] {
+			if slices.Contains(pinnedIds, auction.Id) {
+				result = append(result, auction)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeAuctionRepository) FindAuctionStatuses(
+	ctx context.Context, ids []string) (map[string]auction_entity.AuctionStatus, *internal_error.InternalError) {
+	statuses := make(map[string]auction_entity.AuctionStatus)
+	for _, id := range ids {
+		for _, auction := range f.auctions {
+			if auction.Id == id {
+				statuses[id] = auction.Status
+			}
+		}
+	}
+	return statuses, nil
+}
+
+func newFakeAuctionRepository(count int) *fakeAuctionRepository {
+	repository := &fakeAuctionRepository{}
+	for i := 0; i < count; i++ {
+		repository.auctions = append(repository.auctions, auction_entity.Auction{
+			Id:        fmt.Sprintf("auction-%d", i),
+			Category:  "electronics",
+			Status:    auction_entity.Active,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+	}
+	return repository
+}
+
+func TestFindAuctionsDefaultsToFirstPageOf20(t *testing.T) {
+	repository := newFakeAuctionRepository(25)
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	output, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{})
+
+	assert.Nil(t, err)
+	assert.Len(t, output.Auctions, 20)
+	assert.Equal(t, int64(25), output.Total)
+	assert.Equal(t, int64(20), output.Limit)
+	assert.Zero(t, output.Offset)
+	assert.Equal(t, int64(20), repository.lastFilter.Limit)
+	assert.Zero(t, repository.lastFilter.Skip)
+	assert.Equal(t, auction_entity.SortCreatedDesc, repository.lastFilter.Sort)
+}
+
+func TestFindAuctionsReturnsRequestedPageAndTotal(t *testing.T) {
+	repository := newFakeAuctionRepository(5)
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	output, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{
+		Category: "electronics", Limit: 2, Offset: 4,
+	})
+
+	assert.Nil(t, err)
+	if assert.Len(t, output.Auctions, 1) {
+		assert.Equal(t, "auction-4", output.Auctions[0].Id)
+	}
+	assert.Equal(t, int64(5), output.Total)
+	assert.Equal(t, int64(2), output.Limit)
+	assert.Equal(t, int64(4), output.Offset)
+
+	// Past the last page is an empty page, not an error
+	output, err = useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{Offset: 10})
+
+	assert.Nil(t, err)
+	assert.NotNil(t, output.Auctions)
+	assert.Empty(t, output.Auctions)
+	assert.Equal(t, int64(5), output.Total)
+}
+
+func TestFindAuctionsWithBidsExcludesAuctionsWithoutBids(t *testing.T) {
+	repository := newFakeAuctionRepository(3)
+	repository.highestBids = map[string]*bid_entity.Bid{
+		repository.auctions[1].Id: {Id: "bid-1", AuctionId: repository.auctions[1].Id, AmountCents: 10000},
+	}
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	for _, includeHighestBid := range []bool{false, true} {
+		output, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{
+			HasBids:           true,
+			IncludeHighestBid: includeHighestBid,
+		})
+
+		assert.Nil(t, err)
+		assert.True(t, repository.lastFilter.HasBids)
+		if assert.Len(t, output.Auctions, 1) {
+			assert.Equal(t, repository.auctions[1].Id, output.Auctions[0].Id)
+		}
+	}
+}
+
+func TestFindAuctionsRecentlyCompletedOrdersByCloseAndEmbedsWinner(t *testing.T) {
+	now := time.Now()
+	repository := &fakeAuctionRepository{
+		auctions: []auction_entity.Auction{
+			{Id: "closed-first", Status: auction_entity.Completed, UpdatedAt: now.Add(-3 * time.Minute)},
+			{Id: "still-open", Status: auction_entity.Active, UpdatedAt: now},
+			{Id: "closed-last", Status: auction_entity.Completed, UpdatedAt: now.Add(-time.Minute)},
+			{Id: "closed-unsold", Status: auction_entity.Completed, UpdatedAt: now.Add(-2 * time.Minute)},
+		},
+		highestBids: map[string]*bid_entity.Bid{
+			"closed-first": {Id: "bid-1", UserId: "alice", AuctionId: "closed-first", AmountCents: 15000},
+			"closed-last":  {Id: "bid-2", UserId: "bob", AuctionId: "closed-last", AmountCents: 30000},
+		},
+	}
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	output, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{
+		Sort: auction_usecase.SortClosedDesc,
+	})
+
+	assert.Nil(t, err)
+	if assert.Len(t, output.Auctions, 3) {
+		assert.Equal(t, "closed-last", output.Auctions[0].Id)
+		assert.Equal(t, "closed-unsold", output.Auctions[1].Id)
+		assert.Equal(t, "closed-first", output.Auctions[2].Id)
+
+		assert.True(t, *output.Auctions[0].Sold)
+		assert.Equal(t, "bob", output.Auctions[0].HighestBid.UserId)
+		assert.Equal(t, 300.0, output.Auctions[0].HighestBid.Amount)
+
+		assert.False(t, *output.Auctions[1].Sold)
+		assert.Nil(t, output.Auctions[1].HighestBid)
+
+		assert.Equal(t, "alice", output.Auctions[2].HighestBid.UserId)
+	}
+}
+
+func TestFindAuctionsRecentlyCompletedIsPaginated(t *testing.T) {
+	now := time.Now()
+	repository := &fakeAuctionRepository{}
+	for i := 0; i < 5; i++ {
+		repository.auctions = append(repository.auctions, auction_entity.Auction{
+			Id:        fmt.Sprintf("auction-%d", i),
+			Status:    auction_entity.Completed,
+			UpdatedAt: now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	output, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{
+		Sort: auction_usecase.SortClosedDesc, Limit: 2, Offset: 2,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), repository.lastFilter.Skip)
+	assert.Equal(t, int64(2), repository.lastFilter.Limit)
+	if assert.Len(t, output.Auctions, 2) {
+		assert.Equal(t, "auction-2", output.Auctions[0].Id)
+		assert.Equal(t, "auction-1", output.Auctions[1].Id)
+	}
+	assert.Equal(t, int64(5), output.Total)
+}
+
+func TestFindAuctionsRecentlyCompletedRejectsOtherStatus(t *testing.T) {
+	active := auction_usecase.AuctionStatus(auction_entity.Active)
+	useCase := auction_usecase.NewAuctionUseCase(newFakeAuctionRepository(1), nil, nil, nil, config.Default())
+
+	_, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{
+		Status: &active, Sort: auction_usecase.SortClosedDesc,
+	})
+
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "bad_request", err.Err)
+	}
+}
+
+func TestFindAuctionsValidatesPagination(t *testing.T) {
+	useCase := auction_usecase.NewAuctionUseCase(newFakeAuctionRepository(1), nil, nil, nil, config.Default())
+
+	for _, input := range []auction_usecase.FindAuctionsInputDTO{
+		{Limit: 101}, {Limit: -1}, {Offset: -1},
+	} {
+		_, err := useCase.FindAuctions(context.Background(), input)
+
+		if assert.NotNil(t, err, "%+v", input) {
+			assert.Equal(t, "bad_request", err.Err)
+		}
+	}
+}
+
+func TestFindWinningBidByAuctionIdReportsReserve(t *testing.T) {
+	testCases := []struct {
+		name         string
+		reservePrice float64
+		bids         []bid_entity.Bid
+		reserveMet   bool
+	}{
+		{"no reserve", 0, []bid_entity.Bid{{Id: "bid-1", AmountCents: 10000}}, true},
+		{"reserve met", 100, []bid_entity.Bid{{Id: "bid-1", AmountCents: 10000}}, true},
+		{"reserve not met", 150, []bid_entity.Bid{{Id: "bid-1", AmountCents: 10000}}, false},
+		{"no bids", 0, nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auctionRepository := newFakeAuctionRepository(1)
+			auctionRepository.auctions[0].Status = auction_entity.Completed
+			auctionRepository.auctions[0].ReservePrice = tc.reservePrice
+			useCase := auction_usecase.NewAuctionUseCase(
+				auctionRepository, &fakeBidRepository{bids: tc.bids}, nil, nil, config.Default())
+
+			output, err := useCase.FindWinningBidByAuctionId(
+				context.Background(), auctionRepository.auctions[0].Id)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.reserveMet, output.ReserveMet)
+			// The highest bid is reported even when it does not reach the reserve
+			assert.Equal(t, len(tc.bids) > 0, output.Bid != nil)
+		})
+	}
+}
+
+func TestFindAuctionsRecentlyCompletedIsNotSoldBelowReserve(t *testing.T) {
+	repository := newFakeAuctionRepository(2)
+	for i := range repository.auctions {
+		repository.auctions[i].Status = auction_entity.Completed
+		repository.auctions[i].ReservePrice = 150
+	}
+	repository.highestBids = map[string]*bid_entity.Bid{
+		repository.auctions[0].Id: {Id: "bid-1", AmountCents: 10000},
+		repository.auctions[1].Id: {Id: "bid-2", AmountCents: 20000},
+	}
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	output, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{
+		Sort: auction_usecase.SortClosedDesc, Limit: 10,
+	})
+
+	assert.Nil(t, err)
+	sold := map[string]bool{}
+	for _, auction := range output.Auctions {
+		sold[auction.Id] = *auction.Sold
+	}
+	assert.Equal(t, map[string]bool{repository.auctions[0].Id: false, repository.auctions[1].Id: true}, sold)
+}
+
+func TestCloseExpiredAuctionsReturnsClosedCount(t *testing.T) {
+	repository := &fakeAuctionRepository{auctions: []auction_entity.Auction{
+		{Id: "expired-1", Status: auction_entity.Active, ExpiresAt: time.Now().Add(-time.Minute)},
+		{Id: "expired-2", Status: auction_entity.Active, ExpiresAt: time.Now().Add(-time.Second)},
+		{Id: "open", Status: auction_entity.Active, ExpiresAt: time.Now().Add(time.Minute)},
+		{Id: "completed", Status: auction_entity.Completed, ExpiresAt: time.Now().Add(-time.Hour)},
+	}}
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	output, err := useCase.CloseExpiredAuctions(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), output.Closed)
+	assert.Equal(t, auction_entity.Completed, repository.auctions[0].Status)
+	assert.Equal(t, auction_entity.Completed, repository.auctions[1].Status)
+	assert.Equal(t, auction_entity.Active, repository.auctions[2].Status)
+}
+
+func TestAuctionOutputReportsTimeRemaining(t *testing.T) {
+	repository := &fakeAuctionRepository{}
+	appConfig := config.Default()
+	appConfig.Auction.Interval = 30 * time.Second
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, appConfig)
+
+	err := useCase.CreateAuction(context.Background(), auction_usecase.AuctionInputDTO{
+		ProductName: "iPhone",
+		Category:    "electronics",
+		Description: "Brand new iPhone 15 Pro",
+		Condition:   auction_usecase.ProductCondition(auction_entity.New),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, repository.auctions, 1)
+
+	found, err := useCase.FindAuctionById(context.Background(), repository.auctions[0].Id)
+	assert.Nil(t, err)
+	assert.InDelta(t, 30, found.SecondsRemaining, 1)
+	assert.False(t, found.IsExpired)
+
+	listed, err := useCase.FindAuctions(context.Background(), auction_usecase.FindAuctionsInputDTO{})
+	assert.Nil(t, err)
+	if assert.Len(t, listed.Auctions, 1) {
+		assert.InDelta(t, 30, listed.Auctions[0].SecondsRemaining, 1)
+		assert.False(t, listed.Auctions[0].IsExpired)
+	}
+}
+
+func TestAuctionOutputHasNoTimeRemainingOnceExpiredOrCompleted(t *testing.T) {
+	repository := &fakeAuctionRepository{auctions: []auction_entity.Auction{
+		{Id: "expired", Status: auction_entity.Active, ExpiresAt: time.Now().Add(-time.Minute)},
+		{Id: "closed-early", Status: auction_entity.Completed, ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+	useCase := auction_usecase.NewAuctionUseCase(repository, nil, nil, nil, config.Default())
+
+	for _, id := range []string{"expired", "closed-early"} {
+		output, err := useCase.FindAuctionById(context.Background(), id)
+		assert.Nil(t, err)
+		assert.Zero(t, output.SecondsRemaining, id)
+		assert.True(t, output.IsExpired, id)
+	}
+}
@@ -0,0 +1,46 @@
+package auction_usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+)
+
+// MarshalJSON writes the condition by name (new, used or refurbished), or as
+// a number when it has none.
+func (c ProductCondition) MarshalJSON() ([]byte, error) {
+	condition := auction_entity.ProductCondition(c)
+	if _, err := auction_entity.ParseProductCondition(condition.String()); err != nil {
+		return []byte(strconv.FormatInt(int64(c), 10)), nil
+	}
+
+	return json.Marshal(condition.String())
+}
+
+// UnmarshalJSON reads a condition by name, in any case, or by number as
+// clients did before the names were accepted. An unknown name is a bad
+// request.
+func (c *ProductCondition) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var number int64
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+		*c = ProductCondition(number)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	condition, err := auction_entity.ParseProductCondition(name)
+	if err != nil {
+		return err
+	}
+	*c = ProductCondition(condition)
+	return nil
+}
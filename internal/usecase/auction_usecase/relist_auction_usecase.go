@@ -0,0 +1,44 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// RelistAuction creates a new auction of the product of a completed auction
+// that was not sold, because its highest bid, if any, is below the reserve
+// price. The new auction keeps the bidding rules, expires AUCTION_INTERVAL
+// from now and links back to the original through RelistedFrom.
+func (au *AuctionUseCase) RelistAuction(
+	ctx context.Context,
+	auctionId string) (*AuctionOutputDTO, *internal_error.InternalError) {
+	auction, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status != auction_entity.Completed {
+		return nil, internal_error.NewBadRequestError("Only completed auctions can be relisted")
+	}
+
+	winningBid, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auctionId)
+	if err != nil && !err.IsNotFound() {
+		return nil, err
+	}
+	if winningBid != nil && auction.ReserveMet(winningBid.AmountCents) {
+		return nil, internal_error.NewBadRequestError("Auction was sold: its reserve price was met")
+	}
+
+	relisted, err := auction.Relist(au.auctionInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := au.auctionRepositoryInterface.CreateAuction(ctx, relisted); err != nil {
+		return nil, err
+	}
+
+	output := newAuctionOutputDTO(*relisted)
+	return &output, nil
+}
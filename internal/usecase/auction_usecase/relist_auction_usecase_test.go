@@ -0,0 +1,103 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// newUnsoldAuction returns an auction completed with a reserve price of 500
+// and the given bids.
+func newUnsoldAuction(bidCents ...int64) (*fakeAuctionRepository, *fakeBidRepository) {
+	auctionRepository := newEditableAuction()
+	auctionRepository.auctions[0].Status = auction_entity.Completed
+
+	bidRepository := &fakeBidRepository{}
+	for _, cents := range bidCents {
+		bidRepository.bids = append(bidRepository.bids, bid_entity.Bid{
+			Id: "bid", UserId: "user-1", AuctionId: "auction-1", AmountCents: cents, Timestamp: time.Now(),
+		})
+	}
+
+	return auctionRepository, bidRepository
+}
+
+func TestRelistAuction(t *testing.T) {
+	t.Run("creates a new auction linked to the unsold one", func(t *testing.T) {
+		auctionRepository, bidRepository := newUnsoldAuction(49999)
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, nil, nil, config.Default())
+
+		relisted, err := useCase.RelistAuction(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		if assert.Len(t, auctionRepository.auctions, 2) {
+			original, created := auctionRepository.auctions[0], auctionRepository.auctions[1]
+			assert.Equal(t, created.Id, relisted.Id)
+			assert.NotEqual(t, original.Id, created.Id)
+			assert.Equal(t, "auction-1", created.RelistedFrom)
+			assert.Equal(t, "auction-1", relisted.RelistedFrom)
+			assert.Equal(t, auction_entity.Active, created.Status)
+			assert.True(t, created.ExpiresAt.After(time.Now()))
+
+			assert.Equal(t, original.ProductName, created.ProductName)
+			assert.Equal(t, original.Category, created.Category)
+			assert.Equal(t, original.Description, created.Description)
+			assert.Equal(t, original.Condition, created.Condition)
+			assert.Equal(t, original.AllowSelfOutbid, created.AllowSelfOutbid)
+			assert.Equal(t, original.MinIncrement, created.MinIncrement)
+			assert.Equal(t, original.ReservePrice, created.ReservePrice)
+		}
+	})
+
+	t.Run("auction closed without bids", func(t *testing.T) {
+		auctionRepository, bidRepository := newUnsoldAuction()
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, nil, nil, config.Default())
+
+		_, err := useCase.RelistAuction(context.Background(), "auction-1")
+
+		assert.Nil(t, err)
+		assert.Len(t, auctionRepository.auctions, 2)
+	})
+
+	t.Run("reserve met", func(t *testing.T) {
+		auctionRepository, bidRepository := newUnsoldAuction(30000, 50000)
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, nil, nil, config.Default())
+
+		relisted, err := useCase.RelistAuction(context.Background(), "auction-1")
+
+		assert.Nil(t, relisted)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "bad_request", err.Err)
+		}
+		assert.Len(t, auctionRepository.auctions, 1)
+	})
+
+	t.Run("active auction", func(t *testing.T) {
+		auctionRepository, bidRepository := newUnsoldAuction()
+		auctionRepository.auctions[0].Status = auction_entity.Active
+		useCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, nil, nil, config.Default())
+
+		_, err := useCase.RelistAuction(context.Background(), "auction-1")
+
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "bad_request", err.Err)
+		}
+		assert.Len(t, auctionRepository.auctions, 1)
+	})
+
+	t.Run("missing auction", func(t *testing.T) {
+		useCase := auction_usecase.NewAuctionUseCase(&fakeAuctionRepository{}, &fakeBidRepository{}, nil, nil, config.Default())
+
+		_, err := useCase.RelistAuction(context.Background(), "auction-1")
+
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "not_found", err.Err)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package auction_usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// inputValidator checks an edited AuctionInputDTO against the same binding
+// tags the create endpoint validates, reporting fields by their JSON name.
+var inputValidator = func() *validator.Validate {
+	validate := validator.New()
+	validate.SetTagName("binding")
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	return validate
+}()
+
+// UpdateAuction applies a JSON Merge Patch (RFC 7386) to the editable fields
+// of an active auction, as exposed by AuctionInputDTO: fields absent from the
+// patch are kept, and null removes an optional field (back to its global
+// default). The result is validated like a new auction and saved with the
+// version it was read at. Auctions that already received a bid, persisted or
+// pending, cannot be edited: the check here fails fast, and the repository
+// repeats it atomically against the mark left by the first bid.
+func (au *AuctionUseCase) UpdateAuction(
+	ctx context.Context,
+	auctionId string,
+	patch []byte) (*AuctionOutputDTO, *internal_error.InternalError) {
+	auction, err := au.auctionRepositoryInterface.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status != auction_entity.Active {
+		return nil, internal_error.NewAuctionCompletedError()
+	}
+
+	if err := au.rejectIfHasBids(ctx, auctionId); err != nil {
+		return nil, err
+	}
+
+	input, err := applyAuctionMergePatch(*auction, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedVersion := auction.Version
+	auction.ProductName = input.ProductName
+	auction.Category = input.Category
+	auction.Description = input.Description
+	auction.Condition = auction_entity.ProductCondition(input.Condition)
+	auction.AllowSelfOutbid = input.AllowSelfOutbid
+	auction.MinIncrement = au.minIncrement
+	if input.MinIncrement != nil {
+		auction.MinIncrement = *input.MinIncrement
+	}
+	auction.ReservePrice = input.ReservePrice
+	if err := auction.Validate(); err != nil {
+		return nil, err
+	}
+
+	auction.Touch()
+	if err := au.auctionRepositoryInterface.UpdateAuction(ctx, auction, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	output := newAuctionOutputDTO(*auction)
+	return &output, nil
+}
+
+// rejectIfHasBids fails with a conflict when the auction has a persisted bid
+// or one still waiting in the batch.
+func (au *AuctionUseCase) rejectIfHasBids(ctx context.Context, auctionId string) *internal_error.InternalError {
+	hasBids := false
+	if au.pendingBids != nil {
+		_, hasBids = au.pendingBids.FindPendingBids(ctx)[auctionId]
+	}
+
+	if !hasBids {
+		_, err := au.bidRepositoryInterface.FindWinningBidByAuctionId(ctx, auctionId)
+		if err != nil && !err.IsNotFound() {
+			return err
+		}
+		hasBids = err == nil
+	}
+
+	if hasBids {
+		return internal_error.NewConflictError("Auction cannot be edited once it has received bids")
+	}
+
+	return nil
+}
+
+// applyAuctionMergePatch merges patch into the editable fields of auction and
+// returns them validated.
+func applyAuctionMergePatch(
+	auction auction_entity.Auction, patch []byte) (*AuctionInputDTO, *internal_error.InternalError) {
+	var patchDocument any
+	if err := json.Unmarshal(patch, &patchDocument); err != nil {
+		return nil, internal_error.NewBadRequestError("Merge patch must be valid JSON").WithCause(err)
+	}
+	if _, ok := patchDocument.(map[string]any); !ok {
+		return nil, internal_error.NewBadRequestError("Merge patch must be a JSON object")
+	}
+
+	minIncrement := auction.MinIncrement
+	current, marshalErr := json.Marshal(AuctionInputDTO{
+		ProductName:     auction.ProductName,
+		Category:        auction.Category,
+		Description:     auction.Description,
+		Condition:       ProductCondition(auction.Condition),
+		AllowSelfOutbid: auction.AllowSelfOutbid,
+		MinIncrement:    &minIncrement,
+		ReservePrice:    auction.ReservePrice,
+	})
+	if marshalErr != nil {
+		return nil, internal_error.NewInternalServerError("Error trying to edit auction").WithCause(marshalErr)
+	}
+
+	var document any
+	if err := json.Unmarshal(current, &document); err != nil {
+		return nil, internal_error.NewInternalServerError("Error trying to edit auction").WithCause(err)
+	}
+
+	merged, marshalErr := json.Marshal(mergePatch(document, patchDocument))
+	if marshalErr != nil {
+		return nil, internal_error.NewInternalServerError("Error trying to edit auction").WithCause(marshalErr)
+	}
+
+	// Only the fields of AuctionInputDTO can be edited; status, dates and the
+	// like are rejected instead of silently ignored
+	var input AuctionInputDTO
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		return nil, internal_error.NewBadRequestError("Invalid merge patch: " + err.Error()).WithCause(err)
+	}
+
+	if err := inputValidator.Struct(input); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return nil, internal_error.NewBadRequestError("Invalid field values").WithCause(err)
+		}
+
+		fields := make([]string, 0, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			fields = append(fields, fieldErr.Field())
+		}
+		return nil, internal_error.NewBadRequestError(
+			"Invalid field values: " + strings.Join(fields, ", ")).WithCause(err)
+	}
+
+	return &input, nil
+}
+
+// mergePatch applies an RFC 7386 merge patch to target: objects are merged
+// member by member, null removes a member and any other value replaces it.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
@@ -0,0 +1,157 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEditableAuction() *fakeAuctionRepository {
+	allowSelfOutbid := true
+	createdAt := time.Now().Add(-time.Minute)
+	return &fakeAuctionRepository{auctions: []auction_entity.Auction{{
+		Id:          "auction-1",
+		ProductName: "iPhone 15",
+		Category:    "electronics",
+		Description: "Brand new iPhone 15, sealed in the box",
+		Condition:   auction_entity.New,
+		Status:      auction_entity.Active,
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(time.Hour),
+		UpdatedAt:   createdAt,
+		Version:     3,
+
+		AllowSelfOutbid: &allowSelfOutbid,
+		MinIncrement:    5,
+		ReservePrice:    500,
+	}}}
+}
+
+func TestUpdateAuctionMergePatchChangesOnlyTheSentFields(t *testing.T) {
+	auctionRepository := newEditableAuction()
+	original := auctionRepository.auctions[0]
+	useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, fakePendingBids{}, config.Default())
+
+	output, err := useCase.UpdateAuction(context.Background(), "auction-1",
+		[]byte(`{"description": "Brand new iPhone 15, sealed, with receipt"}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Brand new iPhone 15, sealed, with receipt", output.Description)
+
+	updated := auctionRepository.updated
+	if assert.NotNil(t, updated) {
+		assert.Equal(t, "Brand new iPhone 15, sealed, with receipt", updated.Description)
+		assert.Equal(t, original.ProductName, updated.ProductName)
+		assert.Equal(t, original.Category, updated.Category)
+		assert.Equal(t, original.Condition, updated.Condition)
+		assert.Equal(t, original.AllowSelfOutbid, updated.AllowSelfOutbid)
+		assert.Equal(t, original.MinIncrement, updated.MinIncrement)
+		assert.Equal(t, original.ReservePrice, updated.ReservePrice)
+		assert.Equal(t, original.Status, updated.Status)
+		assert.Equal(t, original.ExpiresAt, updated.ExpiresAt)
+
+		// Saved over the version it was read at
+		assert.Equal(t, int64(3), auctionRepository.expectedVersion)
+		assert.Equal(t, int64(4), updated.Version)
+	}
+}
+
+func TestUpdateAuctionMergePatchNullRemovesOptionalFields(t *testing.T) {
+	appConfig := config.Default()
+	appConfig.Auction.MinIncrement = 1
+	auctionRepository := newEditableAuction()
+	useCase := auction_usecase.NewAuctionUseCase(auctionRepository, &fakeBidRepository{}, nil, fakePendingBids{}, appConfig)
+
+	_, err := useCase.UpdateAuction(context.Background(), "auction-1",
+		[]byte(`{"allow_self_outbid": null, "min_increment": null, "reserve_price": null}`))
+
+	assert.Nil(t, err)
+	if assert.NotNil(t, auctionRepository.updated) {
+		assert.Nil(t, auctionRepository.updated.AllowSelfOutbid)
+		assert.Equal(t, 1.0, auctionRepository.updated.MinIncrement)
+		assert.Zero(t, auctionRepository.updated.ReservePrice)
+		assert.Equal(t, "iPhone 15", auctionRepository.updated.ProductName)
+	}
+}
+
+func TestUpdateAuctionRejections(t *testing.T) {
+	testCases := []struct {
+		name    string
+		patch   string
+		bids    []bid_entity.Bid
+		pending fakePendingBids
+		status  auction_entity.AuctionStatus
+		errKind string
+		message string
+	}{
+		{
+			name:    "persisted bid",
+			patch:   `{"category": "phones"}`,
+			bids:    []bid_entity.Bid{{Id: "bid-1", AuctionId: "auction-1", AmountCents: 10000}},
+			errKind: "conflict",
+			message: "Auction cannot be edited once it has received bids",
+		},
+		{
+			name:    "pending bid",
+			patch:   `{"category": "phones"}`,
+			pending: fakePendingBids{"auction-1": {Id: "bid-1", AuctionId: "auction-1", Amount: 100}},
+			errKind: "conflict",
+			message: "Auction cannot be edited once it has received bids",
+		},
+		{
+			name:    "completed auction",
+			patch:   `{"category": "phones"}`,
+			status:  auction_entity.Completed,
+			errKind: "bad_request",
+			message: "Auction is no longer active",
+		},
+		{
+			name:    "required field removed",
+			patch:   `{"product_name": null}`,
+			errKind: "bad_request",
+			message: "Invalid field values: product_name",
+		},
+		{
+			name:    "invalid value",
+			patch:   `{"description": "short", "min_increment": -1}`,
+			errKind: "bad_request",
+			message: "Invalid field values: description, min_increment",
+		},
+		{
+			name:    "field that is not editable",
+			patch:   `{"status": 1}`,
+			errKind: "bad_request",
+			message: `Invalid merge patch: json: unknown field "status"`,
+		},
+		{
+			name:    "not an object",
+			patch:   `["category"]`,
+			errKind: "bad_request",
+			message: "Merge patch must be a JSON object",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auctionRepository := newEditableAuction()
+			auctionRepository.auctions[0].Status = tc.status
+			useCase := auction_usecase.NewAuctionUseCase(
+				auctionRepository, &fakeBidRepository{bids: tc.bids}, nil, tc.pending, config.Default())
+
+			output, err := useCase.UpdateAuction(context.Background(), "auction-1", []byte(tc.patch))
+
+			assert.Nil(t, output)
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tc.errKind, err.Err)
+				assert.Equal(t, tc.message, err.Message)
+			}
+			assert.Nil(t, auctionRepository.updated)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package auction_usecase
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// FindUserAuctions lists the auctions a user has bid on, newest first, each
+// with the highest bid of that user. Only persisted bids are considered, so a
+// bid still waiting in the batch does not show up yet.
+func (au *AuctionUseCase) FindUserAuctions(
+	ctx context.Context,
+	userId string) ([]UserAuctionOutputDTO, *internal_error.InternalError) {
+	bids, err := au.bidRepositoryInterface.FindHighestBidsByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	output := []UserAuctionOutputDTO{}
+	if len(bids) == 0 {
+		return output, nil
+	}
+
+	auctionIds := make([]string, 0, len(bids))
+	for _, bid := range bids {
+		auctionIds = append(auctionIds, bid.AuctionId)
+	}
+
+	auctions, err := au.auctionRepositoryInterface.FindAuctions(ctx, auction_entity.AuctionFilter{
+		Ids:  auctionIds,
+		Sort: auction_entity.SortCreatedDesc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	highestBids := make(map[string]int, len(bids))
+	for i, bid := range bids {
+		highestBids[bid.AuctionId] = i
+	}
+
+	for _, auction := range auctions {
+		i, ok := highestBids[auction.Id]
+		if !ok {
+			continue
+		}
+		output = append(output, UserAuctionOutputDTO{
+			Auction:        newAuctionOutputDTO(auction),
+			UserHighestBid: *newBidOutputDTO(&bids[i]),
+		})
+	}
+
+	return output, nil
+}
@@ -0,0 +1,43 @@
+package auction_usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/auction_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUserAuctions(t *testing.T) {
+	auctionRepository := newFakeAuctionRepository(3)
+	bidRepository := &fakeBidRepository{bids: []bid_entity.Bid{
+		{Id: "bid-1", UserId: "user-1", AuctionId: "auction-0", AmountCents: 10000},
+		{Id: "bid-2", UserId: "user-1", AuctionId: "auction-0", AmountCents: 15000},
+		{Id: "bid-3", UserId: "user-2", AuctionId: "auction-1", AmountCents: 20000},
+		{Id: "bid-4", UserId: "user-1", AuctionId: "auction-2", AmountCents: 5000},
+	}}
+	useCase := auction_usecase.NewAuctionUseCase(auctionRepository, bidRepository, nil, nil, config.Default())
+
+	t.Run("user who bid on two auctions", func(t *testing.T) {
+		auctions, err := useCase.FindUserAuctions(context.Background(), "user-1")
+
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"auction-0", "auction-2"}, auctionRepository.lastFilter.Ids)
+		highestBids := map[string]string{}
+		for _, auction := range auctions {
+			assert.Equal(t, auction.Auction.Id, auction.UserHighestBid.AuctionId)
+			highestBids[auction.Auction.Id] = auction.UserHighestBid.Id
+		}
+		assert.Equal(t, map[string]string{"auction-0": "bid-2", "auction-2": "bid-4"}, highestBids)
+	})
+
+	t.Run("user without bids", func(t *testing.T) {
+		auctions, err := useCase.FindUserAuctions(context.Background(), "user-3")
+
+		assert.Nil(t, err)
+		assert.NotNil(t, auctions)
+		assert.Empty(t, auctions)
+	})
+}
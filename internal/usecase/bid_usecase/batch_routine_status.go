@@ -0,0 +1,34 @@
+package bid_usecase
+
+import "time"
+
+// BatchRoutineStatusDTO tells whether the batch routine is running and
+// keeping up. Stalled means bids have waited in the channel for longer than
+// BATCH_ROUTINE_STALL_THRESHOLD without the routine taking any, which points
+// to a deadlock or a hung insert rather than to a lack of traffic.
+type BatchRoutineStatusDTO struct {
+	Running        bool      `json:"running"`
+	Stalled        bool      `json:"stalled"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	WaitingBids    int       `json:"waiting_bids"`
+}
+
+// heartbeat records that the batch routine went through its loop.
+func (bu *BidUseCase) heartbeat() {
+	bu.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (bu *BidUseCase) BatchRoutineStatus() BatchRoutineStatusDTO {
+	lastActivityAt := time.Unix(0, bu.lastActivity.Load())
+	status := BatchRoutineStatusDTO{
+		Running:        bu.routineAlive.Load(),
+		LastActivityAt: lastActivityAt,
+		WaitingBids:    len(bu.bidChannel),
+	}
+
+	// Without bids waiting, a quiet routine is only a quiet auction house
+	status.Stalled = status.Running && status.WaitingBids > 0 &&
+		time.Since(lastActivityAt) > bu.stallThreshold
+
+	return status
+}
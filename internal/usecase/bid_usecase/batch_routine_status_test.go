@@ -0,0 +1,51 @@
+package bid_usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchRoutineStatus(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+	t.Setenv("BATCH_ROUTINE_STALL_THRESHOLD", "50ms")
+
+	t.Run("stalled while bids wait on a stuck routine", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, bidRepository := newBidUseCase(auction)
+		bidRepository.insertGate = make(chan struct{})
+
+		// The first bid fills the batch and its insert hangs; the second one
+		// waits in the channel
+		placeBids(t, useCase, auction.Id, 100, 110)
+
+		status := useCase.BatchRoutineStatus()
+		assert.True(t, status.Running)
+		assert.Equal(t, 1, status.WaitingBids)
+		assert.False(t, status.Stalled)
+
+		assert.Eventually(t, func() bool {
+			return useCase.BatchRoutineStatus().Stalled
+		}, time.Second, 5*time.Millisecond)
+
+		// Once the insert returns, the routine takes the waiting bid again
+		close(bidRepository.insertGate)
+		assert.Eventually(t, func() bool {
+			status := useCase.BatchRoutineStatus()
+			return !status.Stalled && status.WaitingBids == 0
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("an idle routine is not stalled", func(t *testing.T) {
+		useCase, _ := newBidUseCase()
+
+		time.Sleep(100 * time.Millisecond)
+
+		status := useCase.BatchRoutineStatus()
+		assert.True(t, status.Running)
+		assert.False(t, status.Stalled)
+		assert.Zero(t, status.WaitingBids)
+	})
+}
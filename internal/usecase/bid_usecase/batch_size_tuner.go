@@ -0,0 +1,100 @@
+package bid_usecase
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+)
+
+// BatchSizeOutputDTO reports the batch size currently used by the bid writer
+// and the bounds it is tuned within.
+type BatchSizeOutputDTO struct {
+	Autotune       bool  `json:"autotune"`
+	BatchSize      int   `json:"batch_size"`
+	MinBatchSize   int   `json:"min_batch_size"`
+	MaxBatchSize   int   `json:"max_batch_size"`
+	TargetLatency  int64 `json:"target_latency_ms"`
+	AverageLatency int64 `json:"average_latency_ms"`
+}
+
+// batchSizeTuner adapts how many bids are inserted at once to the recent
+// insert latency (BATCH_SIZE_AUTOTUNE). Fast inserts of full batches grow the
+// batch by 10% for throughput; an average above the target shrinks it by 25%
+// to bound latency. The size never leaves [min, max]. When disabled the size
+// stays at MAX_BATCH_SIZE.
+type batchSizeTuner struct {
+	enabled       bool
+	min           int
+	max           int
+	targetLatency time.Duration
+
+	size           atomic.Int64
+	averageLatency atomic.Int64 // moving average, in nanoseconds
+}
+
+func newBatchSizeTuner(enabled bool, initial, lower, upper int, targetLatency time.Duration) *batchSizeTuner {
+	if !enabled {
+		lower, upper = initial, initial
+	}
+	lower = max(lower, 1)
+	upper = max(upper, lower)
+
+	tuner := &batchSizeTuner{
+		enabled:       enabled,
+		min:           lower,
+		max:           upper,
+		targetLatency: targetLatency,
+	}
+	tuner.size.Store(int64(min(max(initial, lower), upper)))
+
+	return tuner
+}
+
+// current returns the batch size to flush at.
+func (bt *batchSizeTuner) current() int {
+	return int(bt.size.Load())
+}
+
+// observe records how long inserting a batch of batchLen bids took and
+// adjusts the batch size. It is only called by the batch routine.
+func (bt *batchSizeTuner) observe(batchLen int, latency time.Duration) {
+	if !bt.enabled {
+		return
+	}
+
+	// A moving average keeps a single slow insert from halving throughput
+	average := latency
+	if previous := time.Duration(bt.averageLatency.Load()); previous > 0 {
+		average = (previous*7 + latency*3) / 10
+	}
+	bt.averageLatency.Store(int64(average))
+
+	size := bt.current()
+	next := size
+	switch {
+	case average > bt.targetLatency:
+		next = max(bt.min, min(size*3/4, size-1))
+	case average < bt.targetLatency/2 && batchLen >= size:
+		// Only a full batch shows that a larger one would be filled
+		next = min(bt.max, size+max(1, size/10))
+	}
+
+	if next != size {
+		bt.size.Store(int64(next))
+		logger.Info(fmt.Sprintf("Bid batch size adjusted from %d to %d (average insert latency %s)",
+			size, next, average.Round(time.Millisecond)))
+	}
+}
+
+func (bt *batchSizeTuner) snapshot() BatchSizeOutputDTO {
+	return BatchSizeOutputDTO{
+		Autotune:       bt.enabled,
+		BatchSize:      bt.current(),
+		MinBatchSize:   bt.min,
+		MaxBatchSize:   bt.max,
+		TargetLatency:  bt.targetLatency.Milliseconds(),
+		AverageLatency: time.Duration(bt.averageLatency.Load()).Milliseconds(),
+	}
+}
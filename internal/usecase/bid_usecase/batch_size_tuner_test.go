@@ -0,0 +1,90 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchSizeTunerGrowsOnFastFullBatchesUpToMax(t *testing.T) {
+	tuner := bid_usecase.NewBatchSizeTuner(true, 10, 2, 15, 100*time.Millisecond)
+
+	for i := 0; i < 20; i++ {
+		tuner.Observe(tuner.Current(), 10*time.Millisecond)
+		assert.LessOrEqual(t, tuner.Current(), 15)
+	}
+
+	assert.Equal(t, 15, tuner.Current())
+}
+
+func TestBatchSizeTunerDoesNotGrowOnPartialBatches(t *testing.T) {
+	tuner := bid_usecase.NewBatchSizeTuner(true, 10, 2, 50, 100*time.Millisecond)
+
+	// Batches flushed by the timer before filling up
+	for i := 0; i < 5; i++ {
+		tuner.Observe(3, 10*time.Millisecond)
+	}
+
+	assert.Equal(t, 10, tuner.Current())
+}
+
+func TestBatchSizeTunerShrinksOnSlowInsertsDownToMin(t *testing.T) {
+	tuner := bid_usecase.NewBatchSizeTuner(true, 40, 4, 50, 100*time.Millisecond)
+
+	tuner.Observe(40, 500*time.Millisecond)
+	assert.Equal(t, 30, tuner.Current())
+
+	for i := 0; i < 20; i++ {
+		tuner.Observe(tuner.Current(), 500*time.Millisecond)
+		assert.GreaterOrEqual(t, tuner.Current(), 4)
+	}
+
+	assert.Equal(t, 4, tuner.Current())
+}
+
+func TestBatchSizeTunerSmoothsASingleSlowInsert(t *testing.T) {
+	tuner := bid_usecase.NewBatchSizeTuner(true, 20, 1, 20, 100*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		tuner.Observe(20, 20*time.Millisecond)
+	}
+	// Average becomes (20*7 + 200*3) / 10 = 74ms, still under the target
+	tuner.Observe(20, 200*time.Millisecond)
+
+	assert.Equal(t, 20, tuner.Current())
+}
+
+func TestBatchSizeTunerDisabledKeepsInitialSize(t *testing.T) {
+	tuner := bid_usecase.NewBatchSizeTuner(false, 5, 1, 100, 100*time.Millisecond)
+
+	tuner.Observe(5, time.Millisecond)
+	tuner.Observe(5, time.Second)
+
+	assert.Equal(t, 5, tuner.Current())
+}
+
+func TestBatchSizeTunerClampsInitialSize(t *testing.T) {
+	assert.Equal(t, 50, bid_usecase.NewBatchSizeTuner(true, 500, 1, 50, time.Second).Current())
+	assert.Equal(t, 10, bid_usecase.NewBatchSizeTuner(true, 5, 10, 50, time.Second).Current())
+}
+
+func TestFindBatchSizeReportsConfiguration(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "8")
+	t.Setenv("BATCH_SIZE_AUTOTUNE", "true")
+	t.Setenv("BATCH_SIZE_MIN", "2")
+	t.Setenv("BATCH_SIZE_MAX", "64")
+	t.Setenv("BATCH_TARGET_LATENCY", "150ms")
+
+	useCase, _ := newBidUseCase()
+
+	batchSize := useCase.FindBatchSize(context.Background())
+
+	assert.True(t, batchSize.Autotune)
+	assert.Equal(t, 8, batchSize.BatchSize)
+	assert.Equal(t, 2, batchSize.MinBatchSize)
+	assert.Equal(t, 64, batchSize.MaxBatchSize)
+	assert.Equal(t, int64(150), batchSize.TargetLatency)
+}
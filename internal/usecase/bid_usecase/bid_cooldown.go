@@ -0,0 +1,87 @@
+package bid_usecase
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCooldownEntries bounds the memory used to remember recent bidders. When
+// full, expired entries are dropped first and then the oldest one.
+const maxCooldownEntries = 10000
+
+// bidCooldown remembers when each user last bid on each auction so a user
+// cannot place consecutive bids on the same auction within BID_COOLDOWN.
+type bidCooldown struct {
+	window    time.Duration
+	lastBidAt map[string]time.Time // auctionId + userId -> last accepted bid
+	mutex     sync.Mutex
+}
+
+func newBidCooldown(window time.Duration) *bidCooldown {
+	return &bidCooldown{
+		window:    window,
+		lastBidAt: make(map[string]time.Time),
+	}
+}
+
+// tryAcquire records a bid by userId on auctionId at now, unless the previous
+// one is still inside the window; in that case it returns the time left.
+func (bc *bidCooldown) tryAcquire(auctionId, userId string, now time.Time) (time.Duration, bool) {
+	if bc.window <= 0 {
+		return 0, true
+	}
+
+	key := auctionId + ":" + userId
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	if last, ok := bc.lastBidAt[key]; ok {
+		if remaining := bc.window - now.Sub(last); remaining > 0 {
+			return remaining, false
+		}
+	} else if len(bc.lastBidAt) >= maxCooldownEntries {
+		bc.evict(now)
+	}
+
+	bc.lastBidAt[key] = now
+	return 0, true
+}
+
+// release forgets a bid recorded by tryAcquire at the given time that was
+// not enqueued after all, unless a later bid has been recorded since.
+func (bc *bidCooldown) release(auctionId, userId string, at time.Time) {
+	if bc.window <= 0 {
+		return
+	}
+
+	key := auctionId + ":" + userId
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	if last, ok := bc.lastBidAt[key]; ok && last.Equal(at) {
+		delete(bc.lastBidAt, key)
+	}
+}
+
+// evict drops expired entries, or the oldest one when none has expired.
+// It must be called with mutex held.
+func (bc *bidCooldown) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+
+	for key, last := range bc.lastBidAt {
+		if now.Sub(last) >= bc.window {
+			delete(bc.lastBidAt, key)
+			continue
+		}
+		if oldestKey == "" || last.Before(oldest) {
+			oldestKey, oldest = key, last
+		}
+	}
+
+	if len(bc.lastBidAt) >= maxCooldownEntries {
+		delete(bc.lastBidAt, oldestKey)
+	}
+}
@@ -2,11 +2,12 @@ package bid_usecase
 
 import (
 	"context"
-	"os"
-	"strconv"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
@@ -28,30 +29,156 @@ type BidOutputDTO struct {
 	Timestamp time.Time `json:"timestamp" time_format:"2006-01-02 15:04:05"`
 }
 
+// FindBidsInputDTO selects a page of the bids of an auction: Limit (default
+// 20, at most 100) bids after skipping Offset of them.
+type FindBidsInputDTO struct {
+	Limit  int64
+	Offset int64
+}
+
+// FindBidsOutputDTO is a page of the bids of an auction and how many bids the
+// auction has in total.
+type FindBidsOutputDTO struct {
+	Bids   []BidOutputDTO `json:"data"`
+	Total  int64          `json:"total"`
+	Limit  int64          `json:"limit"`
+	Offset int64          `json:"offset"`
+}
+
+// CreateBidOutputDTO tells the bidder where the accepted bid stands. Rank is
+// 1 for the leading bid; it is omitted when it could not be computed.
+type CreateBidOutputDTO struct {
+	Id        string `json:"id"`
+	IsHighest bool   `json:"is_highest"`
+	Rank      int64  `json:"rank,omitempty"`
+}
+
+// UserWinningStatusOutputDTO tells a bidder whether they currently hold the
+// highest bid of an auction. HighestAmount is 0 while there are no bids.
+type UserWinningStatusOutputDTO struct {
+	AuctionId     string  `json:"auction_id"`
+	UserId        string  `json:"user_id"`
+	Winning       bool    `json:"winning"`
+	HighestAmount float64 `json:"highest_amount"`
+}
+
+// CurrentBidOutputDTO is the bid setting the current price of an auction.
+// Persisted is false while the bid is still waiting in the batch.
+type CurrentBidOutputDTO struct {
+	BidOutputDTO
+	Persisted bool `json:"persisted"`
+}
+
 type BidUseCase struct {
 	BidRepository     bid_entity.BidEntityRepository
 	AuctionRepository auction_entity.AuctionRepositoryInterface
 	UserRepository    user_entity.UserRepositoryInterface
 
 	timer               *time.Timer
-	maxBatchSize        int
+	maxBatchSize        int // initial batch size and channel capacity
 	batchInsertInterval time.Duration
+	enqueueTimeout      time.Duration    // how long CreateBid waits on a full channel
+	enqueueBlocking     bool             // false rejects at once on a full channel
+	rules               config.BidConfig // self outbid, tie and amount rules of each bid
 	bidChannel          chan bid_entity.Bid
 	bidBatch            []bid_entity.Bid
 	bidBatchMutex       *sync.Mutex
+	flushRequests       chan chan bid_entity.BidBatchResult // Flush calls served by the routine
 
-	// Pending bids cache - tracks highest bid per auction before persistence
+	// Pending bids cache - tracks highest bid per auction before persistence,
+	// for at most maxPendingAuctions auctions (MAX_PENDING_AUCTIONS)
 	pendingHighestBid      map[string]*bid_entity.Bid // auctionId -> highest pending bid
 	pendingHighestBidMutex *sync.RWMutex
+	maxPendingAuctions     int
+
+	// Effective batch size, adapted to insert latency (BATCH_SIZE_AUTOTUNE)
+	batchSize *batchSizeTuner
+
+	// Slots for the CreateBid calls in progress (MAX_CONCURRENT_BIDS), nil
+	// when unlimited
+	bidSlots chan struct{}
+
+	// Per-user cooldown between bids on the same auction (BID_COOLDOWN)
+	cooldown *bidCooldown
+
+	// Rejected bids counted by reason, exposed to admins
+	rejections *rejectionMetrics
+
+	// Flushes of the batch routine, exposed to admins
+	pipeline *pipelineStats
+
+	// Optional persistence of rejected attempts (RECORD_REJECTED_BIDS)
+	rejectedBids *rejectedBidRecorder
+
+	// Users confirmed by the user repository, trusted while it is down
+	// (USER_LOOKUP_DEGRADED_MODE)
+	knownUsers *knownUsers
+
+	// Optional durable record of accepted bids, used to rebuild the pending
+	// cache and requeue unpersisted bids after a restart
+	eventLog bid_entity.BidEventLog
+
+	// Optional receiver of the bids of each persisted batch, used to call the
+	// bidders' callback URLs
+	confirmations bid_entity.BidConfirmationNotifier
+
+	// Told when an accepted bid displaces another user's highest bid
+	notifier Notifier
+
+	// Subscribers to the accepted bids of each auction (WebSocket streams)
+	liveBids *liveBids
+
+	// Shutdown state - the channel is closed once and the routine reports
+	// how the final batch was drained on drainResult. Senders hold sendMutex
+	// for reading, so the channel is never closed under a send; stopping
+	// releases the ones blocked on a full channel.
+	closed      atomic.Bool
+	closeOnce   sync.Once
+	sendMutex   sync.RWMutex
+	stopping    chan struct{}
+	drainResult chan PipelineDrainStats
+	queuedBids  atomic.Int64 // bids accepted but not yet handed to the repository
+
+	// Whether the batch routine is running and when it last went through its
+	// loop (Unix nanoseconds), for the health probes
+	routineAlive   atomic.Bool
+	lastActivity   atomic.Int64
+	stallThreshold time.Duration
+}
+
+// PipelineDrainStats summarizes what happened to queued bids on shutdown.
+type PipelineDrainStats struct {
+	FlushedBids int // bids persisted by the final batch
+	DroppedBids int // bids not persisted because their auction had closed
+	LostBids    int // bids that could not be persisted before exit
+}
+
+// BidUseCaseOption adjusts a BidUseCase while NewBidUseCase builds it, before
+// it takes any bid.
+type BidUseCaseOption func(*BidUseCase)
+
+// WithAllowSelfOutbid overrides the ALLOW_SELF_OUTBID setting of the bid
+// config, for the auctions that do not set their own policy.
+func WithAllowSelfOutbid(allow bool) BidUseCaseOption {
+	return func(bu *BidUseCase) {
+		bu.rules.AllowSelfOutbid = allow
+	}
 }
 
 func NewBidUseCase(
 	bidRepository bid_entity.BidEntityRepository,
 	auctionRepository auction_entity.AuctionRepositoryInterface,
 	userRepository user_entity.UserRepositoryInterface,
+	eventLog bid_entity.BidEventLog,
+	rejectedBidRepository bid_entity.RejectedBidRepository,
+	confirmations bid_entity.BidConfirmationNotifier,
+	bidConfig config.BidConfig,
+	options ...BidUseCaseOption,
 ) BidUseCaseInterface {
-	maxSizeInterval := getMaxBatchSizeInterval()
-	maxBatchSize := getMaxBatchSize()
+	maxSizeInterval := bidConfig.BatchInsertInterval
+	maxBatchSize := bidConfig.MaxBatchSize
+	batchSize := newBatchSizeTuner(bidConfig.Autotune, maxBatchSize,
+		bidConfig.MinTunedBatchSize, bidConfig.MaxTunedBatchSize, bidConfig.TargetBatchLatency)
 
 	bidUseCase := &BidUseCase{
 		BidRepository:          bidRepository,
@@ -59,14 +186,38 @@ func NewBidUseCase(
 		UserRepository:         userRepository,
 		maxBatchSize:           maxBatchSize,
 		batchInsertInterval:    maxSizeInterval,
+		enqueueTimeout:         bidConfig.EnqueueTimeout,
+		enqueueBlocking:        bidConfig.QueueBlocking,
+		rules:                  bidConfig,
 		timer:                  time.NewTimer(maxSizeInterval),
 		bidChannel:             make(chan bid_entity.Bid, maxBatchSize),
 		bidBatch:               make([]bid_entity.Bid, 0),
 		bidBatchMutex:          &sync.Mutex{},
+		flushRequests:          make(chan chan bid_entity.BidBatchResult),
 		pendingHighestBid:      make(map[string]*bid_entity.Bid),
 		pendingHighestBidMutex: &sync.RWMutex{},
+		maxPendingAuctions:     bidConfig.MaxPendingAuctions,
+		batchSize:              batchSize,
+		bidSlots:               newBidSlots(bidConfig.MaxConcurrentBids),
+		cooldown:               newBidCooldown(bidConfig.Cooldown),
+		rejections:             newRejectionMetrics(),
+		pipeline:               newPipelineStats(),
+		rejectedBids:           newRejectedBidRecorder(context.Background(), rejectedBidRepository, bidConfig.RecordRejected),
+		knownUsers:             newKnownUsers(bidConfig.UserLookupDegraded),
+		eventLog:               eventLog,
+		confirmations:          confirmations,
+		notifier:               noopNotifier{},
+		liveBids:               newLiveBids(bidConfig.MaxStreamSubscribers),
+		drainResult:            make(chan PipelineDrainStats, 1),
+		stallThreshold:         bidConfig.StallThreshold,
+		stopping:               make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(bidUseCase)
 	}
 
+	bidUseCase.recoverPendingBids()
 	bidUseCase.triggerCreateRoutine(context.Background())
 
 	return bidUseCase
@@ -75,54 +226,111 @@ func NewBidUseCase(
 type BidUseCaseInterface interface {
 	CreateBid(
 		ctx context.Context,
-		bidInputDTO BidInputDTO) *internal_error.InternalError
+		bidInputDTO BidInputDTO) (*CreateBidOutputDTO, *internal_error.InternalError)
 
 	FindWinningBidByAuctionId(
 		ctx context.Context, auctionId string) (*BidOutputDTO, *internal_error.InternalError)
 
 	FindBidByAuctionId(
-		ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError)
+		ctx context.Context,
+		auctionId string,
+		input FindBidsInputDTO) (*FindBidsOutputDTO, *internal_error.InternalError)
+
+	StreamBidsByAuctionId(
+		ctx context.Context, auctionId string) (<-chan BidOutputDTO, *internal_error.InternalError)
+
+	// FindUserWinningStatus reports whether userId holds the current highest
+	// bid of an auction, counting bids not yet persisted
+	FindUserWinningStatus(
+		ctx context.Context, auctionId, userId string) (*UserWinningStatusOutputDTO, *internal_error.InternalError)
+
+	// FindCurrentBid returns the highest bid of an active auction, counting
+	// bids not yet persisted
+	FindCurrentBid(
+		ctx context.Context, auctionId string) (*CurrentBidOutputDTO, *internal_error.InternalError)
+
+	// SubscribeBids streams the bids accepted on an active auction until ctx
+	// is done or the auction is completed
+	SubscribeBids(
+		ctx context.Context, auctionId string) (<-chan BidOutputDTO, *internal_error.InternalError)
+
+	// AuctionsCompleted ends the bid streams of the completed auctions
+	AuctionsCompleted(auctionIds []string)
+
+	// FindPendingBids returns a snapshot of the highest bid accepted but not
+	// yet persisted for each auction, keyed by auction id
+	FindPendingBids(ctx context.Context) map[string]BidOutputDTO
+
+	// FindRejectionCounts returns how many bids were rejected since startup,
+	// keyed by reason
+	FindRejectionCounts(ctx context.Context) map[string]int64
+
+	// RecordRateLimited counts a bid refused by the request rate limit before
+	// it reached CreateBid
+	RecordRateLimited()
+
+	// FindBatchSize reports the batch size the bid writer currently uses
+	FindBatchSize(ctx context.Context) BatchSizeOutputDTO
+
+	// FindPipelineStats reports what the batch routine holds and has flushed
+	FindPipelineStats(ctx context.Context) PipelineStatsOutputDTO
+
+	// BatchRoutineStatus reports whether the routine persisting the batches
+	// is running (it stops on Shutdown) and whether it is stalled
+	BatchRoutineStatus() BatchRoutineStatusDTO
+
+	// Flush persists the bids batched so far and returns how many
+	Flush(ctx context.Context) (int, *internal_error.InternalError)
+
+	Shutdown(ctx context.Context) PipelineDrainStats
 }
 
 func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
+	bu.heartbeat()
+	bu.routineAlive.Store(true)
 	go func() {
-		defer close(bu.bidChannel)
-
 		for {
 			select {
 			case bidEntity, ok := <-bu.bidChannel:
+				bu.heartbeat()
 				if !ok {
+					var stats PipelineDrainStats
 					bu.bidBatchMutex.Lock()
-					if len(bu.bidBatch) > 0 {
-						if err := bu.BidRepository.CreateBid(ctx, bu.bidBatch); err != nil {
-							logger.Error("error trying to process bid batch list", err)
-						}
-					}
+					result := bu.flushBatch(ctx, FlushShutdown)
+					stats.FlushedBids = len(result.Stored)
+					stats.DroppedBids = len(result.Dropped)
+					stats.LostBids = len(result.Failed)
+					bu.bidBatch = nil
 					bu.bidBatchMutex.Unlock()
+					bu.routineAlive.Store(false)
+					bu.drainResult <- stats
 					return
 				}
 
 				bu.bidBatchMutex.Lock()
 				bu.bidBatch = append(bu.bidBatch, bidEntity)
 
-				if len(bu.bidBatch) >= bu.maxBatchSize {
-					if err := bu.BidRepository.CreateBid(ctx, bu.bidBatch); err != nil {
-						logger.Error("error trying to process bid batch list", err)
-					}
-
-					bu.bidBatch = nil
+				if len(bu.bidBatch) >= bu.batchSize.current() {
+					bu.flushBatch(ctx, FlushBatchFull)
 					bu.timer.Reset(bu.batchInsertInterval)
 				}
 				bu.bidBatchMutex.Unlock()
 
-			case <-bu.timer.C:
+			case reply := <-bu.flushRequests:
+				bu.heartbeat()
 				bu.bidBatchMutex.Lock()
-				if len(bu.bidBatch) > 0 {
-					if err := bu.BidRepository.CreateBid(ctx, bu.bidBatch); err != nil {
-						logger.Error("error trying to process bid batch list", err)
-					}
+				// The routine is the only receiver, so every bid counted
+				// here is taken without blocking
+				for queued := len(bu.bidChannel); queued > 0; queued-- {
+					bu.bidBatch = append(bu.bidBatch, <-bu.bidChannel)
 				}
-				bu.bidBatch = nil
+				reply <- bu.flushBatch(ctx, FlushRequested)
+				bu.bidBatchMutex.Unlock()
+
+			case <-bu.timer.C:
+				bu.heartbeat()
+				bu.bidBatchMutex.Lock()
+				bu.flushBatch(ctx, FlushInterval)
 				bu.timer.Reset(bu.batchInsertInterval)
 				bu.bidBatchMutex.Unlock()
 			}
@@ -130,11 +338,187 @@ func (bu *BidUseCase) triggerCreateRoutine(ctx context.Context) {
 	}()
 }
 
-// clearPendingBidsCache clears all pending bids after they are persisted
-func (bu *BidUseCase) clearPendingBidsCache() {
+// flushBatch hands the current batch to the repository and keeps in it only
+// the bids that failed to be written, for the next flush. It returns what
+// happened to each bid, counting as failed every bid that was neither stored
+// nor dropped. trigger tells what caused the flush. It must be called with
+// bidBatchMutex held.
+func (bu *BidUseCase) flushBatch(ctx context.Context, trigger string) bid_entity.BidBatchResult {
+	if len(bu.bidBatch) == 0 {
+		return bid_entity.BidBatchResult{}
+	}
+
+	start := time.Now()
+	result, err := bu.BidRepository.CreateBid(ctx, bu.bidBatch)
+	bu.batchSize.observe(len(bu.bidBatch), time.Since(start))
+	if err != nil {
+		logger.Error("error trying to process bid batch list", err)
+	}
+	bu.pipeline.recordFlush(trigger, len(result.Stored), time.Now())
+
+	// A bid the repository neither stored nor dropped was not written, even
+	// when the repository did not report it as failed
+	handled := make([]bid_entity.Bid, 0, len(result.Stored)+len(result.Dropped))
+	handled = append(handled, result.Stored...)
+	handled = append(handled, result.Dropped...)
+	failed := unhandledBids(bu.bidBatch, handled)
+	bu.queuedBids.Add(-int64(len(handled)))
+
+	// Only the bids the repository is done with leave the event log, so the
+	// failed ones are replayed after a restart
+	bu.settleBids(handled)
+	// A pending bid that was not written stays the highest for validation
+	bu.clearPersistedPendingBids(handled)
+	if bu.confirmations != nil && len(result.Stored) > 0 {
+		bu.confirmations.BidsConfirmed(result.Stored)
+	}
+
+	bu.bidBatch = failed
+	result.Failed = failed
+	return result
+}
+
+// unhandledBids returns the bids of batch missing from handled, in order.
+func unhandledBids(batch, handled []bid_entity.Bid) []bid_entity.Bid {
+	if len(handled) == len(batch) {
+		return nil
+	}
+
+	handledIds := make(map[string]struct{}, len(handled))
+	for _, bid := range handled {
+		handledIds[bid.Id] = struct{}{}
+	}
+
+	var unhandled []bid_entity.Bid
+	for _, bid := range batch {
+		if _, ok := handledIds[bid.Id]; !ok {
+			unhandled = append(unhandled, bid)
+		}
+	}
+	return unhandled
+}
+
+// recoverPendingBids rebuilds the pending cache from the bids accepted before
+// a restart but never settled, and queues them again. Bids that had in fact
+// been inserted are rejected by their unique _id.
+func (bu *BidUseCase) recoverPendingBids() {
+	if bu.eventLog == nil {
+		return
+	}
+
+	bids, err := bu.eventLog.Pending()
+	if err != nil {
+		logger.Error("Error reading pending bids from the event log", err)
+		return
+	}
+
+	for i := range bids {
+		bid := &bids[i]
+		if current := bu.pendingHighestBid[bid.AuctionId]; current == nil || bid.AmountCents > current.AmountCents {
+			bu.storePendingHighestBid(bid)
+		}
+	}
+
+	bu.bidBatch = append(bu.bidBatch, bids...)
+	bu.queuedBids.Add(int64(len(bids)))
+
+	if len(bids) > 0 {
+		logger.Info(fmt.Sprintf("Recovered %d pending bid(s) from the event log", len(bids)))
+	}
+}
+
+// settleBids marks bids as no longer pending in the event log
+func (bu *BidUseCase) settleBids(bids []bid_entity.Bid) {
+	if bu.eventLog == nil || len(bids) == 0 {
+		return
+	}
+
+	if err := bu.eventLog.AppendSettled(bids); err != nil {
+		logger.Error("Error settling bids in the event log", err)
+	}
+}
+
+// Flush persists the bids accepted so far, batched or still in the channel,
+// without waiting for the batch to fill or the interval to elapse and returns
+// how many were written. The routine drains the channel into the batch
+// first, so no bid accepted before the call is left behind. The bids that
+// fail to persist are kept for the next flush.
+func (bu *BidUseCase) Flush(ctx context.Context) (int, *internal_error.InternalError) {
+	var result bid_entity.BidBatchResult
+	reply := make(chan bid_entity.BidBatchResult, 1)
+	select {
+	case bu.flushRequests <- reply:
+		select {
+		case result = <-reply:
+		case <-ctx.Done():
+			return 0, internal_error.NewInternalServerError("Timed out flushing the bid batch")
+		}
+	case <-bu.stopping:
+		// The routine drains the channel itself on shutdown
+		bu.bidBatchMutex.Lock()
+		result = bu.flushBatch(ctx, FlushRequested)
+		bu.bidBatchMutex.Unlock()
+	case <-ctx.Done():
+		return 0, internal_error.NewInternalServerError("Timed out flushing the bid batch")
+	}
+
+	if len(result.Failed) > 0 {
+		return len(result.Stored), internal_error.NewInternalServerError("Error trying to persist the bid batch")
+	}
+
+	return len(result.Stored), nil
+}
+
+// Shutdown stops accepting bids, waits for the routine to persist everything
+// still queued and reports how the final batch was drained. If ctx expires
+// first, the bids still queued or batched are reported as lost.
+func (bu *BidUseCase) Shutdown(ctx context.Context) PipelineDrainStats {
+	bu.closeOnce.Do(func() {
+		bu.closed.Store(true)
+		close(bu.stopping)
+
+		bu.sendMutex.Lock()
+		close(bu.bidChannel)
+		bu.sendMutex.Unlock()
+	})
+
+	select {
+	case stats := <-bu.drainResult:
+		return stats
+	case <-ctx.Done():
+		return PipelineDrainStats{LostBids: int(bu.queuedBids.Load())}
+	}
+}
+
+// clearPersistedPendingBids removes from the pending cache the auctions whose
+// pending highest bid was just persisted. An auction whose highest bid is
+// newer than the batch keeps it, and auctions outside the batch are left
+// untouched.
+func (bu *BidUseCase) clearPersistedPendingBids(bids []bid_entity.Bid) {
+	persisted := make(map[string]struct{}, len(bids))
+	for _, bid := range bids {
+		persisted[bid.Id] = struct{}{}
+	}
+
 	bu.pendingHighestBidMutex.Lock()
-	bu.pendingHighestBid = make(map[string]*bid_entity.Bid)
-	bu.pendingHighestBidMutex.Unlock()
+	defer bu.pendingHighestBidMutex.Unlock()
+
+	for _, bid := range bids {
+		pending := bu.pendingHighestBid[bid.AuctionId]
+		if pending == nil {
+			continue
+		}
+		if _, ok := persisted[pending.Id]; ok {
+			bu.removePendingBid(bid.AuctionId)
+		}
+	}
+}
+
+// removePendingBid drops the pending highest bid of an auction, which is then
+// validated against the database alone. It must be called with
+// pendingHighestBidMutex held.
+func (bu *BidUseCase) removePendingBid(auctionId string) {
+	delete(bu.pendingHighestBid, auctionId)
 }
 
 // getPendingHighestBid returns the highest pending bid for an auction
@@ -144,104 +528,372 @@ func (bu *BidUseCase) getPendingHighestBid(auctionId string) *bid_entity.Bid {
 	return bu.pendingHighestBid[auctionId]
 }
 
-// updatePendingHighestBid updates the pending highest bid for an auction
-func (bu *BidUseCase) updatePendingHighestBid(bid *bid_entity.Bid) {
+// updatePendingHighestBid updates the pending highest bid for an auction and
+// returns the one it replaced
+func (bu *BidUseCase) updatePendingHighestBid(bid *bid_entity.Bid) *bid_entity.Bid {
 	bu.pendingHighestBidMutex.Lock()
 	defer bu.pendingHighestBidMutex.Unlock()
+	return bu.storePendingHighestBid(bid)
+}
+
+// storePendingHighestBid sets the pending highest bid for an auction and
+// returns the one it replaced. When the cache is full, the auction updated
+// longest ago is evicted: its next bid is only validated against the
+// database. It must be called with pendingHighestBidMutex held.
+func (bu *BidUseCase) storePendingHighestBid(bid *bid_entity.Bid) *bid_entity.Bid {
+	previous := bu.pendingHighestBid[bid.AuctionId]
+	if previous == nil && len(bu.pendingHighestBid) >= bu.maxPendingAuctions {
+		bu.evictOldestPendingBid()
+	}
+
 	bu.pendingHighestBid[bid.AuctionId] = bid
+	return previous
+}
+
+// evictOldestPendingBid drops the auction whose pending highest bid is the
+// oldest, i.e. the least recently updated one. It must be called with
+// pendingHighestBidMutex held.
+func (bu *BidUseCase) evictOldestPendingBid() {
+	var oldest *bid_entity.Bid
+
+	for _, bid := range bu.pendingHighestBid {
+		if oldest == nil || bid.Timestamp.Before(oldest.Timestamp) {
+			oldest = bid
+		}
+	}
+
+	if oldest != nil {
+		delete(bu.pendingHighestBid, oldest.AuctionId)
+	}
+}
+
+// restorePendingHighestBid undoes updatePendingHighestBid for a bid that was
+// not enqueued, unless a newer bid has replaced it in the meantime
+func (bu *BidUseCase) restorePendingHighestBid(bid, previous *bid_entity.Bid) {
+	bu.pendingHighestBidMutex.Lock()
+	defer bu.pendingHighestBidMutex.Unlock()
+	if bu.pendingHighestBid[bid.AuctionId] != bid {
+		return
+	}
+	if previous == nil {
+		delete(bu.pendingHighestBid, bid.AuctionId)
+		return
+	}
+	bu.pendingHighestBid[bid.AuctionId] = previous
 }
 
 func (bu *BidUseCase) CreateBid(
 	ctx context.Context,
-	bidInputDTO BidInputDTO) *internal_error.InternalError {
+	bidInputDTO BidInputDTO) (*CreateBidOutputDTO, *internal_error.InternalError) {
+	output, err := bu.createBid(ctx, bidInputDTO)
+	if err != nil {
+		reason := rejectionReason(err)
+		bu.rejections.record(reason)
+		bu.rejectedBids.record(bidInputDTO, reason, err.Message)
+	}
+
+	return output, err
+}
+
+func (bu *BidUseCase) createBid(
+	ctx context.Context,
+	bidInputDTO BidInputDTO) (*CreateBidOutputDTO, *internal_error.InternalError) {
+
+	// Bounds the bids and cache entries held by calls waiting on the pipeline
+	if !bu.acquireBidSlot() {
+		return nil, internal_error.NewTooManyBidsError()
+	}
+	defer bu.releaseBidSlot()
 
 	// Validation 1: Create and validate bid entity (amount > 0, valid UUIDs)
-	bidEntity, err := bid_entity.CreateBid(bidInputDTO.UserId, bidInputDTO.AuctionId, bidInputDTO.Amount)
+	bidEntity, err := bid_entity.CreateBid(
+		bidInputDTO.UserId, bidInputDTO.AuctionId, bidInputDTO.Amount, bu.rules.AmountLimits)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Validation 2: Check if auction exists and is active
+	// Validation 2: Check if auction exists and is open for bids
 	auction, err := bu.AuctionRepository.FindAuctionById(ctx, bidInputDTO.AuctionId)
+	if ctx.Err() != nil {
+		return nil, internal_error.NewContextDoneError(ctx)
+	}
 	if err != nil {
-		return internal_error.NewNotFoundError("Auction not found")
+		return nil, err
 	}
 	if auction.Status == auction_entity.Completed {
-		return internal_error.NewBadRequestError("Auction is no longer active")
+		return nil, internal_error.NewAuctionCompletedError()
+	}
+	if !auction.IsStarted() {
+		return nil, internal_error.NewAuctionNotStartedError()
+	}
+	if auction.IsExpired() {
+		return nil, internal_error.NewAuctionExpiredError()
 	}
 
 	// Validation 3: Check if user exists
-	_, err = bu.UserRepository.FindUserById(ctx, bidInputDTO.UserId)
-	if err != nil {
-		return internal_error.NewNotFoundError("User not found")
+	if err := bu.verifyUser(ctx, bidInputDTO.UserId); err != nil {
+		return nil, err
 	}
 
 	// Validation 4: Get current highest bid (from DB)
-	currentHighestBid, _ := bu.BidRepository.FindWinningBidByAuctionId(ctx, bidInputDTO.AuctionId)
+	currentHighestBid, err := bu.BidRepository.FindWinningBidByAuctionId(ctx, bidInputDTO.AuctionId)
+	if ctx.Err() != nil {
+		return nil, internal_error.NewContextDoneError(ctx)
+	}
+	// Only "no bids yet" lets the bid through; a failed lookup must not be
+	// mistaken for it, or a bid below the persisted highest would be accepted
+	if err != nil && !err.IsNotFound() {
+		return nil, err
+	}
 
 	// Validation 5: Get pending highest bid (from cache - not yet persisted)
 	pendingHighestBid := bu.getPendingHighestBid(bidInputDTO.AuctionId)
 
 	// Determine the effective highest bid (max of DB and pending)
-	var effectiveHighestAmount float64
+	var effectiveHighestCents int64
 	var effectiveHighestUserId string
 
 	if currentHighestBid != nil {
-		effectiveHighestAmount = currentHighestBid.Amount
+		effectiveHighestCents = currentHighestBid.AmountCents
 		effectiveHighestUserId = currentHighestBid.UserId
 	}
 
-	if pendingHighestBid != nil && pendingHighestBid.Amount > effectiveHighestAmount {
-		effectiveHighestAmount = pendingHighestBid.Amount
+	if pendingHighestBid != nil && pendingHighestBid.AmountCents > effectiveHighestCents {
+		effectiveHighestCents = pendingHighestBid.AmountCents
 		effectiveHighestUserId = pendingHighestBid.UserId
 	}
 
 	// Validation 6: If there's a highest bid, check constraints
-	if effectiveHighestAmount > 0 {
-		// Check self-bidding rule (can be enabled via ALLOW_SELF_OUTBID env var)
-		if effectiveHighestUserId == bidInputDTO.UserId {
-			if !getAllowSelfOutbid() {
-				return internal_error.NewBadRequestError("You are already the highest bidder")
+	if err := validateAgainstHighestBid(
+		bidEntity, effectiveHighestCents, effectiveHighestUserId, auction, bu.rules); err != nil {
+		return nil, err
+	}
+
+	if bu.closed.Load() {
+		return nil, internal_error.NewPipelineClosedError()
+	}
+
+	// The first bid closes the auction to edits. The mark only applies to the
+	// version validated above, so an edit saved in between fails this bid
+	// instead of letting it through under the old rules.
+	if currentHighestBid == nil && pendingHighestBid == nil {
+		if err := bu.AuctionRepository.MarkAuctionHasBids(ctx, auction.Id, auction.Version); err != nil {
+			if ctx.Err() != nil {
+				return nil, internal_error.NewContextDoneError(ctx)
 			}
+			return nil, err
 		}
+	}
+
+	// Last chance to give up before the bid becomes visible to others
+	if ctx.Err() != nil {
+		return nil, internal_error.NewContextDoneError(ctx)
+	}
 
-		// New bid must be higher than current highest (DB or pending)
-		if bidInputDTO.Amount <= effectiveHighestAmount {
-			return internal_error.NewBadRequestError("Bid must be higher than current highest bid")
+	// Validation 7: Respect the user's cooldown on this auction. It is checked
+	// last so that only accepted bids start a new cooldown window.
+	if remaining, ok := bu.cooldown.tryAcquire(
+		bidEntity.AuctionId, bidEntity.UserId, bidEntity.Timestamp); !ok {
+		return nil, internal_error.NewBidCooldownError(remaining)
+	}
+
+	// Record the bid durably before anyone can see it as pending
+	if bu.eventLog != nil {
+		if err := bu.eventLog.AppendAccepted(*bidEntity); err != nil {
+			logger.Error("Error recording bid in the event log", err)
+			bu.cooldown.release(bidEntity.AuctionId, bidEntity.UserId, bidEntity.Timestamp)
+			return nil, internal_error.NewInternalServerError("Error trying to record bid").WithCause(err)
 		}
 	}
 
 	// Update pending cache BEFORE adding to channel (atomic operation)
-	bu.updatePendingHighestBid(bidEntity)
+	previousPendingBid := bu.updatePendingHighestBid(bidEntity)
+
+	bu.queuedBids.Add(1)
+	if err := bu.enqueueBid(ctx, bidEntity); err != nil {
+		// The bid is not enqueued: undo everything that made it visible
+		bu.queuedBids.Add(-1)
+		bu.restorePendingHighestBid(bidEntity, previousPendingBid)
+		bu.cooldown.release(bidEntity.AuctionId, bidEntity.UserId, bidEntity.Timestamp)
+		bu.settleBids([]bid_entity.Bid{*bidEntity})
+		return nil, err
+	}
 
-	bu.bidChannel <- *bidEntity
+	bu.liveBids.publish(*bidEntity)
 
-	return nil
+	// The previous leader was outbid, unless there was none or it raised its
+	// own bid
+	if effectiveHighestUserId != "" && effectiveHighestUserId != bidEntity.UserId {
+		bu.notifier.Outbid(OutbidEvent{
+			AuctionId:    bidEntity.AuctionId,
+			OutbidUserId: effectiveHighestUserId,
+			NewAmount:    bidEntity.Amount(),
+		})
+	}
+
+	return bu.rankBid(ctx, bidEntity), nil
 }
 
-func getMaxBatchSizeInterval() time.Duration {
-	batchInsertInterval := os.Getenv("BATCH_INSERT_INTERVAL")
-	duration, err := time.ParseDuration(batchInsertInterval)
-	if err != nil {
-		return 3 * time.Minute
+func newBidSlots(max int) chan struct{} {
+	if max <= 0 {
+		return nil
+	}
+
+	return make(chan struct{}, max)
+}
+
+// acquireBidSlot takes a slot without waiting and reports whether one was
+// free. It always succeeds when the calls are unlimited.
+func (bu *BidUseCase) acquireBidSlot() bool {
+	if bu.bidSlots == nil {
+		return true
+	}
+
+	select {
+	case bu.bidSlots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (bu *BidUseCase) releaseBidSlot() {
+	if bu.bidSlots != nil {
+		<-bu.bidSlots
+	}
+}
+
+// enqueueBid hands the bid to the batch routine without ever blocking for
+// good: it fails when Shutdown starts before the bid is sent, so a bid racing
+// the shutdown is rejected instead of sent on a closed channel, when the
+// client leaves, and when the pipeline stays full for enqueueTimeout. With
+// BID_QUEUE_BLOCKING=false a full pipeline fails at once instead.
+func (bu *BidUseCase) enqueueBid(ctx context.Context, bidEntity *bid_entity.Bid) *internal_error.InternalError {
+	bu.sendMutex.RLock()
+	defer bu.sendMutex.RUnlock()
+
+	if bu.closed.Load() {
+		return internal_error.NewPipelineClosedError()
+	}
+
+	if !bu.enqueueBlocking {
+		select {
+		case bu.bidChannel <- *bidEntity:
+			return nil
+		default:
+			return internal_error.NewPipelineFullError()
+		}
+	}
+
+	full := time.NewTimer(bu.enqueueTimeout)
+	defer full.Stop()
+
+	select {
+	case bu.bidChannel <- *bidEntity:
+		return nil
+	case <-bu.stopping:
+		return internal_error.NewPipelineClosedError()
+	case <-ctx.Done():
+		return internal_error.NewContextDoneError(ctx)
+	case <-full.C:
+		return internal_error.NewPipelineFullError()
+	}
+}
+
+// verifyUser checks that the bidder exists. A lookup failure is not reported
+// as a missing user: the bid is rejected as unavailable, unless degraded mode
+// is enabled and the user was found before.
+func (bu *BidUseCase) verifyUser(ctx context.Context, userId string) *internal_error.InternalError {
+	_, err := bu.UserRepository.FindUserById(ctx, userId)
+	if ctx.Err() != nil {
+		return internal_error.NewContextDoneError(ctx)
+	}
+
+	if err == nil {
+		bu.knownUsers.remember(userId, time.Now())
+		return nil
+	}
+
+	if err.IsNotFound() {
+		return internal_error.NewUserNotFoundError()
+	}
+
+	if bu.knownUsers.contains(userId) {
+		logger.Info("User repository unavailable, accepting bid from known user " + userId)
+		return nil
 	}
 
-	return duration
+	return internal_error.NewUserUnavailableError()
 }
 
-func getMaxBatchSize() int {
-	value, err := strconv.Atoi(os.Getenv("MAX_BATCH_SIZE"))
+// rankBid reports whether an accepted bid is leading and its position among
+// the bids of the auction: persisted bids above it (count query) plus a
+// higher pending bid that is not yet persisted.
+func (bu *BidUseCase) rankBid(ctx context.Context, bidEntity *bid_entity.Bid) *CreateBidOutputDTO {
+	output := &CreateBidOutputDTO{Id: bidEntity.Id}
+
+	var pendingAbove int64
+	if pending := bu.getPendingHighestBid(bidEntity.AuctionId); pending != nil &&
+		pending.Id != bidEntity.Id && pending.AmountCents > bidEntity.AmountCents {
+		pendingAbove = 1
+	}
+
+	persistedAbove, err := bu.BidRepository.CountBidsAboveAmount(
+		ctx, bidEntity.AuctionId, bidEntity.AmountCents)
 	if err != nil {
-		return 5
+		output.IsHighest = pendingAbove == 0
+		return output
 	}
 
-	return value
+	output.Rank = 1 + persistedAbove + pendingAbove
+	output.IsHighest = output.Rank == 1
+	return output
 }
 
-// getAllowSelfOutbid returns whether a user can outbid themselves
-// Default: false (user cannot bid if already highest bidder)
-// Set ALLOW_SELF_OUTBID=true to allow consecutive bids from same user
-func getAllowSelfOutbid() bool {
-	value := os.Getenv("ALLOW_SELF_OUTBID")
-	return value == "true" || value == "1" || value == "yes"
+// validateAgainstHighestBid applies the rules that depend on the current
+// highest bid (DB or pending). It is shared by CreateBid and ReplayBids so a
+// replay always reflects the rules enforced on live bids. Amounts are
+// compared in cents, so 0.1+0.2 and 0.3 are the same amount.
+func validateAgainstHighestBid(
+	bidEntity *bid_entity.Bid,
+	highestCents int64,
+	highestUserId string,
+	auction *auction_entity.Auction,
+	rules config.BidConfig) *internal_error.InternalError {
+	if highestCents <= 0 {
+		return nil
+	}
+
+	// Check self-bidding rule (global ALLOW_SELF_OUTBID or per-auction override)
+	if highestUserId == bidEntity.UserId {
+		if !auction.SelfOutbidAllowed(rules.AllowSelfOutbid) {
+			return internal_error.NewBadRequestError("You are already the highest bidder").
+				WithCode(internal_error.AlreadyHighestBidderCode)
+		}
+
+		// A leader raising their own bid must do so by at least MIN_SELF_RAISE
+		if minSelfRaise := rules.MinSelfRaise; bidEntity.AmountCents < highestCents+bid_entity.ToCents(minSelfRaise) {
+			return internal_error.NewBadRequestError(fmt.Sprintf(
+				"Raising your own bid requires a minimum increment of %.2f", minSelfRaise)).
+				WithCode(internal_error.BidTooLowCode)
+		}
+	}
+
+	// New bid must be higher than current highest (DB or pending). An equal
+	// bid is only accepted, taking the lead, under the last_write_wins policy
+	if bidEntity.AmountCents < highestCents ||
+		bidEntity.AmountCents == highestCents && rules.TiePolicy == bid_entity.RejectEqual {
+		return internal_error.NewBadRequestError("Bid must be higher than current highest bid").
+			WithCode(internal_error.BidTooLowCode)
+	}
+
+	// The auction may require each bid to raise the highest by a minimum
+	minIncrementCents := auction.MinIncrementCents()
+	if minimum := highestCents + minIncrementCents; minIncrementCents > 0 && bidEntity.AmountCents < minimum {
+		return internal_error.NewBadRequestError(fmt.Sprintf(
+			"Bid must be at least %.2f (current highest bid plus the minimum increment of %.2f)",
+			bid_entity.FromCents(minimum), bid_entity.FromCents(minIncrementCents))).WithCode(internal_error.BidTooLowCode)
+	}
+
+	return nil
 }
@@ -0,0 +1,1693 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuctionRepository struct {
+	auctions map[string]*auction_entity.Auction
+	findErr  *internal_error.InternalError // returned by FindAuctionById when set
+
+	// marked holds the version each auction was marked as having bids at;
+	// markErr is returned by MarkAuctionHasBids when set
+	marked    map[string]int64
+	markErr   *internal_error.InternalError
+	markMutex sync.Mutex
+}
+
+func (f *fakeAuctionRepository) CreateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction) *internal_error.InternalError {
+	f.auctions[auctionEntity.Id] = auctionEntity
+	return nil
+}
+
+func (f *fakeAuctionRepository) FindAuctions(
+	ctx context.Context, filter auction_entity.AuctionFilter) ([]auction_entity.Auction, *internal_error.InternalError) {
+	var auctions []auction_entity.Auction
+	for _, auction := range f.auctions {
+		auctions = append(auctions, *auction)
+	}
+	return auctions, nil
+}
+
+func (f *fakeAuctionRepository) FindAuctionsWithHighestBid(
+	ctx context.Context, filter auction_entity.AuctionFilter) ([]auction_entity.AuctionWithHighestBid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (f *fakeAuctionRepository) CountAuctions(
+	ctx context.Context, filter auction_entity.AuctionFilter) (int64, *internal_error.InternalError) {
+	return int64(len(f.auctions)), nil
+}
+
+func (f *fakeAuctionRepository) UpdateAuction(
+	ctx context.Context, auctionEntity *auction_entity.Auction, expectedVersion int64) *internal_error.InternalError {
+	return nil
+}
+
+func (f *fakeAuctionRepository) MarkAuctionHasBids(
+	ctx context.Context, auctionId string, version int64) *internal_error.InternalError {
+	if f.markErr != nil {
+		return f.markErr
+	}
+
+	f.markMutex.Lock()
+	defer f.markMutex.Unlock()
+	if f.marked == nil {
+		f.marked = make(map[string]int64)
+	}
+	f.marked[auctionId] = version
+	return nil
+}
+
+func (f *fakeAuctionRepository) FindAuctionById(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	if f.findErr != nil {
+		return nil, f.findErr
+	}
+	auction, ok := f.auctions[id]
+	if !ok {
+		return nil, internal_error.NewAuctionNotFoundError()
+	}
+	return auction, nil
+}
+
+func (f *fakeAuctionRepository) FindAuctionSummary(
+	ctx context.Context, id string) (*auction_entity.AuctionSummary, *internal_error.InternalError) {
+	auction, err := f.FindAuctionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &auction_entity.AuctionSummary{AuctionWithHighestBid: auction_entity.AuctionWithHighestBid{Auction: *auction}}, nil
+}
+
+func (f *fakeAuctionRepository) CloseAuction(
+	ctx context.Context, id string) (*auction_entity.Auction, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (f *fakeAuctionRepository) DeleteAuction(ctx context.Context, id string) *internal_error.InternalError {
+	return nil
+}
+
+func (f *fakeAuctionRepository) CloseExpiredAuctions(
+	ctx context.Context) (int64, *internal_error.InternalError) {
+	return 0, nil
+}
+
+func (f *fakeAuctionRepository) FindTopAuctionsByCategory(
+	ctx context.Context, category string, limit int64, pinnedIds []string) ([]auction_entity.AuctionWithHighestBid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func (f *fakeAuctionRepository) FindAuctionStatuses(
+	ctx context.Context, ids []string) (map[string]auction_entity.AuctionStatus, *internal_error.InternalError) {
+	statuses := make(map[string]auction_entity.AuctionStatus)
+	for _, id := range ids {
+		if auction, ok := f.auctions[id]; ok {
+			statuses[id] = auction.Status
+		}
+	}
+	return statuses, nil
+}
+
+type fakeUserRepository struct {
+	missing     map[string]bool
+	unavailable atomic.Bool // simulates a database outage
+
+	// lookupGate, when set, holds every lookup until it is closed; lookups
+	// counts the lookups started
+	lookupGate chan struct{}
+	lookups    atomic.Int64
+}
+
+func (f *fakeUserRepository) FindUserById(
+	ctx context.Context, userId string) (*user_entity.User, *internal_error.InternalError) {
+	f.lookups.Add(1)
+	if f.lookupGate != nil {
+		<-f.lookupGate
+	}
+	if f.unavailable.Load() {
+		return nil, internal_error.NewInternalServerError("Error trying to find user by userId")
+	}
+	if f.missing[userId] {
+		return nil, internal_error.NewNotFoundError("User not found with this id = " + userId)
+	}
+	return &user_entity.User{Id: userId, Name: "Test User"}, nil
+}
+
+func (f *fakeUserRepository) CreateUser(
+	ctx context.Context, user *user_entity.User) *internal_error.InternalError {
+	return nil
+}
+
+func (f *fakeUserRepository) UpdateUserCallbackURL(
+	ctx context.Context, userId, callbackURL string) *internal_error.InternalError {
+	return nil
+}
+
+type fakeBidRepository struct {
+	mutex sync.Mutex
+	bids  []bid_entity.Bid
+
+	// concurrentHigherBids simulates higher bids persisted by other requests
+	// after the bid being ranked passed validation.
+	concurrentHigherBids int64
+
+	// insertGate, when set, holds every insert until it is closed
+	insertGate chan struct{}
+
+	// startedInserts counts the inserts started, including the held ones
+	startedInserts atomic.Int64
+
+	// failInserts simulates a database outage: every insert fails
+	failInserts atomic.Bool
+
+	// failWinnerLookups makes FindWinningBidByAuctionId fail as on a database
+	// outage
+	failWinnerLookups atomic.Bool
+
+	// tiePolicy resolves equal amounts in FindWinningBidByAuctionId
+	tiePolicy bid_entity.TiePolicy
+}
+
+func (f *fakeBidRepository) CreateBid(
+	ctx context.Context, bidEntities []bid_entity.Bid) (bid_entity.BidBatchResult, *internal_error.InternalError) {
+	f.startedInserts.Add(1)
+	if f.insertGate != nil {
+		<-f.insertGate
+	}
+	if f.failInserts.Load() {
+		return bid_entity.BidBatchResult{Failed: bidEntities}, internal_error.NewInternalServerError("database unavailable")
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.bids = append(f.bids, bidEntities...)
+	return bid_entity.BidBatchResult{Stored: bidEntities}, nil
+}
+
+func (f *fakeBidRepository) FindBidByAuctionId(
+	ctx context.Context, auctionId string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var bids []bid_entity.Bid
+	for _, bid := range f.bids {
+		if bid.AuctionId == auctionId {
+			bids = append(bids, bid)
+		}
+	}
+	return bids, nil
+}
+
+func (f *fakeBidRepository) FindBidsPageByAuctionId(
+	ctx context.Context, auctionId string, offset, limit int64) ([]bid_entity.Bid, int64, *internal_error.InternalError) {
+	bids, _ := f.FindBidByAuctionId(ctx, auctionId)
+	total := int64(len(bids))
+	bids = bids[min(offset, total):]
+	return bids[:min(limit, int64(len(bids)))], total, nil
+}
+
+func (f *fakeBidRepository) FindWinningBidByAuctionId(
+	ctx context.Context, auctionId string) (*bid_entity.Bid, *internal_error.InternalError) {
+	if f.failWinnerLookups.Load() {
+		return nil, internal_error.NewInternalServerError("Error trying to find the auction winner")
+	}
+	bids, _ := f.FindBidByAuctionId(ctx, auctionId)
+	var winner *bid_entity.Bid
+	for i := range bids {
+		if winner == nil || bids[i].Amount() > winner.Amount() ||
+			bids[i].Amount() == winner.Amount() && f.tiePolicy == bid_entity.LastWriteWins {
+			winner = &bids[i]
+		}
+	}
+	if winner == nil {
+		return nil, internal_error.NewNotFoundError("No bids found")
+	}
+	return winner, nil
+}
+
+func (f *fakeBidRepository) StreamBidsByAuctionId(
+	ctx context.Context, auctionId string) (<-chan bid_entity.Bid, *internal_error.InternalError) {
+	bids, _ := f.FindBidByAuctionId(ctx, auctionId)
+	stream := make(chan bid_entity.Bid, len(bids))
+	for _, bid := range bids {
+		stream <- bid
+	}
+	close(stream)
+	return stream, nil
+}
+
+func (f *fakeBidRepository) CountBidsAboveAmount(
+	ctx context.Context, auctionId string, amountCents int64) (int64, *internal_error.InternalError) {
+	bids, _ := f.FindBidByAuctionId(ctx, auctionId)
+	count := f.concurrentHigherBids
+	for _, bid := range bids {
+		if bid.AmountCents > amountCents {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeBidRepository) FindHighestBidsByUserId(
+	ctx context.Context, userId string) ([]bid_entity.Bid, *internal_error.InternalError) {
+	return nil, nil
+}
+
+func newAuction(mutate func(*auction_entity.Auction)) *auction_entity.Auction {
+	now := time.Now()
+	auction := &auction_entity.Auction{
+		Id:          uuid.New().String(),
+		ProductName: "Test Product",
+		Category:    "electronics",
+		Description: "This is a test product description for auction",
+		Condition:   auction_entity.New,
+		Status:      auction_entity.Active,
+		CreatedAt:   now,
+		StartsAt:    now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+	if mutate != nil {
+		mutate(auction)
+	}
+	return auction
+}
+
+func newBidUseCase(auctions ...*auction_entity.Auction) (bid_usecase.BidUseCaseInterface, *fakeBidRepository) {
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{}}
+	for _, auction := range auctions {
+		auctionRepository.auctions[auction.Id] = auction
+	}
+	rules := bidConfig()
+	bidRepository := &fakeBidRepository{tiePolicy: rules.TiePolicy}
+
+	return bid_usecase.NewBidUseCase(
+		bidRepository, auctionRepository, &fakeUserRepository{}, nil, nil, nil, rules), bidRepository
+}
+
+// bidConfig loads the bid settings from the environment prepared by the test.
+func bidConfig() config.BidConfig {
+	appConfig, err := config.LoadConfig()
+	if err != nil {
+		panic(err)
+	}
+	return appConfig.Bid
+}
+
+func TestCreateBidAuctionStateErrorCodes(t *testing.T) {
+	notStarted := newAuction(func(a *auction_entity.Auction) {
+		a.StartsAt = time.Now().Add(time.Hour)
+		a.ExpiresAt = a.StartsAt.Add(time.Minute)
+	})
+	completed := newAuction(func(a *auction_entity.Auction) { a.Status = auction_entity.Completed })
+	expired := newAuction(func(a *auction_entity.Auction) { a.ExpiresAt = time.Now().Add(-time.Second) })
+
+	useCase, _ := newBidUseCase(notStarted, completed, expired)
+
+	testCases := []struct {
+		name      string
+		auctionId string
+		code      string
+		err       string
+	}{
+		{"not found", uuid.New().String(), internal_error.AuctionNotFoundCode, "not_found"},
+		{"completed", completed.Id, internal_error.AuctionCompletedCode, "bad_request"},
+		{"not started", notStarted.Id, internal_error.AuctionNotStartedCode, "bad_request"},
+		{"expired", expired.Id, internal_error.AuctionExpiredCode, "bad_request"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: tc.auctionId,
+				Amount:    100,
+			})
+
+			assert.NotNil(t, err)
+			assert.Equal(t, tc.code, err.Code)
+			assert.Equal(t, tc.err, err.Err)
+		})
+	}
+}
+
+func TestCreateBidPropagatesAuctionLookupErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		findErr *internal_error.InternalError
+		err     string
+		message string
+	}{
+		{"missing auction", internal_error.NewAuctionNotFoundError(), "not_found", "Auction not found"},
+		{"database failure", internal_error.NewInternalServerError("Error trying to find auction by id"),
+			"internal_server_error", "Error trying to find auction by id"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auctionRepository := &fakeAuctionRepository{
+				auctions: map[string]*auction_entity.Auction{},
+				findErr:  tc.findErr,
+			}
+			useCase := bid_usecase.NewBidUseCase(
+				&fakeBidRepository{}, auctionRepository, &fakeUserRepository{}, nil, nil, nil, bidConfig())
+
+			_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId:    uuid.New().String(),
+				AuctionId: uuid.New().String(),
+				Amount:    100,
+			})
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tc.err, err.Err)
+				assert.Equal(t, tc.message, err.Message)
+			}
+		})
+	}
+}
+
+func TestCreateBidPerAuctionSelfOutbidOverride(t *testing.T) {
+	allow, deny := true, false
+
+	testCases := []struct {
+		name     string
+		global   string
+		override *bool
+		allowed  bool
+	}{
+		{"auction allows while global denies", "false", &allow, true},
+		{"auction denies while global allows", "true", &deny, false},
+		{"nil override follows global allow", "true", nil, true},
+		{"nil override follows global deny", "false", nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("ALLOW_SELF_OUTBID", tc.global)
+
+			auction := newAuction(func(a *auction_entity.Auction) { a.AllowSelfOutbid = tc.override })
+			useCase, _ := newBidUseCase(auction)
+			userId := uuid.New().String()
+
+			_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: userId, AuctionId: auction.Id, Amount: 100,
+			})
+			assert.Nil(t, err)
+
+			_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: userId, AuctionId: auction.Id, Amount: 200,
+			})
+			if tc.allowed {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+				assert.Equal(t, "You are already the highest bidder", err.Message)
+			}
+		})
+	}
+}
+
+func TestCreateBidReadsAllowSelfOutbidOnce(t *testing.T) {
+	t.Setenv("ALLOW_SELF_OUTBID", "false")
+
+	auction := newAuction(nil)
+	useCase, _ := newBidUseCase(auction)
+	userId := uuid.New().String()
+
+	placeBid := func(amount float64) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: amount,
+		})
+		return err
+	}
+	assert.Nil(t, placeBid(100))
+
+	// Changing the environment after construction has no effect
+	t.Setenv("ALLOW_SELF_OUTBID", "true")
+	if err := placeBid(200); assert.NotNil(t, err) {
+		assert.Equal(t, "You are already the highest bidder", err.Message)
+	}
+
+	// Only the option given to the constructor overrides it
+	t.Setenv("ALLOW_SELF_OUTBID", "false")
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	useCase = bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository, &fakeUserRepository{}, nil, nil, nil, bidConfig(),
+		bid_usecase.WithAllowSelfOutbid(true))
+	assert.Nil(t, placeBid(100))
+	assert.Nil(t, placeBid(200))
+}
+
+// BenchmarkCreateBidSelfOutbid measures a bid that goes through the
+// self-outbid check, with the flag read once by the constructor and with the
+// former lookup of ALLOW_SELF_OUTBID on every bid. Run it with -benchmem to
+// compare their allocations.
+func BenchmarkCreateBidSelfOutbid(b *testing.B) {
+	b.Setenv("MAX_BATCH_SIZE", "100")
+	b.Setenv("BATCH_INSERT_INTERVAL", "10ms")
+	b.Setenv("ALLOW_SELF_OUTBID", "true")
+
+	run := func(b *testing.B, allowSelfOutbid func() bool) {
+		auction := newAuction(func(a *auction_entity.Auction) { a.ExpiresAt = a.StartsAt.Add(time.Hour) })
+		auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+		useCase := bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository, &fakeUserRepository{}, nil, nil, nil, bidConfig(),
+			bid_usecase.WithAllowSelfOutbid(true))
+		defer useCase.Shutdown(context.Background())
+		userId := uuid.New().String()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if !allowSelfOutbid() {
+				b.Fatal("self-outbid not allowed")
+			}
+			if _, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: userId, AuctionId: auction.Id, Amount: float64(100 + i),
+			}); err != nil {
+				b.Fatal(err.Message)
+			}
+		}
+	}
+
+	b.Run("read once", func(b *testing.B) {
+		run(b, func() bool { return true })
+	})
+
+	b.Run("read per bid", func(b *testing.B) {
+		run(b, func() bool {
+			value := os.Getenv("ALLOW_SELF_OUTBID")
+			return value == "true" || value == "1" || value == "yes"
+		})
+	})
+}
+
+func TestCreateBidMinimumSelfRaise(t *testing.T) {
+	t.Setenv("ALLOW_SELF_OUTBID", "true")
+	t.Setenv("MIN_SELF_RAISE", "10")
+
+	auction := newAuction(nil)
+	useCase, _ := newBidUseCase(auction)
+	userId := uuid.New().String()
+	otherUserId := uuid.New().String()
+
+	placeBid := func(userId string, amount float64) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: amount,
+		})
+		return err
+	}
+
+	assert.Nil(t, placeBid(userId, 100))
+
+	err := placeBid(userId, 105)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Message, "minimum increment of 10.00")
+
+	assert.Nil(t, placeBid(userId, 110))
+
+	// Other users are only bound by the regular rules
+	assert.Nil(t, placeBid(otherUserId, 111))
+}
+
+func TestCreateBidMinimumIncrement(t *testing.T) {
+	auction := newAuction(func(a *auction_entity.Auction) { a.MinIncrement = 2.5 })
+	useCase, _ := newBidUseCase(auction)
+
+	placeBid := func(amount float64) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: amount,
+		})
+		return err
+	}
+
+	// The first bid has nothing to raise
+	assert.Nil(t, placeBid(10))
+
+	err := placeBid(12)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, internal_error.BidTooLowCode, err.Code)
+		assert.Equal(t, "bad_request", err.Err)
+		assert.Equal(t, "Bid must be at least 12.50 (current highest bid plus the minimum increment of 2.50)", err.Message)
+	}
+
+	// Exactly the highest bid plus the increment is enough
+	assert.Nil(t, placeBid(12.5))
+
+	// The increment applies to the new highest, pending or not
+	err = placeBid(14.99)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Message, "at least 15.00")
+	}
+	assert.Nil(t, placeBid(20))
+}
+
+func TestCreateBidComparesAmountsInCents(t *testing.T) {
+	placeBid := func(useCase bid_usecase.BidUseCaseInterface, auctionId string, amount float64) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: amount,
+		})
+		return err
+	}
+
+	t.Run("float rounding does not fail the minimum increment", func(t *testing.T) {
+		// 0.1 + 0.2 is 0.30000000000000004, a hair above the 0.3 bid
+		auction := newAuction(func(a *auction_entity.Auction) { a.MinIncrement = 0.2 })
+		useCase, _ := newBidUseCase(auction)
+
+		assert.Nil(t, placeBid(useCase, auction.Id, 0.1))
+		assert.Nil(t, placeBid(useCase, auction.Id, 0.3))
+	})
+
+	t.Run("float rounding does not fail the minimum self raise", func(t *testing.T) {
+		t.Setenv("MIN_SELF_RAISE", "0.1")
+		allow := true
+		auction := newAuction(func(a *auction_entity.Auction) { a.AllowSelfOutbid = &allow })
+		useCase, _ := newBidUseCase(auction)
+		userId := uuid.New().String()
+
+		for _, amount := range []float64{0.2, 0.3} {
+			_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: userId, AuctionId: auction.Id, Amount: amount,
+			})
+			assert.Nil(t, err, amount)
+		}
+	})
+
+	t.Run("a cent apart is never a tie", func(t *testing.T) {
+		for _, policy := range []string{"reject_equal", "last_write_wins"} {
+			t.Run(policy, func(t *testing.T) {
+				t.Setenv("BID_TIE_POLICY", policy)
+				auction := newAuction(nil)
+				useCase, _ := newBidUseCase(auction)
+				// 0.29 * 100 is 28.999999999999996 in float
+				assert.Nil(t, placeBid(useCase, auction.Id, 0.29))
+
+				err := placeBid(useCase, auction.Id, 0.28)
+				if assert.NotNil(t, err) {
+					assert.Equal(t, internal_error.BidTooLowCode, err.Code)
+				}
+
+				// Only an equal amount follows the tie policy
+				err = placeBid(useCase, auction.Id, 0.29)
+				if policy == "reject_equal" {
+					if assert.NotNil(t, err) {
+						assert.Equal(t, internal_error.BidTooLowCode, err.Code)
+					}
+				} else {
+					assert.Nil(t, err)
+				}
+
+				assert.Nil(t, placeBid(useCase, auction.Id, 0.30))
+			})
+		}
+	})
+}
+
+func TestShutdownDrainsQueuedBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+
+	for i := 1; i <= 3; i++ {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: float64(i * 100),
+		})
+		assert.Nil(t, err)
+	}
+
+	assert.True(t, useCase.BatchRoutineStatus().Running)
+
+	stats := useCase.Shutdown(context.Background())
+
+	assert.Equal(t, bid_usecase.PipelineDrainStats{FlushedBids: 3}, stats)
+	assert.False(t, useCase.BatchRoutineStatus().Running)
+	bids, _ := bidRepository.FindBidByAuctionId(context.Background(), auction.Id)
+	assert.Len(t, bids, 3)
+
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 1000,
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "Bid pipeline is shutting down", err.Message)
+}
+
+func TestShutdownCountsFailedInsertsAsLost(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+	bidRepository.failInserts.Store(true)
+
+	for i := 1; i <= 2; i++ {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: float64(i * 100),
+		})
+		assert.Nil(t, err)
+	}
+
+	stats := useCase.Shutdown(context.Background())
+
+	assert.Equal(t, bid_usecase.PipelineDrainStats{LostBids: 2}, stats)
+}
+
+func TestShutdownReleasesBidBlockedOnFullPipeline(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auctions := []*auction_entity.Auction{newAuction(nil), newAuction(nil), newAuction(nil)}
+	useCase, bidRepository := newBidUseCase(auctions...)
+	bidRepository.insertGate = make(chan struct{})
+
+	placeBid := func(auctionId string) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: 100,
+		})
+		return err
+	}
+
+	// The first bid holds the routine in the insert, the second one fills the
+	// channel and the third one waits for room
+	assert.Nil(t, placeBid(auctions[0].Id))
+	assert.Nil(t, placeBid(auctions[1].Id))
+	blocked := make(chan *internal_error.InternalError)
+	go func() { blocked <- placeBid(auctions[2].Id) }()
+
+	drained := make(chan bid_usecase.PipelineDrainStats)
+	go func() { drained <- useCase.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-blocked:
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "Bid pipeline is shutting down", err.Message)
+			assert.Equal(t, internal_error.PipelineClosedCode, err.Code)
+			assert.Equal(t, "service_unavailable", err.Err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("bid still blocked after shutdown started")
+	}
+
+	close(bidRepository.insertGate)
+	<-drained
+
+	bids, _ := bidRepository.FindBidByAuctionId(context.Background(), auctions[2].Id)
+	assert.Empty(t, bids)
+	assert.Len(t, bidRepository.bids, 2)
+}
+
+func TestCreateBidOnFullPipeline(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	// fillPipeline holds the routine in the insert of a first bid and fills
+	// the channel with a second one, so the next bid has to wait for room
+	fillPipeline := func(t *testing.T) (bid_usecase.BidUseCaseInterface, string) {
+		auctions := []*auction_entity.Auction{newAuction(nil), newAuction(nil), newAuction(nil)}
+		useCase, bidRepository := newBidUseCase(auctions...)
+		bidRepository.insertGate = make(chan struct{})
+		t.Cleanup(func() {
+			close(bidRepository.insertGate)
+			useCase.Shutdown(context.Background())
+		})
+
+		placeBids(t, useCase, auctions[0].Id, 100)
+		assert.Eventually(t, func() bool {
+			return bidRepository.startedInserts.Load() == 1
+		}, 2*time.Second, time.Millisecond)
+		placeBids(t, useCase, auctions[1].Id, 100)
+		return useCase, auctions[2].Id
+	}
+
+	t.Run("rejected as full after BID_ENQUEUE_TIMEOUT", func(t *testing.T) {
+		t.Setenv("BID_ENQUEUE_TIMEOUT", "20ms")
+		useCase, auctionId := fillPipeline(t)
+
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: 100,
+		})
+		if assert.NotNil(t, err) {
+			assert.Equal(t, internal_error.PipelineFullCode, err.Code)
+			assert.Equal(t, "service_unavailable", err.Err)
+		}
+
+		// The rejected bid is not pending
+		assert.NotContains(t, useCase.FindPendingBids(context.Background()), auctionId)
+	})
+
+	t.Run("rejected at once with BID_QUEUE_BLOCKING=false", func(t *testing.T) {
+		t.Setenv("BID_ENQUEUE_TIMEOUT", "1h")
+		t.Setenv("BID_QUEUE_BLOCKING", "false")
+		useCase, auctionId := fillPipeline(t)
+
+		start := time.Now()
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: 100,
+		})
+		if assert.NotNil(t, err) {
+			assert.Equal(t, internal_error.PipelineFullCode, err.Code)
+			assert.Equal(t, "service_unavailable", err.Err)
+		}
+		assert.Less(t, time.Since(start), time.Second)
+		assert.NotContains(t, useCase.FindPendingBids(context.Background()), auctionId)
+	})
+
+	t.Run("cancelled when the client leaves", func(t *testing.T) {
+		t.Setenv("BID_ENQUEUE_TIMEOUT", "1h")
+		useCase, auctionId := fillPipeline(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		time.AfterFunc(20*time.Millisecond, cancel)
+		_, err := useCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: 100,
+		})
+		if assert.NotNil(t, err) {
+			assert.Equal(t, internal_error.RequestCancelledCode, err.Code)
+		}
+		assert.NotContains(t, useCase.FindPendingBids(context.Background()), auctionId)
+	})
+
+	t.Run("timed out by the request deadline", func(t *testing.T) {
+		t.Setenv("BID_ENQUEUE_TIMEOUT", "1h")
+		useCase, auctionId := fillPipeline(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		result := make(chan *internal_error.InternalError, 1)
+		go func() {
+			_, err := useCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: auctionId, Amount: 100,
+			})
+			result <- err
+		}()
+
+		select {
+		case err := <-result:
+			if assert.NotNil(t, err) {
+				assert.Equal(t, internal_error.RequestTimeoutCode, err.Code)
+				assert.Equal(t, "gateway_timeout", err.Err)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("bid still blocked after the request deadline")
+		}
+		assert.NotContains(t, useCase.FindPendingBids(context.Background()), auctionId)
+	})
+}
+
+func TestCreateBidRejectsCallsOverMaxConcurrentBids(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_BIDS", "2")
+
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	userRepository := &fakeUserRepository{lookupGate: make(chan struct{})}
+	useCase := bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository, userRepository, nil, nil, nil, bidConfig())
+	defer useCase.Shutdown(context.Background())
+
+	placeBid := func(amount float64) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: amount,
+		})
+		return err
+	}
+
+	// Two calls hold the slots while their user lookup is pending
+	var wg sync.WaitGroup
+	for _, amount := range []float64{100, 200} {
+		wg.Add(1)
+		go func(amount float64) {
+			defer wg.Done()
+			placeBid(amount)
+		}(amount)
+	}
+	assert.Eventually(t, func() bool { return userRepository.lookups.Load() == 2 },
+		time.Second, 5*time.Millisecond)
+
+	for _, amount := range []float64{300, 400} {
+		err := placeBid(amount)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, internal_error.TooManyBidsCode, err.Code)
+			assert.Equal(t, "service_unavailable", err.Err)
+		}
+	}
+	assert.Equal(t, int64(2), userRepository.lookups.Load())
+
+	// The slots are released once the calls return
+	close(userRepository.lookupGate)
+	wg.Wait()
+	assert.Nil(t, placeBid(500))
+}
+
+func TestCreateBidRacingShutdownNeverPanics(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	const bidders = 50
+	auctions := make([]*auction_entity.Auction, bidders)
+	for i := range auctions {
+		auctions[i] = newAuction(nil)
+	}
+	useCase, bidRepository := newBidUseCase(auctions...)
+
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for _, auction := range auctions {
+		wg.Add(1)
+		go func(auctionId string) {
+			defer wg.Done()
+			<-start
+			_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: auctionId, Amount: 100,
+			})
+			if err == nil {
+				accepted.Add(1)
+				return
+			}
+			assert.Equal(t, "Bid pipeline is shutting down", err.Message)
+		}(auction.Id)
+	}
+
+	close(start)
+	stats := useCase.Shutdown(context.Background())
+	wg.Wait()
+
+	// Every accepted bid was persisted; the rejected ones were never queued
+	assert.Zero(t, stats.LostBids)
+	bidRepository.mutex.Lock()
+	defer bidRepository.mutex.Unlock()
+	assert.Equal(t, int(accepted.Load()), len(bidRepository.bids))
+}
+
+func TestCreateBidReportsLeadingBid(t *testing.T) {
+	t.Setenv("ALLOW_SELF_OUTBID", "true")
+
+	auction := newAuction(nil)
+	useCase, _ := newBidUseCase(auction)
+	userId := uuid.New().String()
+
+	first, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: userId, AuctionId: auction.Id, Amount: 100,
+	})
+	assert.Nil(t, err)
+	assert.True(t, first.IsHighest)
+	assert.Equal(t, int64(1), first.Rank)
+
+	// Self-outbidding the pending bid still leads
+	second, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: userId, AuctionId: auction.Id, Amount: 200,
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, second.Id)
+	assert.True(t, second.IsHighest)
+	assert.Equal(t, int64(1), second.Rank)
+}
+
+func TestCreateBidReportsNonLeadingBid(t *testing.T) {
+	t.Setenv("ALLOW_SELF_OUTBID", "true")
+
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+	userId := uuid.New().String()
+
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: userId, AuctionId: auction.Id, Amount: 100,
+	})
+	assert.Nil(t, err)
+
+	// Two higher bids are persisted by other requests while this one is placed
+	bidRepository.concurrentHigherBids = 2
+
+	output, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: userId, AuctionId: auction.Id, Amount: 150,
+	})
+	assert.Nil(t, err)
+	assert.False(t, output.IsHighest)
+	assert.Equal(t, int64(3), output.Rank)
+}
+
+func TestCreateBidCooldown(t *testing.T) {
+	t.Setenv("ALLOW_SELF_OUTBID", "true")
+	t.Setenv("BID_COOLDOWN", "100ms")
+
+	auction := newAuction(nil)
+	useCase, _ := newBidUseCase(auction)
+	userId := uuid.New().String()
+
+	placeBid := func(userId string, amount float64) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: amount,
+		})
+		return err
+	}
+
+	assert.Nil(t, placeBid(userId, 100))
+
+	t.Run("inside the window", func(t *testing.T) {
+		err := placeBid(userId, 200)
+		assert.NotNil(t, err)
+		assert.Equal(t, internal_error.BidCooldownCode, err.Code)
+		assert.Contains(t, err.Message, "before bidding again")
+
+		// The cooldown is per user: others can still bid
+		assert.Nil(t, placeBid(uuid.New().String(), 300))
+	})
+
+	t.Run("outside the window", func(t *testing.T) {
+		time.Sleep(150 * time.Millisecond)
+
+		assert.Nil(t, placeBid(userId, 400))
+	})
+}
+
+func TestCreateBidWithCancelledContextEnqueuesNothing(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	output, err := useCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+	assert.Nil(t, output)
+	assert.NotNil(t, err)
+	assert.Equal(t, internal_error.RequestCancelledCode, err.Code)
+
+	// A later bid must not be compared against the cancelled one
+	_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 50,
+	})
+	assert.Nil(t, err)
+
+	stats := useCase.Shutdown(context.Background())
+	assert.Equal(t, bid_usecase.PipelineDrainStats{FlushedBids: 1}, stats)
+
+	bids, _ := bidRepository.FindBidByAuctionId(context.Background(), auction.Id)
+	assert.Len(t, bids, 1)
+	assert.Equal(t, 50.0, bids[0].Amount())
+}
+
+// fakeBidEventLog keeps the event log in memory so it survives a simulated
+// restart, i.e. a new use case built over the same log.
+type fakeBidEventLog struct {
+	mutex   sync.Mutex
+	pending []bid_entity.Bid
+}
+
+func (f *fakeBidEventLog) AppendAccepted(bid bid_entity.Bid) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.pending = append(f.pending, bid)
+	return nil
+}
+
+func (f *fakeBidEventLog) AppendSettled(bids []bid_entity.Bid) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, settled := range bids {
+		for i, bid := range f.pending {
+			if bid.Id == settled.Id {
+				f.pending = append(f.pending[:i], f.pending[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeBidEventLog) Pending() ([]bid_entity.Bid, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]bid_entity.Bid(nil), f.pending...), nil
+}
+
+func TestCreateBidMarksAuctionOnFirstBid(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auction := newAuction(func(a *auction_entity.Auction) { a.Version = 3 })
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	useCase := bid_usecase.NewBidUseCase(
+		&fakeBidRepository{}, auctionRepository, &fakeUserRepository{}, nil, nil, nil, bidConfig())
+
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+	assert.Nil(t, err)
+	// Marked at the version the bid was validated against
+	assert.Equal(t, map[string]int64{auction.Id: 3}, auctionRepository.marked)
+
+	// With a pending bid the auction is already marked: an edit saved since
+	// then no longer matters to the next bids
+	auctionRepository.markErr = internal_error.NewConflictError("Auction was modified concurrently")
+	_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 200,
+	})
+	assert.Nil(t, err)
+}
+
+func TestCreateBidRejectedWhenAuctionChangedBeforeMark(t *testing.T) {
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{
+		auctions: map[string]*auction_entity.Auction{auction.Id: auction},
+		markErr:  internal_error.NewConflictError("Auction was modified concurrently, reload it and try again"),
+	}
+	bidRepository := &fakeBidRepository{}
+	useCase := bid_usecase.NewBidUseCase(
+		bidRepository, auctionRepository, &fakeUserRepository{}, nil, nil, nil, bidConfig())
+
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "conflict", err.Err)
+	}
+	assert.Equal(t, 0, useCase.FindPipelineStats(context.Background()).BatchLength)
+}
+
+func TestCreateBidAfterRestartRespectsUnpersistedBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	bidRepository := &fakeBidRepository{}
+	eventLog := &fakeBidEventLog{}
+
+	beforeRestart := bid_usecase.NewBidUseCase(bidRepository, auctionRepository, &fakeUserRepository{}, eventLog, nil, nil, bidConfig())
+	_, err := beforeRestart.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 200,
+	})
+	assert.Nil(t, err)
+
+	// The process dies before the batch is flushed: nothing reached the repository
+	bids, _ := bidRepository.FindBidByAuctionId(context.Background(), auction.Id)
+	assert.Empty(t, bids)
+
+	afterRestart := bid_usecase.NewBidUseCase(bidRepository, auctionRepository, &fakeUserRepository{}, eventLog, nil, nil, bidConfig())
+
+	_, err = afterRestart.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 150,
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "Bid must be higher than current highest bid", err.Message)
+
+	// The recovered bid is persisted by the new pipeline and settled
+	stats := afterRestart.Shutdown(context.Background())
+	assert.Equal(t, bid_usecase.PipelineDrainStats{FlushedBids: 1}, stats)
+
+	bids, _ = bidRepository.FindBidByAuctionId(context.Background(), auction.Id)
+	assert.Len(t, bids, 1)
+	assert.Equal(t, 200.0, bids[0].Amount())
+
+	pending, _ := eventLog.Pending()
+	assert.Empty(t, pending)
+}
+
+func TestFailedInsertStaysPendingInEventLog(t *testing.T) {
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	bidRepository := &fakeBidRepository{}
+	bidRepository.failInserts.Store(true)
+	eventLog := &fakeBidEventLog{}
+
+	useCase := bid_usecase.NewBidUseCase(bidRepository, auctionRepository, &fakeUserRepository{}, eventLog, nil, nil, bidConfig())
+	output, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 200,
+	})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return useCase.FindPipelineStats(context.Background()).BatchLength == 1
+	}, 2*time.Second, time.Millisecond)
+
+	_, flushErr := useCase.Flush(context.Background())
+	assert.NotNil(t, flushErr)
+
+	// The bid was never written, so a restart must replay it
+	pending, _ := eventLog.Pending()
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, output.Id, pending[0].Id)
+	}
+
+	bidRepository.failInserts.Store(false)
+	_, flushErr = useCase.Flush(context.Background())
+	assert.Nil(t, flushErr)
+	pending, _ = eventLog.Pending()
+	assert.Empty(t, pending)
+}
+
+func TestCreateBidExactTie(t *testing.T) {
+	testCases := []struct {
+		policy   string
+		accepted bool
+	}{
+		{"reject_equal", false},
+		{"", false},
+		{"last_write_wins", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run("policy "+tc.policy, func(t *testing.T) {
+			t.Setenv("BID_TIE_POLICY", tc.policy)
+
+			auction := newAuction(nil)
+			useCase, _ := newBidUseCase(auction)
+
+			_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+			})
+			assert.Nil(t, err)
+
+			output, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+			})
+			if !tc.accepted {
+				assert.NotNil(t, err)
+				assert.Equal(t, "Bid must be higher than current highest bid", err.Message)
+				return
+			}
+
+			// The latest equal bid takes the lead
+			assert.Nil(t, err)
+			assert.True(t, output.IsHighest)
+			assert.Equal(t, int64(1), output.Rank)
+		})
+	}
+}
+
+func TestFindPendingBidsReflectsQueuedBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	first, second := newAuction(nil), newAuction(nil)
+	useCase, _ := newBidUseCase(first, second)
+	assert.Empty(t, useCase.FindPendingBids(context.Background()))
+
+	userId := uuid.New().String()
+	for _, input := range []bid_usecase.BidInputDTO{
+		{UserId: uuid.New().String(), AuctionId: first.Id, Amount: 100},
+		{UserId: userId, AuctionId: first.Id, Amount: 150},
+		{UserId: userId, AuctionId: second.Id, Amount: 80},
+	} {
+		_, err := useCase.CreateBid(context.Background(), input)
+		assert.Nil(t, err)
+	}
+
+	snapshot := useCase.FindPendingBids(context.Background())
+	assert.Len(t, snapshot, 2)
+	assert.Equal(t, 150.0, snapshot[first.Id].Amount)
+	assert.Equal(t, userId, snapshot[first.Id].UserId)
+	assert.Equal(t, 80.0, snapshot[second.Id].Amount)
+
+	// The snapshot is a copy: later bids do not change it
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: second.Id, Amount: 90,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 80.0, snapshot[second.Id].Amount)
+}
+
+func TestPendingBidsCacheIsBounded(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+	t.Setenv("MAX_PENDING_AUCTIONS", "2")
+
+	first, second, third := newAuction(nil), newAuction(nil), newAuction(nil)
+	useCase, bidRepository := newBidUseCase(first, second, third)
+	bidRepository.bids = []bid_entity.Bid{
+		{Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: first.Id, AmountCents: 12000},
+	}
+
+	for _, input := range []bid_usecase.BidInputDTO{
+		{UserId: uuid.New().String(), AuctionId: first.Id, Amount: 200},
+		{UserId: uuid.New().String(), AuctionId: second.Id, Amount: 100},
+		{UserId: uuid.New().String(), AuctionId: third.Id, Amount: 100},
+	} {
+		_, err := useCase.CreateBid(context.Background(), input)
+		assert.Nil(t, err)
+		// Keeps the bid timestamps, which order the eviction, apart
+		time.Sleep(time.Millisecond)
+	}
+
+	// The auction updated longest ago was evicted
+	snapshot := useCase.FindPendingBids(context.Background())
+	assert.Len(t, snapshot, 2)
+	assert.NotContains(t, snapshot, first.Id)
+
+	// Cached auctions are still validated against their pending bid
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: second.Id, Amount: 90,
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "Bid must be higher than current highest bid", err.Message)
+
+	// The evicted auction falls back to the persisted highest bid
+	_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: first.Id, Amount: 110,
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "Bid must be higher than current highest bid", err.Message)
+
+	_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: first.Id, Amount: 150,
+	})
+	assert.Nil(t, err)
+
+	// Bidding on the evicted auction again evicted the next oldest one
+	snapshot = useCase.FindPendingBids(context.Background())
+	assert.Len(t, snapshot, 2)
+	assert.Equal(t, 150.0, snapshot[first.Id].Amount)
+	assert.NotContains(t, snapshot, second.Id)
+	assert.Equal(t, 100.0, snapshot[third.Id].Amount)
+}
+
+func TestPendingBidsAreClearedPerAuctionOnceFlushed(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "100")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	first, second := newAuction(nil), newAuction(nil)
+	useCase, _ := newBidUseCase(first, second)
+
+	// Interleaved bids on both auctions; some lose the race and are rejected
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			auctionId := first.Id
+			if i%2 == 0 {
+				auctionId = second.Id
+			}
+			_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: auctionId, Amount: float64(100 + i),
+			})
+			if err == nil {
+				accepted.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, useCase.FindPendingBids(context.Background()), 2)
+	assert.Eventually(t, func() bool {
+		return useCase.FindPipelineStats(context.Background()).BatchLength == int(accepted.Load())
+	}, 2*time.Second, time.Millisecond)
+
+	flushed, err := useCase.Flush(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int(accepted.Load()), flushed)
+	assert.Empty(t, useCase.FindPendingBids(context.Background()))
+
+	// A bid accepted while its auction's batch is being inserted stays pending
+	t.Setenv("MAX_BATCH_SIZE", "2")
+	useCase, bidRepository := newBidUseCase(first, second)
+	bidRepository.insertGate = make(chan struct{})
+
+	placeBid := func(auctionId string, amount float64) {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: amount,
+		})
+		assert.Nil(t, err)
+	}
+	placeBid(first.Id, 100)
+	placeBid(second.Id, 100)
+	placeBid(first.Id, 150)
+
+	close(bidRepository.insertGate)
+	assert.Eventually(t, func() bool {
+		bids, _ := bidRepository.FindBidByAuctionId(context.Background(), second.Id)
+		return len(bids) == 1
+	}, 2*time.Second, time.Millisecond)
+
+	snapshot := useCase.FindPendingBids(context.Background())
+	assert.Len(t, snapshot, 1)
+	assert.Equal(t, 150.0, snapshot[first.Id].Amount)
+
+	// The persisted bid is still served as the current one
+	current, err := useCase.FindCurrentBid(context.Background(), second.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, 100.0, current.Amount)
+}
+
+func TestCreateBidFailsWhenHighestBidLookupFails(t *testing.T) {
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+	bidRepository.bids = []bid_entity.Bid{
+		{Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auction.Id, AmountCents: 50000},
+	}
+	bidRepository.failWinnerLookups.Store(true)
+
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+
+	// Not taken for "no bids yet": the bid below the persisted 500 is refused
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "internal_server_error", err.Err)
+	}
+	assert.Empty(t, useCase.FindPendingBids(context.Background()))
+}
+
+func TestFailedInsertKeepsPendingHighestBid(t *testing.T) {
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+	bidRepository.failInserts.Store(true)
+
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 200,
+	})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return useCase.FindPipelineStats(context.Background()).BatchLength == 1
+	}, 2*time.Second, time.Millisecond)
+
+	flushed, flushErr := useCase.Flush(context.Background())
+	assert.NotNil(t, flushErr)
+	assert.Equal(t, 0, flushed)
+
+	// The unwritten bid is still the highest, so a lower one is rejected
+	assert.Len(t, useCase.FindPendingBids(context.Background()), 1)
+	_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 150,
+	})
+	assert.NotNil(t, err)
+
+	// It is kept for the next flush, which writes it once the database is back
+	assert.Equal(t, 1, useCase.FindPipelineStats(context.Background()).BatchLength)
+	bidRepository.failInserts.Store(false)
+	flushed, flushErr = useCase.Flush(context.Background())
+	assert.Nil(t, flushErr)
+	assert.Equal(t, 1, flushed)
+	assert.Empty(t, useCase.FindPendingBids(context.Background()))
+}
+
+func TestCreateBidUserLookupFailures(t *testing.T) {
+	placeBid := func(useCase bid_usecase.BidUseCaseInterface, auctionId, userId string, amount float64) *internal_error.InternalError {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auctionId, Amount: amount,
+		})
+		return err
+	}
+
+	newUseCase := func(userRepository *fakeUserRepository) (bid_usecase.BidUseCaseInterface, string) {
+		auction := newAuction(nil)
+		auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+		return bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository, userRepository, nil, nil, nil, bidConfig()), auction.Id
+	}
+
+	t.Run("genuine not found", func(t *testing.T) {
+		userId := uuid.New().String()
+		useCase, auctionId := newUseCase(&fakeUserRepository{missing: map[string]bool{userId: true}})
+
+		err := placeBid(useCase, auctionId, userId, 100)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "not_found", err.Err)
+		assert.Equal(t, internal_error.UserNotFoundCode, err.Code)
+	})
+
+	t.Run("transient failure is not reported as not found", func(t *testing.T) {
+		userRepository := &fakeUserRepository{}
+		userRepository.unavailable.Store(true)
+		useCase, auctionId := newUseCase(userRepository)
+
+		err := placeBid(useCase, auctionId, uuid.New().String(), 100)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "service_unavailable", err.Err)
+		assert.Equal(t, internal_error.UserUnavailableCode, err.Code)
+	})
+
+	t.Run("degraded mode disabled rejects known users", func(t *testing.T) {
+		userRepository := &fakeUserRepository{}
+		useCase, auctionId := newUseCase(userRepository)
+		knownUserId := uuid.New().String()
+
+		assert.Nil(t, placeBid(useCase, auctionId, knownUserId, 100))
+		assert.Nil(t, placeBid(useCase, auctionId, uuid.New().String(), 200))
+
+		userRepository.unavailable.Store(true)
+		err := placeBid(useCase, auctionId, knownUserId, 300)
+		assert.NotNil(t, err)
+		assert.Equal(t, internal_error.UserUnavailableCode, err.Code)
+	})
+
+	t.Run("degraded mode accepts only previously seen users", func(t *testing.T) {
+		t.Setenv("USER_LOOKUP_DEGRADED_MODE", "true")
+
+		userRepository := &fakeUserRepository{}
+		useCase, auctionId := newUseCase(userRepository)
+		knownUserId := uuid.New().String()
+
+		assert.Nil(t, placeBid(useCase, auctionId, knownUserId, 100))
+		assert.Nil(t, placeBid(useCase, auctionId, uuid.New().String(), 200))
+
+		userRepository.unavailable.Store(true)
+
+		err := placeBid(useCase, auctionId, uuid.New().String(), 300)
+		assert.NotNil(t, err)
+		assert.Equal(t, internal_error.UserUnavailableCode, err.Code)
+
+		assert.Nil(t, placeBid(useCase, auctionId, knownUserId, 300))
+	})
+}
+
+func TestCreateBidCountsRejectionsByReason(t *testing.T) {
+	t.Setenv("ALLOW_SELF_OUTBID", "true")
+	t.Setenv("BID_COOLDOWN", "1h")
+
+	deny := false
+	open := newAuction(nil)
+	noSelfOutbid := newAuction(func(a *auction_entity.Auction) { a.AllowSelfOutbid = &deny })
+	completed := newAuction(func(a *auction_entity.Auction) { a.Status = auction_entity.Completed })
+
+	missingUserId := uuid.New().String()
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{
+		open.Id: open, noSelfOutbid.Id: noSelfOutbid, completed.Id: completed,
+	}}
+	userRepository := &fakeUserRepository{missing: map[string]bool{missingUserId: true}}
+	useCase := bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository, userRepository, nil, nil, nil, bidConfig())
+
+	placeBid := func(userId, auctionId string, amount float64) {
+		_, _ = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auctionId, Amount: amount,
+		})
+	}
+
+	leader, other := uuid.New().String(), uuid.New().String()
+	placeBid(leader, open.Id, 100)         // accepted
+	placeBid(leader, open.Id, 200)         // rate_limited
+	placeBid(other, open.Id, 50)           // too_low
+	placeBid(leader, noSelfOutbid.Id, 100) // accepted
+	placeBid(leader, noSelfOutbid.Id, 200) // self_outbid
+	placeBid(other, completed.Id, 100)     // auction_closed
+	placeBid(other, uuid.New().String(), 100)
+	placeBid(missingUserId, open.Id, 300)
+	placeBid("not-a-uuid", open.Id, 300)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = useCase.CreateBid(ctx, bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: open.Id, Amount: 300,
+	})
+
+	assert.Equal(t, map[string]int64{
+		bid_usecase.RejectedAuctionNotFound: 1,
+		bid_usecase.RejectedAuctionClosed:   1,
+		bid_usecase.RejectedTooLow:          1,
+		bid_usecase.RejectedSelfOutbid:      1,
+		bid_usecase.RejectedUserNotFound:    1,
+		bid_usecase.RejectedRateLimited:     1,
+		bid_usecase.RejectedInvalidBid:      1,
+		bid_usecase.RejectedCancelled:       1,
+		bid_usecase.RejectedOther:           0,
+	}, useCase.FindRejectionCounts(context.Background()))
+}
+
+func TestCreateBidCountsConcurrentRejections(t *testing.T) {
+	completed := newAuction(func(a *auction_entity.Auction) { a.Status = auction_entity.Completed })
+	useCase, _ := newBidUseCase(completed)
+
+	const bidders = 50
+	var wg sync.WaitGroup
+	for i := 0; i < bidders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+				UserId: uuid.New().String(), AuctionId: completed.Id, Amount: 100,
+			})
+		}()
+	}
+	wg.Wait()
+
+	counts := useCase.FindRejectionCounts(context.Background())
+	assert.Equal(t, int64(bidders), counts[bid_usecase.RejectedAuctionClosed])
+	assert.Zero(t, counts[bid_usecase.RejectedOther])
+}
+
+func TestRecordRateLimitedCountsRateLimitedRejection(t *testing.T) {
+	useCase, _ := newBidUseCase()
+
+	useCase.RecordRateLimited()
+	useCase.RecordRateLimited()
+
+	counts := useCase.FindRejectionCounts(context.Background())
+	assert.Equal(t, int64(2), counts[bid_usecase.RejectedRateLimited])
+	assert.Zero(t, counts[bid_usecase.RejectedOther])
+}
+
+type fakeRejectedBidRepository struct {
+	mutex        sync.Mutex
+	rejectedBids []bid_entity.RejectedBid
+}
+
+func (f *fakeRejectedBidRepository) CreateRejectedBids(
+	ctx context.Context, rejectedBids []bid_entity.RejectedBid) *internal_error.InternalError {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.rejectedBids = append(f.rejectedBids, rejectedBids...)
+	return nil
+}
+
+func (f *fakeRejectedBidRepository) recorded() []bid_entity.RejectedBid {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]bid_entity.RejectedBid(nil), f.rejectedBids...)
+}
+
+func TestCreateBidRecordsRejectedBids(t *testing.T) {
+	newUseCase := func(auction *auction_entity.Auction) (bid_usecase.BidUseCaseInterface, *fakeRejectedBidRepository) {
+		auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+		rejectedBidRepository := &fakeRejectedBidRepository{}
+		return bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository,
+			&fakeUserRepository{}, nil, rejectedBidRepository, nil, bidConfig()), rejectedBidRepository
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Setenv("RECORD_REJECTED_BIDS", "true")
+
+		auction := newAuction(nil)
+		useCase, rejectedBidRepository := newUseCase(auction)
+		userId := uuid.New().String()
+
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: 100,
+		})
+		assert.Nil(t, err)
+
+		_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 50,
+		})
+		assert.NotNil(t, err)
+
+		assert.Eventually(t, func() bool {
+			return len(rejectedBidRepository.recorded()) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		rejected := rejectedBidRepository.recorded()[0]
+		assert.Equal(t, auction.Id, rejected.AuctionId)
+		assert.Equal(t, 50.0, rejected.Amount)
+		assert.Equal(t, bid_usecase.RejectedTooLow, rejected.Reason)
+		assert.Equal(t, err.Message, rejected.Message)
+		assert.NotEmpty(t, rejected.Id)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		completed := newAuction(func(a *auction_entity.Auction) { a.Status = auction_entity.Completed })
+		useCase, rejectedBidRepository := newUseCase(completed)
+
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: completed.Id, Amount: 100,
+		})
+		assert.NotNil(t, err)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, rejectedBidRepository.recorded())
+	})
+}
+
+type fakeConfirmationNotifier struct {
+	bidRepository *fakeBidRepository
+	confirmed     chan []bid_entity.Bid
+	persisted     atomic.Bool // whether the bids were stored when notified
+}
+
+func (f *fakeConfirmationNotifier) BidsConfirmed(bids []bid_entity.Bid) {
+	stored, _ := f.bidRepository.FindBidByAuctionId(context.Background(), bids[0].AuctionId)
+	f.persisted.Store(len(stored) == len(bids))
+	f.confirmed <- bids
+}
+
+func TestCreateBidNotifiesConfirmationAfterFlush(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	bidRepository := &fakeBidRepository{}
+	notifier := &fakeConfirmationNotifier{
+		bidRepository: bidRepository,
+		confirmed:     make(chan []bid_entity.Bid, 1),
+	}
+	useCase := bid_usecase.NewBidUseCase(bidRepository, auctionRepository, &fakeUserRepository{}, nil, nil, notifier, bidConfig())
+
+	output, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+	assert.Nil(t, err)
+
+	select {
+	case bids := <-notifier.confirmed:
+		assert.Len(t, bids, 1)
+		assert.Equal(t, output.Id, bids[0].Id)
+		assert.True(t, notifier.persisted.Load())
+	case <-time.After(2 * time.Second):
+		t.Fatal("bid confirmation not notified")
+	}
+}
+
+func TestCreateBidDoesNotConfirmFailedInserts(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "1")
+
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	bidRepository := &fakeBidRepository{}
+	bidRepository.failInserts.Store(true)
+	notifier := &fakeConfirmationNotifier{
+		bidRepository: bidRepository,
+		confirmed:     make(chan []bid_entity.Bid, 1),
+	}
+	useCase := bid_usecase.NewBidUseCase(bidRepository, auctionRepository, &fakeUserRepository{}, nil, nil, notifier, bidConfig())
+
+	_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+		UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 100,
+	})
+	assert.Nil(t, err)
+
+	select {
+	case bids := <-notifier.confirmed:
+		t.Fatalf("unexpected confirmation for %+v", bids)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,18 @@
+package bid_usecase
+
+import "time"
+
+// BatchSizeTuner exposes the batch size tuner to the external tests.
+type BatchSizeTuner = batchSizeTuner
+
+func NewBatchSizeTuner(enabled bool, initial, lower, upper int, targetLatency time.Duration) *BatchSizeTuner {
+	return newBatchSizeTuner(enabled, initial, lower, upper, targetLatency)
+}
+
+func (bt *batchSizeTuner) Observe(batchLen int, latency time.Duration) {
+	bt.observe(batchLen, latency)
+}
+
+func (bt *batchSizeTuner) Current() int {
+	return bt.current()
+}
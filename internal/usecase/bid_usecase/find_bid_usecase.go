@@ -2,29 +2,88 @@ package bid_usecase
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
 	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
 )
 
+// FindBidByAuctionId returns a page of the persisted bids of an auction,
+// oldest first, and how many bids it has in total.
 func (bu *BidUseCase) FindBidByAuctionId(
-	ctx context.Context, auctionId string) ([]BidOutputDTO, *internal_error.InternalError) {
-	bidList, err := bu.BidRepository.FindBidByAuctionId(ctx, auctionId)
+	ctx context.Context,
+	auctionId string,
+	input FindBidsInputDTO) (*FindBidsOutputDTO, *internal_error.InternalError) {
+	if input.Limit == 0 {
+		input.Limit = defaultPageSize
+	}
+	if input.Limit < 1 || input.Limit > maxPageSize || input.Offset < 0 {
+		return nil, internal_error.NewBadRequestError(fmt.Sprintf(
+			"limit must be between 1 and %d and offset must not be negative", maxPageSize))
+	}
+
+	bidList, total, err := bu.BidRepository.FindBidsPageByAuctionId(ctx, auctionId, input.Offset, input.Limit)
 	if err != nil {
 		return nil, err
 	}
 
-	var bidOutputList []BidOutputDTO
+	bidOutputList := make([]BidOutputDTO, 0, len(bidList))
 	for _, bid := range bidList {
 		bidOutputList = append(bidOutputList, BidOutputDTO{
 			Id:        bid.Id,
 			UserId:    bid.UserId,
 			AuctionId: bid.AuctionId,
-			Amount:    bid.Amount,
+			Amount:    bid.Amount(),
 			Timestamp: bid.Timestamp,
 		})
 	}
 
-	return bidOutputList, nil
+	return &FindBidsOutputDTO{
+		Bids:   bidOutputList,
+		Total:  total,
+		Limit:  input.Limit,
+		Offset: input.Offset,
+	}, nil
+}
+
+const (
+	// defaultPageSize is the page size of a listing without a limit
+	defaultPageSize = 20
+
+	// maxPageSize bounds how many bids a single page may hold
+	maxPageSize = 100
+)
+
+// StreamBidsByAuctionId sends the bids of an auction, oldest first, keeping
+// memory bounded for large auctions. The channel is closed when the stream
+// ends or ctx is done.
+func (bu *BidUseCase) StreamBidsByAuctionId(
+	ctx context.Context, auctionId string) (<-chan BidOutputDTO, *internal_error.InternalError) {
+	bids, err := bu.BidRepository.StreamBidsByAuctionId(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	bidOutputs := make(chan BidOutputDTO)
+	go func() {
+		defer close(bidOutputs)
+		for bid := range bids {
+			select {
+			case bidOutputs <- BidOutputDTO{
+				Id:        bid.Id,
+				UserId:    bid.UserId,
+				AuctionId: bid.AuctionId,
+				Amount:    bid.Amount(),
+				Timestamp: bid.Timestamp,
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return bidOutputs, nil
 }
 
 func (bu *BidUseCase) FindWinningBidByAuctionId(
@@ -38,9 +97,129 @@ func (bu *BidUseCase) FindWinningBidByAuctionId(
 		Id:        bidEntity.Id,
 		UserId:    bidEntity.UserId,
 		AuctionId: bidEntity.AuctionId,
-		Amount:    bidEntity.Amount,
+		Amount:    bidEntity.Amount(),
 		Timestamp: bidEntity.Timestamp,
 	}
 
 	return bidOutput, nil
 }
+
+// FindUserWinningStatus compares the user with the current highest bid,
+// pending bids included.
+func (bu *BidUseCase) FindUserWinningStatus(
+	ctx context.Context, auctionId, userId string) (*UserWinningStatusOutputDTO, *internal_error.InternalError) {
+	if _, err := bu.AuctionRepository.FindAuctionById(ctx, auctionId); err != nil {
+		return nil, err
+	}
+
+	highestBid, _, err := bu.findHighestBid(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &UserWinningStatusOutputDTO{
+		AuctionId: auctionId,
+		UserId:    userId,
+	}
+	if highestBid != nil {
+		status.Winning = highestBid.UserId == userId
+		status.HighestAmount = highestBid.Amount()
+	}
+
+	return status, nil
+}
+
+// FindCurrentBid returns the bid setting the price of an active auction right
+// now. Unlike the winner, it may still be pending in the batch.
+func (bu *BidUseCase) FindCurrentBid(
+	ctx context.Context, auctionId string) (*CurrentBidOutputDTO, *internal_error.InternalError) {
+	auction, err := bu.AuctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status != auction_entity.Active {
+		return nil, internal_error.NewAuctionCompletedError()
+	}
+
+	highestBid, persisted, err := bu.findHighestBid(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if highestBid == nil {
+		return nil, internal_error.NewNotFoundError("No bids found for the auction")
+	}
+
+	return &CurrentBidOutputDTO{
+		BidOutputDTO: BidOutputDTO{
+			Id:        highestBid.Id,
+			UserId:    highestBid.UserId,
+			AuctionId: highestBid.AuctionId,
+			Amount:    highestBid.Amount(),
+			Timestamp: highestBid.Timestamp,
+		},
+		Persisted: persisted,
+	}, nil
+}
+
+// findHighestBid compares the persisted winner with the pending highest bid,
+// so a bid accepted moments ago already counts. Ties follow BID_TIE_POLICY, as
+// in the bid validation. The bid is nil when the auction has none, and
+// persisted reports whether it is already stored.
+func (bu *BidUseCase) findHighestBid(
+	ctx context.Context, auctionId string) (highestBid *bid_entity.Bid, persisted bool, err *internal_error.InternalError) {
+	highestBid, err = bu.BidRepository.FindWinningBidByAuctionId(ctx, auctionId)
+	if err != nil && !err.IsNotFound() {
+		return nil, false, err
+	}
+	persisted = highestBid != nil
+
+	if pending := bu.getPendingHighestBid(auctionId); pending != nil {
+		if highestBid == nil || pending.AmountCents > highestBid.AmountCents ||
+			(pending.AmountCents == highestBid.AmountCents && bu.rules.TiePolicy == bid_entity.LastWriteWins) {
+			// The bid may have been flushed since the cache was read
+			persisted = highestBid != nil && highestBid.Id == pending.Id
+			highestBid = pending
+		}
+	}
+
+	return highestBid, persisted, nil
+}
+
+func (bu *BidUseCase) FindPendingBids(ctx context.Context) map[string]BidOutputDTO {
+	bu.pendingHighestBidMutex.RLock()
+	defer bu.pendingHighestBidMutex.RUnlock()
+
+	pendingBids := make(map[string]BidOutputDTO, len(bu.pendingHighestBid))
+	for auctionId, bid := range bu.pendingHighestBid {
+		pendingBids[auctionId] = BidOutputDTO{
+			Id:        bid.Id,
+			UserId:    bid.UserId,
+			AuctionId: bid.AuctionId,
+			Amount:    bid.Amount(),
+			Timestamp: bid.Timestamp,
+		}
+	}
+
+	return pendingBids
+}
+
+func (bu *BidUseCase) FindRejectionCounts(ctx context.Context) map[string]int64 {
+	return bu.rejections.snapshot()
+}
+
+func (bu *BidUseCase) FindBatchSize(ctx context.Context) BatchSizeOutputDTO {
+	return bu.batchSize.snapshot()
+}
+
+func (bu *BidUseCase) FindPipelineStats(ctx context.Context) PipelineStatsOutputDTO {
+	bu.bidBatchMutex.Lock()
+	stats := PipelineStatsOutputDTO{
+		BatchLength:     len(bu.bidBatch),
+		ChannelLength:   len(bu.bidChannel),
+		ChannelCapacity: cap(bu.bidChannel),
+	}
+	bu.bidBatchMutex.Unlock()
+
+	bu.pipeline.snapshot(&stats)
+	return stats
+}
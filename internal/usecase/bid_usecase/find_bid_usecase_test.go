@@ -0,0 +1,233 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUserWinningStatus(t *testing.T) {
+	// Accepted bids stay pending, so the status must read them from the cache
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	t.Run("user leading with a pending bid", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+		userId := uuid.New().String()
+
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: 100,
+		})
+		assert.Nil(t, err)
+
+		status, err := useCase.FindUserWinningStatus(context.Background(), auction.Id, userId)
+		assert.Nil(t, err)
+		assert.True(t, status.Winning)
+		assert.Equal(t, 100.0, status.HighestAmount)
+	})
+
+	t.Run("user leading with a persisted bid", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, bidRepository := newBidUseCase(auction)
+		userId := uuid.New().String()
+		bidRepository.bids = []bid_entity.Bid{
+			{Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auction.Id, AmountCents: 5000, Timestamp: time.Now()},
+			{Id: uuid.New().String(), UserId: userId, AuctionId: auction.Id, AmountCents: 8000, Timestamp: time.Now()},
+		}
+
+		status, err := useCase.FindUserWinningStatus(context.Background(), auction.Id, userId)
+		assert.Nil(t, err)
+		assert.True(t, status.Winning)
+		assert.Equal(t, 80.0, status.HighestAmount)
+	})
+
+	t.Run("user outbid by a pending bid", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, bidRepository := newBidUseCase(auction)
+		userId := uuid.New().String()
+		bidRepository.bids = []bid_entity.Bid{
+			{Id: uuid.New().String(), UserId: userId, AuctionId: auction.Id, AmountCents: 8000, Timestamp: time.Now()},
+		}
+
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 120,
+		})
+		assert.Nil(t, err)
+
+		status, err := useCase.FindUserWinningStatus(context.Background(), auction.Id, userId)
+		assert.Nil(t, err)
+		assert.False(t, status.Winning)
+		assert.Equal(t, 120.0, status.HighestAmount)
+	})
+
+	t.Run("no bids", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+		userId := uuid.New().String()
+
+		status, err := useCase.FindUserWinningStatus(context.Background(), auction.Id, userId)
+		assert.Nil(t, err)
+		assert.Equal(t, auction.Id, status.AuctionId)
+		assert.Equal(t, userId, status.UserId)
+		assert.False(t, status.Winning)
+		assert.Zero(t, status.HighestAmount)
+	})
+
+	t.Run("unknown auction", func(t *testing.T) {
+		useCase, _ := newBidUseCase()
+
+		_, err := useCase.FindUserWinningStatus(
+			context.Background(), uuid.New().String(), uuid.New().String())
+		assert.NotNil(t, err)
+		assert.Equal(t, internal_error.AuctionNotFoundCode, err.Code)
+	})
+}
+
+func TestFindCurrentBid(t *testing.T) {
+	// Accepted bids stay pending, so the current bid must read them from the cache
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	t.Run("persisted bid is the highest", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, bidRepository := newBidUseCase(auction)
+		userId := uuid.New().String()
+		bidRepository.bids = []bid_entity.Bid{
+			{Id: "bid-1", UserId: uuid.New().String(), AuctionId: auction.Id, AmountCents: 5000, Timestamp: time.Now()},
+			{Id: "bid-2", UserId: userId, AuctionId: auction.Id, AmountCents: 8000, Timestamp: time.Now()},
+		}
+
+		currentBid, err := useCase.FindCurrentBid(context.Background(), auction.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "bid-2", currentBid.Id)
+		assert.Equal(t, userId, currentBid.UserId)
+		assert.Equal(t, 80.0, currentBid.Amount)
+		assert.True(t, currentBid.Persisted)
+	})
+
+	t.Run("only a pending bid", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+		userId := uuid.New().String()
+
+		created, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: 100,
+		})
+		assert.Nil(t, err)
+
+		currentBid, err := useCase.FindCurrentBid(context.Background(), auction.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, created.Id, currentBid.Id)
+		assert.Equal(t, userId, currentBid.UserId)
+		assert.Equal(t, 100.0, currentBid.Amount)
+		assert.False(t, currentBid.Timestamp.IsZero())
+		assert.False(t, currentBid.Persisted)
+	})
+
+	t.Run("pending bid above the persisted one", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, bidRepository := newBidUseCase(auction)
+		bidRepository.bids = []bid_entity.Bid{
+			{Id: "bid-1", UserId: uuid.New().String(), AuctionId: auction.Id, AmountCents: 8000, Timestamp: time.Now()},
+		}
+
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: 120,
+		})
+		assert.Nil(t, err)
+
+		currentBid, err := useCase.FindCurrentBid(context.Background(), auction.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, 120.0, currentBid.Amount)
+		assert.False(t, currentBid.Persisted)
+	})
+
+	t.Run("no bids", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+
+		_, err := useCase.FindCurrentBid(context.Background(), auction.Id)
+		if assert.NotNil(t, err) {
+			assert.True(t, err.IsNotFound())
+		}
+	})
+
+	t.Run("completed auction", func(t *testing.T) {
+		auction := newAuction(func(a *auction_entity.Auction) { a.Status = auction_entity.Completed })
+		useCase, _ := newBidUseCase(auction)
+
+		_, err := useCase.FindCurrentBid(context.Background(), auction.Id)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, internal_error.AuctionCompletedCode, err.Code)
+		}
+	})
+
+	t.Run("unknown auction", func(t *testing.T) {
+		useCase, _ := newBidUseCase()
+
+		_, err := useCase.FindCurrentBid(context.Background(), uuid.New().String())
+		if assert.NotNil(t, err) {
+			assert.Equal(t, internal_error.AuctionNotFoundCode, err.Code)
+		}
+	})
+}
+
+func TestFindBidByAuctionIdIsPaginated(t *testing.T) {
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+	for i := 0; i < 25; i++ {
+		bidRepository.bids = append(bidRepository.bids, bid_entity.Bid{
+			Id: uuid.New().String(), UserId: uuid.New().String(), AuctionId: auction.Id,
+			AmountCents: int64(i+1) * 100, Timestamp: time.Now(),
+		})
+	}
+
+	t.Run("defaults to the first 20 bids", func(t *testing.T) {
+		output, err := useCase.FindBidByAuctionId(context.Background(), auction.Id, bid_usecase.FindBidsInputDTO{})
+
+		assert.Nil(t, err)
+		assert.Len(t, output.Bids, 20)
+		assert.Equal(t, int64(25), output.Total)
+		assert.Equal(t, int64(20), output.Limit)
+		assert.Zero(t, output.Offset)
+	})
+
+	t.Run("returns the requested page", func(t *testing.T) {
+		output, err := useCase.FindBidByAuctionId(context.Background(), auction.Id,
+			bid_usecase.FindBidsInputDTO{Limit: 10, Offset: 20})
+
+		assert.Nil(t, err)
+		if assert.Len(t, output.Bids, 5) {
+			assert.Equal(t, 21.0, output.Bids[0].Amount)
+		}
+		assert.Equal(t, int64(25), output.Total)
+	})
+
+	t.Run("auction without bids", func(t *testing.T) {
+		output, err := useCase.FindBidByAuctionId(context.Background(), uuid.New().String(),
+			bid_usecase.FindBidsInputDTO{})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, output.Bids)
+		assert.Empty(t, output.Bids)
+		assert.Zero(t, output.Total)
+	})
+
+	t.Run("rejects an invalid page", func(t *testing.T) {
+		for _, input := range []bid_usecase.FindBidsInputDTO{{Limit: 101}, {Limit: -1}, {Offset: -1}} {
+			_, err := useCase.FindBidByAuctionId(context.Background(), auction.Id, input)
+
+			if assert.NotNil(t, err, "%+v", input) {
+				assert.Equal(t, "bad_request", err.Err)
+			}
+		}
+	})
+}
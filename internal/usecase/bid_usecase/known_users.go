@@ -0,0 +1,71 @@
+package bid_usecase
+
+import (
+	"sync"
+	"time"
+)
+
+// maxKnownUsers bounds the memory used to remember users whose existence was
+// confirmed. When full, the user seen longest ago is forgotten.
+const maxKnownUsers = 10000
+
+// knownUsers remembers the users found by the user repository, so that bids
+// from them can still be accepted while the repository is unavailable
+// (USER_LOOKUP_DEGRADED_MODE).
+type knownUsers struct {
+	enabled  bool
+	lastSeen map[string]time.Time // userId -> last successful lookup
+	mutex    sync.Mutex
+}
+
+func newKnownUsers(enabled bool) *knownUsers {
+	return &knownUsers{
+		enabled:  enabled,
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// remember records that userId was found at now.
+func (ku *knownUsers) remember(userId string, now time.Time) {
+	if !ku.enabled {
+		return
+	}
+
+	ku.mutex.Lock()
+	defer ku.mutex.Unlock()
+
+	if _, ok := ku.lastSeen[userId]; !ok && len(ku.lastSeen) >= maxKnownUsers {
+		ku.evictOldest()
+	}
+
+	ku.lastSeen[userId] = now
+}
+
+// contains reports whether userId was found before. It is always false when
+// degraded mode is disabled.
+func (ku *knownUsers) contains(userId string) bool {
+	if !ku.enabled {
+		return false
+	}
+
+	ku.mutex.Lock()
+	defer ku.mutex.Unlock()
+
+	_, ok := ku.lastSeen[userId]
+	return ok
+}
+
+// evictOldest drops the user seen longest ago. It must be called with mutex
+// held.
+func (ku *knownUsers) evictOldest() {
+	var oldestId string
+	var oldest time.Time
+
+	for userId, seenAt := range ku.lastSeen {
+		if oldestId == "" || seenAt.Before(oldest) {
+			oldestId, oldest = userId, seenAt
+		}
+	}
+
+	delete(ku.lastSeen, oldestId)
+}
@@ -0,0 +1,139 @@
+package bid_usecase
+
+import (
+	"context"
+	"sync"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// liveBidsBuffer is how many bids a subscriber may lag behind before it is
+// dropped as a slow consumer
+const liveBidsBuffer = 32
+
+// liveBids fans out the accepted bids of each auction to its subscribers, so
+// clients are pushed new bids instead of polling. Publishing never blocks
+// CreateBid.
+type liveBids struct {
+	mutex          sync.Mutex
+	subscribers    map[string]map[*liveBidsSubscriber]struct{} // auctionId -> subscribers
+	count          int
+	maxSubscribers int
+}
+
+type liveBidsSubscriber struct {
+	auctionId string
+	bids      chan BidOutputDTO
+}
+
+func newLiveBids(maxSubscribers int) *liveBids {
+	return &liveBids{
+		subscribers:    make(map[string]map[*liveBidsSubscriber]struct{}),
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+// subscribe registers a subscriber to the bids of an auction. The returned
+// channel is closed when ctx is done, when the auction is completed or when
+// the subscriber falls too far behind.
+func (lb *liveBids) subscribe(
+	ctx context.Context, auctionId string) (<-chan BidOutputDTO, *internal_error.InternalError) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	if lb.count >= lb.maxSubscribers {
+		return nil, internal_error.NewServiceUnavailableError("Too many bid stream subscribers")
+	}
+
+	subscriber := &liveBidsSubscriber{
+		auctionId: auctionId,
+		bids:      make(chan BidOutputDTO, liveBidsBuffer),
+	}
+	if lb.subscribers[auctionId] == nil {
+		lb.subscribers[auctionId] = make(map[*liveBidsSubscriber]struct{})
+	}
+	lb.subscribers[auctionId][subscriber] = struct{}{}
+	lb.count++
+
+	go func() {
+		<-ctx.Done()
+		lb.mutex.Lock()
+		lb.remove(subscriber)
+		lb.mutex.Unlock()
+	}()
+
+	return subscriber.bids, nil
+}
+
+// publish sends an accepted bid to the subscribers of its auction, dropping
+// the ones whose buffer is full.
+func (lb *liveBids) publish(bid bid_entity.Bid) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	output := BidOutputDTO{
+		Id:        bid.Id,
+		UserId:    bid.UserId,
+		AuctionId: bid.AuctionId,
+		Amount:    bid.Amount(),
+		Timestamp: bid.Timestamp,
+	}
+	for subscriber := range lb.subscribers[bid.AuctionId] {
+		select {
+		case subscriber.bids <- output:
+		default:
+			lb.remove(subscriber)
+		}
+	}
+}
+
+// closeAuction ends the subscriptions of an auction that no longer takes bids.
+func (lb *liveBids) closeAuction(auctionId string) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	for subscriber := range lb.subscribers[auctionId] {
+		lb.remove(subscriber)
+	}
+}
+
+// remove unregisters a subscriber and closes its channel. It must be called
+// with mutex held.
+func (lb *liveBids) remove(subscriber *liveBidsSubscriber) {
+	subscribers := lb.subscribers[subscriber.auctionId]
+	if _, ok := subscribers[subscriber]; !ok {
+		return
+	}
+
+	delete(subscribers, subscriber)
+	if len(subscribers) == 0 {
+		delete(lb.subscribers, subscriber.auctionId)
+	}
+	lb.count--
+	close(subscriber.bids)
+}
+
+// SubscribeBids streams the bids accepted on an active auction from now on.
+// The channel is closed when ctx is done or when the auction is completed.
+func (bu *BidUseCase) SubscribeBids(
+	ctx context.Context, auctionId string) (<-chan BidOutputDTO, *internal_error.InternalError) {
+	auction, err := bu.AuctionRepository.FindAuctionById(ctx, auctionId)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status != auction_entity.Active {
+		return nil, internal_error.NewAuctionCompletedError()
+	}
+
+	return bu.liveBids.subscribe(ctx, auctionId)
+}
+
+// AuctionsCompleted ends the bid streams of the auctions the closer has just
+// completed. It implements auction_entity.AuctionCompletionListener.
+func (bu *BidUseCase) AuctionsCompleted(auctionIds []string) {
+	for _, auctionId := range auctionIds {
+		bu.liveBids.closeAuction(auctionId)
+	}
+}
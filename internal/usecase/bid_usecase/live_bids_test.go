@@ -0,0 +1,117 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func receiveBid(t *testing.T, bids <-chan bid_usecase.BidOutputDTO) (bid_usecase.BidOutputDTO, bool) {
+	t.Helper()
+	select {
+	case bid, ok := <-bids:
+		return bid, ok
+	case <-time.After(2 * time.Second):
+		t.Fatal("bid stream did not send or close")
+		return bid_usecase.BidOutputDTO{}, false
+	}
+}
+
+func TestSubscribeBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	t.Run("sends the accepted bids of the auction", func(t *testing.T) {
+		auction, other := newAuction(nil), newAuction(nil)
+		useCase, _ := newBidUseCase(auction, other)
+
+		bids, err := useCase.SubscribeBids(context.Background(), auction.Id)
+		assert.Nil(t, err)
+		otherBids, err := useCase.SubscribeBids(context.Background(), other.Id)
+		assert.Nil(t, err)
+
+		userId := uuid.New().String()
+		created, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: 100,
+		})
+		assert.Nil(t, err)
+
+		bid, ok := receiveBid(t, bids)
+		assert.True(t, ok)
+		assert.Equal(t, created.Id, bid.Id)
+		assert.Equal(t, userId, bid.UserId)
+		assert.Equal(t, 100.0, bid.Amount)
+		assert.Empty(t, otherBids)
+	})
+
+	t.Run("rejected bids are not sent", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+
+		bids, err := useCase.SubscribeBids(context.Background(), auction.Id)
+		assert.Nil(t, err)
+
+		_, err = useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: -1,
+		})
+		assert.NotNil(t, err)
+		assert.Empty(t, bids)
+	})
+
+	t.Run("closes when the auction is completed", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+
+		bids, err := useCase.SubscribeBids(context.Background(), auction.Id)
+		assert.Nil(t, err)
+
+		useCase.AuctionsCompleted([]string{auction.Id})
+
+		_, ok := receiveBid(t, bids)
+		assert.False(t, ok)
+	})
+
+	t.Run("closes when the subscriber leaves", func(t *testing.T) {
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		bids, err := useCase.SubscribeBids(ctx, auction.Id)
+		assert.Nil(t, err)
+
+		cancel()
+
+		_, ok := receiveBid(t, bids)
+		assert.False(t, ok)
+	})
+
+	t.Run("completed auction", func(t *testing.T) {
+		auction := newAuction(func(a *auction_entity.Auction) { a.Status = auction_entity.Completed })
+		useCase, _ := newBidUseCase(auction)
+
+		_, err := useCase.SubscribeBids(context.Background(), auction.Id)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, internal_error.AuctionCompletedCode, err.Code)
+		}
+	})
+
+	t.Run("too many subscribers", func(t *testing.T) {
+		t.Setenv("MAX_BID_STREAM_SUBSCRIBERS", "1")
+		auction := newAuction(nil)
+		useCase, _ := newBidUseCase(auction)
+
+		_, err := useCase.SubscribeBids(context.Background(), auction.Id)
+		assert.Nil(t, err)
+
+		_, err = useCase.SubscribeBids(context.Background(), auction.Id)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "service_unavailable", err.Err)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package bid_usecase
+
+// OutbidEvent tells that OutbidUserId no longer holds the highest bid of an
+// auction because another user bid NewAmount.
+type OutbidEvent struct {
+	AuctionId    string
+	OutbidUserId string
+	NewAmount    float64
+}
+
+// Notifier is told when an accepted bid displaces the highest bid of another
+// user, so the previous leader can be notified by whatever transport
+// implements it. It is called on the bid request path and must return quickly.
+type Notifier interface {
+	Outbid(event OutbidEvent)
+}
+
+// noopNotifier is the Notifier of a use case nobody registered one on.
+type noopNotifier struct{}
+
+func (noopNotifier) Outbid(OutbidEvent) {}
+
+// SetNotifier registers the notifier told about outbid users; nil restores
+// the no-op default. It must be called before the use case takes bids.
+func (bu *BidUseCase) SetNotifier(notifier Notifier) {
+	if notifier == nil {
+		notifier = noopNotifier{}
+	}
+	bu.notifier = notifier
+}
@@ -0,0 +1,71 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNotifier struct {
+	events []bid_usecase.OutbidEvent
+}
+
+func (f *fakeNotifier) Outbid(event bid_usecase.OutbidEvent) {
+	f.events = append(f.events, event)
+}
+
+func TestCreateBidNotifiesOutbidUser(t *testing.T) {
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	useCase := bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository, &fakeUserRepository{}, nil, nil, nil, bidConfig(),
+		bid_usecase.WithAllowSelfOutbid(true))
+	notifier := &fakeNotifier{}
+	useCase.(*bid_usecase.BidUseCase).SetNotifier(notifier)
+
+	firstUser, secondUser := uuid.New().String(), uuid.New().String()
+	placeBid := func(userId string, amount float64) {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: userId, AuctionId: auction.Id, Amount: amount,
+		})
+		assert.Nil(t, err)
+	}
+
+	// First bid: nobody to outbid
+	placeBid(firstUser, 100)
+	assert.Empty(t, notifier.events)
+
+	// Another user takes the lead
+	placeBid(secondUser, 200)
+	assert.Equal(t, []bid_usecase.OutbidEvent{
+		{AuctionId: auction.Id, OutbidUserId: firstUser, NewAmount: 200},
+	}, notifier.events)
+
+	// The leader raising their own bid outbids nobody
+	placeBid(secondUser, 300)
+	assert.Len(t, notifier.events, 1)
+
+	// The first user takes the lead back
+	placeBid(firstUser, 400)
+	if assert.Len(t, notifier.events, 2) {
+		assert.Equal(t, secondUser, notifier.events[1].OutbidUserId)
+		assert.Equal(t, 400.0, notifier.events[1].NewAmount)
+	}
+}
+
+func TestCreateBidWithoutNotifier(t *testing.T) {
+	auction := newAuction(nil)
+	auctionRepository := &fakeAuctionRepository{auctions: map[string]*auction_entity.Auction{auction.Id: auction}}
+	useCase := bid_usecase.NewBidUseCase(&fakeBidRepository{}, auctionRepository, &fakeUserRepository{}, nil, nil, nil, bidConfig())
+	useCase.(*bid_usecase.BidUseCase).SetNotifier(nil)
+
+	for _, amount := range []float64{100, 200} {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auction.Id, Amount: amount,
+		})
+		assert.Nil(t, err)
+	}
+}
@@ -0,0 +1,66 @@
+package bid_usecase
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// What made the batch routine flush, as reported by FindPipelineStats.
+const (
+	FlushBatchFull = "batch_full"
+	FlushInterval  = "interval"
+	FlushShutdown  = "shutdown"
+	FlushRequested = "requested"
+)
+
+// PipelineStatsOutputDTO reports the state of the batch routine: what is
+// waiting to be inserted and what it has flushed since startup.
+type PipelineStatsOutputDTO struct {
+	BatchLength      int              `json:"batch_length"`
+	ChannelLength    int              `json:"channel_length"`
+	ChannelCapacity  int              `json:"channel_capacity"`
+	LastFlushAt      *time.Time       `json:"last_flush_at,omitempty"`
+	FlushesByTrigger map[string]int64 `json:"flushes_by_trigger"`
+	PersistedBids    int64            `json:"persisted_bids"`
+}
+
+// pipelineStats counts the flushes of the batch routine. Like the rejection
+// metrics, it is only updated atomically.
+type pipelineStats struct {
+	flushes       map[string]*atomic.Int64
+	persistedBids atomic.Int64
+	lastFlushAt   atomic.Int64 // Unix nanoseconds of the last persisted batch
+}
+
+func newPipelineStats() *pipelineStats {
+	return &pipelineStats{flushes: map[string]*atomic.Int64{
+		FlushBatchFull: {},
+		FlushInterval:  {},
+		FlushShutdown:  {},
+		FlushRequested: {},
+	}}
+}
+
+// recordFlush counts a non-empty flush, persisted or not, and the bids it
+// persisted.
+func (ps *pipelineStats) recordFlush(trigger string, persisted int, at time.Time) {
+	ps.flushes[trigger].Add(1)
+	if persisted > 0 {
+		ps.persistedBids.Add(int64(persisted))
+		ps.lastFlushAt.Store(at.UnixNano())
+	}
+}
+
+// snapshot fills in the counters of stats.
+func (ps *pipelineStats) snapshot(stats *PipelineStatsOutputDTO) {
+	stats.FlushesByTrigger = make(map[string]int64, len(ps.flushes))
+	for trigger, counter := range ps.flushes {
+		stats.FlushesByTrigger[trigger] = counter.Load()
+	}
+
+	stats.PersistedBids = ps.persistedBids.Load()
+	if lastFlushAt := ps.lastFlushAt.Load(); lastFlushAt != 0 {
+		at := time.Unix(0, lastFlushAt)
+		stats.LastFlushAt = &at
+	}
+}
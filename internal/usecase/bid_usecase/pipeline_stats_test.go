@@ -0,0 +1,113 @@
+package bid_usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func placeBids(t *testing.T, useCase bid_usecase.BidUseCaseInterface, auctionId string, amounts ...float64) {
+	t.Helper()
+	for _, amount := range amounts {
+		_, err := useCase.CreateBid(context.Background(), bid_usecase.BidInputDTO{
+			UserId: uuid.New().String(), AuctionId: auctionId, Amount: amount,
+		})
+		assert.Nil(t, err)
+	}
+}
+
+func TestFindPipelineStats(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "3")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auction := newAuction(nil)
+	useCase, _ := newBidUseCase(auction)
+
+	stats := useCase.FindPipelineStats(context.Background())
+	assert.Equal(t, 3, stats.ChannelCapacity)
+	assert.Nil(t, stats.LastFlushAt)
+	assert.Equal(t, map[string]int64{
+		bid_usecase.FlushBatchFull: 0,
+		bid_usecase.FlushInterval:  0,
+		bid_usecase.FlushShutdown:  0,
+		bid_usecase.FlushRequested: 0,
+	}, stats.FlushesByTrigger)
+
+	// Two bids wait in the batch for a third one
+	placeBids(t, useCase, auction.Id, 100, 110)
+	assert.Eventually(t, func() bool {
+		return useCase.FindPipelineStats(context.Background()).BatchLength == 2
+	}, time.Second, 5*time.Millisecond)
+	stats = useCase.FindPipelineStats(context.Background())
+	assert.Zero(t, stats.ChannelLength)
+	assert.Zero(t, stats.PersistedBids)
+
+	// The third bid fills the batch and flushes it
+	before := time.Now()
+	placeBids(t, useCase, auction.Id, 120)
+	assert.Eventually(t, func() bool {
+		return useCase.FindPipelineStats(context.Background()).PersistedBids == 3
+	}, time.Second, 5*time.Millisecond)
+	stats = useCase.FindPipelineStats(context.Background())
+	assert.Zero(t, stats.BatchLength)
+	assert.Equal(t, int64(1), stats.FlushesByTrigger[bid_usecase.FlushBatchFull])
+	if assert.NotNil(t, stats.LastFlushAt) {
+		assert.False(t, stats.LastFlushAt.Before(before))
+	}
+
+	// The last bid is flushed on shutdown
+	placeBids(t, useCase, auction.Id, 130)
+	useCase.Shutdown(context.Background())
+	stats = useCase.FindPipelineStats(context.Background())
+	assert.Equal(t, int64(4), stats.PersistedBids)
+	assert.Equal(t, int64(1), stats.FlushesByTrigger[bid_usecase.FlushShutdown])
+	assert.Zero(t, stats.FlushesByTrigger[bid_usecase.FlushInterval])
+}
+
+func TestFindPipelineStatsCountsIntervalFlushes(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "10ms")
+
+	auction := newAuction(nil)
+	useCase, _ := newBidUseCase(auction)
+
+	placeBids(t, useCase, auction.Id, 100)
+	assert.Eventually(t, func() bool {
+		stats := useCase.FindPipelineStats(context.Background())
+		return stats.PersistedBids == 1 && stats.FlushesByTrigger[bid_usecase.FlushInterval] == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// Ticks with an empty batch are not flushes
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(1), useCase.FindPipelineStats(context.Background()).FlushesByTrigger[bid_usecase.FlushInterval])
+}
+
+func TestFlushPersistsBatchedBids(t *testing.T) {
+	t.Setenv("MAX_BATCH_SIZE", "10")
+	t.Setenv("BATCH_INSERT_INTERVAL", "1h")
+
+	auction := newAuction(nil)
+	useCase, bidRepository := newBidUseCase(auction)
+
+	placeBids(t, useCase, auction.Id, 100, 110)
+	assert.Eventually(t, func() bool {
+		return useCase.FindPipelineStats(context.Background()).BatchLength == 2
+	}, time.Second, 5*time.Millisecond)
+
+	flushed, err := useCase.Flush(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, flushed)
+
+	bids, _ := bidRepository.FindBidByAuctionId(context.Background(), auction.Id)
+	assert.Len(t, bids, 2)
+	stats := useCase.FindPipelineStats(context.Background())
+	assert.Zero(t, stats.BatchLength)
+	assert.Equal(t, int64(1), stats.FlushesByTrigger[bid_usecase.FlushRequested])
+
+	// Nothing is left for the final batch
+	assert.Equal(t, bid_usecase.PipelineDrainStats{}, useCase.Shutdown(context.Background()))
+}
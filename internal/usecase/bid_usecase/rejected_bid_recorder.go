@@ -0,0 +1,100 @@
+package bid_usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/logger"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+)
+
+const (
+	// rejectedBidsBuffer is how many rejected attempts may wait to be written
+	// before new ones are dropped
+	rejectedBidsBuffer = 1000
+
+	// maxRejectedBidsBatch caps how many attempts are written at once
+	maxRejectedBidsBatch = 100
+)
+
+// rejectedBidRecorder persists rejected bid attempts in the background, so
+// recording never delays the response to the bidder (RECORD_REJECTED_BIDS).
+type rejectedBidRecorder struct {
+	repository bid_entity.RejectedBidRepository
+	attempts   chan bid_entity.RejectedBid
+}
+
+// newRejectedBidRecorder returns nil, disabling the recording, unless it is
+// enabled and a repository is available.
+func newRejectedBidRecorder(
+	ctx context.Context, repository bid_entity.RejectedBidRepository, enabled bool) *rejectedBidRecorder {
+	if repository == nil || !enabled {
+		return nil
+	}
+
+	recorder := &rejectedBidRecorder{
+		repository: repository,
+		attempts:   make(chan bid_entity.RejectedBid, rejectedBidsBuffer),
+	}
+	go recorder.run(ctx)
+
+	return recorder
+}
+
+// record queues a rejected attempt. When the buffer is full the attempt is
+// dropped rather than blocking the bid path.
+func (rr *rejectedBidRecorder) record(input BidInputDTO, reason, message string) {
+	if rr == nil {
+		return
+	}
+
+	rejectedBid := bid_entity.RejectedBid{
+		Id:        uuid.New().String(),
+		UserId:    input.UserId,
+		AuctionId: input.AuctionId,
+		Amount:    input.Amount,
+		Reason:    reason,
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+
+	select {
+	case rr.attempts <- rejectedBid:
+	default:
+		logger.Error("Rejected bid dropped, recording buffer is full",
+			errors.New("rejected bid buffer full"))
+	}
+}
+
+// run writes the queued attempts, grouping the ones already waiting into a
+// single insert, until ctx is done.
+func (rr *rejectedBidRecorder) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case rejectedBid := <-rr.attempts:
+			batch := rr.collect([]bid_entity.RejectedBid{rejectedBid})
+			if err := rr.repository.CreateRejectedBids(ctx, batch); err != nil {
+				logger.Error("Error trying to record rejected bids", err)
+			}
+		}
+	}
+}
+
+// collect appends to batch the attempts already waiting, up to
+// maxRejectedBidsBatch.
+func (rr *rejectedBidRecorder) collect(batch []bid_entity.RejectedBid) []bid_entity.RejectedBid {
+	for len(batch) < maxRejectedBidsBatch {
+		select {
+		case rejectedBid := <-rr.attempts:
+			batch = append(batch, rejectedBid)
+		default:
+			return batch
+		}
+	}
+
+	return batch
+}
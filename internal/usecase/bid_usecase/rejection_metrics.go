@@ -0,0 +1,100 @@
+package bid_usecase
+
+import (
+	"sync/atomic"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// Reasons a bid is rejected, as reported by FindRejectionCounts.
+const (
+	RejectedAuctionNotFound = "auction_not_found"
+	RejectedAuctionClosed   = "auction_closed" // completed, expired or not started
+	RejectedTooLow          = "too_low"
+	RejectedSelfOutbid      = "self_outbid"
+	RejectedUserNotFound    = "user_not_found"
+	RejectedRateLimited     = "rate_limited"
+	RejectedInvalidBid      = "invalid_bid"
+	RejectedCancelled       = "cancelled"
+	RejectedOther           = "other"
+)
+
+var rejectionReasons = []string{
+	RejectedAuctionNotFound,
+	RejectedAuctionClosed,
+	RejectedTooLow,
+	RejectedSelfOutbid,
+	RejectedUserNotFound,
+	RejectedRateLimited,
+	RejectedInvalidBid,
+	RejectedCancelled,
+	RejectedOther,
+}
+
+// rejectionMetrics counts rejected bids by reason. The counters are created
+// once and only updated atomically, so the concurrent bid path never locks.
+type rejectionMetrics struct {
+	counters map[string]*atomic.Int64
+}
+
+func newRejectionMetrics() *rejectionMetrics {
+	counters := make(map[string]*atomic.Int64, len(rejectionReasons))
+	for _, reason := range rejectionReasons {
+		counters[reason] = &atomic.Int64{}
+	}
+
+	return &rejectionMetrics{counters: counters}
+}
+
+// record counts a bid rejected for reason.
+func (rm *rejectionMetrics) record(reason string) {
+	rm.counters[reason].Add(1)
+}
+
+// snapshot returns the current count of every reason, including zeros.
+func (rm *rejectionMetrics) snapshot() map[string]int64 {
+	counts := make(map[string]int64, len(rm.counters))
+	for reason, counter := range rm.counters {
+		counts[reason] = counter.Load()
+	}
+
+	return counts
+}
+
+// RecordRateLimited counts a bid refused by the request rate limit as
+// rate_limited, like the bids refused by the cooldown.
+func (bu *BidUseCase) RecordRateLimited() {
+	bu.rejections.record(RejectedRateLimited)
+}
+
+func rejectionReason(err *internal_error.InternalError) string {
+	switch err.Code {
+	case internal_error.AuctionNotFoundCode:
+		return RejectedAuctionNotFound
+	case internal_error.AuctionCompletedCode,
+		internal_error.AuctionExpiredCode,
+		internal_error.AuctionNotStartedCode:
+		return RejectedAuctionClosed
+	case internal_error.BidTooLowCode:
+		return RejectedTooLow
+	case internal_error.AlreadyHighestBidderCode:
+		return RejectedSelfOutbid
+	case internal_error.UserNotFoundCode:
+		return RejectedUserNotFound
+	case internal_error.BidCooldownCode:
+		return RejectedRateLimited
+	case internal_error.RequestCancelledCode, internal_error.RequestTimeoutCode:
+		return RejectedCancelled
+	case internal_error.InvalidUserIdCode,
+		internal_error.InvalidAuctionIdCode,
+		internal_error.InvalidAmountCode:
+		return RejectedInvalidBid
+	}
+
+	// Remaining validation failures carry no code
+	if err.Err == "bad_request" {
+		return RejectedInvalidBid
+	}
+
+	return RejectedOther
+}
@@ -0,0 +1,83 @@
+package bid_usecase
+
+import (
+	"sort"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+// RejectedBid is a replayed bid that the current rules would refuse.
+type RejectedBid struct {
+	Bid    bid_entity.Bid
+	Reason *internal_error.InternalError
+}
+
+// ReplayResult is the outcome of replaying a bid history.
+type ReplayResult struct {
+	Winner   *bid_entity.Bid
+	Accepted []bid_entity.Bid
+	Rejected []RejectedBid
+}
+
+// ReplayBids runs a list of historical bids through the bid validation rules
+// and returns the winner they would produce, without persisting anything.
+// Bids are replayed in timestamp order (ties broken by id) so the result is
+// deterministic regardless of the input order. The auction window is checked
+// against each bid's own timestamp instead of the current time, and rules
+// are the settings live bids are validated with.
+func ReplayBids(auction *auction_entity.Auction, bids []bid_entity.Bid, rules config.BidConfig) ReplayResult {
+	ordered := make([]bid_entity.Bid, len(bids))
+	copy(ordered, bids)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Timestamp.Equal(ordered[j].Timestamp) {
+			return ordered[i].Id < ordered[j].Id
+		}
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+
+	var result ReplayResult
+	for _, bid := range ordered {
+		if err := replayBid(auction, result.Winner, bid, rules); err != nil {
+			result.Rejected = append(result.Rejected, RejectedBid{Bid: bid, Reason: err})
+			continue
+		}
+
+		result.Accepted = append(result.Accepted, bid)
+		winner := bid
+		result.Winner = &winner
+	}
+
+	return result
+}
+
+func replayBid(
+	auction *auction_entity.Auction,
+	highest *bid_entity.Bid,
+	bid bid_entity.Bid,
+	rules config.BidConfig) *internal_error.InternalError {
+	if err := bid.Validate(rules.AmountLimits); err != nil {
+		return err
+	}
+
+	if bid.AuctionId != auction.Id {
+		return internal_error.NewAuctionNotFoundError()
+	}
+	if bid.Timestamp.Before(auction.StartsAt) {
+		return internal_error.NewAuctionNotStartedError()
+	}
+	if bid.Timestamp.After(auction.ExpiresAt) {
+		return internal_error.NewAuctionExpiredError()
+	}
+
+	var highestCents int64
+	var highestUserId string
+	if highest != nil {
+		highestCents = highest.AmountCents
+		highestUserId = highest.UserId
+	}
+
+	return validateAgainstHighestBid(&bid, highestCents, highestUserId, auction, rules)
+}
@@ -0,0 +1,70 @@
+package bid_usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/configuration/config"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/auction_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/bid_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/bid_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayBidsDeterminesWinner(t *testing.T) {
+	auction := newAuction(nil)
+	alice := uuid.New().String()
+	bob := uuid.New().String()
+	start := auction.StartsAt
+
+	bid := func(userId string, amount float64, offset time.Duration) bid_entity.Bid {
+		return bid_entity.Bid{
+			Id:          uuid.New().String(),
+			UserId:      userId,
+			AuctionId:   auction.Id,
+			AmountCents: bid_entity.ToCents(amount),
+			Timestamp:   start.Add(offset),
+		}
+	}
+
+	history := []bid_entity.Bid{
+		bid(bob, 150, 3*time.Second),   // accepted: outbids alice
+		bid(alice, 100, 1*time.Second), // accepted: first bid
+		bid(alice, 120, 2*time.Second), // rejected: alice is already the highest bidder
+		bid(alice, 140, 4*time.Second), // rejected: lower than bob's bid
+		bid(alice, 200, 5*time.Second), // accepted: outbids bob
+		bid(bob, 500, 2*time.Minute),   // rejected: after the auction expired
+	}
+
+	result := bid_usecase.ReplayBids(auction, history, config.Default().Bid)
+
+	assert.NotNil(t, result.Winner)
+	assert.Equal(t, alice, result.Winner.UserId)
+	assert.Equal(t, 200.0, result.Winner.Amount())
+	assert.Len(t, result.Accepted, 3)
+	assert.Len(t, result.Rejected, 3)
+
+	// Replaying the same history in another order yields the same winner
+	reversed := make([]bid_entity.Bid, len(history))
+	for i, b := range history {
+		reversed[len(history)-1-i] = b
+	}
+	assert.Equal(t, result, bid_usecase.ReplayBids(auction, reversed, config.Default().Bid))
+}
+
+func TestReplayBidsWithoutAcceptedBids(t *testing.T) {
+	auction := newAuction(func(a *auction_entity.Auction) { a.ExpiresAt = a.StartsAt })
+
+	result := bid_usecase.ReplayBids(auction, []bid_entity.Bid{{
+		Id:          uuid.New().String(),
+		UserId:      uuid.New().String(),
+		AuctionId:   auction.Id,
+		AmountCents: 1000,
+		Timestamp:   auction.StartsAt.Add(time.Second),
+	}}, config.Default().Bid)
+
+	assert.Nil(t, result.Winner)
+	assert.Empty(t, result.Accepted)
+	assert.Len(t, result.Rejected, 1)
+}
@@ -0,0 +1,30 @@
+package user_usecase
+
+import (
+	"context"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+type UserInputDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateUser registers a user with a generated id and returns it.
+func (u *UserUseCase) CreateUser(
+	ctx context.Context, input UserInputDTO) (*UserOutputDTO, *internal_error.InternalError) {
+	userEntity, err := user_entity.CreateUser(input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.UserRepository.CreateUser(ctx, userEntity); err != nil {
+		return nil, err
+	}
+
+	return &UserOutputDTO{
+		Id:   userEntity.Id,
+		Name: userEntity.Name,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package user_usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/entity/user_entity"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/usecase/user_usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUserRepository struct {
+	user_entity.UserRepositoryInterface
+
+	created   []user_entity.User
+	createErr *internal_error.InternalError
+}
+
+func (f *fakeUserRepository) CreateUser(
+	ctx context.Context, user *user_entity.User) *internal_error.InternalError {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.created = append(f.created, *user)
+	return nil
+}
+
+func TestCreateUser(t *testing.T) {
+	repository := &fakeUserRepository{}
+	useCase := user_usecase.NewUserUseCase(repository)
+
+	output, err := useCase.CreateUser(context.Background(), user_usecase.UserInputDTO{Name: "  Ana  "})
+
+	assert.Nil(t, err)
+	assert.NoError(t, uuid.Validate(output.Id))
+	assert.Equal(t, "Ana", output.Name)
+	assert.Equal(t, []user_entity.User{{Id: output.Id, Name: "Ana"}}, repository.created)
+}
+
+func TestCreateUserRejectsShortNames(t *testing.T) {
+	for _, name := range []string{"", "A", "   ", " B "} {
+		t.Run(name, func(t *testing.T) {
+			repository := &fakeUserRepository{}
+			useCase := user_usecase.NewUserUseCase(repository)
+
+			output, err := useCase.CreateUser(context.Background(), user_usecase.UserInputDTO{Name: name})
+
+			assert.Nil(t, output)
+			if assert.NotNil(t, err) {
+				assert.Equal(t, "bad_request", err.Err)
+			}
+			assert.Empty(t, repository.created)
+		})
+	}
+}
+
+func TestCreateUserReportsRepositoryFailure(t *testing.T) {
+	useCase := user_usecase.NewUserUseCase(&fakeUserRepository{
+		createErr: internal_error.NewInternalServerError("Error trying to insert user"),
+	})
+
+	output, err := useCase.CreateUser(context.Background(), user_usecase.UserInputDTO{Name: "Ana"})
+
+	assert.Nil(t, output)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "internal_server_error", err.Err)
+	}
+}
@@ -18,14 +18,30 @@ type UserUseCase struct {
 }
 
 type UserOutputDTO struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+type UserCallbackInputDTO struct {
+	CallbackURL string `json:"callback_url"`
 }
 
 type UserUseCaseInterface interface {
+	CreateUser(
+		ctx context.Context,
+		input UserInputDTO) (*UserOutputDTO, *internal_error.InternalError)
+
 	FindUserById(
 		ctx context.Context,
 		id string) (*UserOutputDTO, *internal_error.InternalError)
+
+	// UpdateCallbackURL registers the URL called when the user's bids are
+	// persisted or the user wins an auction; an empty URL removes it
+	UpdateCallbackURL(
+		ctx context.Context,
+		id string,
+		input UserCallbackInputDTO) (*UserOutputDTO, *internal_error.InternalError)
 }
 
 func (u *UserUseCase) FindUserById(
@@ -36,7 +52,8 @@ func (u *UserUseCase) FindUserById(
 	}
 
 	return &UserOutputDTO{
-		Id:   userEntity.Id,
-		Name: userEntity.Name,
+		Id:          userEntity.Id,
+		Name:        userEntity.Name,
+		CallbackURL: userEntity.CallbackURL,
 	}, nil
 }
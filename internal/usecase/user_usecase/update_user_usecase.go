@@ -0,0 +1,32 @@
+package user_usecase
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/markuscandido/go-expert-desafio-concorrencia-com-golang-leilao/internal/internal_error"
+)
+
+func (u *UserUseCase) UpdateCallbackURL(
+	ctx context.Context,
+	id string,
+	input UserCallbackInputDTO) (*UserOutputDTO, *internal_error.InternalError) {
+	if input.CallbackURL != "" && !isCallbackURL(input.CallbackURL) {
+		return nil, internal_error.NewBadRequestError("callback_url must be an absolute http or https URL")
+	}
+
+	if err := u.UserRepository.UpdateUserCallbackURL(ctx, id, input.CallbackURL); err != nil {
+		return nil, err
+	}
+
+	return u.FindUserById(ctx, id)
+}
+
+func isCallbackURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+
+	return parsed.Scheme == "http" || parsed.Scheme == "https"
+}